@@ -6,6 +6,7 @@ import (
 
 	"github.com/jerbe/jcache/v2/driver"
 	"github.com/jerbe/jcache/v2/errors"
+	"github.com/jerbe/jcache/v2/internal/singleflight"
 
 	jerrors "github.com/jerbe/go-errors"
 	"github.com/redis/go-redis/v9"
@@ -22,24 +23,135 @@ var (
 	ErrNoCacheClient = errors.ErrNoCacheClient
 )
 
-// returnable 检测值是否可以返回
+type (
+	// WriteError 写操作在一个或多个驱动中失败时返回的错误
+	WriteError = errors.WriteError
+
+	// DriverResult 某个驱动的写入结果
+	DriverResult = errors.DriverResult
+)
+
+const (
+	// DefaultTimeout 默认的操作超时时间
+	DefaultTimeout = time.Second * 5
+
+	// DefaultBlockingTimeout 阻塞类操作在阻塞时长之外额外等待的默认时间
+	DefaultBlockingTimeout = time.Second
+)
+
+// ClientOptions 客户端选项
+type ClientOptions struct {
+	// Timeout 默认的操作超时时间,只在调用方的 ctx 没有设置截止时间时生效
+	// 为0时使用 DefaultTimeout,小于0时不设置超时时间
+	Timeout time.Duration
+
+	// BlockingTimeout 阻塞类操作(如 BLPop)在阻塞时长之外额外等待的时间,用于网络往返
+	// 为0时使用 DefaultBlockingTimeout,小于0时不额外等待
+	BlockingTimeout time.Duration
+
+	// Promote 低层驱动命中后回写到高层驱动的策略,默认为 PromoteNone
+	// 回写时会带上数据在低层驱动中的剩余存活时长
+	Promote PromotePolicy
+
+	// PromoteMaxTTL 回写数据的最大存活时长,为0时不限制
+	PromoteMaxTTL time.Duration
+
+	// WriteBehindQueueSize 角色为 RoleWriteBehind 的驱动的异步写入队列长度,为0时使用 DefaultWriteBehindQueueSize
+	WriteBehindQueueSize int
+
+	// WritePolicy 写操作分发到多个驱动时的策略,默认为 WriteBestEffort
+	WritePolicy WritePolicy
+}
+
+// timeout 返回默认的操作超时时间
+func (opts *ClientOptions) timeout() time.Duration {
+	if opts == nil || opts.Timeout == 0 {
+		return DefaultTimeout
+	}
+	return opts.Timeout
+}
+
+// blockingTimeout 返回阻塞类操作额外等待的时间
+func (opts *ClientOptions) blockingTimeout() time.Duration {
+	if opts == nil || opts.BlockingTimeout == 0 {
+		return DefaultBlockingTimeout
+	}
+	if opts.BlockingTimeout < 0 {
+		return 0
+	}
+	return opts.BlockingTimeout
+}
+
+// writeBehindQueueSize 返回异步写入队列的长度
+func (opts *ClientOptions) writeBehindQueueSize() int {
+	if opts == nil || opts.WriteBehindQueueSize <= 0 {
+		return DefaultWriteBehindQueueSize
+	}
+	return opts.WriteBehindQueueSize
+}
+
+// writePolicy 返回写操作的策略
+func (opts *ClientOptions) writePolicy() WritePolicy {
+	if opts == nil {
+		return WriteBestEffort
+	}
+	return opts.WritePolicy
+}
+
+// returnable 检测值是否可以返回,数据不存在时需要继续从下一个驱动中获取
 func returnable(val errors.ErrorValuer) bool {
-	return val.Err() == nil || !jerrors.IsIn(val.Err(), redis.Nil, driver.MemoryNil)
+	return val.Err() == nil || !jerrors.IsIn(val.Err(), Nil, redis.Nil, driver.MemoryNil)
+}
+
+// found 检测值是否可以返回,空结果也视为未命中,需要继续从下一个驱动中获取
+// 用于集合类的读取,这类读取在key不存在时不会返回 Nil
+func found(val errors.ErrorValuer, empty bool) bool {
+	return returnable(val) && (val.Err() != nil || !empty)
 }
 
+// ttlFound 检测存活时长的读取结果是否可以返回,key不存在时返回的 -2 视为未命中,需要继续从下一个驱动中获取
+func ttlFound(val driver.DurationValuer) bool {
+	return returnable(val) && (val.Err() != nil || val.Val() != -2)
+}
+
+// preCheck 检测客户端是否可用,并从调用方的 ctx 派生出本次操作使用的 ctx
+// 调用方没有设置截止时间时,使用客户端的默认超时时间;返回的 cancel 必须在操作结束后调用
 func (cli *BaseClient) preCheck(ctx context.Context) (context.Context, context.CancelFunc) {
 	if len(cli.drivers) == 0 {
 		panic(ErrNoCacheClient)
 	}
 
-	var cancelFunc context.CancelFunc
 	if ctx == nil {
-		ctx, cancelFunc = context.WithTimeout(context.Background(), time.Second*5)
-	} else {
-		ctx, cancelFunc = context.WithCancel(context.Background())
+		ctx = context.Background()
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
 	}
 
-	return ctx, cancelFunc
+	timeout := cli.options.timeout()
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// preCheckBlocking 同 preCheck,用于 BLPop 这类会阻塞的操作
+// block 为操作本身的阻塞时长,0 表示一直阻塞直到 ctx 结束;超时时间为 block 加上 ClientOptions.BlockingTimeout
+func (cli *BaseClient) preCheckBlocking(ctx context.Context, block time.Duration) (context.Context, context.CancelFunc) {
+	if len(cli.drivers) == 0 {
+		panic(ErrNoCacheClient)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if _, ok := ctx.Deadline(); ok || block <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, block+cli.options.blockingTimeout())
 }
 
 // =======================================================
@@ -47,12 +159,94 @@ func (cli *BaseClient) preCheck(ctx context.Context) (context.Context, context.C
 // =======================================================
 
 type BaseClient struct {
+	// drivers 参与读取的驱动,按读取顺序排列
 	drivers []driver.Common
+
+	// writers 参与写入的驱动,按写入顺序排列
+	writers []*clientWriter
+
+	// readOnlys 只读的驱动,写操作时会删除其中对应的键
+	readOnlys []driver.Common
+
+	options *ClientOptions
+
+	// group 合并相同key的并发加载
+	group *singleflight.Group
+
+	// closer 关闭状态
+	closer *clientCloser
+}
+
+// Close 关闭客户端,先等待异步写入队列跟异步回写执行完成,再关闭所有驱动
+// ctx 结束时不再等待未完成的异步操作;所有驱动都会被关闭,返回第一个遇到的错误
+// 多个客户端共用同一个驱动时,关闭其中一个客户端后驱动不能再被其他客户端使用
+func (cli *BaseClient) Close(ctx context.Context) error {
+	cli.closer.once.Do(func() {
+		var err error
+		for _, w := range cli.writers {
+			if w.behind == nil {
+				continue
+			}
+			if e := w.behind.close(ctx); e != nil && err == nil {
+				err = e
+			}
+		}
+
+		inflight := make(chan struct{})
+		go func() {
+			cli.closer.inflight.Wait()
+			close(inflight)
+		}()
+		select {
+		case <-inflight:
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+
+		for _, c := range cli.allDrivers() {
+			closer, ok := c.(driver.Closer)
+			if !ok {
+				continue
+			}
+			if e := closer.Close(ctx); e != nil && err == nil {
+				err = e
+			}
+		}
+		cli.closer.err = err
+	})
+	return cli.closer.err
+}
+
+// allDrivers 返回客户端中所有不重复的驱动
+func (cli *BaseClient) allDrivers() []driver.Common {
+	result := make([]driver.Common, 0, len(cli.drivers)+len(cli.writers))
+	seen := make(map[driver.Common]struct{})
+	add := func(c driver.Common) {
+		if _, ok := seen[c]; ok {
+			return
+		}
+		seen[c] = struct{}{}
+		result = append(result, c)
+	}
+
+	for _, c := range cli.drivers {
+		add(c)
+	}
+	for _, w := range cli.writers {
+		add(w.driver)
+	}
+	for _, c := range cli.readOnlys {
+		add(c)
+	}
+	return result
 }
 
 // Exists 判断某个Key是否存在
 func (cli *BaseClient) Exists(ctx context.Context, keys ...string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.IntValuer
 	for _, c := range cli.drivers {
@@ -65,55 +259,125 @@ func (cli *BaseClient) Exists(ctx context.Context, keys ...string) driver.IntVal
 
 // Del 删除键
 func (cli *BaseClient) Del(ctx context.Context, keys ...string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.IntValuer
-	for i, c := range cli.drivers {
-		if v := c.Del(ctx, keys...); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, keys, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.Del(ctx, keys...)
+	}).(driver.IntValuer)
 }
 
 // Expire 设置某个Key的TTL时长
 func (cli *BaseClient) Expire(ctx context.Context, key string, expiration time.Duration) driver.BoolValuer {
-	ctx, _ = cli.preCheck(ctx)
-	var value driver.BoolValuer
-	for i, c := range cli.drivers {
-		if v := c.Expire(ctx, key, expiration); i == 0 {
-			value = v
-		}
-	}
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.Expire(ctx, key, expiration)
+	}).(driver.BoolValuer)
+}
 
-	return value
+// ExpireNX 只有key没有到期时间时才设置存活时间
+// 每个驱动按自身的到期时间判断条件
+func (cli *BaseClient) ExpireNX(ctx context.Context, key string, expiration time.Duration) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.ExpireNX(ctx, key, expiration)
+	}).(driver.BoolValuer)
+}
+
+// ExpireXX 只有key已经有到期时间时才设置存活时间
+// 每个驱动按自身的到期时间判断条件
+func (cli *BaseClient) ExpireXX(ctx context.Context, key string, expiration time.Duration) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.ExpireXX(ctx, key, expiration)
+	}).(driver.BoolValuer)
+}
+
+// ExpireGT 只有新的到期时间比原来的晚时才设置存活时间,没有到期时间的key视为永不到期
+// 每个驱动按自身的到期时间判断条件
+func (cli *BaseClient) ExpireGT(ctx context.Context, key string, expiration time.Duration) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.ExpireGT(ctx, key, expiration)
+	}).(driver.BoolValuer)
+}
+
+// ExpireLT 只有新的到期时间比原来的早时才设置存活时间,没有到期时间的key视为永不到期
+// 每个驱动按自身的到期时间判断条件
+func (cli *BaseClient) ExpireLT(ctx context.Context, key string, expiration time.Duration) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.ExpireLT(ctx, key, expiration)
+	}).(driver.BoolValuer)
 }
 
 // ExpireAt 设置某个key在指定时间内到期
 func (cli *BaseClient) ExpireAt(ctx context.Context, key string, at time.Time) driver.BoolValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.ExpireAt(ctx, key, at)
+	}).(driver.BoolValuer)
+}
+
+// Persist 设置某个key成为持久性的
+func (cli *BaseClient) Persist(ctx context.Context, key string) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.Persist(ctx, key)
+	}).(driver.BoolValuer)
+}
 
-	var value driver.BoolValuer
-	for i, c := range cli.drivers {
-		if v := c.ExpireAt(ctx, key, at); i == 0 {
-			value = v
+// TTL 获取某个key的剩余存活时长
+// 按读取顺序从各个驱动中获取,key不存在时返回 -2, key没有设置存活时长时返回 -1
+func (cli *BaseClient) TTL(ctx context.Context, key string) driver.DurationValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.DurationValuer
+	for _, c := range cli.drivers {
+		if value = c.TTL(ctx, key); ttlFound(value) {
+			return value
 		}
 	}
-
 	return value
 }
 
-// Persist 设置某个key成为持久性的
-func (cli *BaseClient) Persist(ctx context.Context, key string) driver.BoolValuer {
-	ctx, _ = cli.preCheck(ctx)
+// PTTL 获取某个key的剩余存活时长,精确到毫秒
+// 按读取顺序从各个驱动中获取,key不存在时返回 -2, key没有设置存活时长时返回 -1
+func (cli *BaseClient) PTTL(ctx context.Context, key string) driver.DurationValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.BoolValuer
-	for i, c := range cli.drivers {
-		if v := c.Persist(ctx, key); i == 0 {
-			value = v
+	var value driver.DurationValuer
+	for _, c := range cli.drivers {
+		if value = c.PTTL(ctx, key); ttlFound(value) {
+			return value
 		}
 	}
+	return value
+}
 
+// ExpireTime 获取某个key的到期时间,以距离 Unix 纪元的时长表示,精确到秒
+// 按读取顺序从各个驱动中获取,key不存在时返回 -2, key没有设置存活时长时返回 -1
+func (cli *BaseClient) ExpireTime(ctx context.Context, key string) driver.DurationValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.DurationValuer
+	for _, c := range cli.drivers {
+		if value = c.ExpireTime(ctx, key); ttlFound(value) {
+			return value
+		}
+	}
 	return value
 }
 
@@ -126,28 +390,48 @@ type Client struct {
 	StringClient
 	HashClient
 	ListClient
+	SetClient
 	SortedSetClient
 }
 
 // NewClient 实例化出一个客户端
 func NewClient(drivers ...driver.Cache) *Client {
-	drs := make([]driver.Common, 0)
+	return NewClientWithOptions(ClientOptions{}, drivers...)
+}
+
+// NewClientWithOptions 根据选项实例化出一个客户端
+// 按传入的顺序读取,所有驱动都同步写入
+func NewClientWithOptions(opts ClientOptions, drivers ...driver.Cache) *Client {
+	drs := make([]DriverOptions, 0)
+
+	for i := 0; i < len(drivers); i++ {
+		drs = append(drs, DriverOptions{Driver: drivers[i]})
+	}
+
+	return NewClientWithDrivers(opts, drs...)
+}
+
+// NewClientWithDrivers 根据选项跟驱动选项实例化出一个客户端
+// 可以为每个驱动指定读取权重跟角色,例如: 先读内存再读redis,只写redis,内存通过回写(Promote)填充
+func NewClientWithDrivers(opts ClientOptions, drivers ...DriverOptions) *Client {
+	drs := make([]clientDriver, 0)
 
 	for i := 0; i < len(drivers); i++ {
-		drs = append(drs, drivers[i])
+		drs = append(drs, clientDriver{driver: drivers[i].Driver, weight: drivers[i].Weight, role: drivers[i].Role})
 	}
 
 	if len(drs) == 0 {
-		drs = append(drs, driver.NewMemory())
+		drs = append(drs, clientDriver{driver: driver.NewMemory()})
 	}
 
-	cli := BaseClient{drivers: drs}
+	cli := newBaseClient(&opts, drs...)
 
 	return &Client{
 		BaseClient:      cli,
 		StringClient:    StringClient{BaseClient: cli},
 		HashClient:      HashClient{BaseClient: cli},
 		ListClient:      ListClient{BaseClient: cli},
+		SetClient:       SetClient{BaseClient: cli},
 		SortedSetClient: SortedSetClient{BaseClient: cli},
 	}
 }
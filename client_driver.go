@@ -0,0 +1,437 @@
+package jcache
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/errors"
+	"github.com/jerbe/jcache/v2/internal/singleflight"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/13 10:15
+  @describe : 客户端驱动的读取顺序跟写入方式
+*/
+
+// DefaultWriteBehindQueueSize 异步写入队列的默认长度
+const DefaultWriteBehindQueueSize = 1024
+
+// DriverRole 驱动在客户端中的角色
+type DriverRole int
+
+const (
+	// RoleWriteThrough 可读,同步写入,默认角色
+	RoleWriteThrough DriverRole = iota
+
+	// RoleWriteBehind 可读,异步写入
+	// 写操作进入队列后立即返回,按写入顺序依次执行
+	RoleWriteBehind
+
+	// RoleReadOnly 只读,只能通过低层驱动命中后的回写(Promote)填充数据
+	// 对某个key进行写操作时,会删除该驱动中的这个key,避免读到旧数据
+	RoleReadOnly
+
+	// RoleWriteOnly 只写,不参与读取
+	RoleWriteOnly
+)
+
+// String 返回角色名称
+func (r DriverRole) String() string {
+	switch r {
+	case RoleWriteThrough:
+		return "WriteThrough"
+	case RoleWriteBehind:
+		return "WriteBehind"
+	case RoleReadOnly:
+		return "ReadOnly"
+	case RoleWriteOnly:
+		return "WriteOnly"
+	}
+	return "Unknown"
+}
+
+// readable 是否参与读取
+func (r DriverRole) readable() bool {
+	return r != RoleWriteOnly
+}
+
+// DriverOptions 驱动选项
+type DriverOptions struct {
+	// Driver 缓存驱动
+	Driver driver.Cache
+
+	// Weight 读取权重,权重越大越先读取;权重相同时按传入的顺序读取
+	// 写入顺序跟读取顺序一致
+	Weight int
+
+	// Role 驱动角色,默认为 RoleWriteThrough
+	Role DriverRole
+}
+
+// clientDriver 客户端内部使用的驱动选项
+type clientDriver struct {
+	driver driver.Common
+	weight int
+	role   DriverRole
+}
+
+// clientWriter 可写入的驱动
+type clientWriter struct {
+	driver driver.Common
+
+	// behind 异步写入队列,为nil时表示同步写入
+	behind *writeBehind
+}
+
+// writeBehind 异步写入队列
+// 每个驱动一个队列,保证同一个驱动的写操作按顺序执行
+type writeBehind struct {
+	queue chan func()
+
+	// done 队列中的写操作全部执行完成后关闭
+	done chan struct{}
+
+	// mutex 推入队列时持有读锁,关闭队列时持有写锁
+	mutex sync.RWMutex
+
+	// closed 指示队列已经关闭,不再接收写操作
+	closed bool
+}
+
+// newWriteBehind 返回一个异步写入队列,并启动执行协程
+func newWriteBehind(size int) *writeBehind {
+	wb := &writeBehind{queue: make(chan func(), size), done: make(chan struct{})}
+	go wb.run()
+	return wb
+}
+
+// run 依次执行队列中的写操作,队列关闭并执行完剩余的写操作后退出
+func (wb *writeBehind) run() {
+	defer close(wb.done)
+	for fn := range wb.queue {
+		wb.exec(fn)
+	}
+}
+
+// exec 执行写操作,避免某个写操作的异常导致整个队列退出
+func (wb *writeBehind) exec(fn func()) {
+	defer func() {
+		if obj := recover(); obj != nil {
+			log.Printf("[WriteBehind] panic: %v", obj)
+		}
+	}()
+	fn()
+}
+
+// push 将写操作推入队列,队列满时阻塞等待,队列已经关闭时丢弃
+func (wb *writeBehind) push(fn func()) {
+	wb.mutex.RLock()
+	defer wb.mutex.RUnlock()
+	if wb.closed {
+		log.Printf("[WriteBehind] queue closed, write dropped")
+		return
+	}
+	wb.queue <- fn
+}
+
+// close 关闭队列,等待剩余的写操作执行完成,ctx 结束时不再等待
+func (wb *writeBehind) close(ctx context.Context) error {
+	wb.mutex.Lock()
+	if !wb.closed {
+		wb.closed = true
+		close(wb.queue)
+	}
+	wb.mutex.Unlock()
+
+	select {
+	case <-wb.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// clientCloser 客户端的关闭状态,同一个客户端的各个类型的客户端共用
+type clientCloser struct {
+	once sync.Once
+
+	// inflight 正在执行的异步回写
+	inflight sync.WaitGroup
+
+	err error
+}
+
+// newBaseClient 根据驱动选项返回一个基础客户端
+// 读取驱动按权重从大到小排列,只写的驱动不参与读取,只读的驱动不参与写入
+func newBaseClient(opts *ClientOptions, drivers ...clientDriver) BaseClient {
+	sorted := make([]clientDriver, len(drivers))
+	copy(sorted, drivers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].weight > sorted[j].weight
+	})
+
+	cli := BaseClient{
+		drivers:   make([]driver.Common, 0, len(sorted)),
+		writers:   make([]*clientWriter, 0, len(sorted)),
+		readOnlys: make([]driver.Common, 0),
+		options:   opts,
+		group:     new(singleflight.Group),
+		closer:    new(clientCloser),
+	}
+
+	for _, d := range sorted {
+		if d.role.readable() {
+			cli.drivers = append(cli.drivers, d.driver)
+		}
+
+		switch d.role {
+		case RoleReadOnly:
+			cli.readOnlys = append(cli.readOnlys, d.driver)
+		case RoleWriteBehind:
+			cli.writers = append(cli.writers, &clientWriter{driver: d.driver, behind: newWriteBehind(opts.writeBehindQueueSize())})
+		default:
+			cli.writers = append(cli.writers, &clientWriter{driver: d.driver})
+		}
+	}
+
+	// 没有同步写入的驱动时,第一个异步写入的驱动改为同步写入,用于返回写入结果
+	if len(cli.writers) > 0 && cli.syncWriter() == nil {
+		cli.writers[0].behind = nil
+	}
+	return cli
+}
+
+// syncWriter 返回第一个同步写入的驱动
+func (cli *BaseClient) syncWriter() *clientWriter {
+	for _, w := range cli.writers {
+		if w.behind == nil {
+			return w
+		}
+	}
+	return nil
+}
+
+// WritePolicy 写操作分发到多个驱动时的策略
+// 只作用于同步写入的驱动,异步写入的驱动失败时只记录日志
+type WritePolicy int
+
+const (
+	// WriteBestEffort 尽力写入所有驱动,默认策略
+	// 有驱动写入失败时,返回值的错误为 *errors.WriteError
+	WriteBestEffort WritePolicy = iota
+
+	// WriteFailFast 有驱动写入失败时立即停止,不再写入后续的驱动
+	WriteFailFast
+
+	// WriteAllMustSucceed 写入所有驱动,有驱动写入失败时删除写入成功的驱动中相关的键进行补偿,避免各层数据不一致
+	WriteAllMustSucceed
+)
+
+// String 返回策略名称
+func (p WritePolicy) String() string {
+	switch p {
+	case WriteBestEffort:
+		return "BestEffort"
+	case WriteFailFast:
+		return "FailFast"
+	case WriteAllMustSucceed:
+		return "AllMustSucceed"
+	}
+	return "Unknown"
+}
+
+// errorSetter 可以设置错误的值对象, go-redis 的所有 Cmd 都实现了该接口
+type errorSetter interface {
+	SetErr(error)
+}
+
+// writeFunc 对某个驱动执行写操作
+type writeFunc func(ctx context.Context, c driver.Common) errors.ErrorValuer
+
+// followFunc 其他驱动根据基准驱动的结果执行的写操作
+type followFunc func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer
+
+// leaderWriter 第一个执行的驱动作为基准,其他驱动根据基准的结果写入,用于结果不确定或者依赖原有数据的写操作
+// 例如随机弹出、计数器,各个驱动各自执行会导致数据不一致
+type leaderWriter struct {
+	mutex sync.Mutex
+
+	// lead 基准驱动的结果,为nil时表示还没有执行
+	lead errors.ErrorValuer
+}
+
+// writeFunc 返回写入函数,第一次调用时执行 lead,之后的调用根据 lead 的结果执行 follow
+// 异步写入的驱动也可能是第一个执行的,所以需要加锁
+func (w *leaderWriter) writeFunc(lead writeFunc, follow followFunc) writeFunc {
+	return func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+
+		if w.lead == nil {
+			w.lead = lead(ctx, c)
+			return w.lead
+		}
+		return follow(ctx, c, w.lead)
+	}
+}
+
+// write 按写入策略将写操作分发到所有可写入的驱动中,返回第一个同步写入驱动的结果
+// 有驱动写入失败时,返回值的错误会被替换成 *errors.WriteError,包含每个驱动的写入结果
+// keys 为本次写操作涉及的键,会从只读的驱动中删除,避免读到旧数据
+func (cli *BaseClient) write(ctx context.Context, keys []string, fn writeFunc) errors.ErrorValuer {
+	if len(cli.writers) == 0 {
+		panic(ErrNoCacheClient)
+	}
+
+	policy := cli.options.writePolicy()
+
+	var value errors.ErrorValuer
+	results := make([]errors.DriverResult, 0, len(cli.writers))
+	failed := false
+	for i, w := range cli.writers {
+		if w.behind != nil {
+			continue
+		}
+
+		v := fn(ctx, w.driver)
+		if value == nil {
+			value = v
+		}
+
+		// 数据不存在不算写入失败,如 LPop 一个空列表
+		result := errors.DriverResult{Index: i}
+		if err := v.Err(); err != nil && !isNilErr(err) {
+			result.Err = err
+			failed = true
+		}
+		results = append(results, result)
+
+		if failed && policy == WriteFailFast {
+			break
+		}
+	}
+
+	if failed {
+		werr := &errors.WriteError{Results: results}
+		if policy == WriteAllMustSucceed {
+			werr.Compensated = cli.compensate(keys, results)
+		}
+		if setter, ok := value.(errorSetter); ok {
+			setter.SetErr(werr)
+		}
+	}
+
+	// 同步写入失败时,只有尽力写入的策略才继续异步写入
+	if !failed || policy == WriteBestEffort {
+		for _, w := range cli.writers {
+			if w.behind != nil {
+				cli.writeBehind(w, keys, fn)
+			}
+		}
+	}
+
+	cli.invalidate(ctx, keys...)
+	return value
+}
+
+// block 阻塞类操作的共同过程
+// 只会在同步写入的驱动中依次阻塞等待,第一个取得数据的驱动作为基准,其他写入驱动以 follow 重放基准的结果,
+// 重放失败的驱动删除 keys 返回的键;成功后同样删除只读驱动中的这些键
+func (cli *BaseClient) block(ctx context.Context, timeout time.Duration, fn writeFunc, follow followFunc, keys func(value errors.ErrorValuer) []string) errors.ErrorValuer {
+	ctx, cancel := cli.preCheckBlocking(ctx, timeout)
+	defer cancel()
+
+	var value errors.ErrorValuer
+	hit := -1
+	for i, w := range cli.writers {
+		if w.behind != nil {
+			continue
+		}
+		if value = fn(ctx, w.driver); returnable(value) {
+			hit = i
+			break
+		}
+	}
+	if hit < 0 || value.Err() != nil {
+		return value
+	}
+
+	affected := keys(value)
+	replay := func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return follow(ctx, c, value)
+	}
+	cli.replay(hit, affected, replay)
+	cli.invalidate(ctx, affected...)
+	return value
+}
+
+// replay 在基准驱动以外的写入驱动中执行 fn
+// 阻塞等待后调用方的 ctx 可能所剩无几,使用新的 ctx;同步写入的驱动执行失败时删除 keys,避免跟基准驱动不一致
+func (cli *BaseClient) replay(hit int, keys []string, fn writeFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), cli.options.timeout())
+	defer cancel()
+
+	for i, w := range cli.writers {
+		if i == hit {
+			continue
+		}
+		if w.behind != nil {
+			cli.writeBehind(w, keys, fn)
+			continue
+		}
+		if v := fn(ctx, w.driver); v.Err() != nil && !isNilErr(v.Err()) {
+			log.Printf("[Replay] write failed. keys:%v, err:%v", keys, v.Err())
+			w.driver.Del(ctx, keys...)
+		}
+	}
+}
+
+// writeBehind 将写操作推入驱动的异步写入队列
+func (cli *BaseClient) writeBehind(w *clientWriter, keys []string, fn writeFunc) {
+	c := w.driver
+	w.behind.push(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cli.options.timeout())
+		defer cancel()
+		if v := fn(ctx, c); v.Err() != nil && !isNilErr(v.Err()) {
+			log.Printf("[WriteBehind] write failed. keys:%v, err:%v", keys, v.Err())
+		}
+	})
+}
+
+// compensate 删除写入成功的驱动中相关的键,全部删除成功时返回true
+// 使用新的 ctx,避免因为调用方的 ctx 超时导致补偿失败
+func (cli *BaseClient) compensate(keys []string, results []errors.DriverResult) bool {
+	if len(keys) == 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cli.options.timeout())
+	defer cancel()
+
+	compensated := true
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		if err := cli.writers[r.Index].driver.Del(ctx, keys...).Err(); err != nil {
+			log.Printf("[Compensate] delete failed. keys:%v, err:%v", keys, err)
+			compensated = false
+		}
+	}
+	return compensated
+}
+
+// invalidate 从只读的驱动中删除指定的键
+func (cli *BaseClient) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	for _, c := range cli.readOnlys {
+		c.Del(ctx, keys...)
+	}
+}
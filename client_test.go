@@ -4,10 +4,14 @@ import (
 	"context"
 	"github.com/redis/go-redis/v9"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/jerbe/jcache/v2/driver"
+
+	jerrors "github.com/jerbe/go-errors"
 )
 
 /**
@@ -201,3 +205,906 @@ func TestBaseClient_Persist(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseClient_TTL(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClient(l1, l2)
+
+	// 只在第二层存在的key,从第二层读取
+	l2.Set(ctx, "l2-only", "value", time.Minute)
+	if ttl := cli.TTL(ctx, "l2-only").Val(); ttl > time.Minute || ttl < time.Second*59 {
+		t.Errorf("TTL(l2-only) got = %v", ttl)
+	}
+	if ttl := cli.PTTL(ctx, "l2-only").Val(); ttl > time.Minute || ttl < time.Second*59 {
+		t.Errorf("PTTL(l2-only) got = %v", ttl)
+	}
+	if at := cli.ExpireTime(ctx, "l2-only").Val(); at <= 0 {
+		t.Errorf("ExpireTime(l2-only) got = %v", at)
+	}
+	if ttl := cli.TTL(ctx, "not-exists").Val(); ttl != -2 {
+		t.Errorf("TTL(not-exists) got = %v, want -2", ttl)
+	}
+
+	// 条件设置在每个驱动中分别判断
+	cli.Set(ctx, "key", "value", 0)
+	if ok := cli.ExpireNX(ctx, "key", time.Hour).Val(); !ok {
+		t.Errorf("ExpireNX() got = %v, want true", ok)
+	}
+	if ok := cli.ExpireGT(ctx, "key", time.Minute).Val(); ok {
+		t.Errorf("ExpireGT() got = %v, want false", ok)
+	}
+	if ok := cli.ExpireLT(ctx, "key", time.Minute).Val(); !ok {
+		t.Errorf("ExpireLT() got = %v, want true", ok)
+	}
+	for _, c := range []driver.Cache{l1, l2} {
+		if ttl := c.TTL(ctx, "key").Val(); ttl > time.Minute || ttl < time.Second*59 {
+			t.Errorf("driver TTL(key) got = %v", ttl)
+		}
+	}
+}
+
+func TestBaseClient_preCheck(t *testing.T) {
+	type ctxKey struct{}
+	parent := context.WithValue(context.Background(), ctxKey{}, "trace")
+
+	deadlineCtx, deadlineCancel := context.WithTimeout(parent, time.Hour)
+	defer deadlineCancel()
+
+	tests := []struct {
+		name         string
+		options      *ClientOptions
+		ctx          context.Context
+		wantDeadline time.Duration
+	}{
+		{
+			name:         "默认超时时间",
+			ctx:          parent,
+			wantDeadline: DefaultTimeout,
+		},
+		{
+			name:         "自定义超时时间",
+			options:      &ClientOptions{Timeout: time.Minute},
+			ctx:          parent,
+			wantDeadline: time.Minute,
+		},
+		{
+			name:    "不设置超时时间",
+			options: &ClientOptions{Timeout: -1},
+			ctx:     parent,
+		},
+		{
+			name:         "保留调用方的截止时间",
+			options:      &ClientOptions{Timeout: time.Minute},
+			ctx:          deadlineCtx,
+			wantDeadline: time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &BaseClient{drivers: []driver.Common{driver.NewMemory()}, options: tt.options}
+			ctx, cancel := cli.preCheck(tt.ctx)
+			defer cancel()
+
+			if got := ctx.Value(ctxKey{}); got != "trace" {
+				t.Errorf("preCheck() value = %v, want trace", got)
+			}
+
+			deadline, ok := ctx.Deadline()
+			if ok != (tt.wantDeadline > 0) {
+				t.Fatalf("preCheck() has deadline = %v, want %v", ok, tt.wantDeadline > 0)
+			}
+			if ok {
+				if d := time.Until(deadline); d > tt.wantDeadline || d < tt.wantDeadline-time.Second {
+					t.Errorf("preCheck() deadline = %v, want %v", d, tt.wantDeadline)
+				}
+			}
+
+			cancel()
+			if ctx.Err() == nil {
+				t.Errorf("preCheck() ctx not canceled")
+			}
+		})
+	}
+
+	t.Run("调用方取消", func(t *testing.T) {
+		cli := newClient()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := cli.Set(ctx, "canceled", "value", time.Minute).Err(); err == nil {
+			t.Errorf("Set() error = nil, want context canceled")
+		}
+	})
+}
+
+func TestListClient_BRPop(t *testing.T) {
+	cli := NewClientWithOptions(ClientOptions{BlockingTimeout: time.Millisecond * 100}, driver.NewMemory())
+	cli.LPush(context.Background(), "brpop", "a")
+
+	got, err := cli.BRPop(context.Background(), time.Second, "brpop").Result()
+	if err != nil || !reflect.DeepEqual(got, []string{"brpop", "a"}) {
+		t.Fatalf("BRPop() got = %v, err = %v", got, err)
+	}
+
+	// 阻塞时长加上额外等待时间后超时
+	start := time.Now()
+	if err = cli.BRPop(context.Background(), time.Millisecond*100, "brpop").Err(); err == nil {
+		t.Errorf("BRPop() error = nil, want timeout")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("BRPop() blocked %v", d)
+	}
+}
+
+func TestListClient_Commands(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithOptions(ClientOptions{}, l1, l2)
+
+	cli.RPush(ctx, "list", "a", "b", "c")
+	cli.LPush(ctx, "list", "z")
+	cli.LSet(ctx, "list", -1, "cc")
+	cli.LInsertBefore(ctx, "list", "b", "a")
+	cli.LRem(ctx, "list", -1, "a")
+	if got := cli.LMove(ctx, "list", "dst", "LEFT", "RIGHT").Val(); got != "z" {
+		t.Errorf("LMove() got = %v, want z", got)
+	}
+	if got := cli.RPopLPush(ctx, "list", "dst").Val(); got != "cc" {
+		t.Errorf("RPopLPush() got = %v, want cc", got)
+	}
+
+	// 每个驱动执行相同的命令后结果一致
+	for i, d := range []driver.List{l1, l2} {
+		if got := d.LRange(ctx, "list", 0, -1).Val(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("l%d LRange(list) got = %v, want [a b]", i+1, got)
+		}
+		if got := d.LRange(ctx, "dst", 0, -1).Val(); !reflect.DeepEqual(got, []string{"cc", "z"}) {
+			t.Errorf("l%d LRange(dst) got = %v, want [cc z]", i+1, got)
+		}
+	}
+
+	if got := cli.LIndex(ctx, "list", 1).Val(); got != "b" {
+		t.Errorf("LIndex() got = %v, want b", got)
+	}
+	if got := cli.LPos(ctx, "dst", "z", driver.LPosArgs{}).Val(); got != 1 {
+		t.Errorf("LPos() got = %v, want 1", got)
+	}
+	if err := cli.LPos(ctx, "dst", "missing", driver.LPosArgs{}).Err(); err != Nil {
+		t.Errorf("LPos() error = %v, want Nil", err)
+	}
+	if got := cli.RPop(ctx, "list").Val(); got != "b" {
+		t.Errorf("RPop() got = %v, want b", got)
+	}
+	if got := cli.LPop(ctx, "list").Val(); got != "a" {
+		t.Errorf("LPop() got = %v, want a", got)
+	}
+}
+
+func TestListClient_BLMove(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithDrivers(ClientOptions{Promote: PromoteSync}, DriverOptions{Driver: l1, Role: RoleReadOnly}, DriverOptions{Driver: l2})
+
+	l2.RPush(ctx, "dst", "x")
+	cli.LLen(ctx, "dst")
+	if got := l1.LLen(ctx, "dst").Val(); got != 1 {
+		t.Fatalf("l1 LLen() got = %v, want 1", got)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		cli.RPush(ctx, "src", "a")
+	}()
+	got, err := cli.BLMove(ctx, "src", "dst", "LEFT", "LEFT", time.Second).Result()
+	if err != nil || got != "a" {
+		t.Fatalf("BLMove() got = %v, err = %v", got, err)
+	}
+	// 移动成功后只读驱动中的旧数据被删除
+	if got := l1.Exists(ctx, "dst").Val(); got != 0 {
+		t.Errorf("l1 Exists() got = %v, want 0", got)
+	}
+	if got := cli.LRange(ctx, "dst", 0, -1).Val(); !reflect.DeepEqual(got, []string{"a", "x"}) {
+		t.Errorf("LRange() got = %v, want [a x]", got)
+	}
+}
+
+func TestListClient_BLPop_writers(t *testing.T) {
+	ctx := context.Background()
+	l1, l2, l3 := driver.NewMemory(), driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithDrivers(ClientOptions{}, DriverOptions{Driver: l1}, DriverOptions{Driver: l2}, DriverOptions{Driver: l3, Role: RoleWriteBehind})
+	cli.RPush(ctx, "list", "a", "b", "c", "d")
+	cli.ZAdd(ctx, "zset", driver.Z{Member: "a", Score: 1}, driver.Z{Member: "b", Score: 2})
+
+	if got := cli.BLPop(ctx, time.Second, "list").Val(); !reflect.DeepEqual(got, []string{"list", "a"}) {
+		t.Errorf("BLPop() got = %v", got)
+	}
+	if got := cli.BRPop(ctx, time.Second, "list").Val(); !reflect.DeepEqual(got, []string{"list", "d"}) {
+		t.Errorf("BRPop() got = %v", got)
+	}
+	if got := cli.BLMove(ctx, "list", "dst", "LEFT", "RIGHT", time.Second).Val(); got != "b" {
+		t.Errorf("BLMove() got = %v, want b", got)
+	}
+	if got := cli.BZPopMax(ctx, time.Second, "zset").Val(); got == nil || got.Member != "b" {
+		t.Errorf("BZPopMax() got = %v", got)
+	}
+
+	// 其他写入驱动重放第一个驱动的结果,异步写入的驱动最终也一致
+	deadline := time.Now().Add(time.Second)
+	for l3.ZCard(ctx, "zset").Val() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	for i, d := range []driver.Common{l1, l2, l3} {
+		if got := d.(driver.List).LRange(ctx, "list", 0, -1).Val(); !reflect.DeepEqual(got, []string{"c"}) {
+			t.Errorf("l%d LRange(list) got = %v, want [c]", i+1, got)
+		}
+		if got := d.(driver.List).LRange(ctx, "dst", 0, -1).Val(); !reflect.DeepEqual(got, []string{"b"}) {
+			t.Errorf("l%d LRange(dst) got = %v, want [b]", i+1, got)
+		}
+		if got := d.(driver.SortedSet).ZRange(ctx, "zset", 0, -1).Val(); !reflect.DeepEqual(got, []string{"a"}) {
+			t.Errorf("l%d ZRange(zset) got = %v, want [a]", i+1, got)
+		}
+	}
+}
+
+func TestSetClient_SPop(t *testing.T) {
+	ctx := context.Background()
+	l1, l2, l3 := driver.NewMemory(), driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithDrivers(ClientOptions{}, DriverOptions{Driver: l1}, DriverOptions{Driver: l2}, DriverOptions{Driver: l3, Role: RoleWriteBehind})
+	cli.SAdd(ctx, "set", "a", "b", "c", "d")
+
+	// 其他驱动移除跟第一个驱动相同的成员
+	popped := cli.SPop(ctx, "set").Val()
+	more := cli.SPopN(ctx, "set", 2).Val()
+	if popped == "" || len(more) != 2 {
+		t.Fatalf("SPop() got = %v, SPopN() got = %v", popped, more)
+	}
+
+	want := l1.SMembers(ctx, "set").Val()
+	if len(want) != 1 {
+		t.Fatalf("l1 SMembers() got = %v, want 1 member", want)
+	}
+	if got := l2.SMembers(ctx, "set").Val(); !reflect.DeepEqual(got, want) {
+		t.Errorf("l2 SMembers() got = %v, want %v", got, want)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for l3.SCard(ctx, "set").Val() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if got := l3.SMembers(ctx, "set").Val(); !reflect.DeepEqual(got, want) {
+		t.Errorf("l3 SMembers() got = %v, want %v", got, want)
+	}
+
+	// 集合为空时返回 Nil
+	cli.SPop(ctx, "set")
+	if err := cli.SPop(ctx, "set").Err(); err != Nil {
+		t.Errorf("SPop() error = %v, want Nil", err)
+	}
+}
+
+func TestStringClient_IncrBy(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithDrivers(ClientOptions{}, DriverOptions{Driver: l1}, DriverOptions{Driver: l2})
+
+	// 第二个驱动中的旧值会被第一个驱动计算后的结果覆盖
+	l2.Set(ctx, "counter", 100, time.Hour)
+	if got := cli.IncrBy(ctx, "counter", 5).Val(); got != 5 {
+		t.Fatalf("IncrBy() got = %v, want 5", got)
+	}
+	if got := cli.Decr(ctx, "counter").Val(); got != 4 {
+		t.Fatalf("Decr() got = %v, want 4", got)
+	}
+	if got := l2.Get(ctx, "counter").Val(); got != "4" {
+		t.Errorf("l2 Get() got = %v, want 4", got)
+	}
+	if ttl := l2.TTL(ctx, "counter").Val(); ttl <= 0 {
+		t.Errorf("l2 TTL() got = %v, want keep ttl", ttl)
+	}
+
+	// 第一个驱动报错时其他驱动不写入
+	cli.Set(ctx, "text", "abc", 0)
+	if err := cli.Incr(ctx, "text").Err(); err == nil {
+		t.Errorf("Incr() error = nil, want error")
+	}
+	if got := l2.Get(ctx, "text").Val(); got != "abc" {
+		t.Errorf("l2 Get() got = %v, want abc", got)
+	}
+
+	// 只有第一个驱动设置成功时其他驱动才写入
+	l2.Set(ctx, "k2", "old", 0)
+	if ok := cli.MSetNX(ctx, "k1", "v1", "k2", "v2").Val(); !ok {
+		t.Fatalf("MSetNX() got = false, want true")
+	}
+	if got := l2.MGet(ctx, "k1", "k2").Val(); !reflect.DeepEqual(got, []interface{}{"v1", "v2"}) {
+		t.Errorf("l2 MGet() got = %v", got)
+	}
+	if ok := cli.MSetNX(ctx, map[string]interface{}{"k1": "x", "k3": "v3"}).Val(); ok {
+		t.Errorf("MSetNX() got = true, want false")
+	}
+	if got := l2.Exists(ctx, "k3").Val(); got != 0 {
+		t.Errorf("l2 Exists() got = %v, want 0", got)
+	}
+}
+
+func TestStringClient_GetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	mem1, mem2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClient(mem1, mem2)
+
+	// 并发未命中只会调用一次加载函数
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(time.Millisecond * 50)
+		return "loaded", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var dst string
+			if err := cli.GetOrLoad(ctx, "load", &dst, loader, &LoadOptions{Expiration: time.Minute}); err != nil || dst != "loaded" {
+				t.Errorf("GetOrLoad() got = %v, err = %v", dst, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+
+	// 加载到的数据写入到所有驱动
+	for i, mem := range []driver.Cache{mem1, mem2} {
+		if got := mem.Get(ctx, "load").Val(); got != "loaded" {
+			t.Errorf("driver[%d] Get() got = %v, want loaded", i, got)
+		}
+	}
+
+	// 空结果会被缓存
+	calls = 0
+	empty := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}
+	for i := 0; i < 2; i++ {
+		var dst string
+		if err := cli.GetOrLoad(ctx, "empty", &dst, empty, nil); err != Nil {
+			t.Errorf("GetOrLoad() error = %v, want Nil", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+
+	// 第一个调用方取消后加载继续执行,其他等待的调用方依然可以拿到结果
+	type ctxKey struct{}
+	var once sync.Once
+	started, release := make(chan struct{}), make(chan struct{})
+	slow := func(ctx context.Context) (interface{}, error) {
+		once.Do(func() { close(started) })
+		<-release
+		if _, ok := ctx.Deadline(); !ok || ctx.Err() != nil || ctx.Value(ctxKey{}) != "first" {
+			return nil, redis.TxFailedErr
+		}
+		return "slow", nil
+	}
+	firstCtx, cancel := context.WithCancel(context.WithValue(ctx, ctxKey{}, "first"))
+	firstErr := make(chan error, 1)
+	go func() {
+		var dst string
+		firstErr <- cli.GetOrLoad(firstCtx, "slow", &dst, slow, nil)
+	}()
+	<-started
+
+	waiterErr := make(chan error, 1)
+	var waiterDst string
+	go func() {
+		waiterErr <- cli.GetOrLoad(ctx, "slow", &waiterDst, slow, nil)
+	}()
+	cancel()
+	if err := <-firstErr; err != context.Canceled {
+		t.Errorf("GetOrLoad() first error = %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	if err := <-waiterErr; err != nil || waiterDst != "slow" {
+		t.Errorf("GetOrLoad() waiter got = %v, err = %v", waiterDst, err)
+	}
+
+	// 加载失败不缓存
+	var dst string
+	if err := cli.GetOrLoad(ctx, "failed", &dst, func(ctx context.Context) (interface{}, error) {
+		return nil, redis.TxFailedErr
+	}, nil); err != redis.TxFailedErr {
+		t.Errorf("GetOrLoad() error = %v, want %v", err, redis.TxFailedErr)
+	}
+	if got := cli.Exists(ctx, "failed").Val(); got != 0 {
+		t.Errorf("Exists() got = %v, want 0", got)
+	}
+}
+
+func TestHashClient_HGetAllOrLoad(t *testing.T) {
+	ctx := context.Background()
+	cli := newClient()
+
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return map[string]string{"f1": "v1", "f2": "v2"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		var dst map[string]string
+		if err := cli.HGetAllOrLoad(ctx, "hash", &dst, loader, nil); err != nil || !reflect.DeepEqual(dst, map[string]string{"f1": "v1", "f2": "v2"}) {
+			t.Errorf("HGetAllOrLoad() got = %v, err = %v", dst, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+
+	var field string
+	if err := cli.HGetOrLoad(ctx, "hash", "f2", &field, loader, nil); err != nil || field != "v2" {
+		t.Errorf("HGetOrLoad() got = %v, err = %v", field, err)
+	}
+	if err := cli.HGetOrLoad(ctx, "hash", "f3", &field, loader, nil); err != Nil {
+		t.Errorf("HGetOrLoad() error = %v, want Nil", err)
+	}
+
+	// 字段级加载,整个Hash表被加载
+	if err := cli.HGetOrLoad(ctx, "hash2", "f1", &field, loader, nil); err != nil || field != "v1" {
+		t.Errorf("HGetOrLoad() got = %v, err = %v", field, err)
+	}
+
+	// 空结果会被缓存
+	calls = 0
+	empty := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, Nil
+	}
+	for i := 0; i < 2; i++ {
+		var dst map[string]string
+		if err := cli.HGetAllOrLoad(ctx, "empty", &dst, empty, nil); err != Nil {
+			t.Errorf("HGetAllOrLoad() error = %v, want Nil", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader called %d times, want 1", calls)
+	}
+}
+
+func TestHashClient_HGetAllAndScan(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithDrivers(ClientOptions{}, DriverOptions{Driver: l1}, DriverOptions{Driver: l2})
+
+	type profile struct {
+		Name     string        `redis:"name"`
+		Age      int           `redis:"age"`
+		Score    float64       `redis:"score"`
+		Vip      bool          `redis:"vip"`
+		Login    time.Time     `redis:"login"`
+		Timeout  time.Duration `redis:"timeout"`
+		Password string        `redis:"-"`
+	}
+
+	want := profile{Name: "jerbe", Age: 18, Score: 99.5, Vip: true, Login: time.Now().Round(0), Timeout: time.Minute, Password: "secret"}
+	if err := cli.HSet(ctx, "profile", want).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 忽略的字段不会写入
+	var got profile
+	if err := cli.HGetAllAndScan(ctx, &got, "profile"); err != nil {
+		t.Fatal(err)
+	}
+	want.Password = ""
+	if !got.Login.Equal(want.Login) {
+		t.Errorf("HGetAllAndScan() login got = %v, want %v", got.Login, want.Login)
+	}
+	got.Login = want.Login
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HGetAllAndScan() got = %+v, want %+v", got, want)
+	}
+
+	// 计数器以第一个驱动的结果为准
+	l2.HSet(ctx, "profile", "age", 100)
+	if got := cli.HIncrBy(ctx, "profile", "age", 1).Val(); got != 19 {
+		t.Errorf("HIncrBy() got = %v, want 19", got)
+	}
+	if got := l2.HGet(ctx, "profile", "age").Val(); got != "19" {
+		t.Errorf("l2 HGet() got = %v, want 19", got)
+	}
+}
+
+func TestHashClient_HExpire(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithDrivers(ClientOptions{Promote: PromoteSync}, DriverOptions{Driver: l1}, DriverOptions{Driver: l2})
+
+	cli.HSet(ctx, "session", "token", "t", "user", "u")
+	if got := cli.HExpire(ctx, "session", time.Hour, "token", "missing").Val(); !reflect.DeepEqual(got, []int64{1, -2}) {
+		t.Fatalf("HExpire() got = %v, want [1 -2]", got)
+	}
+	if got := l2.HTTL(ctx, "session", "token", "user").Val(); !reflect.DeepEqual(got, []int64{3600, -1}) {
+		t.Errorf("l2 HTTL() got = %v, want [3600 -1]", got)
+	}
+
+	// 提升到上层驱动时保持字段的存活时长
+	l1.Del(ctx, "session")
+	if got := cli.HTTL(ctx, "session", "token").Val(); !reflect.DeepEqual(got, []int64{3600}) {
+		t.Errorf("HTTL() got = %v, want [3600]", got)
+	}
+	if got := l1.HTTL(ctx, "session", "token", "user").Val(); !reflect.DeepEqual(got, []int64{3600, -1}) {
+		t.Errorf("l1 HTTL() got = %v, want [3600 -1]", got)
+	}
+
+	if got := cli.HPersist(ctx, "session", "token").Val(); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("HPersist() got = %v, want [1]", got)
+	}
+}
+
+func TestSortedSetClient_Commands(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithOptions(ClientOptions{}, l1, l2)
+
+	cli.ZAdd(ctx, "board", driver.Z{Member: "a", Score: 10}, driver.Z{Member: "b", Score: 20}, driver.Z{Member: "c", Score: 30})
+	if got := cli.ZAddGT(ctx, "board", driver.Z{Member: "a", Score: 5}, driver.Z{Member: "d", Score: 40}).Val(); got != 1 {
+		t.Errorf("ZAddGT() got = %v, want 1", got)
+	}
+	if got := cli.ZAddArgsIncr(ctx, "board", driver.ZAddArgs{XX: true, Members: []driver.Z{{Member: "b", Score: 15}}}).Val(); got != 35 {
+		t.Errorf("ZAddArgsIncr() got = %v, want 35", got)
+	}
+	cli.ZAdd(ctx, "incr", driver.Z{Member: "b", Score: 35})
+	l2.ZAdd(ctx, "incr", driver.Z{Member: "b", Score: 100})
+	if got := cli.ZAddArgsIncr(ctx, "incr", driver.ZAddArgs{Members: []driver.Z{{Member: "b", Score: 1}}}).Val(); got != 36 {
+		t.Errorf("ZAddArgsIncr() got = %v, want 36", got)
+	}
+	if got := l2.ZScore(ctx, "incr", "b").Val(); got != 36 {
+		t.Errorf("l2 ZScore() got = %v, want 36", got)
+	}
+	// 条件不满足时其他驱动也不写入
+	if err := cli.ZAddArgsIncr(ctx, "incr", driver.ZAddArgs{XX: true, Members: []driver.Z{{Member: "x", Score: 1}}}).Err(); err != Nil {
+		t.Errorf("ZAddArgsIncr() err = %v, want Nil", err)
+	}
+	if got := l2.ZScore(ctx, "incr", "x").Err(); got != Nil {
+		t.Errorf("l2 ZScore() err = %v, want Nil", got)
+	}
+	if got := cli.ZPopMin(ctx, "board").Val(); !reflect.DeepEqual(got, []driver.Z{{Member: "a", Score: 10}}) {
+		t.Errorf("ZPopMin() got = %v", got)
+	}
+	cli.ZAdd(ctx, "bonus", driver.Z{Member: "c", Score: 1}, driver.Z{Member: "e", Score: 1})
+	if got := cli.ZUnionStore(ctx, "total", &driver.ZStore{Keys: []string{"board", "bonus"}}).Val(); got != 4 {
+		t.Errorf("ZUnionStore() got = %v, want 4", got)
+	}
+	if got := cli.ZInterStore(ctx, "both", &driver.ZStore{Keys: []string{"board", "bonus"}, Aggregate: "MIN"}).Val(); got != 1 {
+		t.Errorf("ZInterStore() got = %v, want 1", got)
+	}
+
+	// 第二个驱动中的旧值会被第一个驱动计算后的结果覆盖
+	l2.ZAdd(ctx, "counter", driver.Z{Member: "m", Score: 100})
+	if got := cli.ZIncrBy(ctx, "counter", 2.5, "m").Val(); got != 2.5 {
+		t.Errorf("ZIncrBy() got = %v, want 2.5", got)
+	}
+	if got := l2.ZScore(ctx, "counter", "m").Val(); got != 2.5 {
+		t.Errorf("l2 ZScore() got = %v, want 2.5", got)
+	}
+
+	// 每个驱动执行相同的命令后结果一致
+	want := []driver.Z{{Member: "e", Score: 1}, {Member: "c", Score: 31}, {Member: "b", Score: 35}, {Member: "d", Score: 40}}
+	for i, d := range []driver.SortedSet{l1, l2} {
+		if got := d.ZRangeWithScores(ctx, "total", 0, -1).Val(); !reflect.DeepEqual(got, want) {
+			t.Errorf("l%d ZRangeWithScores(total) got = %v, want %v", i+1, got, want)
+		}
+		if got := d.ZMScore(ctx, "both", "c", "b").Val(); !reflect.DeepEqual(got, []float64{1, 0}) {
+			t.Errorf("l%d ZMScore(both) got = %v, want [1 0]", i+1, got)
+		}
+	}
+
+	if got := cli.ZRevRangeByScore(ctx, "total", &driver.ZRangeBy{Min: "(1", Max: "+inf", Count: 2}).Val(); !reflect.DeepEqual(got, []string{"d", "b"}) {
+		t.Errorf("ZRevRangeByScore() got = %v, want [d b]", got)
+	}
+	if got := cli.ZRandMemberWithScores(ctx, "total", -5).Val(); len(got) != 5 {
+		t.Errorf("ZRandMemberWithScores() got = %v, want 5 members", got)
+	}
+
+	type entry struct {
+		Name  string  `redis:"member"`
+		Score float64 `redis:"score"`
+	}
+	var top []*entry
+	if err := cli.ZRevRangeWithScoresAndScan(ctx, &top, "total", 0, 1); err != nil {
+		t.Fatalf("ZRevRangeWithScoresAndScan() error = %v", err)
+	}
+	if len(top) != 2 || *top[0] != (entry{Name: "d", Score: 40}) || *top[1] != (entry{Name: "b", Score: 35}) {
+		t.Errorf("ZRevRangeWithScoresAndScan() got = %v", top)
+	}
+	var popped []entry
+	if err := cli.ZPopMaxAndScan(ctx, &popped, "total", 2); err != nil || !reflect.DeepEqual(popped, []entry{{Name: "d", Score: 40}, {Name: "b", Score: 35}}) {
+		t.Errorf("ZPopMaxAndScan() got = %v, err = %v", popped, err)
+	}
+	var zs []driver.Z
+	if err := cli.ZRangeWithScoresAndScan(ctx, &zs, "total", 0, -1); err != nil || !reflect.DeepEqual(zs, want[:2]) {
+		t.Errorf("ZRangeWithScoresAndScan() got = %v, err = %v", zs, err)
+	}
+	if err := cli.ZRangeWithScoresAndScan(ctx, &entry{}, "total", 0, -1); err == nil {
+		t.Errorf("ZRangeWithScoresAndScan() error = nil, want non-slice error")
+	}
+}
+
+func TestSortedSetClient_BZPopMin(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithDrivers(ClientOptions{Promote: PromoteSync}, DriverOptions{Driver: l1, Role: RoleReadOnly}, DriverOptions{Driver: l2})
+
+	l2.ZAdd(ctx, "queue", driver.Z{Member: "low", Score: 1})
+	cli.ZCard(ctx, "queue")
+	if got := l1.ZCard(ctx, "queue").Val(); got != 1 {
+		t.Fatalf("l1 ZCard() got = %v, want 1", got)
+	}
+
+	got, err := cli.BZPopMin(ctx, time.Second, "missing", "queue").Result()
+	if err != nil || !reflect.DeepEqual(got, &driver.ZWithKey{Z: driver.Z{Member: "low", Score: 1}, Key: "queue"}) {
+		t.Fatalf("BZPopMin() got = %v, err = %v", got, err)
+	}
+	// 弹出成功后只读驱动中的旧数据被删除
+	if got := l1.Exists(ctx, "queue").Val(); got != 0 {
+		t.Errorf("l1 Exists() got = %v, want 0", got)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		cli.ZAdd(ctx, "queue", driver.Z{Member: "a", Score: 1}, driver.Z{Member: "b", Score: 2})
+	}()
+	if got, err = cli.BZPopMax(ctx, time.Second, "queue").Result(); err != nil || got.Member != "b" {
+		t.Errorf("BZPopMax() got = %v, err = %v", got, err)
+	}
+	if err = cli.BZPopMin(ctx, time.Millisecond*20, "missing").Err(); err != Nil {
+		t.Errorf("BZPopMin() error = %v, want Nil", err)
+	}
+}
+
+func TestBaseClient_promote(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := driver.NewMemory(), driver.NewMemory()
+	cli := NewClientWithOptions(ClientOptions{Promote: PromoteSync, PromoteMaxTTL: time.Minute}, l1, l2)
+
+	l2.Set(ctx, "string", "value", time.Hour)
+	l2.HSet(ctx, "hash", "f1", "v1", "f2", "v2")
+	l2.LPush(ctx, "list", "a", "b", "c")
+	l2.Expire(ctx, "list", time.Second*30)
+	l2.ZAdd(ctx, "zset", driver.Z{Score: 1, Member: "a"}, driver.Z{Score: 2, Member: "b"})
+	l2.SAdd(ctx, "set", "a", "b")
+
+	if got := cli.Get(ctx, "string").Val(); got != "value" {
+		t.Errorf("Get() got = %v, want value", got)
+	}
+	if got := l1.Get(ctx, "string").Val(); got != "value" {
+		t.Errorf("l1 Get() got = %v, want value", got)
+	}
+	// 存活时长不超过 PromoteMaxTTL
+	if ttl := l1.TTL(ctx, "string").Val(); ttl > time.Minute || ttl < time.Second*59 {
+		t.Errorf("l1 TTL(string) got = %v", ttl)
+	}
+
+	cli.HGet(ctx, "hash", "f1")
+	if got := l1.HGetAll(ctx, "hash").Val(); !reflect.DeepEqual(got, map[string]string{"f1": "v1", "f2": "v2"}) {
+		t.Errorf("l1 HGetAll() got = %v", got)
+	}
+
+	cli.LRange(ctx, "list", 0, -1)
+	if got := l1.LRange(ctx, "list", 0, -1).Val(); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+		t.Errorf("l1 LRange() got = %v", got)
+	}
+	// 保留低层驱动中的剩余存活时长
+	if ttl := l1.TTL(ctx, "list").Val(); ttl > time.Second*30 || ttl < time.Second*29 {
+		t.Errorf("l1 TTL(list) got = %v", ttl)
+	}
+
+	cli.ZScore(ctx, "zset", "a")
+	if got := l1.ZRangeWithScores(ctx, "zset", 0, -1).Val(); !reflect.DeepEqual(got, []driver.Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}}) {
+		t.Errorf("l1 ZRangeWithScores() got = %v", got)
+	}
+
+	cli.SIsMember(ctx, "set", "a")
+	if got := l1.SMIsMember(ctx, "set", "a", "b").Val(); !reflect.DeepEqual(got, []bool{true, true}) {
+		t.Errorf("l1 SMIsMember() got = %v", got)
+	}
+
+	// 剩余存活时长不足一秒的键回写后仍然会过期
+	l2.Set(ctx, "short", "value", time.Millisecond*500)
+	cli.Get(ctx, "short")
+	if ttl := l1.PTTL(ctx, "short").Val(); ttl <= 0 || ttl > time.Millisecond*500 {
+		t.Errorf("l1 PTTL(short) got = %v, want (0, 500ms]", ttl)
+	}
+
+	// 默认不回写
+	l1, l2 = driver.NewMemory(), driver.NewMemory()
+	cli = NewClient(l1, l2)
+	l2.Set(ctx, "string", "value", time.Hour)
+	cli.Get(ctx, "string")
+	if got := l1.Exists(ctx, "string").Val(); got != 0 {
+		t.Errorf("l1 Exists() got = %v, want 0", got)
+	}
+}
+
+func TestNewClientWithDrivers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("读取权重", func(t *testing.T) {
+		low, high := driver.NewMemory(), driver.NewMemory()
+		cli := NewClientWithDrivers(ClientOptions{}, DriverOptions{Driver: low}, DriverOptions{Driver: high, Weight: 10})
+		low.Set(ctx, "key", "low", time.Minute)
+		high.Set(ctx, "key", "high", time.Minute)
+		if got := cli.Get(ctx, "key").Val(); got != "high" {
+			t.Errorf("Get() got = %v, want high", got)
+		}
+	})
+
+	t.Run("只读跟只写", func(t *testing.T) {
+		l1, l2, backup := driver.NewMemory(), driver.NewMemory(), driver.NewMemory()
+		cli := NewClientWithDrivers(ClientOptions{Promote: PromoteSync},
+			DriverOptions{Driver: l1, Weight: 10, Role: RoleReadOnly},
+			DriverOptions{Driver: l2},
+			DriverOptions{Driver: backup, Role: RoleWriteOnly},
+		)
+
+		cli.Set(ctx, "key", "v1", time.Minute)
+		if got := l1.Exists(ctx, "key").Val(); got != 0 {
+			t.Errorf("l1 Exists() got = %v, want 0", got)
+		}
+		if got := backup.Get(ctx, "key").Val(); got != "v1" {
+			t.Errorf("backup Get() got = %v, want v1", got)
+		}
+
+		// 只读驱动通过回写填充
+		if got := cli.Get(ctx, "key").Val(); got != "v1" {
+			t.Errorf("Get() got = %v, want v1", got)
+		}
+		if got := l1.Get(ctx, "key").Val(); got != "v1" {
+			t.Errorf("l1 Get() got = %v, want v1", got)
+		}
+
+		// 写操作删除只读驱动中的旧数据
+		cli.Set(ctx, "key", "v2", time.Minute)
+		if got := l1.Exists(ctx, "key").Val(); got != 0 {
+			t.Errorf("l1 Exists() got = %v, want 0", got)
+		}
+		if got := cli.Get(ctx, "key").Val(); got != "v2" {
+			t.Errorf("Get() got = %v, want v2", got)
+		}
+
+		// 只写驱动不参与读取
+		backup.Set(ctx, "backup-only", "value", time.Minute)
+		if err := cli.Get(ctx, "backup-only").Err(); err != Nil {
+			t.Errorf("Get() error = %v, want Nil", err)
+		}
+	})
+
+	t.Run("异步写入", func(t *testing.T) {
+		l1, l2 := driver.NewMemory(), driver.NewMemory()
+		cli := NewClientWithDrivers(ClientOptions{}, DriverOptions{Driver: l1}, DriverOptions{Driver: l2, Role: RoleWriteBehind})
+		for i := 0; i < 100; i++ {
+			cli.LPush(ctx, "list", i)
+		}
+		if got := cli.LLen(ctx, "list").Val(); got != 100 {
+			t.Errorf("LLen() got = %v, want 100", got)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for l2.LLen(ctx, "list").Val() != 100 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond * 10)
+		}
+		if got, want := l2.LRange(ctx, "list", 0, -1).Val(), l1.LRange(ctx, "list", 0, -1).Val(); !reflect.DeepEqual(got, want) {
+			t.Errorf("l2 LRange() got = %v, want %v", got, want)
+		}
+	})
+}
+
+// failSetDriver Set 总是失败的驱动
+type failSetDriver struct {
+	driver.Cache
+}
+
+func (d *failSetDriver) Set(ctx context.Context, key string, data interface{}, ttl time.Duration) driver.StatusValuer {
+	cmd := redis.NewStatusCmd(ctx)
+	cmd.SetErr(redis.ErrClosed)
+	return cmd
+}
+
+func TestBaseClient_write(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		policy      WritePolicy
+		wantResults int
+		wantL1      bool
+		wantL3      bool
+		compensated bool
+	}{
+		{name: "尽力写入", policy: WriteBestEffort, wantResults: 3, wantL1: true, wantL3: true},
+		{name: "快速失败", policy: WriteFailFast, wantResults: 2, wantL1: true},
+		{name: "全部成功", policy: WriteAllMustSucceed, wantResults: 3, compensated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l1, l3 := driver.NewMemory(), driver.NewMemory()
+			cli := NewClientWithOptions(ClientOptions{WritePolicy: tt.policy}, l1, &failSetDriver{driver.NewMemory()}, l3)
+
+			err := cli.Set(ctx, "key", "value", time.Minute).Err()
+			var werr *WriteError
+			if !jerrors.As(err, &werr) {
+				t.Fatalf("Set() error = %v, want *WriteError", err)
+			}
+			if len(werr.Results) != tt.wantResults || len(werr.Failed()) != 1 || werr.Failed()[0].Index != 1 {
+				t.Errorf("Set() results = %+v", werr.Results)
+			}
+			if werr.Compensated != tt.compensated {
+				t.Errorf("Set() compensated = %v, want %v", werr.Compensated, tt.compensated)
+			}
+			if !jerrors.Is(err, redis.ErrClosed) {
+				t.Errorf("Set() error = %v, want wrapping %v", err, redis.ErrClosed)
+			}
+
+			if got := l1.Exists(ctx, "key").Val() > 0; got != tt.wantL1 {
+				t.Errorf("l1 Exists() got = %v, want %v", got, tt.wantL1)
+			}
+			if got := l3.Exists(ctx, "key").Val() > 0; got != tt.wantL3 {
+				t.Errorf("l3 Exists() got = %v, want %v", got, tt.wantL3)
+			}
+		})
+	}
+
+	// 数据不存在不算写入失败
+	cli := NewClientWithOptions(ClientOptions{WritePolicy: WriteAllMustSucceed}, driver.NewMemory(), driver.NewMemory())
+	if err := cli.LPop(ctx, "empty").Err(); err != Nil {
+		t.Errorf("LPop() error = %v, want Nil", err)
+	}
+}
+
+// closeCountDriver 记录 Close 调用次数的驱动
+type closeCountDriver struct {
+	driver.Cache
+	closed int32
+}
+
+func (d *closeCountDriver) Close(ctx context.Context) error {
+	atomic.AddInt32(&d.closed, 1)
+	return d.Cache.Close(ctx)
+}
+
+func TestBaseClient_Close(t *testing.T) {
+	ctx := context.Background()
+
+	l1 := &closeCountDriver{Cache: driver.NewMemory()}
+	l2 := &closeCountDriver{Cache: driver.NewMemory()}
+	cli := NewClientWithDrivers(ClientOptions{},
+		DriverOptions{Driver: l1},
+		DriverOptions{Driver: l2, Role: RoleWriteBehind},
+		DriverOptions{Driver: l1, Role: RoleWriteOnly},
+	)
+	for i := 0; i < 100; i++ {
+		cli.LPush(ctx, "list", i)
+	}
+
+	if err := cli.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// 关闭前异步写入队列中的数据需要全部写入
+	if got := l2.LLen(ctx, "list").Val(); got != 100 {
+		t.Errorf("l2 LLen() got = %v, want 100", got)
+	}
+	if err := cli.Close(ctx); err != nil {
+		t.Errorf("Close() again error = %v", err)
+	}
+	if c1, c2 := atomic.LoadInt32(&l1.closed), atomic.LoadInt32(&l2.closed); c1 != 1 || c2 != 1 {
+		t.Errorf("driver Close() called %d,%d times, want 1,1", c1, c2)
+	}
+
+	// 关闭后的异步写入会被丢弃,不能 panic
+	cli.LPush(ctx, "list", 100)
+}
@@ -2,7 +2,11 @@ package driver
 
 import (
 	"context"
+	"hash/fnv"
+	"math"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	utils "github.com/jerbe/go-utils"
@@ -19,7 +23,8 @@ const (
 	// KeepTTL 保持原有的存活时间
 	KeepTTL time.Duration = -1
 
-	// ValueMaxTTL 数值最多可存活时长
+	// ValueMaxTTL 旧版本中数值最多可存活的时长,现在默认不限制
+	// 需要保持原来的行为时,可以设置到 MemoryConfig.MaxTTL
 	ValueMaxTTL = time.Hour * 6
 )
 
@@ -43,145 +48,426 @@ type expireable interface {
 
 	// SetExpireAt 设置时间
 	SetExpireAt(*time.Time)
+
+	// ExpireTime 获取到期时间
+	ExpireTime() *time.Time
+
+	// Type 返回数值的存储类型
+	Type() driverStoreType
+
+	// touch 记录一次访问
+	touch()
+
+	// accessInfo 返回最后访问的时间戳(纳秒)跟访问频率
+	accessInfo() (int64, uint32)
+
+	// size 数值占用的字节数估算值
+	size() int64
+
+	// charge 记录当前占用的字节数,返回跟上次记录时的差值
+	charge() int64
+
+	// reschedule 到期时间跟上次加入过期队列时不一样时返回新的到期时间(纳秒)跟true
+	reschedule() (int64, bool)
+
+	// discharge 清除记录的字节数,并返回清除前的值
+	discharge() int64
+}
+
+// dumpable 可以导出成字符串切片的
+type dumpable interface {
+	// dump 导出数值
+	dump() []string
+}
+
+// storeEntry 存储器中某个键的导出数据,用于全量同步
+type storeEntry struct {
+	// Key 键
+	Key string
+
+	// Type 存储类型
+	Type driverStoreType
+
+	// Values 导出的数值,各类型的格式如下
+	// String: [value]
+	// Hash: [field1, value1, field2, value2...]
+	// List: [item1, item2...]
+	// Set: [member1, member2...]
+	// SortedSet: [member1, score1, member2, score2...]
+	Values []string
+
+	// ExpireAt 到期时间
+	ExpireAt *time.Time
+
+	// FieldExpires 哈希表字段的到期时间(纳秒),只有设置过字段存活时长的哈希表才有
+	FieldExpires map[string]int64
 }
 
 type expireValue struct {
-	// expireAt 到期时间
+	// accessAt 最后访问的时间戳(纳秒),用于LRU淘汰
+	// 放在第一个字段,保证32位平台上原子操作的内存对齐
+	accessAt int64
+
+	// charged 已经计入键空间的字节数
+	charged int64
+
+	// freq 访问频率,用于LFU淘汰
+	freq uint32
+
+	// scheduled 最后一次加入过期队列时的到期时间(纳秒),0表示没有加入
+	scheduled int64
+
+	// expireAt 到期时间,只在持有写锁时修改
 	expireAt *time.Time
+}
 
-	expired bool
+// touch 记录一次访问,读操作只持有读锁,所以使用原子操作
+func (ev *expireValue) touch() {
+	atomic.StoreInt64(&ev.accessAt, time.Now().UnixNano())
+	if atomic.LoadUint32(&ev.freq) < math.MaxUint32 {
+		atomic.AddUint32(&ev.freq, 1)
+	}
 }
 
-// IsExpire 是否已经过期
+// accessInfo 返回最后访问的时间戳(纳秒)跟访问频率
+func (ev *expireValue) accessInfo() (int64, uint32) {
+	return atomic.LoadInt64(&ev.accessAt), atomic.LoadUint32(&ev.freq)
+}
+
+// chargeSize 记录占用的字节数,返回跟上次记录时的差值
+func (ev *expireValue) chargeSize(size int64) int64 {
+	delta := size - ev.charged
+	ev.charged = size
+	return delta
+}
+
+// discharge 清除记录的字节数,并返回清除前的值
+func (ev *expireValue) discharge() int64 {
+	charged := ev.charged
+	ev.charged = 0
+	return charged
+}
+
+// IsExpire 是否已经过期,没有到期时间的数值永不过期
+// 只读取到期时间,持有读锁时也可以调用
 func (ev *expireValue) IsExpire() bool {
 	if ev.expireAt == nil {
-		// 没有到期时间,强制设定一个到期时间
-		e := time.Now().Add(ValueMaxTTL)
-		ev.expireAt = &e
 		return false
 	}
-	if ev.expired {
-		return true
+	return !ev.expireAt.After(time.Now())
+}
+
+// reschedule 到期时间跟上次加入过期队列时不一样时返回新的到期时间(纳秒)跟true,调用方需要持有写锁
+func (ev *expireValue) reschedule() (int64, bool) {
+	if ev.expireAt == nil {
+		ev.scheduled = 0
+		return 0, false
+	}
+
+	at := ev.expireAt.UnixNano()
+	if at == ev.scheduled {
+		return at, false
 	}
-	ev.expired = ev.expireAt.Before(time.Now())
-	return ev.expired
+	ev.scheduled = at
+	return at, true
 }
 
-// SetExpire 设置可存活时长
+// ExpireTime 获取到期时间,没有到期时间时返回nil
+func (ev *expireValue) ExpireTime() *time.Time {
+	return ev.expireAt
+}
+
+// SetExpire 设置可存活时长,KeepTTL 时保持原有的到期时间
 func (ev *expireValue) SetExpire(d time.Duration) {
-	ev.expired = false
 	if d == KeepTTL {
-		if ev.expireAt == nil {
-			e := time.Now().Add(ValueMaxTTL)
-			ev.expireAt = &e
-		}
 		return
 	}
 
-	if d > ValueMaxTTL {
-		d = ValueMaxTTL
-	}
-
 	t := time.Now().Add(d)
 	ev.expireAt = &t
 }
 
-// SetExpireAt 设置存活到期时间
+// SetExpireAt 设置存活到期时间,为空时表示永不过期
 func (ev *expireValue) SetExpireAt(t *time.Time) {
-	ev.expired = false
-	// 限定一个value最多只能存活 ValueMaxTTL 时
-	// 如果是空值,直接设置成空
 	if utils.IsNil(t) {
-		e := time.Now().Add(ValueMaxTTL)
-		ev.expireAt = &e
+		ev.expireAt = nil
 		return
 	}
+	ev.expireAt = t
+}
+
+// DefaultKeyspaceShards 键空间默认的分片数量
+const DefaultKeyspaceShards = 64
+
+// keyspaceShard 键空间分片,每个分片使用独立的锁,不同分片的键互不影响
+type keyspaceShard struct {
+	// entries,bytes 分片中键的数量跟占用字节数的估算值,不持有锁时也可以读取
+	entries int64
+	bytes   int64
+
+	values map[string]expireable
 
-	maxTTL := time.Now().Add(ValueMaxTTL)
-	if t.After(maxTTL) {
-		t = &maxTTL
+	// maxTTL 数值最多可存活的时长,小于等于0时不限制
+	maxTTL time.Duration
+
+	// expires 按到期时间排列的过期队列
+	expires expireQueue
+
+	rwMutex sync.RWMutex
+}
+
+// limitTTL 把数值的到期时间限制在 maxTTL 内,调用方需要持有写锁
+func (sh *keyspaceShard) limitTTL(v expireable) {
+	if sh.maxTTL <= 0 {
+		return
+	}
+
+	maxAt := time.Now().Add(sh.maxTTL)
+	if at := v.ExpireTime(); at == nil || at.After(maxAt) {
+		v.SetExpireAt(&maxAt)
 	}
-	ev.expireAt = t
 }
 
-type baseStoreer interface {
-	Del(ctx context.Context, keys ...string) (int64, error)
+// store 写入数值并更新分片的统计数据,调用方需要持有写锁
+// 原地修改过的数值也需要调用,用于重新计算占用的字节数
+func (sh *keyspaceShard) store(key string, v expireable) {
+	if old, ok := sh.values[key]; !ok {
+		atomic.AddInt64(&sh.entries, 1)
+		atomic.AddInt64(&sh.bytes, int64(len(key)))
+	} else if old != v {
+		atomic.AddInt64(&sh.bytes, -old.discharge())
+	}
 
-	Exists(ctx context.Context, keys ...string) (int64, error)
+	sh.values[key] = v
+	sh.limitTTL(v)
+	sh.schedule(key, v)
+	atomic.AddInt64(&sh.bytes, v.charge())
+	v.touch()
+}
 
-	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+// remove 删除键并更新分片的统计数据,调用方需要持有写锁
+func (sh *keyspaceShard) remove(key string) (expireable, bool) {
+	v, ok := sh.values[key]
+	if !ok {
+		return nil, false
+	}
 
-	ExpireAt(ctx context.Context, key string, at time.Time) (bool, error)
+	delete(sh.values, key)
+	atomic.AddInt64(&sh.entries, -1)
+	atomic.AddInt64(&sh.bytes, -v.discharge()-int64(len(key)))
+	return v, true
+}
 
-	Persist(ctx context.Context, key string) (bool, error)
+// reset 清空分片,调用方需要持有写锁
+func (sh *keyspaceShard) reset() {
+	sh.values = make(map[string]expireable)
+	sh.expires = nil
+	atomic.StoreInt64(&sh.entries, 0)
+	atomic.StoreInt64(&sh.bytes, 0)
+}
 
-	Type() driverStoreType
+// lookup 查找未过期的键,调用方需要持有分片的锁
+// 键不存在或已经过期时返回nil,键的类型不是 storeType 时返回 MemoryWrongType
+func (sh *keyspaceShard) lookup(key string, storeType driverStoreType) (expireable, error) {
+	v, ok := sh.values[key]
+	if !ok || v.IsExpire() {
+		return nil, nil
+	}
 
-	KeyExists(key string) bool
+	if v.Type() != storeType {
+		return nil, MemoryWrongType
+	}
+	v.touch()
+	return v, nil
 }
 
-// baseStore 基础存储
-type baseStore struct {
-	values map[string]expireable
+// keyspace 键空间,所有类型的存储器共用同一个键空间
+// 每个键只对应一个类型的数值,类型检测跟写入在同一个锁内完成
+// 键根据哈希值分布到多个分片中,多个键的操作按分片序号从小到大加锁,避免死锁
+type keyspace struct {
+	// evictions,expirations,rejections 淘汰、过期清理跟因为容量不足拒绝写入的次数
+	evictions   int64
+	expirations int64
+	rejections  int64
 
-	rwMutex sync.RWMutex
+	shards []*keyspaceShard
+
+	// expirePrecision 主动过期检测的间隔(纳秒),原子读写
+	expirePrecision int64
+
+	// closed 关闭后停止主动过期检测
+	closed    chan struct{}
+	closeOnce sync.Once
 
-	expireTicker *time.Ticker
+	// limits 容量限制跟淘汰策略
+	limits keyspaceLimits
+
+	// onEvict 键被淘汰或者过期清理后的回调
+	onEvict func(key string, reason EvictReason)
+}
+
+// newKeyspace 返回一个默认分片数量的键空间
+func newKeyspace() *keyspace {
+	return newShardedKeyspace(DefaultKeyspaceShards)
+}
+
+// newShardedKeyspace 返回一个指定分片数量的键空间,并启动过期检测
+func newShardedKeyspace(shards int) *keyspace {
+	if shards <= 0 {
+		shards = DefaultKeyspaceShards
+	}
+
+	ks := &keyspace{
+		shards:          make([]*keyspaceShard, shards),
+		expirePrecision: int64(DefaultExpirePrecision),
+		closed:          make(chan struct{}),
+	}
+	for i := range ks.shards {
+		ks.shards[i] = &keyspaceShard{values: make(map[string]expireable)}
+	}
+
+	// 定时检测到期key
+	go ks.activeExpireLoop()
+	return ks
+}
+
+// setMaxTTL 设置数值最多可存活的时长,小于等于0时不限制
+func (ks *keyspace) setMaxTTL(d time.Duration) {
+	for _, sh := range ks.shards {
+		sh.rwMutex.Lock()
+		sh.maxTTL = d
+		sh.rwMutex.Unlock()
+	}
+}
+
+// shardIndex 返回键所在分片的序号
+func (ks *keyspace) shardIndex(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(ks.shards)))
+}
+
+// shard 返回键所在的分片
+func (ks *keyspace) shard(key string) *keyspaceShard {
+	return ks.shards[ks.shardIndex(key)]
 }
 
-// deleteExpiredKeys 删除过期的键
-func (s *baseStore) deleteExpiredKeys() {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+// lockShards 按分片序号从小到大锁住多个键所在的分片,返回解锁函数
+// write 为true时加写锁,否则加读锁
+func (ks *keyspace) lockShards(write bool, keys ...string) func() {
+	indexes := make([]int, 0, len(keys))
+	seen := make(map[int]struct{}, len(keys))
+	for _, key := range keys {
+		idx := ks.shardIndex(key)
+		if _, ok := seen[idx]; ok {
+			continue
+		}
+		seen[idx] = struct{}{}
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	for _, idx := range indexes {
+		if write {
+			ks.shards[idx].rwMutex.Lock()
+		} else {
+			ks.shards[idx].rwMutex.RLock()
+		}
+	}
 
-	// 移除已经被标记成过期的key
-	for k, v := range s.values {
-		if v.IsExpire() {
-			delete(s.values, k)
+	return func() {
+		for i := len(indexes) - 1; i >= 0; i-- {
+			if write {
+				ks.shards[indexes[i]].rwMutex.Unlock()
+			} else {
+				ks.shards[indexes[i]].rwMutex.RUnlock()
+			}
 		}
 	}
 }
 
-// checkExpireTick 检测到期的tick
-func (s *baseStore) checkExpireTick() {
-	defer func() {
-		if obj := recover(); obj != nil {
-			go s.checkExpireTick()
+// value 返回键对应的数值,不检测是否过期
+func (ks *keyspace) value(key string) expireable {
+	sh := ks.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+	return sh.values[key]
+}
+
+// checkType 检测多个键的类型,调用方不能持有锁
+func (ks *keyspace) checkType(storeType driverStoreType, keys ...string) error {
+	unlock := ks.lockShards(false, keys...)
+	defer unlock()
+
+	for _, key := range keys {
+		if _, err := ks.shard(key).lookup(key, storeType); err != nil {
+			return err
 		}
-	}()
-	for {
-		select {
-		case <-s.expireTicker.C:
-			s.deleteExpiredKeys()
+	}
+	return nil
+}
+
+// entries 导出所有未过期的数据
+func (ks *keyspace) entries() []*storeEntry {
+	result := make([]*storeEntry, 0)
+	for _, sh := range ks.shards {
+		sh.rwMutex.RLock()
+		for k, v := range sh.values {
+			if v.IsExpire() {
+				continue
+			}
+
+			d, ok := v.(dumpable)
+			if !ok {
+				continue
+			}
+
+			entry := &storeEntry{Key: k, Type: v.Type(), Values: d.dump()}
+			if at := v.ExpireTime(); at != nil {
+				t := *at
+				entry.ExpireAt = &t
+			}
+			if fv, ok := v.(fieldExpireable); ok {
+				entry.FieldExpires = fv.fieldExpireTimes()
+			}
+			result = append(result, entry)
 		}
+		sh.rwMutex.RUnlock()
 	}
+	return result
 }
 
-// KeyExists 验证键是否存在
-func (s *baseStore) KeyExists(key string) bool {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
-	_, ok := s.values[key]
-	return ok
+// restore 写入已构建好的数值
+func (ks *keyspace) restore(key string, value expireable) {
+	sh := ks.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+	sh.store(key, value)
 }
 
-// Type 返回存储器类型
-func (s *baseStore) Type() driverStoreType {
-	return ""
+// flush 清空所有数据,按分片序号锁住所有分片后再清空
+func (ks *keyspace) flush() {
+	for _, sh := range ks.shards {
+		sh.rwMutex.Lock()
+	}
+	for i := len(ks.shards) - 1; i >= 0; i-- {
+		ks.shards[i].reset()
+		ks.shards[i].rwMutex.Unlock()
+	}
 }
 
 // Del 删除指定键数量
-func (s *baseStore) Del(ctx context.Context, keys ...string) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+func (ks *keyspace) Del(ctx context.Context, keys ...string) (int64, error) {
+	unlock := ks.lockShards(true, keys...)
+	defer unlock()
 	select {
 	case <-ctx.Done():
 		return 0, ctx.Err()
 	default:
 		cnt := int64(0)
 		for _, key := range keys {
-			if _, ok := s.values[key]; ok {
-				delete(s.values, key)
+			// 已经过期的键等同于不存在
+			if v, ok := ks.shard(key).remove(key); ok && !v.IsExpire() {
 				cnt++
 			}
 		}
@@ -190,9 +476,9 @@ func (s *baseStore) Del(ctx context.Context, keys ...string) (int64, error) {
 }
 
 // Exists 判断键是否存在
-func (s *baseStore) Exists(ctx context.Context, keys ...string) (int64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+func (ks *keyspace) Exists(ctx context.Context, keys ...string) (int64, error) {
+	unlock := ks.lockShards(false, keys...)
+	defer unlock()
 	cnt := int64(0)
 	select {
 	case <-ctx.Done():
@@ -200,7 +486,7 @@ func (s *baseStore) Exists(ctx context.Context, keys ...string) (int64, error) {
 	default:
 
 		for _, key := range keys {
-			if _, ok := s.values[key]; ok {
+			if v, ok := ks.shard(key).values[key]; ok && !v.IsExpire() {
 				cnt++
 			}
 		}
@@ -208,57 +494,241 @@ func (s *baseStore) Exists(ctx context.Context, keys ...string) (int64, error) {
 	}
 }
 
+// expireMode 设置存活时间的条件,跟 redis 的 EXPIRE 命令的选项一致
+type expireMode string
+
+const (
+	// expireModeNone 不设置条件
+	expireModeNone expireMode = ""
+
+	// expireModeNX 只有key没有到期时间时才设置
+	expireModeNX expireMode = "NX"
+
+	// expireModeXX 只有key已经有到期时间时才设置
+	expireModeXX expireMode = "XX"
+
+	// expireModeGT 只有新的到期时间比原来的晚时才设置,没有到期时间的key视为永不到期
+	expireModeGT expireMode = "GT"
+
+	// expireModeLT 只有新的到期时间比原来的早时才设置,没有到期时间的key视为永不到期
+	expireModeLT expireMode = "LT"
+)
+
+// allow 判断原来的到期时间 old 是否满足设置新的到期时间 at 的条件
+func (mode expireMode) allow(old *time.Time, at time.Time) bool {
+	switch mode {
+	case expireModeNX:
+		return old == nil
+	case expireModeXX:
+		return old != nil
+	case expireModeGT:
+		return old != nil && at.After(*old)
+	case expireModeLT:
+		return old == nil || at.Before(*old)
+	}
+	return true
+}
+
 // Expire 设置某个key的存活时间
-func (s *baseStore) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+func (ks *keyspace) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return ks.ExpireMode(ctx, key, ttl, expireModeNone)
+}
+
+// ExpireMode 满足条件时设置某个key的存活时间
+func (ks *keyspace) ExpireMode(ctx context.Context, key string, ttl time.Duration, mode expireMode) (bool, error) {
+	sh := ks.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return false, ctx.Err()
 	default:
-		v, ok := s.values[key]
-		if !ok {
+		v, ok := sh.values[key]
+		if !ok || v.IsExpire() {
 			return false, nil
 		}
+		if !mode.allow(v.ExpireTime(), time.Now().Add(ttl)) {
+			return false, nil
+		}
+		// 存活时长不是正数时跟 redis 一样直接删除;不能交给 SetExpire,否则 -1 会被当作 KeepTTL 而保持原来的到期时间
+		if ttl <= 0 {
+			sh.remove(key)
+			return true, nil
+		}
 		v.SetExpire(ttl)
+		sh.limitTTL(v)
+		sh.schedule(key, v)
 		return true, nil
 	}
 }
 
 // ExpireAt 设置某个key在某个时间后失效
-func (s *baseStore) ExpireAt(ctx context.Context, key string, at time.Time) (bool, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+func (ks *keyspace) ExpireAt(ctx context.Context, key string, at time.Time) (bool, error) {
+	sh := ks.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return false, ctx.Err()
 	default:
-		v, ok := s.values[key]
-		if !ok {
+		v, ok := sh.values[key]
+		if !ok || v.IsExpire() {
 			return false, nil
 		}
 		v.SetExpireAt(&at)
+		sh.limitTTL(v)
+		sh.schedule(key, v)
 		return true, nil
 	}
 }
 
 // Persist 设置某个key成为持久性的
-func (s *baseStore) Persist(ctx context.Context, key string) (bool, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+func (ks *keyspace) Persist(ctx context.Context, key string) (bool, error) {
+	sh := ks.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return false, ctx.Err()
 	default:
-		v, ok := s.values[key]
-		if !ok {
+		v, ok := sh.values[key]
+		if !ok || v.IsExpire() {
 			return false, nil
 		}
 
 		v.SetExpireAt(nil)
+		sh.limitTTL(v)
+		sh.schedule(key, v)
 		return true, nil
 	}
 }
+
+// TTL 获取某个key的剩余存活时长,跟 redis 一样精确到秒
+// key不存在或已经过期时返回 -2, 没有到期时间时返回 -1
+func (ks *keyspace) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := ks.ttl(ctx, key)
+	if err != nil || ttl < 0 {
+		return ttl, err
+	}
+	return ttl.Truncate(time.Second), nil
+}
+
+// ttl 获取某个key未经截断的剩余存活时长
+// key不存在或已经过期时返回 -2, 没有到期时间时返回 -1
+func (ks *keyspace) ttl(ctx context.Context, key string) (time.Duration, error) {
+	sh := ks.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, ok := sh.values[key]
+		if !ok {
+			return -2, nil
+		}
+
+		at := v.ExpireTime()
+		if at == nil {
+			return -1, nil
+		}
+
+		ttl := time.Until(*at)
+		if ttl <= 0 {
+			return -2, nil
+		}
+		return ttl, nil
+	}
+}
+
+// PTTL 获取某个key的剩余存活时长,精确到毫秒
+// key不存在或已经过期时返回 -2, 没有到期时间时返回 -1
+func (ks *keyspace) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := ks.ttl(ctx, key)
+	if err != nil || ttl < 0 {
+		return ttl, err
+	}
+	return ttl.Truncate(time.Millisecond), nil
+}
+
+// ExpireTime 获取某个key的到期时间,以距离 Unix 纪元的时长表示,精确到秒
+// key不存在或已经过期时返回 -2, 没有到期时间时返回 -1
+func (ks *keyspace) ExpireTime(ctx context.Context, key string) (time.Duration, error) {
+	sh := ks.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, ok := sh.values[key]
+		if !ok || v.IsExpire() {
+			return -2, nil
+		}
+
+		at := v.ExpireTime()
+		if at == nil {
+			return -1, nil
+		}
+		return time.Duration(at.Unix()) * time.Second, nil
+	}
+}
+
+// baseStore 基础存储,各类型的存储器共用同一个键空间
+type baseStore struct {
+	*keyspace
+}
+
+// block 在 try 取得数据之前阻塞等待 keys 中的键有新数据写入,新数据写入时由 sig 发布键名;try 返回 true 表示已经取得数据或者不需要再等待
+// 列表跟有序集合的阻塞弹出共用该过程
+func (s *baseStore) block(ctx context.Context, storeType driverStoreType, sig *utils.PubSub, timeout time.Duration, keys []string, try func() bool) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// 有键的类型不符时直接返回错误,不进入等待
+	if err := s.checkType(storeType, keys...); err != nil {
+		return err
+	}
+
+	// 先订阅再尝试获取,避免尝试跟订阅之间推入的数据被错过
+	sb := sig.Subscribe()
+	defer sb.Close()
+
+	if try() {
+		return nil
+	}
+
+	// timeout 为0时没有计时器,循环至死
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeoutC:
+			return MemoryNil
+		case k := <-sb.C:
+			for _, key := range keys {
+				if key == k {
+					if try() {
+						return nil
+					}
+					break
+				}
+			}
+		}
+	}
+}
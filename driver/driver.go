@@ -22,7 +22,16 @@ type Cache interface {
 	String
 	Hash
 	List
+	Set
 	SortedSet
+	Closer
+}
+
+// Closer 可以关闭的驱动
+type Closer interface {
+	// Close 关闭驱动,释放运行中的协程跟连接,关闭后不能再使用
+	// ctx 结束时不再等待未完成的操作
+	Close(ctx context.Context) error
 }
 
 // Common 通用接口
@@ -36,11 +45,35 @@ type Common interface {
 	// Expire 设置某个key的存活时间
 	Expire(ctx context.Context, key string, ttl time.Duration) BoolValuer
 
+	// ExpireNX 只有key没有到期时间时才设置存活时间
+	ExpireNX(ctx context.Context, key string, ttl time.Duration) BoolValuer
+
+	// ExpireXX 只有key已经有到期时间时才设置存活时间
+	ExpireXX(ctx context.Context, key string, ttl time.Duration) BoolValuer
+
+	// ExpireGT 只有新的到期时间比原来的晚时才设置存活时间,没有到期时间的key视为永不到期
+	ExpireGT(ctx context.Context, key string, ttl time.Duration) BoolValuer
+
+	// ExpireLT 只有新的到期时间比原来的早时才设置存活时间,没有到期时间的key视为永不到期
+	ExpireLT(ctx context.Context, key string, ttl time.Duration) BoolValuer
+
 	// ExpireAt 设置某个key在指定时间内到期
 	ExpireAt(ctx context.Context, key string, at time.Time) BoolValuer
 
 	// Persist 将某个Key设置成持久性
 	Persist(ctx context.Context, key string) BoolValuer
+
+	// TTL 获取某个key的剩余存活时长
+	// key不存在时返回 -2, key没有设置存活时长时返回 -1
+	TTL(ctx context.Context, key string) DurationValuer
+
+	// PTTL 获取某个key的剩余存活时长,精确到毫秒
+	// key不存在时返回 -2, key没有设置存活时长时返回 -1
+	PTTL(ctx context.Context, key string) DurationValuer
+
+	// ExpireTime 获取某个key的到期时间,以距离 Unix 纪元的时长表示,精确到秒
+	// key不存在时返回 -2, key没有设置存活时长时返回 -1
+	ExpireTime(ctx context.Context, key string) DurationValuer
 }
 
 // String 字符串
@@ -58,6 +91,52 @@ type String interface {
 
 	// MGet 获取多个key的数据
 	MGet(ctx context.Context, keys ...string) SliceValuer
+
+	// Incr 将key中储存的数字加1,key不存在时先初始化为0
+	Incr(ctx context.Context, key string) IntValuer
+
+	// IncrBy 将key中储存的数字加上增量 value,key不存在时先初始化为0
+	IncrBy(ctx context.Context, key string, value int64) IntValuer
+
+	// IncrByFloat 将key中储存的数字加上浮点数增量 value,key不存在时先初始化为0
+	IncrByFloat(ctx context.Context, key string, value float64) FloatValuer
+
+	// Decr 将key中储存的数字减1,key不存在时先初始化为0
+	Decr(ctx context.Context, key string) IntValuer
+
+	// DecrBy 将key中储存的数字减去 decrement,key不存在时先初始化为0
+	DecrBy(ctx context.Context, key string, decrement int64) IntValuer
+
+	// Append 将 value 追加到key原来的值的末尾,返回追加之后字符串的长度
+	Append(ctx context.Context, key, value string) IntValuer
+
+	// GetRange 返回key中字符串值的子字符串,截取的范围由 start 和 end 决定(包括 start 和 end 在内)
+	GetRange(ctx context.Context, key string, start, end int64) StringValuer
+
+	// SetRange 用 value 覆写key中字符串值从偏移量 offset 开始的内容,返回覆写之后字符串的长度
+	SetRange(ctx context.Context, key string, offset int64, value string) IntValuer
+
+	// StrLen 返回key中字符串值的长度
+	StrLen(ctx context.Context, key string) IntValuer
+
+	// GetSet 设置key的值并返回原来的值,原来的存活时长会被清除
+	GetSet(ctx context.Context, key string, data interface{}) StringValuer
+
+	// GetDel 获取key的值并删除key
+	GetDel(ctx context.Context, key string) StringValuer
+
+	// GetEx 获取key的值并设置存活时长
+	// ttl 大于0时设置存活时长,等于0时移除存活时长,小于0时保持原有的存活时长
+	GetEx(ctx context.Context, key string, ttl time.Duration) StringValuer
+
+	// SetXX 只有key已经存在时才设置数据
+	SetXX(ctx context.Context, key string, data interface{}, ttl time.Duration) BoolValuer
+
+	// MSet 同时设置多个key的值
+	MSet(ctx context.Context, values ...interface{}) StatusValuer
+
+	// MSetNX 只有所有的key都不存在时才同时设置多个key的值
+	MSetNX(ctx context.Context, values ...interface{}) BoolValuer
 }
 
 // Hash 哈希表
@@ -93,9 +172,45 @@ type Hash interface {
 
 	// HLen 哈希表所有字段的数量
 	HLen(ctx context.Context, key string) IntValuer
+
+	// HIncrBy 哈希表field的值加上增量 incr,field不存在时先初始化为0
+	HIncrBy(ctx context.Context, key, field string, incr int64) IntValuer
+
+	// HIncrByFloat 哈希表field的值加上浮点数增量 incr,field不存在时先初始化为0
+	HIncrByFloat(ctx context.Context, key, field string, incr float64) FloatValuer
+
+	// HStrLen 哈希表field的值的长度
+	HStrLen(ctx context.Context, key, field string) IntValuer
+
+	// HRandField 随机返回哈希表中的字段
+	// count 为正数时返回最多 count 个不重复的字段;为负数时返回 -count 个字段,字段可能重复
+	HRandField(ctx context.Context, key string, count int) StringSliceValuer
+
+	// HScan 以游标的方式扫描哈希表,返回 字段1,值1,字段2,值2... 以及下一次扫描的游标
+	// 游标为0时从头开始扫描,返回的游标为0时表示扫描结束
+	HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ScanValuer
+
+	// HExpire 设置哈希表字段的存活时长,精确到秒
+	// 每个字段的返回值: -2 字段不存在, 1 设置成功, 2 存活时长小于等于0,字段被删除
+	HExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) IntSliceValuer
+
+	// HPExpire 设置哈希表字段的存活时长,精确到毫秒,返回值同 HExpire
+	HPExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) IntSliceValuer
+
+	// HExpireAt 设置哈希表字段的到期时间,返回值同 HExpire
+	HExpireAt(ctx context.Context, key string, tm time.Time, fields ...string) IntSliceValuer
+
+	// HTTL 返回哈希表字段剩余的存活时长(秒)
+	// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间
+	HTTL(ctx context.Context, key string, fields ...string) IntSliceValuer
+
+	// HPersist 清除哈希表字段的到期时间
+	// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间, 1 清除成功
+	HPersist(ctx context.Context, key string, fields ...string) IntSliceValuer
 }
 
 // List 列表
+// 下标跟 Redis 一致,0 表示列表头部(左边),-1 表示列表尾部(右边)
 type List interface {
 	Common
 
@@ -105,31 +220,139 @@ type List interface {
 	//你也可以使用负数下标，以 -1 表示列表的最后一个元素， -2 表示列表的倒数第二个元素，以此类推。
 	LTrim(ctx context.Context, key string, start, stop int64) StatusValuer
 
-	// LPush 将数据推入到列表中
+	// LPush 依次将数据推入到列表头部,返回推入后的列表长度
 	LPush(ctx context.Context, key string, data ...interface{}) IntValuer
 
-	// LRang 提取列表范围内的数据
-	LRang(ctx context.Context, key string, start, stop int64) StringSliceValuer
+	// RPush 依次将数据推入到列表尾部,返回推入后的列表长度
+	RPush(ctx context.Context, key string, data ...interface{}) IntValuer
 
-	// LPop 推出列表尾的最后数据
+	// LRange 按从头部到尾部的顺序提取列表范围内的数据,start 跟 stop 都包含在内
+	LRange(ctx context.Context, key string, start, stop int64) StringSliceValuer
+
+	// LPop 推出列表头部的第一个数据
 	LPop(ctx context.Context, key string) StringValuer
 
-	// LBPop 移出并获取列表的最后一个元素， 如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止
-	LBPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer
+	// RPop 推出列表尾部的最后一个数据
+	RPop(ctx context.Context, key string) StringValuer
+
+	// LIndex 返回列表中下标对应的元素
+	LIndex(ctx context.Context, key string, index int64) StringValuer
+
+	// LSet 替换列表中下标对应的元素,键不存在或者下标超出范围时返回错误
+	LSet(ctx context.Context, key string, index int64, value interface{}) StatusValuer
+
+	// LRem 移除列表中与 value 相等的元素,返回被移除的数量
+	// count > 0 从头部开始移除 count 个; count < 0 从尾部开始移除 |count| 个; count = 0 移除所有
+	LRem(ctx context.Context, key string, count int64, value interface{}) IntValuer
+
+	// LInsert 把 value 插入到列表中第一个 pivot 的前面(op 为 BEFORE)或者后面(op 为 AFTER),返回插入后的列表长度
+	// 键不存在时返回 0,找不到 pivot 时返回 -1
+	LInsert(ctx context.Context, key, op string, pivot, value interface{}) IntValuer
+
+	// LPos 返回列表中第一个与 value 相等的元素的下标,可以通过 args 指定从第几个匹配开始(Rank)以及最多比较的元素数量(MaxLen)
+	LPos(ctx context.Context, key string, value string, args LPosArgs) IntValuer
+
+	// LPosCount 同 LPos,最多返回 count 个匹配元素的下标,count 为0时返回所有匹配元素的下标
+	LPosCount(ctx context.Context, key string, value string, count int64, args LPosArgs) IntSliceValuer
+
+	// LMove 从 source 的 srcpos(LEFT/RIGHT) 推出一个元素,再推入 destination 的 destpos(LEFT/RIGHT),返回该元素
+	LMove(ctx context.Context, source, destination, srcpos, destpos string) StringValuer
+
+	// RPopLPush 从 source 的尾部推出一个元素,再推入 destination 的头部,返回该元素
+	RPopLPush(ctx context.Context, source, destination string) StringValuer
+
+	// BLMove 同 LMove,source 为空时阻塞等待,直到超时或者有可移动的元素为止
+	BLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) StringValuer
+
+	// BLPop 移出并获取第一个非空列表的头部元素,返回 [key, 元素]
+	// 如果列表都没有元素会阻塞列表直到等待超时或发现可弹出元素为止
+	BLPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer
 
-	// LShift 推出列表头的第一个数据
-	LShift(ctx context.Context, key string) StringValuer
+	// BRPop 同 BLPop,移出并获取列表的尾部元素
+	BRPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer
 
 	// LLen 获取list列表的长度
 	LLen(ctx context.Context, key string) IntValuer
 }
 
+// Set 集合
+type Set interface {
+	Common
+
+	// SAdd 添加一个或多个成员到集合中,已经存在的成员将被忽略
+	SAdd(ctx context.Context, key string, members ...interface{}) IntValuer
+
+	// SRem 移除集合中的一个或多个成员,不存在的成员将被忽略
+	SRem(ctx context.Context, key string, members ...interface{}) IntValuer
+
+	// SMembers 返回集合中的所有成员
+	SMembers(ctx context.Context, key string) StringSliceValuer
+
+	// SIsMember 判断成员是否在集合中
+	SIsMember(ctx context.Context, key string, member interface{}) BoolValuer
+
+	// SMIsMember 判断多个成员是否在集合中,返回结果的顺序跟 members 一致
+	SMIsMember(ctx context.Context, key string, members ...interface{}) BoolSliceValuer
+
+	// SCard 返回集合的成员数量
+	SCard(ctx context.Context, key string) IntValuer
+
+	// SPop 随机移除并返回集合中的一个成员
+	SPop(ctx context.Context, key string) StringValuer
+
+	// SPopN 随机移除并返回集合中最多 count 个成员
+	SPopN(ctx context.Context, key string, count int64) StringSliceValuer
+
+	// SRandMember 随机返回集合中的一个成员
+	SRandMember(ctx context.Context, key string) StringValuer
+
+	// SRandMemberN 随机返回集合中的成员
+	// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+	SRandMemberN(ctx context.Context, key string, count int64) StringSliceValuer
+
+	// SInter 返回多个集合的交集
+	SInter(ctx context.Context, keys ...string) StringSliceValuer
+
+	// SUnion 返回多个集合的并集
+	SUnion(ctx context.Context, keys ...string) StringSliceValuer
+
+	// SDiff 返回第一个集合跟其他集合的差集
+	SDiff(ctx context.Context, keys ...string) StringSliceValuer
+
+	// SInterStore 把多个集合的交集保存到 destination 中,返回结果集合的成员数量
+	SInterStore(ctx context.Context, destination string, keys ...string) IntValuer
+
+	// SUnionStore 把多个集合的并集保存到 destination 中,返回结果集合的成员数量
+	SUnionStore(ctx context.Context, destination string, keys ...string) IntValuer
+
+	// SDiffStore 把第一个集合跟其他集合的差集保存到 destination 中,返回结果集合的成员数量
+	SDiffStore(ctx context.Context, destination string, keys ...string) IntValuer
+}
+
 // SortedSet 排序集合
 type SortedSet interface {
 	Common
 
 	ZAdd(ctx context.Context, key string, members ...Z) IntValuer
 
+	// ZAddNX 只添加新成员,不更新已经存在的成员
+	ZAddNX(ctx context.Context, key string, members ...Z) IntValuer
+
+	// ZAddXX 只更新已经存在的成员,不添加新成员
+	ZAddXX(ctx context.Context, key string, members ...Z) IntValuer
+
+	// ZAddGT 新分数大于原来的分数时才更新,新成员照常添加
+	ZAddGT(ctx context.Context, key string, members ...Z) IntValuer
+
+	// ZAddLT 新分数小于原来的分数时才更新,新成员照常添加
+	ZAddLT(ctx context.Context, key string, members ...Z) IntValuer
+
+	// ZAddArgs 按 NX/XX/GT/LT/CH 条件添加成员,设置 Ch 时返回值包括分数被更新的成员数量
+	ZAddArgs(ctx context.Context, key string, args ZAddArgs) IntValuer
+
+	// ZAddArgsIncr 同 ZAddArgs,在原来的分数上增加,只能有一个成员;条件不满足时返回 errors.Nil
+	ZAddArgsIncr(ctx context.Context, key string, args ZAddArgs) FloatValuer
+
 	ZCard(ctx context.Context, key string) IntValuer
 
 	ZCount(ctx context.Context, key, min, max string) IntValuer
@@ -140,6 +363,15 @@ type SortedSet interface {
 
 	ZRangeByScore(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer
 
+	ZRangeWithScores(ctx context.Context, key string, start, stop int64) ZSliceValuer
+
+	// ZRangeByScoreWithScores 同 ZRangeByScore, 返回的成员带上 score 值
+	ZRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ZSliceValuer
+
+	// ZRangeByLex 返回成员在字典序区间内的成员,区间以 "[" 或 "(" 开头,"-" "+" 表示无穷小跟无穷大
+	// 只有所有成员的 score 值相同时结果才有意义
+	ZRangeByLex(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer
+
 	ZRank(ctx context.Context, key, member string) IntValuer
 
 	ZRem(ctx context.Context, key string, members ...interface{}) IntValuer
@@ -150,9 +382,54 @@ type SortedSet interface {
 
 	ZRevRange(ctx context.Context, key string, start, stop int64) StringSliceValuer
 
+	// ZRevRangeWithScores 同 ZRevRange, 返回的成员带上 score 值
+	ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ZSliceValuer
+
+	// ZRevRangeByScore 同 ZRangeByScore,成员按 score 值递减(从大到小)排列
+	ZRevRangeByScore(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer
+
+	// ZRevRangeByScoreWithScores 同 ZRevRangeByScore, 返回的成员带上 score 值
+	ZRevRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ZSliceValuer
+
+	// ZRevRangeByLex 同 ZRangeByLex,成员按字典序的逆序排列
+	ZRevRangeByLex(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer
+
 	ZRevRank(ctx context.Context, key, member string) IntValuer
 
 	ZScore(ctx context.Context, key, member string) FloatValuer
+
+	// ZMScore 返回多个成员的 score 值,不存在的成员返回0
+	ZMScore(ctx context.Context, key string, members ...string) FloatSliceValuer
+
+	// ZPopMin 移除并返回 score 值最小的 count 个成员,不传 count 时为1
+	ZPopMin(ctx context.Context, key string, count ...int64) ZSliceValuer
+
+	// ZPopMax 移除并返回 score 值最大的 count 个成员,不传 count 时为1
+	ZPopMax(ctx context.Context, key string, count ...int64) ZSliceValuer
+
+	// BZPopMin 从第一个非空的有序集合中移除并返回 score 值最小的成员
+	// 有序集合都为空时阻塞等待,直到超时或者有可弹出的成员为止;timeout 为0时一直阻塞
+	BZPopMin(ctx context.Context, timeout time.Duration, keys ...string) ZWithKeyValuer
+
+	// BZPopMax 同 BZPopMin,移除并返回 score 值最大的成员
+	BZPopMax(ctx context.Context, timeout time.Duration, keys ...string) ZWithKeyValuer
+
+	// ZRandMember 随机返回有序集合中的成员
+	// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+	ZRandMember(ctx context.Context, key string, count int) StringSliceValuer
+
+	// ZRandMemberWithScores 同 ZRandMember, 返回的成员带上 score 值
+	ZRandMemberWithScores(ctx context.Context, key string, count int) ZSliceValuer
+
+	// ZUnionStore 把多个有序集合的并集保存到 destination 中,返回结果的成员数量
+	// 成员的 score 值乘以对应的权重后按 Aggregate(SUM/MIN/MAX) 合并,keys 中也可以是普通集合,成员的 score 值视为1
+	ZUnionStore(ctx context.Context, destination string, store *ZStore) IntValuer
+
+	// ZInterStore 把多个有序集合的交集保存到 destination 中,返回结果的成员数量,score 值的计算同 ZUnionStore
+	ZInterStore(ctx context.Context, destination string, store *ZStore) IntValuer
+
+	// ZDiffStore 把第一个有序集合跟其他有序集合的差集保存到 destination 中,返回结果的成员数量
+	ZDiffStore(ctx context.Context, destination string, keys ...string) IntValuer
 }
 
 // ================================================================================================
@@ -203,6 +480,38 @@ type MapStringStringValuer interface {
 	Result() (map[string]string, error)
 }
 
+// ScanValuer 游标扫描数值接口
+type ScanValuer interface {
+	Val() (keys []string, cursor uint64)
+	Err() error
+
+	Result() (keys []string, cursor uint64, err error)
+}
+
+// DurationValuer 时长数值接口
+type DurationValuer interface {
+	Val() time.Duration
+	Err() error
+
+	Result() (time.Duration, error)
+}
+
+// ZSliceValuer 有序集合成员切片数值接口
+type ZSliceValuer interface {
+	Val() []Z
+	Err() error
+
+	Result() ([]Z, error)
+}
+
+// ZWithKeyValuer 带键名的有序集合成员数值接口
+type ZWithKeyValuer interface {
+	Val() *ZWithKey
+	Err() error
+
+	Result() (*ZWithKey, error)
+}
+
 // SliceValuer 切片数值接口
 type SliceValuer interface {
 	Val() []interface{}
@@ -220,6 +529,14 @@ type IntValuer interface {
 	Result() (int64, error)
 }
 
+// IntSliceValuer 整形切片数值接口
+type IntSliceValuer interface {
+	Val() []int64
+	Err() error
+
+	Result() ([]int64, error)
+}
+
 // BoolValuer 布尔数值接口
 type BoolValuer interface {
 	Val() bool
@@ -228,6 +545,14 @@ type BoolValuer interface {
 	Result() (bool, error)
 }
 
+// BoolSliceValuer 布尔切片数值接口
+type BoolSliceValuer interface {
+	Val() []bool
+	Err() error
+
+	Result() ([]bool, error)
+}
+
 // FloatValuer 浮点型数值接口
 type FloatValuer interface {
 	Val() float64
@@ -236,12 +561,32 @@ type FloatValuer interface {
 	Result() (float64, error)
 }
 
+// FloatSliceValuer 浮点型切片数值接口
+type FloatSliceValuer interface {
+	Val() []float64
+	Err() error
+
+	Result() ([]float64, error)
+}
+
 //---------------------------------------------------------------------
 
 type Z = redis.Z
 
 type ZRangeBy = redis.ZRangeBy
 
+// ZAddArgs ZAddArgs 的参数,跟 go-redis 一样 NX 优先于 XX/GT/LT,GT 优先于 LT
+type ZAddArgs = redis.ZAddArgs
+
+// ZStore ZUnionStore/ZInterStore 的参数,Weights 为空时权重都为1,Aggregate 为空时为 SUM
+type ZStore = redis.ZStore
+
+// ZWithKey 阻塞弹出的有序集合成员以及所在的键
+type ZWithKey = redis.ZWithKey
+
+// LPosArgs LPos 的可选参数,Rank 为负数时从尾部开始查找,MaxLen 为0时比较整个列表
+type LPosArgs = redis.LPosArgs
+
 //---------------------------------------------------------------------
 
 // nilErrors 用于判断是否是空值的错误列表,用于统一返回错误,方便下游错误判断
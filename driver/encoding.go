@@ -2,6 +2,7 @@ package driver
 
 import (
 	"encoding"
+	"errors"
 	"fmt"
 	"net"
 	"reflect"
@@ -95,6 +96,42 @@ func sliceArgs(args []interface{}) []interface{} {
 	return dst
 }
 
+// marshalPairs 将参数列表释放成切片并序列化,用于 key1, value1, key2, value2... 格式的参数
+func marshalPairs(args []interface{}) ([]string, error) {
+	dataSlice := sliceArgs(args)
+	if len(dataSlice)%2 != 0 {
+		return nil, errors.New("the number of parameters is incorrect")
+	}
+
+	pairs := make([]string, len(dataSlice))
+	for i := 0; i < len(dataSlice); i++ {
+		data, err := marshalData(dataSlice[i])
+		if err != nil {
+			return nil, err
+		}
+		pairs[i] = data
+	}
+	return pairs, nil
+}
+
+// parseInts 把同步结果中的整数还原,无法解析的记为0
+func parseInts(values []string) []int64 {
+	result := make([]int64, len(values))
+	for i, v := range values {
+		result[i], _ = strconv.ParseInt(v, 10, 64)
+	}
+	return result
+}
+
+// pairKeys 返回 key1, value1, key2, value2... 格式参数中的所有key
+func pairKeys(pairs []string) []string {
+	keys := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		keys = append(keys, pairs[i])
+	}
+	return keys
+}
+
 func sliceArg(dst []interface{}, arg interface{}) []interface{} {
 	switch arg := arg.(type) {
 	case []string:
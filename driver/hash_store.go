@@ -3,7 +3,11 @@ package driver
 import (
 	"context"
 	"errors"
-	"sync"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -19,39 +23,231 @@ type hashValue struct {
 
 	// value 值
 	value map[string]string
+
+	// bytes 所有字段跟值的字节数
+	bytes int64
+
+	// fieldExpires 字段的到期时间(纳秒),没有设置过字段存活时长时为nil
+	fieldExpires map[string]int64
+
+	// fieldScheduled 最后一次加入过期队列时最早的字段到期时间(纳秒),0表示没有加入
+	fieldScheduled int64
 }
 
 func newHashValue() *hashValue {
-	defaultExpireAt := time.Now().Add(ValueMaxTTL)
 	return &hashValue{
-		expireValue: expireValue{
-			expireAt: &defaultExpireAt,
-			expired:  false,
-		},
-		value: make(map[string]string),
+		expireValue: expireValue{},
+		value:       make(map[string]string),
+	}
+}
+
+// Type 返回数值的存储类型
+func (v *hashValue) Type() driverStoreType {
+	return driverStoreTypeHash
+}
+
+// size 数值占用的字节数估算值
+func (v *hashValue) size() int64 {
+	return v.bytes
+}
+
+// charge 记录当前占用的字节数,返回跟上次记录时的差值
+func (v *hashValue) charge() int64 {
+	return v.chargeSize(v.size())
+}
+
+// IsExpire 是否已经过期,所有字段都已经过期时也视为整个哈希表过期
+func (v *hashValue) IsExpire() bool {
+	if v.expireValue.IsExpire() {
+		return true
+	}
+	if len(v.fieldExpires) == 0 || len(v.fieldExpires) < len(v.value) {
+		return false
+	}
+
+	now := time.Now().UnixNano()
+	for _, at := range v.fieldExpires {
+		if at > now {
+			return false
+		}
+	}
+	return true
+}
+
+// set 设置字段的值并清除字段的到期时间,返回是否新增的字段
+func (v *hashValue) set(field, value string) bool {
+	v.persistField(field)
+	return v.update(field, value)
+}
+
+// update 设置字段的值,保持字段原有的到期时间,返回是否新增的字段
+func (v *hashValue) update(field, value string) bool {
+	old, ok := v.value[field]
+	if ok {
+		v.bytes -= int64(len(old))
+	} else {
+		v.bytes += int64(len(field))
+	}
+	v.bytes += int64(len(value))
+	v.value[field] = value
+	return !ok
+}
+
+// del 删除字段,返回字段是否存在
+func (v *hashValue) del(field string) bool {
+	old, ok := v.value[field]
+	if !ok {
+		return false
+	}
+	v.bytes -= int64(len(field) + len(old))
+	delete(v.value, field)
+	v.persistField(field)
+	return true
+}
+
+// get 返回没有过期的字段的值
+func (v *hashValue) get(field string, now int64) (string, bool) {
+	value, ok := v.value[field]
+	if !ok || v.fieldExpired(field, now) {
+		return "", false
+	}
+	return value, true
+}
+
+// length 返回没有过期的字段数量
+func (v *hashValue) length(now int64) int {
+	cnt := len(v.value)
+	for _, at := range v.fieldExpires {
+		if at <= now {
+			cnt--
+		}
+	}
+	return cnt
+}
+
+// fieldExpired 字段是否已经过期,没有到期时间的字段永不过期
+func (v *hashValue) fieldExpired(field string, now int64) bool {
+	at, ok := v.fieldExpires[field]
+	return ok && at <= now
+}
+
+// expireField 设置字段的到期时间(纳秒)
+func (v *hashValue) expireField(field string, at int64) {
+	if v.fieldExpires == nil {
+		v.fieldExpires = make(map[string]int64)
+	}
+	if _, ok := v.fieldExpires[field]; !ok {
+		v.bytes += 8
+	}
+	v.fieldExpires[field] = at
+}
+
+// persistField 清除字段的到期时间,返回字段原来是否有到期时间
+func (v *hashValue) persistField(field string) bool {
+	if _, ok := v.fieldExpires[field]; !ok {
+		return false
+	}
+	v.bytes -= 8
+	delete(v.fieldExpires, field)
+	if len(v.fieldExpires) == 0 {
+		v.fieldExpires = nil
+	}
+	return true
+}
+
+// expireFields 删除已经过期的字段,返回删除后是否没有任何字段,调用方需要持有写锁
+func (v *hashValue) expireFields(now int64) bool {
+	for field, at := range v.fieldExpires {
+		if at <= now {
+			v.del(field)
+		}
+	}
+	return len(v.value) == 0
+}
+
+// nextFieldExpire 返回最早的字段到期时间(纳秒),没有字段设置到期时间时返回0
+func (v *hashValue) nextFieldExpire() int64 {
+	next := int64(0)
+	for _, at := range v.fieldExpires {
+		if next == 0 || at < next {
+			next = at
+		}
+	}
+	return next
+}
+
+// rescheduleFields 最早的字段到期时间跟上次加入过期队列时不一样时返回新的到期时间(纳秒)跟true,调用方需要持有写锁
+func (v *hashValue) rescheduleFields() (int64, bool) {
+	at := v.nextFieldExpire()
+	if at == v.fieldScheduled {
+		return at, false
+	}
+	v.fieldScheduled = at
+	return at, at > 0
+}
+
+// fieldExpireTimes 导出没有过期的字段的到期时间
+func (v *hashValue) fieldExpireTimes() map[string]int64 {
+	if len(v.fieldExpires) == 0 {
+		return nil
+	}
+
+	now := time.Now().UnixNano()
+	result := make(map[string]int64, len(v.fieldExpires))
+	for field, at := range v.fieldExpires {
+		if at > now {
+			result[field] = at
+		}
+	}
+	return result
+}
+
+// fields 返回没有过期的字段
+func (v *hashValue) fields(now int64) []string {
+	result := make([]string, 0, len(v.value))
+	for field := range v.value {
+		if !v.fieldExpired(field, now) {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// dump 导出数值,不包括已经过期的字段
+func (v *hashValue) dump() []string {
+	now := time.Now().UnixNano()
+	result := make([]string, 0, len(v.value)*2)
+	for field, value := range v.value {
+		if !v.fieldExpired(field, now) {
+			result = append(result, field, value)
+		}
 	}
+	return result
 }
 
 type hashStore struct {
 	baseStore
 }
 
-func newHashStore() *hashStore {
-	ticker := time.NewTicker(time.Second * 10)
-	store := &hashStore{
-		baseStore: baseStore{
-			values:       make(map[string]expireable),
-			rwMutex:      sync.RWMutex{},
-			expireTicker: ticker,
-		},
+func newHashStore(ks *keyspace) *hashStore {
+	return &hashStore{
+		baseStore: baseStore{keyspace: ks},
 	}
-
-	go store.checkExpireTick()
-	return store
 }
 
-func (s *hashStore) Type() driverStoreType {
-	return driverStoreTypeHash
+// restore 根据导出的数据恢复某个键
+func (s *hashStore) restore(entry *storeEntry) {
+	val := newHashValue()
+	for i := 0; i+1 < len(entry.Values); i += 2 {
+		val.set(entry.Values[i], entry.Values[i+1])
+	}
+	for field, at := range entry.FieldExpires {
+		if _, ok := val.value[field]; ok {
+			val.expireField(field, at)
+		}
+	}
+	val.SetExpireAt(entry.ExpireAt)
+	s.baseStore.restore(entry.Key, val)
 }
 
 // HSet 写入hash数据
@@ -66,56 +262,91 @@ func (s *hashStore) Type() driverStoreType {
 // HSet("myhash", MyHash{"value1", "value2"}) 警告：redis-server >= 4.0
 // 对于struct，可以是结构体指针类型，我们只解析标签为redis的字段。如果你不想读取该字段，可以使用 `redis:"-"` 标志来忽略它，或者不需要设置 redis 标签。对于结构体字段的类型，我们只支持简单的数据类型：string、int/uint(8,16,32,64)、float(32,64)、time.Time(to RFC3339Nano)、time.Duration(to Nanoseconds) ），如果是其他更复杂或者自定义的数据类型，请实现encoding.BinaryMarshaler接口。
 func (s *hashStore) HSet(ctx context.Context, key string, data ...string) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return 0, ctx.Err()
 	default:
 
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			val = newHashValue()
 		}
+		val.expireFields(time.Now().UnixNano())
 		if len(data)%2 != 0 {
 			return 0, errors.New("the number of parameters is incorrect")
 		}
 
 		newCnt := int64(0)
 		for i := 0; i < len(data); i += 2 {
-			field := data[i]
-			if _, ok := val.value[field]; !ok {
+			if val.set(data[i], data[i+1]) {
 				newCnt++
 			}
-
-			value := data[i+1]
-			val.value[field] = value
 		}
 
-		s.values[key] = val
+		sh.store(key, val)
 		return newCnt, nil
 	}
 }
 
+// HSetKeepTTL 设置字段的值并保持字段原有的到期时间
+func (s *hashStore) HSetKeepTTL(ctx context.Context, key, field, data string) error {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			val = newHashValue()
+		}
+		val.expireFields(time.Now().UnixNano())
+		val.update(field, data)
+		sh.store(key, val)
+		return nil
+	}
+}
+
 // HSetNX 如果field不存在则设置成功
 func (s *hashStore) HSetNX(ctx context.Context, key, field string, data string) (bool, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return false, ctx.Err()
 	default:
 
-		val, ok := s.values[key].(*hashValue)
-		if ok {
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return false, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			val = newHashValue()
+		}
+		val.expireFields(time.Now().UnixNano())
+		if _, ok := val.value[field]; ok {
 			return false, nil
 		}
 
-		val = newHashValue()
-		val.value[field] = data
-		s.values[key] = val
+		val.set(field, data)
+		sh.store(key, val)
 
 		return true, nil
 	}
@@ -123,49 +354,58 @@ func (s *hashStore) HSetNX(ctx context.Context, key, field string, data string)
 
 // HExists 判断field是否存在
 func (s *hashStore) HExists(ctx context.Context, key, field string) (bool, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return false, ctx.Err()
 	default:
 
-		val, ok := s.values[key].(*hashValue)
-		if !ok {
-			return false, nil
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return false, err
 		}
-
-		_, ok = val.value[field]
+		val, ok := v.(*hashValue)
 		if !ok {
 			return false, nil
 		}
-		return true, nil
+
+		_, ok = val.get(field, time.Now().UnixNano())
+		return ok, nil
 	}
 }
 
 // HDel 哈希表删除指定字段(fields)
 func (s *hashStore) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return 0, ctx.Err()
 	default:
 		affectsCnt := int64(0)
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			return 0, nil
 		}
+		val.expireFields(time.Now().UnixNano())
 		for _, field := range fields {
-			if _, ok := val.value[field]; ok {
+			if val.del(field) {
 				affectsCnt++
-				delete(val.value, field)
 			}
 		}
 		if len(val.value) == 0 {
-			delete(s.values, key)
+			sh.remove(key)
+		} else {
+			sh.store(key, val)
 		}
 		return affectsCnt, nil
 	}
@@ -173,19 +413,24 @@ func (s *hashStore) HDel(ctx context.Context, key string, fields ...string) (int
 
 // HGet 哈希表获取一个数据
 func (s *hashStore) HGet(ctx context.Context, key string, field string) (string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return "", ctx.Err()
 	default:
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return "", err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			return "", MemoryNil
 		}
 
-		str, ok := val.value[field]
+		str, ok := val.get(field, time.Now().UnixNano())
 		if !ok {
 			return "", MemoryNil
 		}
@@ -197,8 +442,9 @@ func (s *hashStore) HGet(ctx context.Context, key string, field string) (string,
 
 // HMGet 哈希表获取多个数据
 func (s *hashStore) HMGet(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
@@ -206,15 +452,20 @@ func (s *hashStore) HMGet(ctx context.Context, key string, fields ...string) ([]
 	default:
 		rest := make([]interface{}, len(fields), len(fields))
 
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			return rest, nil
 		}
 
 		// 不能使用for rang, 因为它是无序的
+		now := time.Now().UnixNano()
 		for i := 0; i < len(fields); i++ {
 			field := fields[i]
-			str, ok := val.value[field]
+			str, ok := val.get(field, now)
 			if ok {
 				rest[i] = str
 			}
@@ -226,47 +477,52 @@ func (s *hashStore) HMGet(ctx context.Context, key string, fields ...string) ([]
 
 // HKeys 哈希表获取某个Key的所有字段(field)
 func (s *hashStore) HKeys(ctx context.Context, key string) ([]string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			return []string{}, nil
 		}
 
-		rest := make([]string, len(val.value))
-		var i int
-		for field, _ := range val.value {
-			rest[i] = field
-			i++
-		}
-		return rest, nil
+		return val.fields(time.Now().UnixNano()), nil
 	}
 }
 
 // HVals 哈希表获取所有值
 func (s *hashStore) HVals(ctx context.Context, key string) ([]string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return []string{}, ctx.Err()
 	default:
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			return []string{}, nil
 		}
 
-		rest := make([]string, len(val.value))
-		var i int
-		for _, value := range val.value {
-			rest[i] = value
-			i++
+		now := time.Now().UnixNano()
+		rest := make([]string, 0, len(val.value))
+		for field, value := range val.value {
+			if !val.fieldExpired(field, now) {
+				rest = append(rest, value)
+			}
 		}
 		return rest, nil
 	}
@@ -274,21 +530,29 @@ func (s *hashStore) HVals(ctx context.Context, key string) ([]string, error) {
 
 // HGetAll 获取哈希表所有值
 func (s *hashStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return map[string]string{}, ctx.Err()
 	default:
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			return map[string]string{}, nil
 		}
 
+		now := time.Now().UnixNano()
 		rest := make(map[string]string)
 		for k, v := range val.value {
-			rest[k] = v
+			if !val.fieldExpired(k, now) {
+				rest[k] = v
+			}
 		}
 		return rest, nil
 	}
@@ -296,18 +560,446 @@ func (s *hashStore) HGetAll(ctx context.Context, key string) (map[string]string,
 
 // HLen 哈希表所有字段的数量
 func (s *hashStore) HLen(ctx context.Context, key string) (int64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return 0, ctx.Err()
 	default:
-		val, ok := s.values[key].(*hashValue)
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*hashValue)
 		if !ok {
 			return 0, nil
 		}
 
-		return int64(len(val.value)), nil
+		return int64(val.length(time.Now().UnixNano())), nil
+	}
+}
+
+// HIncrBy 将哈希表中field的值加上增量 incr,field不存在时先初始化为0
+// @return 增加之后的值
+func (s *hashStore) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			val = newHashValue()
+		}
+		val.expireFields(time.Now().UnixNano())
+
+		var i int64
+		if str, ok := val.value[field]; ok {
+			i, err = strconv.ParseInt(str, 10, 64)
+			if err != nil {
+				return 0, MemoryNotInteger
+			}
+		}
+
+		if (incr > 0 && i > math.MaxInt64-incr) || (incr < 0 && i < math.MinInt64-incr) {
+			return 0, MemoryOverflow
+		}
+
+		// 跟redis一致,计数不会清除字段的到期时间
+		i += incr
+		val.update(field, strconv.FormatInt(i, 10))
+		sh.store(key, val)
+		return i, nil
+	}
+}
+
+// HIncrByFloat 将哈希表中field的值加上浮点数增量 incr,field不存在时先初始化为0
+// @return 增加之后的值
+func (s *hashStore) HIncrByFloat(ctx context.Context, key, field string, incr float64) (float64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			val = newHashValue()
+		}
+		val.expireFields(time.Now().UnixNano())
+
+		var f float64
+		if str, ok := val.value[field]; ok {
+			f, err = strconv.ParseFloat(str, 64)
+			if err != nil {
+				return 0, MemoryNotFloat
+			}
+		}
+
+		f += incr
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, MemoryNaN
+		}
+
+		val.update(field, strconv.FormatFloat(f, 'f', -1, 64))
+		sh.store(key, val)
+		return f, nil
+	}
+}
+
+// HStrLen 返回哈希表中field的值的长度,key或者field不存在时返回0
+func (s *hashStore) HStrLen(ctx context.Context, key, field string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			return 0, nil
+		}
+
+		str, _ := val.get(field, time.Now().UnixNano())
+		return int64(len(str)), nil
+	}
+}
+
+// HRandField 随机返回哈希表中的字段
+// count 为正数时返回最多 count 个不重复的字段;为负数时返回 -count 个字段,字段可能重复
+func (s *hashStore) HRandField(ctx context.Context, key string, count int) ([]string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok || count == 0 {
+			return []string{}, nil
+		}
+
+		fields := val.fields(time.Now().UnixNano())
+		if len(fields) == 0 {
+			return []string{}, nil
+		}
+		if count < 0 {
+			result := make([]string, -count)
+			for i := range result {
+				result[i] = fields[rand.Intn(len(fields))]
+			}
+			return result, nil
+		}
+
+		if count >= len(fields) {
+			return fields, nil
+		}
+
+		// 只打乱前 count 个位置
+		for i := 0; i < count; i++ {
+			j := i + rand.Intn(len(fields)-i)
+			fields[i], fields[j] = fields[j], fields[i]
+		}
+		return fields[:count], nil
+	}
+}
+
+// HExpireAt 设置哈希表字段的到期时间
+// 每个字段的返回值: -2 字段不存在, 1 设置成功, 2 到期时间已经过去,字段被删除
+func (s *hashStore) HExpireAt(ctx context.Context, key string, at time.Time, fields ...string) ([]int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		result := make([]int64, len(fields))
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			for i := range result {
+				result[i] = -2
+			}
+			return result, nil
+		}
+
+		now := time.Now().UnixNano()
+		val.expireFields(now)
+		for i, field := range fields {
+			if _, ok := val.value[field]; !ok {
+				result[i] = -2
+				continue
+			}
+			if at.UnixNano() <= now {
+				val.del(field)
+				result[i] = 2
+				continue
+			}
+			val.expireField(field, at.UnixNano())
+			result[i] = 1
+		}
+
+		if len(val.value) == 0 {
+			sh.remove(key)
+		} else {
+			sh.store(key, val)
+		}
+		return result, nil
+	}
+}
+
+// HTTL 返回哈希表字段剩余的存活时长
+// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间,其他为剩余的存活时长
+func (s *hashStore) HTTL(ctx context.Context, key string, fields ...string) ([]time.Duration, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		result := make([]time.Duration, len(fields))
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
+
+		now := time.Now().UnixNano()
+		for i, field := range fields {
+			if !ok {
+				result[i] = -2
+				continue
+			}
+			if _, exists := val.get(field, now); !exists {
+				result[i] = -2
+				continue
+			}
+			at, expires := val.fieldExpires[field]
+			if !expires {
+				result[i] = -1
+				continue
+			}
+			result[i] = time.Duration(at - now)
+		}
+		return result, nil
+	}
+}
+
+// HPersist 清除哈希表字段的到期时间
+// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间, 1 清除成功
+func (s *hashStore) HPersist(ctx context.Context, key string, fields ...string) ([]int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		result := make([]int64, len(fields))
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			for i := range result {
+				result[i] = -2
+			}
+			return result, nil
+		}
+
+		val.expireFields(time.Now().UnixNano())
+		for i, field := range fields {
+			if _, ok := val.value[field]; !ok {
+				result[i] = -2
+				continue
+			}
+			if !val.persistField(field) {
+				result[i] = -1
+				continue
+			}
+			result[i] = 1
+		}
+
+		sh.store(key, val)
+		return result, nil
+	}
+}
+
+// DefaultScanCount 扫描时每次默认返回的数量
+const DefaultScanCount = 10
+
+// scanCursor 返回字段的扫描游标,字段按游标从小到大扫描
+// 使用字段的哈希值作为游标,字段增删不会影响其他字段的扫描顺序
+func scanCursor(field string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(field))
+	return h.Sum64()
+}
+
+// HScan 以游标的方式扫描哈希表,返回 字段1,值1,字段2,值2... 以及下一次扫描的游标
+// 游标为0时从头开始扫描,返回的游标为0时表示扫描结束;match 不为空时只返回字段匹配的数据
+// 扫描期间一直存在的字段至少会被返回一次,扫描期间增删的字段可能返回也可能不返回
+func (s *hashStore) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeHash)
+		if err != nil {
+			return nil, 0, err
+		}
+		val, ok := v.(*hashValue)
+		if !ok {
+			return []string{}, 0, nil
+		}
+		if count <= 0 {
+			count = DefaultScanCount
+		}
+
+		type item struct {
+			field  string
+			cursor uint64
+		}
+		now := time.Now().UnixNano()
+		items := make([]item, 0, len(val.value))
+		for field := range val.value {
+			if val.fieldExpired(field, now) {
+				continue
+			}
+			if c := scanCursor(field); c >= cursor {
+				items = append(items, item{field: field, cursor: c})
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].cursor != items[j].cursor {
+				return items[i].cursor < items[j].cursor
+			}
+			return items[i].field < items[j].field
+		})
+
+		result := make([]string, 0)
+		for i := 0; i < len(items); i++ {
+			// 游标相同的字段需要在同一次扫描中返回
+			if int64(i) >= count && items[i].cursor != items[i-1].cursor {
+				return result, items[i].cursor, nil
+			}
+			if match == "" || globMatch(match, items[i].field) {
+				result = append(result, items[i].field, val.value[items[i].field])
+			}
+		}
+		return result, 0, nil
+	}
+}
+
+// globMatch 判断字符串是否匹配glob风格的模式,规则跟redis一致
+// * 匹配任意数量的字符, ? 匹配单个字符, [abc] [^a] [a-z] 匹配字符集合, \ 转义
+func globMatch(pattern, str string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if globMatch(pattern[1:], str[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+			str = str[1:]
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+			matched := false
+			for len(pattern) > 0 && pattern[0] != ']' {
+				if pattern[0] == '\\' && len(pattern) > 1 {
+					pattern = pattern[1:]
+					matched = matched || pattern[0] == str[0]
+				} else if len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']' {
+					lo, hi := pattern[0], pattern[2]
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					matched = matched || (str[0] >= lo && str[0] <= hi)
+					pattern = pattern[2:]
+				} else {
+					matched = matched || pattern[0] == str[0]
+				}
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				// 没有闭合的 [ 按redis的处理方式,视为匹配到结尾
+				return matched != not && len(str) == 1
+			}
+			if matched == not {
+				return false
+			}
+			str = str[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || pattern[0] != str[0] {
+				return false
+			}
+			str = str[1:]
+		}
+		pattern = pattern[1:]
 	}
+	return len(str) == 0
 }
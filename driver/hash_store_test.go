@@ -2,9 +2,11 @@ package driver
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 )
 
 /**
@@ -14,7 +16,7 @@ import (
 */
 
 func Test_hashStore_HDel(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 	s.HSet(context.Background(), "key", "f1", "v1", "f2", "v2")
 	s.HSet(context.Background(), "key", "1", "1", "2", "2")
 	type args struct {
@@ -75,7 +77,7 @@ func Test_hashStore_HDel(t *testing.T) {
 }
 
 func Test_hashStore_HGet(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 
 	s.HSet(context.Background(), "key", "f1", "v1", "f2", "v2", "today", "today", "yesterday", "yesterday")
 	type args struct {
@@ -145,7 +147,7 @@ func Test_hashStore_HGet(t *testing.T) {
 }
 
 func Test_hashStore_HKeys(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 
 	s.HSet(context.Background(), "key", "f1", "v1", "f2", "v2", "today", "today", "yesterday", "yesterday")
 
@@ -196,7 +198,7 @@ func Test_hashStore_HKeys(t *testing.T) {
 }
 
 func Test_hashStore_HLen(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 
 	s.HSet(context.Background(), "key", "f1", "v1", "f2", "v2", "today", "today", "yesterday", "yesterday")
 
@@ -235,7 +237,7 @@ func Test_hashStore_HLen(t *testing.T) {
 }
 
 func Test_hashStore_HMGet(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 
 	s.HSet(context.Background(), "key", "f1", "v1", "f2", "v2", "today", "today", "yesterday", "yesterday")
 
@@ -306,7 +308,7 @@ func Test_hashStore_HMGet(t *testing.T) {
 }
 
 func Test_hashStore_HSet(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 	type HashData struct {
 		Today string `redis:"today"`
 
@@ -371,7 +373,7 @@ func Test_hashStore_HSet(t *testing.T) {
 }
 
 func Test_hashStore_HVals(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 
 	s.HSet(context.Background(), "key", "f1", "v1", "f2", "v2", "f3", "v3", "f4", "v4", "f5", "v5", "f6", "v6", "today", "今天", "yesterday", "昨天")
 
@@ -420,7 +422,7 @@ func Test_hashStore_HVals(t *testing.T) {
 }
 
 func Test_hashStore_HGetAll(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 
 	type HashData struct {
 		Today string `redis:"today"`
@@ -484,7 +486,7 @@ func Test_hashStore_HGetAll(t *testing.T) {
 }
 
 func Test_hashStore_HSetNX(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 
 	type args struct {
 		ctx   context.Context
@@ -518,6 +520,16 @@ func Test_hashStore_HSetNX(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "哈希表已存在但字段不存在",
+			args: args{
+				ctx:   context.Background(),
+				key:   "key",
+				field: "f2",
+				data:  "v2",
+			},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -535,7 +547,7 @@ func Test_hashStore_HSetNX(t *testing.T) {
 }
 
 func Test_hashStore_HExists(t *testing.T) {
-	s := newHashStore()
+	s := newHashStore(newKeyspace())
 	s.HSet(context.Background(), "key", "f1", "v1", "f2", "v2")
 
 	type args struct {
@@ -599,3 +611,238 @@ func Test_hashStore_HExists(t *testing.T) {
 		})
 	}
 }
+
+func Test_hashStore_HIncrBy(t *testing.T) {
+	s := newHashStore(newKeyspace())
+	ctx := context.Background()
+	s.HSet(ctx, "key", "text", "abc", "max", "9223372036854775807")
+
+	type args struct {
+		field string
+		incr  int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int64
+		wantErr error
+	}{
+		{
+			name: "字段不存在时从0开始",
+			args: args{field: "counter", incr: 5},
+			want: 5,
+		},
+		{
+			name: "在原来的值上累加",
+			args: args{field: "counter", incr: -7},
+			want: -2,
+		},
+		{
+			name:    "值不是整数",
+			args:    args{field: "text", incr: 1},
+			wantErr: MemoryNotInteger,
+		},
+		{
+			name:    "溢出",
+			args:    args{field: "max", incr: 1},
+			wantErr: MemoryOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.HIncrBy(ctx, "key", tt.args.field, tt.args.incr)
+			if err != tt.wantErr {
+				t.Errorf("HIncrBy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("HIncrBy() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if f, err := s.HIncrByFloat(ctx, "key", "counter", 0.5); err != nil || f != -1.5 {
+		t.Errorf("HIncrByFloat() got = %v, error = %v, want -1.5", f, err)
+	}
+	if l, _ := s.HStrLen(ctx, "key", "counter"); l != 4 {
+		t.Errorf("HStrLen() got = %v, want 4", l)
+	}
+}
+
+func Test_hashStore_HRandField(t *testing.T) {
+	s := newHashStore(newKeyspace())
+	ctx := context.Background()
+	s.HSet(ctx, "key", "f1", "v1", "f2", "v2", "f3", "v3")
+
+	got, _ := s.HRandField(ctx, "key", 2)
+	if len(got) != 2 || got[0] == got[1] {
+		t.Errorf("HRandField() got = %v, want 2 distinct fields", got)
+	}
+	if got, _ = s.HRandField(ctx, "key", 10); len(got) != 3 {
+		t.Errorf("HRandField() got = %v, want 3 fields", got)
+	}
+	if got, _ = s.HRandField(ctx, "key", -5); len(got) != 5 {
+		t.Errorf("HRandField() got = %v, want 5 fields", got)
+	}
+	if got, _ = s.HRandField(ctx, "missing", 1); len(got) != 0 {
+		t.Errorf("HRandField() got = %v, want empty", got)
+	}
+}
+
+func Test_hashStore_HScan(t *testing.T) {
+	s := newHashStore(newKeyspace())
+	ctx := context.Background()
+	want := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		field, value := fmt.Sprintf("field:%d", i), fmt.Sprintf("value:%d", i)
+		s.HSet(ctx, "key", field, value)
+		want[field] = value
+	}
+	s.HSet(ctx, "key", "other", "value")
+
+	got := make(map[string]string)
+	var cursor uint64
+	for rounds := 0; ; rounds++ {
+		if rounds > 100 {
+			t.Fatalf("HScan() did not finish")
+		}
+
+		page, next, err := s.HScan(ctx, "key", cursor, "field:*", 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(page); i += 2 {
+			got[page[i]] = page[i+1]
+		}
+
+		// 扫描期间删除的字段不影响其他字段的扫描
+		if rounds == 2 {
+			s.HDel(ctx, "key", "field:0", "field:1")
+			delete(want, "field:0")
+			delete(want, "field:1")
+			delete(got, "field:0")
+			delete(got, "field:1")
+		}
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HScan() got %d fields, want %d", len(got), len(want))
+	}
+}
+
+func Test_globMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		str     string
+		want    bool
+	}{
+		{pattern: "*", str: "", want: true},
+		{pattern: "h?llo", str: "hello", want: true},
+		{pattern: "h?llo", str: "hllo", want: false},
+		{pattern: "h*llo", str: "heeeello", want: true},
+		{pattern: "h[ae]llo", str: "hallo", want: true},
+		{pattern: "h[ae]llo", str: "hillo", want: false},
+		{pattern: "h[^e]llo", str: "hallo", want: true},
+		{pattern: "h[^e]llo", str: "hello", want: false},
+		{pattern: "h[a-b]llo", str: "hbllo", want: true},
+		{pattern: "h\\*llo", str: "h*llo", want: true},
+		{pattern: "h\\*llo", str: "hello", want: false},
+		{pattern: "user:*:name", str: "user:1:name", want: true},
+		{pattern: "user:*:name", str: "user:1:age", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.str, func(t *testing.T) {
+			if got := globMatch(tt.pattern, tt.str); got != tt.want {
+				t.Errorf("globMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hashStore_HExpireAt(t *testing.T) {
+	s := newHashStore(newKeyspace())
+	ctx := context.Background()
+	s.HSet(ctx, "key", "f1", "v1", "f2", "v2", "f3", "v3")
+
+	got, _ := s.HExpireAt(ctx, "key", time.Now().Add(time.Millisecond*50), "f1", "f2", "missing")
+	if want := []int64{1, 1, -2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("HExpireAt() got = %v, want %v", got, want)
+	}
+	if got, _ = s.HPersist(ctx, "key", "f2", "f3", "missing"); !reflect.DeepEqual(got, []int64{1, -1, -2}) {
+		t.Errorf("HPersist() got = %v, want [1 -1 -2]", got)
+	}
+	ttls, _ := s.HTTL(ctx, "key", "f1", "f2", "missing")
+	if ttls[0] <= 0 || ttls[0] > time.Millisecond*50 || ttls[1] != -1 || ttls[2] != -2 {
+		t.Errorf("HTTL() got = %v", ttls)
+	}
+
+	// 过期的字段不可见
+	time.Sleep(time.Millisecond * 80)
+	if _, err := s.HGet(ctx, "key", "f1"); err != MemoryNil {
+		t.Errorf("HGet() error = %v, want %v", err, MemoryNil)
+	}
+	if l, _ := s.HLen(ctx, "key"); l != 2 {
+		t.Errorf("HLen() got = %v, want 2", l)
+	}
+	if all, _ := s.HGetAll(ctx, "key"); !reflect.DeepEqual(all, map[string]string{"f2": "v2", "f3": "v3"}) {
+		t.Errorf("HGetAll() got = %v", all)
+	}
+
+	// HSet 覆盖字段时清除到期时间,HIncrBy 保持到期时间
+	s.HExpireAt(ctx, "key", time.Now().Add(time.Hour), "f2")
+	s.HSet(ctx, "key", "f2", "x", "counter", "1")
+	s.HExpireAt(ctx, "key", time.Now().Add(time.Hour), "counter")
+	s.HIncrBy(ctx, "key", "counter", 1)
+	if ttls, _ = s.HTTL(ctx, "key", "f2", "counter"); ttls[0] != -1 || ttls[1] <= 0 {
+		t.Errorf("HTTL() got = %v", ttls)
+	}
+
+	// 到期时间已经过去时直接删除字段
+	if got, _ = s.HExpireAt(ctx, "key", time.Now().Add(-time.Second), "f3"); !reflect.DeepEqual(got, []int64{2}) {
+		t.Errorf("HExpireAt() got = %v, want [2]", got)
+	}
+	if ok, _ := s.HExists(ctx, "key", "f3"); ok {
+		t.Errorf("HExists() got = true, want false")
+	}
+}
+
+func Test_hashStore_ActiveFieldExpire(t *testing.T) {
+	ks := newKeyspace()
+	defer ks.close()
+	s := newHashStore(ks)
+	ctx := context.Background()
+	s.HSet(ctx, "partial", "f1", "v1", "f2", "v2")
+	s.HSet(ctx, "all", "f1", "v1")
+
+	at := time.Now().Add(time.Millisecond * 20)
+	s.HExpireAt(ctx, "partial", at, "f1")
+	s.HExpireAt(ctx, "all", at, "f1")
+
+	// 所有字段都过期时整个键视为过期
+	time.Sleep(time.Millisecond * 30)
+	if v := ks.value("all"); v == nil || !v.IsExpire() {
+		t.Errorf("all IsExpire() got = false, want true")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for ks.value("all") != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if ks.value("all") != nil {
+		t.Errorf("all should be removed by active expire")
+	}
+
+	v, ok := ks.value("partial").(*hashValue)
+	if !ok {
+		t.Fatalf("partial should not be removed")
+	}
+	sh := ks.shard("partial")
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+	if _, ok := v.value["f1"]; ok || len(v.fieldExpires) != 0 {
+		t.Errorf("partial got = %v, fieldExpires = %v", v.value, v.fieldExpires)
+	}
+}
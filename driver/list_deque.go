@@ -0,0 +1,195 @@
+package driver
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/20 09:40
+  @describe : 列表使用的分块双端队列
+*/
+
+// listChunkSize 每个分块可以存放的元素数量
+const listChunkSize = 32
+
+// listChunk 存放列表元素的分块
+type listChunk [listChunkSize]string
+
+// listDeque 分块的双端队列,两端推入跟推出都是 O(1),按下标访问也是 O(1)
+// chunks 是存放分块指针的环形缓冲区,容量为2的幂;只有两端的分块可能没有装满,
+// 空出的分块会立即释放,所以占用的内存跟元素数量成正比。零值可以直接使用
+type listDeque struct {
+	// chunks 分块指针的环形缓冲区
+	chunks []*listChunk
+
+	// first 第一个分块在环中的位置
+	first int
+
+	// used 使用中的分块数量
+	used int
+
+	// head 第一个元素在第一个分块中的位置
+	head int
+
+	// length 元素数量
+	length int
+}
+
+// chunk 返回第 n 个使用中的分块
+func (d *listDeque) chunk(n int) *listChunk {
+	return d.chunks[(d.first+n)&(len(d.chunks)-1)]
+}
+
+// locate 返回下标 i 的元素所在的分块跟分块中的位置
+func (d *listDeque) locate(i int) (*listChunk, int) {
+	p := d.head + i
+	return d.chunk(p / listChunkSize), p % listChunkSize
+}
+
+// resize 把分块指针按顺序复制到容量为 capacity 的新环中
+func (d *listDeque) resize(capacity int) {
+	chunks := make([]*listChunk, capacity)
+	for n := 0; n < d.used; n++ {
+		chunks[n] = d.chunk(n)
+	}
+	d.chunks = chunks
+	d.first = 0
+}
+
+// grow 环中没有空位时扩容一倍
+func (d *listDeque) grow() {
+	if d.used < len(d.chunks) {
+		return
+	}
+	capacity := len(d.chunks) * 2
+	if capacity == 0 {
+		capacity = 1
+	}
+	d.resize(capacity)
+}
+
+// shrink 使用中的分块不到环容量的四分之一时缩容一半
+func (d *listDeque) shrink() {
+	if len(d.chunks) > 4 && d.used <= len(d.chunks)/4 {
+		d.resize(len(d.chunks) / 2)
+	}
+}
+
+// clear 清空所有元素并释放分块
+func (d *listDeque) clear() {
+	*d = listDeque{}
+}
+
+// len 元素数量
+func (d *listDeque) len() int {
+	return d.length
+}
+
+// pushFront 在头部推入元素
+func (d *listDeque) pushFront(item string) {
+	if d.head == 0 || d.used == 0 {
+		d.grow()
+		d.first = (d.first - 1) & (len(d.chunks) - 1)
+		d.chunks[d.first] = new(listChunk)
+		d.used++
+		d.head = listChunkSize
+	}
+	d.head--
+	d.chunks[d.first][d.head] = item
+	d.length++
+}
+
+// pushBack 在尾部推入元素
+func (d *listDeque) pushBack(item string) {
+	p := d.head + d.length
+	if p/listChunkSize == d.used {
+		d.grow()
+		d.chunks[(d.first+d.used)&(len(d.chunks)-1)] = new(listChunk)
+		d.used++
+	}
+	d.chunk(p / listChunkSize)[p%listChunkSize] = item
+	d.length++
+}
+
+// popFront 推出头部的元素,调用方需要保证队列不为空
+func (d *listDeque) popFront() string {
+	c := d.chunks[d.first]
+	item := c[d.head]
+	c[d.head] = ""
+	d.head++
+	d.length--
+
+	if d.length == 0 {
+		d.clear()
+		return item
+	}
+	// 第一个分块已经空了,释放掉
+	if d.head == listChunkSize {
+		d.chunks[d.first] = nil
+		d.first = (d.first + 1) & (len(d.chunks) - 1)
+		d.used--
+		d.head = 0
+		d.shrink()
+	}
+	return item
+}
+
+// popBack 推出尾部的元素,调用方需要保证队列不为空
+func (d *listDeque) popBack() string {
+	p := d.head + d.length - 1
+	n := p / listChunkSize
+	c := d.chunk(n)
+	item := c[p%listChunkSize]
+	c[p%listChunkSize] = ""
+	d.length--
+
+	if d.length == 0 {
+		d.clear()
+		return item
+	}
+	// 最后一个分块已经空了,释放掉
+	if p%listChunkSize == 0 {
+		d.chunks[(d.first+n)&(len(d.chunks)-1)] = nil
+		d.used--
+		d.shrink()
+	}
+	return item
+}
+
+// get 返回下标 i 的元素,调用方需要保证下标在 [0, len) 之内
+func (d *listDeque) get(i int) string {
+	c, off := d.locate(i)
+	return c[off]
+}
+
+// set 替换下标 i 的元素,返回原来的元素,调用方需要保证下标在 [0, len) 之内
+func (d *listDeque) set(i int, item string) string {
+	c, off := d.locate(i)
+	old := c[off]
+	c[off] = item
+	return old
+}
+
+// insert 把元素插入到下标 i 的位置,调用方需要保证下标在 [0, len] 之内
+// 从离 i 较近的一端移动元素,最多移动 len/2 个
+func (d *listDeque) insert(i int, item string) {
+	if i < d.length/2 {
+		if i == 0 {
+			d.pushFront(item)
+			return
+		}
+		d.pushFront(d.get(0))
+		for j := 1; j < i; j++ {
+			d.set(j, d.get(j+1))
+		}
+		d.set(i, item)
+		return
+	}
+
+	if i == d.length {
+		d.pushBack(item)
+		return
+	}
+	d.pushBack(d.get(d.length - 1))
+	for j := d.length - 2; j > i; j-- {
+		d.set(j, d.get(j-1))
+	}
+	d.set(i, item)
+}
@@ -2,8 +2,7 @@ package driver
 
 import (
 	"context"
-	"sort"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/jerbe/go-errors"
@@ -16,77 +15,234 @@ import (
   @describe :
 */
 
-// listValue 字符串值
+// listValue 列表值
+// 对外的下标跟 Redis 一致,0 表示列表头部(左边),-1 表示列表尾部(右边)
 type listValue struct {
 	expireValue
 
-	// value 值
-	value []string
+	// value 值,从列表头部到尾部存放在分块双端队列中
+	value listDeque
+
+	// bytes 所有元素的字节数
+	bytes int64
 }
 
 func newListValue() *listValue {
-	defaultExpireAt := time.Now().Add(ValueMaxTTL)
 	return &listValue{
-		expireValue: expireValue{
-			expireAt: &defaultExpireAt,
-			expired:  false,
-		},
-		value: make([]string, 0, 1<<8), // 预先进行容量设定,防止append时的扩容耗能
+		expireValue: expireValue{},
+	}
+}
+
+// Type 返回数值的存储类型
+func (v *listValue) Type() driverStoreType {
+	return driverStoreTypeList
+}
+
+// size 数值占用的字节数估算值
+func (v *listValue) size() int64 {
+	return v.bytes
+}
+
+// charge 记录当前占用的字节数,返回跟上次记录时的差值
+func (v *listValue) charge() int64 {
+	return v.chargeSize(v.size())
+}
+
+// length 列表长度
+func (v *listValue) length() int64 {
+	return int64(v.value.len())
+}
+
+// pushLeft 依次把元素推入列表头部,最后推入的元素成为新的头部
+func (v *listValue) pushLeft(items ...string) {
+	for _, item := range items {
+		v.bytes += int64(len(item))
+		v.value.pushFront(item)
 	}
 }
 
+// pushRight 依次把元素推入列表尾部,最后推入的元素成为新的尾部
+func (v *listValue) pushRight(items ...string) {
+	for _, item := range items {
+		v.bytes += int64(len(item))
+		v.value.pushBack(item)
+	}
+}
+
+// popLeft 推出列表头部的第一个元素,调用方需要保证列表不为空
+func (v *listValue) popLeft() string {
+	item := v.value.popFront()
+	v.bytes -= int64(len(item))
+	return item
+}
+
+// popRight 推出列表尾部的最后一个元素,调用方需要保证列表不为空
+func (v *listValue) popRight() string {
+	item := v.value.popBack()
+	v.bytes -= int64(len(item))
+	return item
+}
+
+// index 返回下标对应的元素,调用方需要保证下标在 [0, length) 之内
+func (v *listValue) index(i int64) string {
+	return v.value.get(int(i))
+}
+
+// set 替换下标对应的元素,调用方需要保证下标在 [0, length) 之内
+func (v *listValue) set(i int64, item string) {
+	old := v.value.set(int(i), item)
+	v.bytes += int64(len(item)) - int64(len(old))
+}
+
+// insert 把元素插入到下标 i 的位置,原来在 i 及之后的元素往尾部移动,调用方需要保证下标在 [0, length] 之内
+func (v *listValue) insert(i int64, item string) {
+	v.value.insert(int(i), item)
+	v.bytes += int64(len(item))
+}
+
+// trim 只保留闭区间 [start, stop] 内的元素,从两端推出区间外的元素,调用方需要保证区间有效
+func (v *listValue) trim(start, stop int64) {
+	for i := int64(0); i < start; i++ {
+		v.popLeft()
+	}
+	for i := v.length() - 1; i > stop-start; i-- {
+		v.popRight()
+	}
+}
+
+// rangeItems 按从头部到尾部的顺序返回闭区间 [start, stop] 内的元素,调用方需要保证区间有效
+func (v *listValue) rangeItems(start, stop int64) []string {
+	result := make([]string, 0, stop-start+1)
+	for i := start; i <= stop; i++ {
+		result = append(result, v.index(i))
+	}
+	return result
+}
+
+// reset 按从头部到尾部的顺序替换列表的所有元素,并重新计算字节数
+func (v *listValue) reset(items []string) {
+	v.value.clear()
+	v.bytes = 0
+	v.pushRight(items...)
+}
+
+// dump 导出数值,按从列表尾部到头部的顺序,恢复时依次推入头部即可
+func (v *listValue) dump() []string {
+	result := make([]string, 0, v.value.len())
+	for i := v.value.len() - 1; i >= 0; i-- {
+		result = append(result, v.value.get(i))
+	}
+	return result
+}
+
+// listIndex 把可能为负数的下标换算成从列表头部开始的下标,超出范围时返回 false
+func listIndex(index, listLen int64) (int64, bool) {
+	if index < 0 {
+		index = listLen + index
+	}
+	if index < 0 || index >= listLen {
+		return 0, false
+	}
+	return index, true
+}
+
+// listRange 把可能为负数的 start 跟 stop 换算成从列表头部开始的闭区间,区间为空时返回 false
+func listRange(start, stop, listLen int64) (int64, int64, bool) {
+	if start < 0 {
+		start = listLen + start
+	}
+	if stop < 0 {
+		stop = listLen + stop
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= listLen {
+		stop = listLen - 1
+	}
+	if listLen == 0 || start > stop {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+// listSide 解析 LMove 的方向参数,返回是否是左边(头部)
+func listSide(side string) (bool, error) {
+	switch strings.ToUpper(side) {
+	case "LEFT":
+		return true, nil
+	case "RIGHT":
+		return false, nil
+	}
+	return false, MemorySyntaxError
+}
+
 type listStore struct {
 	baseStore
 
 	evtSig *utils.PubSub
 }
 
-func newListStore() *listStore {
-	ticker := time.NewTicker(time.Second * 10)
-	store := &listStore{
-		baseStore: baseStore{
-			values:       make(map[string]expireable),
-			rwMutex:      sync.RWMutex{},
-			expireTicker: ticker,
-		},
-		evtSig: utils.NewPubSub(),
+func newListStore(ks *keyspace) *listStore {
+	return &listStore{
+		baseStore: baseStore{keyspace: ks},
+		evtSig:    utils.NewPubSub(),
 	}
-
-	go store.checkExpireTick()
-	return store
 }
 
-func (s *listStore) Type() driverStoreType {
-	return driverStoreTypeList
+// restore 根据导出的数据恢复某个键
+func (s *listStore) restore(entry *storeEntry) {
+	val := newListValue()
+	val.pushLeft(entry.Values...)
+	val.SetExpireAt(entry.ExpireAt)
+	s.baseStore.restore(entry.Key, val)
 }
 
-// LPush 将数据推入到列表中
-// 推入后列表顺序,先推入在左,后推入在右 [a,b,c,d,e...]
+// LPush 依次将数据推入到列表头部,返回推入后的列表长度
+// 例如 LPush(key, a, b, c) 之后列表为 [c,b,a]
 func (s *listStore) LPush(ctx context.Context, key string, data ...string) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	return s.push(ctx, key, true, data...)
+}
+
+// RPush 依次将数据推入到列表尾部,返回推入后的列表长度
+// 例如 RPush(key, a, b, c) 之后列表为 [a,b,c]
+func (s *listStore) RPush(ctx context.Context, key string, data ...string) (int64, error) {
+	return s.push(ctx, key, false, data...)
+}
+
+func (s *listStore) push(ctx context.Context, key string, left bool, data ...string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return 0, ctx.Err()
 	default:
-		val, ok := s.values[key].(*listValue)
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*listValue)
 		if !ok {
 			val = newListValue()
 		}
-		dataLen := len(data)
 
-		if dataLen == 0 {
-			return int64(len(val.value)), errors.New("the number of parameters is incorrect")
+		if len(data) == 0 {
+			return val.length(), errors.New("the number of parameters is incorrect")
 		}
 
-		val.value = append(val.value, data...)
-		s.values[key] = val
+		if left {
+			val.pushLeft(data...)
+		} else {
+			val.pushRight(data...)
+		}
+		sh.store(key, val)
 
 		// 通道中写入事件
 		s.evtSig.Publish(key)
 
-		return int64(len(val.value)), nil
+		return val.length(), nil
 	}
 }
 
@@ -94,293 +250,488 @@ func (s *listStore) LPush(ctx context.Context, key string, data ...string) (int6
 // 举个例子，执行命令 LTRIM list 0 2 ，表示只保留列表 list 的前三个元素，其余元素全部删除。
 // 下标(index)参数 start 和 stop 都以 0 为底，也就是说，以 0 表示列表的第一个元素，以 1 表示列表的第二个元素，以此类推。
 // 你也可以使用负数下标，以 -1 表示列表的最后一个元素， -2 表示列表的倒数第二个元素，以此类推。
-// 列表顺序 先推入在左,后推入在右 [a,b,c,d,e]
-// 如果 start = 0, stop = 1, 裁剪后应该保留 [d,e]
-// 如果 start = -2, stop = -1, 裁剪后应该保留 [a,b]
 func (s *listStore) LTrim(ctx context.Context, key string, start, stop int64) error {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		val, ok := s.values[key].(*listValue)
-		if !ok {
-			return nil
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return err
 		}
-		listLen := int64(len(val.value))
-		if listLen == 0 {
+		val, ok := v.(*listValue)
+		if !ok {
 			return nil
 		}
 
-		if start < 0 {
-			start = listLen + start
-		}
-		if stop < 0 {
-			stop = listLen + stop
-		}
-
-		if stop < start || start >= listLen || stop < 0 {
-			val.value = make([]string, 0)
+		start, stop, ok = listRange(start, stop, val.length())
+		if !ok {
+			val.reset(nil)
+			sh.remove(key)
 			return nil
 		}
 
-		// 提取正确的索引位置
-		if start < 0 {
-			start = 0
-		}
-
-		if stop >= listLen {
-			stop = listLen - 1
-		}
-
-		tmp := listLen - stop
-		stop = listLen - start
-		start = tmp
-
-		result := make([]string, stop-start+1, stop-start+1)
-		if len(result) == 0 {
-			val.value = nil
-			delete(s.values, key)
-			return nil
-		}
-		copy(result, val.value[start-1:stop])
-		val.value = result
+		val.trim(start, stop)
+		sh.store(key, val)
 		return nil
 	}
 }
 
-// LRang 提取列表范围内的数据
-// 列表顺序 先推入在左,后推入在右 [a,b,c,d,e]
-// 如果 start = 0, stop = 1, 取得范围应该 [e,d]
-// 如果 start = -2, stop = -1, 取得范围应该 [b,a]
-func (s *listStore) LRang(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+// LRange 按从头部到尾部的顺序提取列表范围内的数据,start 跟 stop 都包含在内
+// 列表为 [a,b,c,d,e] 时
+// 如果 start = 0, stop = 1, 取得范围应该 [a,b]
+// 如果 start = -2, stop = -1, 取得范围应该 [d,e]
+func (s *listStore) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
-		val, ok := s.values[key].(*listValue)
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*listValue)
 		if !ok {
 			return []string{}, nil
 		}
 
-		listLen := int64(len(val.value))
-
-		if start < 0 {
-			start = listLen + start
-		}
-		if stop < 0 {
-			stop = listLen + stop
-		}
-
-		if listLen == 0 || stop < start || start >= listLen || stop < 0 {
+		start, stop, ok = listRange(start, stop, val.length())
+		if !ok {
 			return []string{}, nil
 		}
+		return val.rangeItems(start, stop), nil
+	}
+}
 
-		// 提取正确的索引位置
-		if start < 0 {
-			start = 0
-		}
+// LPop 推出列表头部的第一个数据
+// 列表为 [a,b,c,d,e] 时得到的数据应该是'a'
+func (s *listStore) LPop(ctx context.Context, key string) (string, error) {
+	return s.pop(ctx, key, true)
+}
 
-		if stop >= listLen {
-			stop = listLen - 1
-		}
+// RPop 推出列表尾部的最后一个数据
+// 列表为 [a,b,c,d,e] 时得到的数据应该是'e'
+func (s *listStore) RPop(ctx context.Context, key string) (string, error) {
+	return s.pop(ctx, key, false)
+}
 
-		tmp := listLen - stop
-		stop = listLen - start
-		start = tmp
+func (s *listStore) pop(ctx context.Context, key string, left bool) (string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
-		result := make([]string, stop-start+1, stop-start+1)
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return "", err
+		}
+		item, ok := s.popValue(sh, key, v, left)
+		if !ok {
+			return "", MemoryNil
+		}
+		return item, nil
+	}
+}
 
-		copy(result, val.value[start-1:stop])
-		sort.Slice(result, func(i, j int) bool {
-			return true
-		})
+// popValue 从已经查找到的列表中推出一个元素,列表为空时删除该键,调用方需要持有分片的写锁
+func (s *listStore) popValue(sh *keyspaceShard, key string, v expireable, left bool) (string, bool) {
+	val, ok := v.(*listValue)
+	if !ok {
+		return "", false
+	}
+	if val.length() == 0 {
+		sh.remove(key)
+		return "", false
+	}
 
-		return result, nil
+	var item string
+	if left {
+		item = val.popLeft()
+	} else {
+		item = val.popRight()
 	}
+	if val.length() == 0 {
+		sh.remove(key)
+	} else {
+		sh.store(key, val)
+	}
+	return item, true
 }
 
-// LPop 推出列表尾的最后数据
-// 列表顺序 先推入在左,后推入在右 [a,b,c,d,e]
-// LPop得到的数据应该是'a'
-func (s *listStore) LPop(ctx context.Context, key string) (string, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+// LIndex 返回列表中下标对应的元素,下标超出范围时返回 MemoryNil
+func (s *listStore) LIndex(ctx context.Context, key string, index int64) (string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return "", ctx.Err()
 	default:
-		val, ok := s.values[key].(*listValue)
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return "", err
+		}
+		val, ok := v.(*listValue)
 		if !ok {
 			return "", MemoryNil
 		}
 
-		listLen := len(val.value)
-
-		if listLen == 0 {
+		index, ok = listIndex(index, val.length())
+		if !ok {
 			return "", MemoryNil
 		}
-
-		item := val.value[0]
-		if len(val.value) == 1 {
-			val.value = nil
-			delete(s.values, key)
-		} else {
-			val.value = val.value[1:listLen:listLen]
-		}
-		return item, nil
+		return val.index(index), nil
 	}
 }
 
-// LBPop 推出列表尾的最后数据
-// 列表顺序 先推入在左,后推入在右 [a,b,c,d,e]
-// LPop得到的数据应该是'a'
-func (s *listStore) LBPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+// LSet 替换列表中下标对应的元素
+// 键不存在时返回 MemoryNoSuchKey,下标超出范围时返回 MemoryIndexOutOfRange
+func (s *listStore) LSet(ctx context.Context, key string, index int64, data string) error {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return ctx.Err()
 	default:
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return err
+		}
+		val, ok := v.(*listValue)
+		if !ok {
+			return MemoryNoSuchKey
+		}
+
+		index, ok = listIndex(index, val.length())
+		if !ok {
+			return MemoryIndexOutOfRange
+		}
+		val.set(index, data)
+		sh.store(key, val)
+		return nil
 	}
+}
 
-	result := make([]string, 0)
+// LRem 移除列表中与 data 相等的元素,返回被移除的数量
+// count > 0 从头部开始移除 count 个; count < 0 从尾部开始移除 |count| 个; count = 0 移除所有
+func (s *listStore) LRem(ctx context.Context, key string, count int64, data string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
-	get := func() ([]string, bool) {
-		s.rwMutex.Lock()
-		defer func() {
-			s.rwMutex.Unlock()
-		}()
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*listValue)
+		if !ok {
+			return 0, nil
+		}
 
-		rst := make([]string, 0)
-		for _, key := range keys {
-			val, ok := s.values[key].(*listValue)
-			if !ok {
-				continue
+		listLen := val.length()
+		items := val.rangeItems(0, listLen-1)
+		removed := make([]bool, listLen)
+		limit := count
+		if limit < 0 {
+			limit = -limit
+		}
+
+		var cnt int64
+		for n := int64(0); n < listLen && (limit == 0 || cnt < limit); n++ {
+			i := n
+			if count < 0 {
+				i = listLen - 1 - n
+			}
+			if items[i] == data {
+				removed[i] = true
+				cnt++
 			}
+		}
+		if cnt == 0 {
+			return 0, nil
+		}
 
-			listLen := len(val.value)
-			if listLen == 0 {
-				delete(s.values, key)
-				continue
+		result := make([]string, 0, listLen-cnt)
+		for i, item := range items {
+			if !removed[i] {
+				result = append(result, item)
 			}
+		}
+		if len(result) == 0 {
+			val.reset(nil)
+			sh.remove(key)
+			return cnt, nil
+		}
+		val.reset(result)
+		sh.store(key, val)
+		return cnt, nil
+	}
+}
+
+// LInsert 把 data 插入到列表中第一个 pivot 的前面(BEFORE)或者后面(AFTER),返回插入后的列表长度
+// 键不存在时返回 0,找不到 pivot 时返回 -1
+func (s *listStore) LInsert(ctx context.Context, key string, op string, pivot, data string) (int64, error) {
+	var before bool
+	switch strings.ToUpper(op) {
+	case "BEFORE":
+		before = true
+	case "AFTER":
+	default:
+		return 0, MemorySyntaxError
+	}
+
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
-			item := val.value[0]
-			if len(val.value) == 1 {
-				val.value = nil
-				delete(s.values, key)
-			} else {
-				val.value = val.value[1:listLen:listLen]
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*listValue)
+		if !ok {
+			return 0, nil
+		}
+
+		listLen := val.length()
+		for i := int64(0); i < listLen; i++ {
+			if val.index(i) != pivot {
+				continue
 			}
-			rst = append(rst, key, item)
-			return rst, true
-		}
-		return rst, false
-	}
-
-	// 先尝试获取一次,没有获取到再进入循环获取
-	rst, ok := get()
-	if ok {
-		return rst, nil
-	}
-
-	sb := s.evtSig.Subscribe()
-	defer sb.Close()
-
-	if timeout == 0 {
-		//循环至死
-		for {
-			select {
-			case <-ctx.Done():
-				return result, ctx.Err()
-			case k := <-sb.C:
-				for _, key := range keys {
-					if key == k {
-						if rst, ok = get(); ok {
-							return rst, nil
-						}
-					}
-				}
-			default:
+			if !before {
+				i++
 			}
+			val.insert(i, data)
+			sh.store(key, val)
+			return val.length(), nil
 		}
+		return -1, nil
 	}
+}
 
-	timer := time.NewTimer(timeout)
-	// 循环到指定时间
-	for {
-		select {
-		case <-ctx.Done():
-			return result, ctx.Err()
-		case <-timer.C:
-			return result, MemoryNil
-		case k := <-sb.C:
-			for _, key := range keys {
-				if key == k {
-					if rst, ok = get(); ok {
-						return rst, nil
-					}
-				}
+// LPos 返回列表中与 data 相等的元素的下标
+// rank 表示从第几个匹配的元素开始返回,负数表示从尾部开始查找,0 视为 1
+// count 表示最多返回多少个下标,0 表示返回所有匹配的下标
+// maxLen 表示最多比较多少个元素,0 表示比较整个列表
+func (s *listStore) LPos(ctx context.Context, key string, data string, rank, count, maxLen int64) ([]int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]int64, 0)
+		val, ok := v.(*listValue)
+		if !ok {
+			return result, nil
+		}
+
+		if rank == 0 {
+			rank = 1
+		}
+		skip := rank - 1
+		if rank < 0 {
+			skip = -rank - 1
+		}
+
+		listLen := val.length()
+		for n := int64(0); n < listLen && (maxLen == 0 || n < maxLen); n++ {
+			i := n
+			if rank < 0 {
+				i = listLen - 1 - n
+			}
+			if val.index(i) != data {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			result = append(result, i)
+			if count > 0 && int64(len(result)) >= count {
+				break
 			}
-		default:
 		}
+		return result, nil
 	}
 }
 
-// LShift 推出列表头的第一个数据
-// 列表顺序 先推入在左,后推入在右 [a,b,c,d,e]
-// LShift得到的数据应该是'e'
-func (s *listStore) LShift(ctx context.Context, key string) (string, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+// LMove 从 source 的 srcpos(LEFT/RIGHT) 推出一个元素,再推入 destination 的 destpos(LEFT/RIGHT),返回该元素
+// source 不存在时返回 MemoryNil;source 跟 destination 可以是同一个列表
+func (s *listStore) LMove(ctx context.Context, source, destination, srcpos, destpos string) (string, error) {
+	srcLeft, err := listSide(srcpos)
+	if err != nil {
+		return "", err
+	}
+	destLeft, err := listSide(destpos)
+	if err != nil {
+		return "", err
+	}
 
 	select {
 	case <-ctx.Done():
 		return "", ctx.Err()
 	default:
+		return s.tryMove(source, destination, srcLeft, destLeft)
+	}
+}
 
-		val, ok := s.values[key].(*listValue)
-		if !ok {
-			return "", MemoryNil
-		}
+// move 执行 LMove 的移动过程,调用方需要持有 source 跟 destination 所在分片的写锁
+func (s *listStore) move(source, destination string, srcLeft, destLeft bool) (string, error) {
+	srcSh, destSh := s.shard(source), s.shard(destination)
+	sv, err := srcSh.lookup(source, driverStoreTypeList)
+	if err != nil {
+		return "", err
+	}
+	dv, err := destSh.lookup(destination, driverStoreTypeList)
+	if err != nil {
+		return "", err
+	}
 
-		listLen := len(val.value)
+	item, ok := s.popValue(srcSh, source, sv, srcLeft)
+	if !ok {
+		return "", MemoryNil
+	}
 
-		if listLen == 0 {
-			return "", MemoryNil
-		}
+	// 同一个列表时弹出后可能已经被删除,需要重新查找
+	if source == destination {
+		dv, _ = destSh.lookup(destination, driverStoreTypeList)
+	}
+	val, ok := dv.(*listValue)
+	if !ok {
+		val = newListValue()
+	}
+	if destLeft {
+		val.pushLeft(item)
+	} else {
+		val.pushRight(item)
+	}
+	destSh.store(destination, val)
 
-		item := val.value[listLen-1]
-		if len(val.value) == 1 {
-			val.value = nil
-			delete(s.values, key)
-		} else {
-			val.value = val.value[: listLen-1 : listLen-1]
+	// 通道中写入事件
+	s.evtSig.Publish(destination)
+	return item, nil
+}
+
+// BLPop 依次检查 keys,从第一个非空列表的头部推出一个元素,返回 [key, 元素]
+// 所有列表都为空时阻塞等待,直到超时(返回 MemoryNil)或者有可弹出的元素为止;timeout 为0时一直阻塞直到 ctx 结束
+func (s *listStore) BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return s.bPop(ctx, timeout, true, keys...)
+}
+
+// BRPop 同 BLPop,从列表尾部推出元素
+func (s *listStore) BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return s.bPop(ctx, timeout, false, keys...)
+}
+
+func (s *listStore) bPop(ctx context.Context, timeout time.Duration, left bool, keys ...string) ([]string, error) {
+	var result []string
+	err := s.block(ctx, driverStoreTypeList, s.evtSig, timeout, keys, func() bool {
+		result = s.popFirst(keys, left)
+		return result != nil
+	})
+	if err != nil {
+		return []string{}, err
+	}
+	return result, nil
+}
+
+// popFirst 从第一个非空列表中推出一个元素,返回 [key, 元素];所有列表都为空时返回nil
+func (s *listStore) popFirst(keys []string, left bool) []string {
+	// 按分片序号锁住所有键所在的分片
+	unlock := s.lockShards(true, keys...)
+	defer unlock()
+
+	for _, key := range keys {
+		// 等待期间键可能被改成其他类型,跳过即可
+		sh := s.shard(key)
+		v, _ := sh.lookup(key, driverStoreTypeList)
+		if item, ok := s.popValue(sh, key, v, left); ok {
+			return []string{key, item}
 		}
+	}
+	return nil
+}
 
-		return item, nil
+// BLMove 同 LMove,source 为空时阻塞等待,直到超时(返回 MemoryNil)或者有可移动的元素为止
+func (s *listStore) BLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) (string, error) {
+	srcLeft, err := listSide(srcpos)
+	if err != nil {
+		return "", err
+	}
+	destLeft, err := listSide(destpos)
+	if err != nil {
+		return "", err
 	}
+
+	var result string
+	var moveErr error
+	err = s.block(ctx, driverStoreTypeList, s.evtSig, timeout, []string{source}, func() bool {
+		result, moveErr = s.tryMove(source, destination, srcLeft, destLeft)
+		// 只有 source 为空时才继续等待,类型错误等直接返回
+		return moveErr != MemoryNil
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, moveErr
+}
+
+// tryMove 锁住 source 跟 destination 所在的分片后执行一次移动,source 为空时返回 MemoryNil
+func (s *listStore) tryMove(source, destination string, srcLeft, destLeft bool) (string, error) {
+	unlock := s.lockShards(true, source, destination)
+	defer unlock()
+
+	return s.move(source, destination, srcLeft, destLeft)
 }
 
 // LLen 列表长度
 func (s *listStore) LLen(ctx context.Context, key string) (int64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return 0, ctx.Err()
 	default:
-		val, ok := s.values[key].(*listValue)
+		v, err := sh.lookup(key, driverStoreTypeList)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*listValue)
 		if !ok {
 			return 0, nil
 		}
 
-		listLen := len(val.value)
-		return int64(listLen), nil
+		return val.length(), nil
 	}
 }
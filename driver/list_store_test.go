@@ -18,7 +18,7 @@ import (
 	@describe :
 */
 func Test_listStore_LPush(t *testing.T) {
-	s := newListStore()
+	s := newListStore(newKeyspace())
 	type args struct {
 		ctx  context.Context
 		key  string
@@ -56,8 +56,8 @@ func Test_listStore_LPush(t *testing.T) {
 	}
 }
 
-func Test_listStore_LPop(t *testing.T) {
-	s := newListStore()
+func Test_listStore_RPop(t *testing.T) {
+	s := newListStore(newKeyspace())
 	s.LPush(context.Background(), "key", "v1", "v2")
 	type args struct {
 		ctx context.Context
@@ -99,20 +99,20 @@ func Test_listStore_LPop(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := s.LPop(tt.args.ctx, tt.args.key)
+			got, err := s.RPop(tt.args.ctx, tt.args.key)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("LPop() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("RPop() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("LPop() got = %v, want %v", got, tt.want)
+				t.Errorf("RPop() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_listStore_LRang(t *testing.T) {
-	s := newListStore()
+func Test_listStore_LRange(t *testing.T) {
+	s := newListStore(newKeyspace())
 	// [5,g,3,你好,1,x]
 	// [0,1,2,3,  3,4]
 	s.LPush(context.Background(), "key", "x", "1", "你好", "3", "g", "5")
@@ -296,20 +296,20 @@ func Test_listStore_LRang(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := s.LRang(tt.args.ctx, tt.args.key, tt.args.start, tt.args.stop)
+			got, err := s.LRange(tt.args.ctx, tt.args.key, tt.args.start, tt.args.stop)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("LRang() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("LRange() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("LRang() got = %v, want %v", got, tt.want)
+				t.Errorf("LRange() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_listStore_LShift(t *testing.T) {
-	s := newListStore()
+func Test_listStore_LPop(t *testing.T) {
+	s := newListStore(newKeyspace())
 	s.LPush(context.Background(), "key", "0", "1", "2") // [2,1,0]
 
 	type args struct {
@@ -362,13 +362,13 @@ func Test_listStore_LShift(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			got, err := s.LShift(tt.args.ctx, tt.args.key)
+			got, err := s.LPop(tt.args.ctx, tt.args.key)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("LShift() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("LPop() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("LShift() got = %v, want %v", got, tt.want)
+				t.Errorf("LPop() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -376,7 +376,7 @@ func Test_listStore_LShift(t *testing.T) {
 
 func Test_listStore_LTrim(t *testing.T) {
 	var f = func() *listStore {
-		s := newListStore()
+		s := newListStore(newKeyspace())
 		s.LPush(context.Background(), "key", "x", "1", "你好", "3", "g", "5")
 		return s
 	}
@@ -580,12 +580,12 @@ func Test_listStore_LTrim(t *testing.T) {
 			if err := s.LTrim(tt.args.ctx, tt.args.key, tt.args.start, tt.args.stop); (err != nil) != tt.wantErr {
 				t.Errorf("LTrim() error = %v, wantErr %v", err, tt.wantErr)
 			}
-			got, err := s.LRang(context.Background(), tt.args.key, 0, -1)
+			got, err := s.LRange(context.Background(), tt.args.key, 0, -1)
 			if err != nil {
-				t.Errorf("LRang() after LTrim() error = %v", err)
+				t.Errorf("LRange() after LTrim() error = %v", err)
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("LRang() after LTrim() got = %v, want %v", got, tt.want)
+				t.Errorf("LRange() after LTrim() got = %v, want %v", got, tt.want)
 			}
 
 		})
@@ -593,7 +593,7 @@ func Test_listStore_LTrim(t *testing.T) {
 }
 
 func Test_listStore_LLen(t *testing.T) {
-	s := newListStore()
+	s := newListStore(newKeyspace())
 	s.LPush(context.Background(), "key", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16", "17", "18", "19", "20")
 
 	type args struct {
@@ -640,8 +640,311 @@ func Test_listStore_LLen(t *testing.T) {
 	}
 }
 
+func Test_listStore_Push(t *testing.T) {
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+	s.LPush(ctx, "key", "a", "b")
+	s.RPush(ctx, "key", "c", "d")
+	if got, _ := s.LRange(ctx, "key", 0, -1); !reflect.DeepEqual(got, []string{"b", "a", "c", "d"}) {
+		t.Fatalf("LRange() got = %v, want [b a c d]", got)
+	}
+	if got, _ := s.LPop(ctx, "key"); got != "b" {
+		t.Errorf("LPop() got = %v, want b", got)
+	}
+	if got, _ := s.RPop(ctx, "key"); got != "d" {
+		t.Errorf("RPop() got = %v, want d", got)
+	}
+	if got, _ := s.LRange(ctx, "key", 0, -1); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("LRange() got = %v, want [a c]", got)
+	}
+}
+
+func Test_listStore_LIndex_LSet(t *testing.T) {
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+	s.RPush(ctx, "key", "a", "b", "c")
+
+	if got, _ := s.LIndex(ctx, "key", -1); got != "c" {
+		t.Errorf("LIndex(-1) got = %v, want c", got)
+	}
+	if _, err := s.LIndex(ctx, "key", 3); err != MemoryNil {
+		t.Errorf("LIndex(3) error = %v, want %v", err, MemoryNil)
+	}
+	if err := s.LSet(ctx, "key", 1, "bb"); err != nil {
+		t.Fatalf("LSet() error = %v", err)
+	}
+	if got, _ := s.LIndex(ctx, "key", 1); got != "bb" {
+		t.Errorf("LIndex(1) got = %v, want bb", got)
+	}
+	if err := s.LSet(ctx, "key", -4, "x"); err != MemoryIndexOutOfRange {
+		t.Errorf("LSet() error = %v, want %v", err, MemoryIndexOutOfRange)
+	}
+	if err := s.LSet(ctx, "missing", 0, "x"); err != MemoryNoSuchKey {
+		t.Errorf("LSet() error = %v, want %v", err, MemoryNoSuchKey)
+	}
+}
+
+func Test_listStore_LRem(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int64
+		want  int64
+		items []string
+	}{
+		{name: "从头部移除2个", count: 2, want: 2, items: []string{"b", "c", "a", "b"}},
+		{name: "从尾部移除2个", count: -2, want: 2, items: []string{"a", "b", "c", "b"}},
+		{name: "移除所有", count: 0, want: 3, items: []string{"b", "c", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newListStore(newKeyspace())
+			s.RPush(ctx, "key", "a", "b", "a", "c", "a", "b")
+			got, err := s.LRem(ctx, "key", tt.count, "a")
+			if err != nil || got != tt.want {
+				t.Fatalf("LRem() got = %v, err = %v, want %v", got, err, tt.want)
+			}
+			if items, _ := s.LRange(ctx, "key", 0, -1); !reflect.DeepEqual(items, tt.items) {
+				t.Errorf("LRange() got = %v, want %v", items, tt.items)
+			}
+		})
+	}
+
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+	s.RPush(ctx, "key", "a", "a")
+	s.LRem(ctx, "key", 0, "a")
+	if cnt, _ := s.Exists(ctx, "key"); cnt != 0 {
+		t.Errorf("Exists() got = %v, want 0", cnt)
+	}
+}
+
+func Test_listStore_LInsert(t *testing.T) {
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+	s.RPush(ctx, "key", "a", "c")
+
+	if got, _ := s.LInsert(ctx, "key", "BEFORE", "c", "b"); got != 3 {
+		t.Errorf("LInsert(BEFORE) got = %v, want 3", got)
+	}
+	if got, _ := s.LInsert(ctx, "key", "after", "c", "d"); got != 4 {
+		t.Errorf("LInsert(AFTER) got = %v, want 4", got)
+	}
+	if got, _ := s.LRange(ctx, "key", 0, -1); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("LRange() got = %v, want [a b c d]", got)
+	}
+	if got, _ := s.LInsert(ctx, "key", "BEFORE", "x", "y"); got != -1 {
+		t.Errorf("LInsert() pivot not found got = %v, want -1", got)
+	}
+	if got, _ := s.LInsert(ctx, "missing", "BEFORE", "x", "y"); got != 0 {
+		t.Errorf("LInsert() missing key got = %v, want 0", got)
+	}
+	if _, err := s.LInsert(ctx, "key", "MIDDLE", "a", "y"); err != MemorySyntaxError {
+		t.Errorf("LInsert() error = %v, want %v", err, MemorySyntaxError)
+	}
+}
+
+func Test_listStore_LPos(t *testing.T) {
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+	s.RPush(ctx, "key", "a", "b", "c", "1", "2", "3", "c", "c")
+
+	tests := []struct {
+		name                string
+		rank, count, maxLen int64
+		want                []int64
+	}{
+		{name: "第一个", count: 1, want: []int64{2}},
+		{name: "第二个开始", rank: 2, count: 1, want: []int64{6}},
+		{name: "从尾部开始", rank: -1, count: 2, want: []int64{7, 6}},
+		{name: "所有", count: 0, want: []int64{2, 6, 7}},
+		{name: "限制比较数量", count: 0, maxLen: 7, want: []int64{2, 6}},
+		{name: "超过匹配数量", rank: 4, count: 1, want: []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.LPos(ctx, "key", "c", tt.rank, tt.count, tt.maxLen)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LPos() got = %v, err = %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_listStore_LMove(t *testing.T) {
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+	s.RPush(ctx, "src", "a", "b", "c")
+
+	if got, _ := s.LMove(ctx, "src", "dst", "RIGHT", "LEFT"); got != "c" {
+		t.Errorf("LMove() got = %v, want c", got)
+	}
+	if got, _ := s.LMove(ctx, "src", "dst", "LEFT", "RIGHT"); got != "a" {
+		t.Errorf("LMove() got = %v, want a", got)
+	}
+	if got, _ := s.LRange(ctx, "dst", 0, -1); !reflect.DeepEqual(got, []string{"c", "a"}) {
+		t.Errorf("LRange(dst) got = %v, want [c a]", got)
+	}
+
+	// 同一个列表时相当于轮转
+	s.RPush(ctx, "src", "d")
+	s.LMove(ctx, "src", "src", "LEFT", "RIGHT")
+	if got, _ := s.LRange(ctx, "src", 0, -1); !reflect.DeepEqual(got, []string{"d", "b"}) {
+		t.Errorf("LRange(src) got = %v, want [d b]", got)
+	}
+
+	if _, err := s.LMove(ctx, "missing", "dst", "LEFT", "LEFT"); err != MemoryNil {
+		t.Errorf("LMove() error = %v, want %v", err, MemoryNil)
+	}
+	if _, err := s.LMove(ctx, "src", "dst", "UP", "LEFT"); err != MemorySyntaxError {
+		t.Errorf("LMove() error = %v, want %v", err, MemorySyntaxError)
+	}
+}
+
+func Test_listStore_BLPop(t *testing.T) {
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+
+	start := time.Now()
+	if _, err := s.BLPop(ctx, time.Millisecond*50, "k1", "k2"); err != MemoryNil {
+		t.Fatalf("BLPop() error = %v, want %v", err, MemoryNil)
+	}
+	if d := time.Since(start); d < time.Millisecond*50 {
+		t.Errorf("BLPop() returned after %v, want >= 50ms", d)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		s.RPush(ctx, "k2", "a", "b")
+	}()
+	got, err := s.BLPop(ctx, time.Second, "k1", "k2")
+	if err != nil || !reflect.DeepEqual(got, []string{"k2", "a"}) {
+		t.Errorf("BLPop() got = %v, err = %v, want [k2 a]", got, err)
+	}
+	got, err = s.BRPop(ctx, time.Second, "k1", "k2")
+	if err != nil || !reflect.DeepEqual(got, []string{"k2", "b"}) {
+		t.Errorf("BRPop() got = %v, err = %v, want [k2 b]", got, err)
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*20)
+	defer cancel()
+	if _, err = s.BLPop(cctx, 0, "k1"); err != context.DeadlineExceeded {
+		t.Errorf("BLPop() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_listStore_BLMove(t *testing.T) {
+	ctx := context.Background()
+	s := newListStore(newKeyspace())
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		s.LPush(ctx, "src", "a")
+	}()
+	got, err := s.BLMove(ctx, "src", "dst", "LEFT", "RIGHT", time.Second)
+	if err != nil || got != "a" {
+		t.Fatalf("BLMove() got = %v, err = %v, want a", got, err)
+	}
+	if items, _ := s.LRange(ctx, "dst", 0, -1); !reflect.DeepEqual(items, []string{"a"}) {
+		t.Errorf("LRange(dst) got = %v, want [a]", items)
+	}
+	if _, err = s.BLMove(ctx, "src", "dst", "LEFT", "RIGHT", time.Millisecond*20); err != MemoryNil {
+		t.Errorf("BLMove() error = %v, want %v", err, MemoryNil)
+	}
+}
+
+func Test_listDeque(t *testing.T) {
+	var d listDeque
+	model := make([]string, 0)
+	r := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 20000; n++ {
+		item := strconv.Itoa(n)
+		switch op := r.Intn(7); {
+		case op == 0 || op == 1:
+			d.pushFront(item)
+			model = append([]string{item}, model...)
+		case op == 2 || op == 3:
+			d.pushBack(item)
+			model = append(model, item)
+		case op == 4 && len(model) > 0:
+			if got := d.popFront(); got != model[0] {
+				t.Fatalf("popFront() got = %v, want %v", got, model[0])
+			}
+			model = model[1:]
+		case op == 5 && len(model) > 0:
+			if got := d.popBack(); got != model[len(model)-1] {
+				t.Fatalf("popBack() got = %v, want %v", got, model[len(model)-1])
+			}
+			model = model[:len(model)-1]
+		case op == 6:
+			i := r.Intn(len(model) + 1)
+			d.insert(i, item)
+			model = append(model[:i], append([]string{item}, model[i:]...)...)
+		}
+
+		if d.len() != len(model) {
+			t.Fatalf("len() got = %v, want %v", d.len(), len(model))
+		}
+		if len(model) > 0 {
+			i := r.Intn(len(model))
+			if got := d.get(i); got != model[i] {
+				t.Fatalf("get(%d) got = %v, want %v", i, got, model[i])
+			}
+		}
+	}
+
+	for i := range model {
+		if got := d.get(i); got != model[i] {
+			t.Fatalf("get(%d) got = %v, want %v", i, got, model[i])
+		}
+	}
+}
+
+func Test_listDeque_release(t *testing.T) {
+	var d listDeque
+	for i := 0; i < listChunkSize*100; i++ {
+		d.pushBack(strconv.Itoa(i))
+	}
+
+	// 队列式的使用,从头部推出后分块被释放,环也会缩容
+	for d.len() > 1 {
+		d.popFront()
+	}
+	if d.used != 1 || len(d.chunks) > 4 {
+		t.Errorf("used = %v, chunks = %v, want 1 used and at most 4 chunks", d.used, len(d.chunks))
+	}
+	if got := d.get(0); got != strconv.Itoa(listChunkSize*100-1) {
+		t.Errorf("get(0) got = %v", got)
+	}
+
+	d.popBack()
+	if d.chunks != nil || d.used != 0 {
+		t.Errorf("empty deque still holds %v chunks", len(d.chunks))
+	}
+}
+
+func Test_listValue_bytes(t *testing.T) {
+	v := newListValue()
+	v.pushRight("a", "bb", "ccc")
+	v.pushLeft("dddd")
+	v.set(0, "e")
+	v.insert(2, "ff")
+	v.trim(1, 3)
+	// [e a ff bb ccc] 裁剪后剩下 [a ff bb]
+	if got := v.rangeItems(0, v.length()-1); !reflect.DeepEqual(got, []string{"a", "ff", "bb"}) {
+		t.Fatalf("rangeItems() got = %v", got)
+	}
+	if v.size() != 5 {
+		t.Errorf("size() got = %v, want 5", v.size())
+	}
+	if got := v.dump(); !reflect.DeepEqual(got, []string{"bb", "ff", "a"}) {
+		t.Errorf("dump() got = %v, want [bb ff a]", got)
+	}
+}
+
 func Benchmark_listStore_LPush(b *testing.B) {
-	s := newListStore()
+	s := newListStore(newKeyspace())
 	b.SetParallelism(10000)
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
@@ -653,8 +956,8 @@ func Benchmark_listStore_LPush(b *testing.B) {
 
 }
 
-func Benchmark_listStore_LPop(b *testing.B) {
-	s := newListStore()
+func Benchmark_listStore_RPop(b *testing.B) {
+	s := newListStore(newKeyspace())
 	b.SetParallelism(10000)
 
 	b.StopTimer()
@@ -669,14 +972,14 @@ func Benchmark_listStore_LPop(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			var key = strconv.FormatInt(rand.Int63n(100), 10)
-			s.LPop(context.Background(), key)
+			s.RPop(context.Background(), key)
 
 		}
 	})
 }
 
-func Benchmark_listStore_LShift(b *testing.B) {
-	s := newListStore()
+func Benchmark_listStore_LPop(b *testing.B) {
+	s := newListStore(newKeyspace())
 	b.SetParallelism(10000)
 
 	b.StopTimer()
@@ -690,14 +993,14 @@ func Benchmark_listStore_LShift(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			var key = strconv.FormatInt(rand.Int63n(100), 10)
-			s.LShift(context.Background(), key)
+			s.LPop(context.Background(), key)
 
 		}
 	})
 }
 
-func Benchmark_listStore_LRang(b *testing.B) {
-	s := newListStore()
+func Benchmark_listStore_LRange(b *testing.B) {
+	s := newListStore(newKeyspace())
 	b.SetParallelism(10000)
 
 	b.StopTimer()
@@ -712,14 +1015,14 @@ func Benchmark_listStore_LRang(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
 			var key = strconv.FormatInt(rand.Int63n(100), 10)
-			s.LRang(context.Background(), key, rand.Int63n(10000000), rand.Int63n(10000000))
+			s.LRange(context.Background(), key, rand.Int63n(10000000), rand.Int63n(10000000))
 		}
 	})
 	b.Log("<<:结束")
 }
 
 func Test_listStore_LX(t *testing.T) {
-	s := newListStore()
+	s := newListStore(newKeyspace())
 	now := time.Now()
 	for i := 0; i < 1000000; i++ {
 		var key = strconv.FormatInt(rand.Int63n(100), 10)
@@ -735,14 +1038,14 @@ func Test_listStore_LX(t *testing.T) {
 	}
 	fmt.Println("再插入一批看看", time.Now().Sub(now))
 	key := strconv.FormatInt(rand.Int63n(100), 10)
-	s.LRang(context.Background(), key, rand.Int63n(10000000), rand.Int63n(10000000))
+	s.LRange(context.Background(), key, rand.Int63n(10000000), rand.Int63n(10000000))
 
 	fmt.Println("获取耗时:", time.Now().Sub(now))
 
 }
 
 func Benchmark_listStore_X(b *testing.B) {
-	s := newListStore()
+	s := newListStore(newKeyspace())
 	b.SetParallelism(100000)
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
@@ -751,7 +1054,7 @@ func Benchmark_listStore_X(b *testing.B) {
 			s.LPush(context.Background(), key, value)
 
 			key = strconv.FormatInt(rand.Int63n(1000), 10)
-			s.LShift(context.Background(), key)
+			s.LPop(context.Background(), key)
 
 			s.LPush(context.Background(), key, value)
 			s.LPush(context.Background(), key, value, value, value, value, value, value, value)
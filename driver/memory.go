@@ -3,7 +3,7 @@ package driver
 import (
 	"context"
 	"errors"
-	"fmt"
+	"math"
 	"strconv"
 	"sync"
 	"time"
@@ -24,15 +24,63 @@ import (
 
 var MemoryNil = errors.New("memory cache: nil")
 
+// MemoryWrongType 键已经存在,但数值类型跟操作的类型不一致
+var MemoryWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// MemoryNotInteger 数值不是整数或者超出了范围
+var MemoryNotInteger = errors.New("ERR value is not an integer or out of range")
+
+// MemoryNotFloat 数值不是有效的浮点数
+var MemoryNotFloat = errors.New("ERR value is not a valid float")
+
+// MemoryOverflow 自增或者自减后的数值溢出
+var MemoryOverflow = errors.New("ERR increment or decrement would overflow")
+
+// MemoryNaN 浮点数自增后的结果是 NaN 或者 Infinity
+var MemoryNaN = errors.New("ERR increment would produce NaN or Infinity")
+
+// MemoryOutOfRange 偏移量超出了范围
+var MemoryOutOfRange = errors.New("ERR offset is out of range")
+
+// MemoryStringTooLong 字符串超过了 MaxStringSize
+var MemoryStringTooLong = errors.New("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+
+// MemorySyntaxError 参数不符合语法,如 LInsert 的位置不是 BEFORE/AFTER
+var MemorySyntaxError = errors.New("ERR syntax error")
+
+// MemoryNoSuchKey 操作要求键已经存在,如 LSet
+var MemoryNoSuchKey = errors.New("ERR no such key")
+
+// MemoryIndexOutOfRange 列表下标超出了范围
+var MemoryIndexOutOfRange = errors.New("ERR index out of range")
+
+// MemoryInvalidLexRange 字典序区间的边界不是以 "[" 或 "(" 开头,也不是 "-" 或 "+"
+var MemoryInvalidLexRange = errors.New("ERR min or max not valid string range item")
+
+// MemoryZAddIncrPair ZAddArgsIncr 只能有一个成员
+var MemoryZAddIncrPair = errors.New("ERR INCR option supports a single increment-element pair")
+
+// MemoryScoreNaN 有序集合成员的分数计算后是 NaN
+var MemoryScoreNaN = errors.New("ERR resulting score is not a number (NaN)")
+
+// memoryErrors 从主节点返回后需要还原的错误
+var memoryErrors = []error{
+	MemoryWrongType, MemoryNotInteger, MemoryNotFloat, MemoryOverflow, MemoryNaN, MemoryOutOfRange, MemoryStringTooLong,
+	MemorySyntaxError, MemoryNoSuchKey, MemoryIndexOutOfRange, MemoryInvalidLexRange, MemoryZAddIncrPair, MemoryScoreNaN,
+}
+
 var (
 	_ Cache = new(Memory)
 )
 
 // Memory 内存驱动
 type Memory struct {
-	rwMutex sync.RWMutex
+	// applyMutex 写入本地并同步的过程持有读锁,生成快照时持有写锁,
+	// 保证快照的数据跟同步序号一致
+	applyMutex sync.RWMutex
 
-	storeList []baseStoreer
+	// keyspace 所有类型共用的键空间
+	keyspace *keyspace
 
 	ss *stringStore
 
@@ -40,9 +88,15 @@ type Memory struct {
 
 	ls *listStore
 
+	sets *setStore
+
 	sts *sortedSetStore
 
 	syncer *memorySyncer
+
+	persistence *memoryPersistence
+
+	closeOnce sync.Once
 }
 
 /*
@@ -51,20 +105,20 @@ type Memory struct {
 
 // NewMemory 实例化一个内存核心的缓存驱动
 func NewMemory() Cache {
-	ss := newStringStore()
-	hs := newHashStore()
-	ls := newListStore()
-	sts := newSortSetStore()
+	return newMemory(DefaultKeyspaceShards)
+}
 
-	storeList := []baseStoreer{ss, hs, ls, sts}
+// newMemory 实例化一个指定键空间分片数量的内存驱动
+func newMemory(shards int) *Memory {
+	ks := newShardedKeyspace(shards)
 
 	return &Memory{
-		rwMutex:   sync.RWMutex{},
-		storeList: storeList,
-		ss:        ss,
-		hs:        hs,
-		ls:        ls,
-		sts:       sts,
+		keyspace: ks,
+		ss:       newStringStore(ks),
+		hs:       newHashStore(ks),
+		ls:       newListStore(ks),
+		sets:     newSetStore(ks),
+		sts:      newSortSetStore(ks),
 	}
 }
 
@@ -73,7 +127,7 @@ type EtcdConfig = v3.Config
 
 // MemoryConfig 内存配置
 type MemoryConfig struct {
-	// Prefix 业务名前缀,如果用于隔离不同业务
+	// Prefix 业务名前缀,如果用于隔离不同业务,使用ETCD集群时必须设置
 	Prefix string
 
 	// Port 如果打算启用多个驱动,请分别设置多个不冲突的IP用于启动服务
@@ -85,24 +139,107 @@ type MemoryConfig struct {
 	// Password 密码
 	Password string
 
-	// EtcdConfig 用于启用ETCD的服务
+	// EtcdConfig 用于启用ETCD的服务,未设置 Cluster 时使用
 	EtcdConfig EtcdConfig
 
+	// Cluster 集群成员发现跟选主,如 NewStaticCluster 返回的静态集群
+	// 为空时使用 EtcdConfig 跟 Prefix 创建ETCD集群
+	Cluster Cluster
+
+	// Persistence 持久化配置,为空时不进行持久化
+	Persistence *PersistenceConfig
+
+	// Shards 键空间的分片数量,每个分片使用独立的锁,默认为 DefaultKeyspaceShards
+	Shards int
+
+	// MaxTTL 数值最多可存活的时长,设置更长的存活时长或者持久化时会被限制在该时长内
+	// 小于等于0时不限制,没有设置存活时长的键永不过期
+	MaxTTL time.Duration
+
+	// ExpirePrecision 主动清理过期键的检测间隔,过期的键最迟在到期后的这个时长内被清理
+	// 默认为 DefaultExpirePrecision,访问到已经过期的键时会马上当作不存在
+	ExpirePrecision time.Duration
+
+	// MaxEntries 最多可以保存的键数量,小于等于0时不限制
+	MaxEntries int64
+
+	// MaxBytes 键跟数值最多可以占用的字节数估算值,小于等于0时不限制
+	MaxBytes int64
+
+	// EvictionPolicy 超出 MaxEntries 或 MaxBytes 时的淘汰策略,默认为 EvictNoEviction
+	EvictionPolicy EvictionPolicy
+
+	// EvictionSamples 每次淘汰时抽样的键数量,默认为 DefaultEvictionSamples
+	EvictionSamples int
+
+	// OnEvict 键因为过期或者超出容量限制被移除时的回调
+	// 回调时可能持有内部的锁,不能在回调中再调用同一个驱动
+	OnEvict func(key string, reason EvictReason)
+
+	// SyncMaxLag 从节点最多可以落后的同步数据条数,超过后会被标记成过期并重新全量同步
+	// 默认为 DefaultSyncMaxLag
+	SyncMaxLag int
+
 	// Context 上下文
 	Context context.Context
 }
 
-// NewMemoryWithConfig 实例化一个分布式的内存核心的缓存驱动
+// NewMemoryWithConfig 实例化一个分布式或者带持久化的内存核心的缓存驱动
+// 只设置了 Persistence 时为单机带持久化的驱动
 func NewMemoryWithConfig(cfg MemoryConfig) (Cache, error) {
-	mem := NewMemory().(*Memory)
-	syncer, err := newMemorySyncer(&cfg)
-	if err != nil {
-		return nil, err
+	mem := newMemory(cfg.Shards)
+	mem.keyspace.setMaxTTL(cfg.MaxTTL)
+	mem.keyspace.setExpirePrecision(cfg.ExpirePrecision)
+	mem.keyspace.limits = newKeyspaceLimits(&cfg)
+	mem.keyspace.onEvict = cfg.OnEvict
+
+	// 先从磁盘恢复数据,加入集群后如果是从节点会再从主节点全量同步
+	if cfg.Persistence != nil {
+		persistence, err := newMemoryPersistence(cfg.Context, *cfg.Persistence, mem)
+		if err != nil {
+			return nil, err
+		}
+		mem.persistence = persistence
+	}
+
+	if cfg.Persistence == nil || cfg.distributed() {
+		_, err := newMemorySyncer(&cfg, mem)
+		if err != nil {
+			if mem.persistence != nil {
+				mem.persistence.Close()
+			}
+			mem.keyspace.close()
+			return nil, err
+		}
 	}
-	syncer.setMemory(mem)
 	return mem, nil
 }
 
+// Close 关闭驱动,释放运行中的协程跟连接,关闭后不能再使用
+// 分布式的驱动会先等待从节点的同步队列投递完成,再离开集群并放弃主节点身份,关闭同步服务跟所有连接
+// 带持久化的驱动会将剩余的日志写入磁盘
+// ctx 结束时不再等待同步队列跟正在处理的同步请求
+func (m *Memory) Close(ctx context.Context) error {
+	var err error
+	m.closeOnce.Do(func() {
+		if m.syncer != nil {
+			err = m.syncer.Shutdown(ctx)
+		}
+		if m.persistence != nil {
+			if e := m.persistence.Close(); err == nil {
+				err = e
+			}
+		}
+		m.keyspace.close()
+	})
+	return err
+}
+
+// distributed 判断是否设置了分布式相关的配置
+func (cfg *MemoryConfig) distributed() bool {
+	return cfg.Port > 0 || cfg.Cluster != nil || len(cfg.EtcdConfig.Endpoints) > 0
+}
+
 // NewStringMemory 实例化一个仅带字符串存储功能的内存核心缓存驱动
 func NewStringMemory() String {
 	return NewMemory()
@@ -112,36 +249,51 @@ func NewStringMemory() String {
 // ====================================== PRIVATE ==================================================
 // ================================================================================================
 
-// checkKeyExists 检测Key是否已经存在,并放回存储该key的容器
-func (m *Memory) checkKeyExists(key string) (baseStoreer, bool) {
-	for _, store := range m.storeList {
-		if store.KeyExists(key) {
-			return store, true
+// ensureCapacity 写入 keys 前检测容量,超出限制时按淘汰策略淘汰其他的键
+// 被淘汰的键会同步删除到从节点
+func (m *Memory) ensureCapacity(keys ...string) error {
+	return m.keyspace.ensureCapacity(func(key string) {
+		m.syncToSlave(proto.Action_Del, key)
+	}, keys...)
+}
+
+// lockApply 锁住写入过程,防止在写入本地跟分配同步序号之间生成快照
+func (m *Memory) lockApply() func() {
+	m.applyMutex.RLock()
+	return m.applyMutex.RUnlock
+}
+
+// entries 导出所有存储器中未过期的数据
+func (m *Memory) entries() []*storeEntry {
+	return m.keyspace.entries()
+}
+
+// loadEntries 清空本地数据,并加载导出的数据
+func (m *Memory) loadEntries(entries []*storeEntry) {
+	m.keyspace.flush()
+
+	for _, entry := range entries {
+		switch entry.Type {
+		case driverStoreTypeString:
+			m.ss.restore(entry)
+		case driverStoreTypeHash:
+			m.hs.restore(entry)
+		case driverStoreTypeList:
+			m.ls.restore(entry)
+		case driverStoreTypeSet:
+			m.sets.restore(entry)
+		case driverStoreTypeSortedSet:
+			m.sts.restore(entry)
 		}
 	}
-	return nil, false
 }
 
-// checkKeyAble 检测Key是否可以使用
-func (m *Memory) checkKeyAble(key string, storeType driverStoreType) (bool, error) {
-	if s, b := m.checkKeyExists(key); b && s.Type() != storeType {
-		return false, fmt.Errorf("the key is already of type '%s' and cannot be set to type'%s'", s.Type(), storeType)
-	}
-	return true, nil
-}
-
-// checkKeysAble 检测 Keys 是否是合法的key
-func (m *Memory) checkKeysAble(keys []string, storeType driverStoreType) (bool, error) {
-	for _, k := range keys {
-		if s, b := m.checkKeyExists(k); b && s.Type() != storeType {
-			return false, fmt.Errorf("the key[%s] is already of type '%s' and cannot be set to type'%s'", k, s.Type(), storeType)
-		}
+// syncToSlave 写入持久化日志,并同步数据到各个终端
+func (m *Memory) syncToSlave(action proto.Action, values ...string) {
+	if m.persistence != nil {
+		m.persistence.append(action, values...)
 	}
-	return true, nil
-}
 
-// syncToSlave 同步数据到各个终端
-func (m *Memory) syncToSlave(action proto.Action, values ...string) {
 	// 没有同步器就退出
 	if m.syncer == nil {
 		return
@@ -154,8 +306,8 @@ func (m *Memory) syncToSlave(action proto.Action, values ...string) {
 	m.syncer.syncToSlaves(action, values...)
 }
 
-// syncToMaster 同步数据到主节点
-func (m *Memory) syncToMaster(action proto.Action, values ...string) ([]string, error) {
+// syncToMaster 同步数据到主节点,ctx 结束时放弃等待主节点的返回
+func (m *Memory) syncToMaster(ctx context.Context, action proto.Action, values ...string) ([]string, error) {
 	empty := make([]string, 1)
 	if m.syncer == nil {
 		return empty, errors.New("Memory: no syncer")
@@ -165,30 +317,25 @@ func (m *Memory) syncToMaster(action proto.Action, values ...string) ([]string,
 		return empty, errors.New("Memory: syncer no a slave node")
 	}
 
-	return m.syncer.syncToMaster(action, values...)
+	return m.syncer.syncToMaster(ctx, action, values...)
 }
 
 // ================================================================================================
 // ====================================== COMMON ==================================================
 // ================================================================================================
 
+// Stats 返回键的数量、占用的字节数估算值以及淘汰相关的统计数据
+func (m *Memory) Stats() MemoryStats {
+	return m.keyspace.stats()
+}
+
 // Exists 判断某个Key是否存在
 func (m *Memory) Exists(ctx context.Context, keys ...string) IntValuer {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
 	result := &redis.IntCmd{}
 
-	cnt := int64(0)
-	for _, store := range m.storeList {
-		i, err := store.Exists(ctx, keys...)
-		if err != nil {
-			result.SetErr(err)
-			return result
-		}
-		cnt += i
-	}
-
+	cnt, err := m.keyspace.Exists(ctx, keys...)
 	result.SetVal(cnt)
+	result.SetErr(err)
 	return result
 }
 
@@ -204,16 +351,16 @@ func (m *Memory) Del(ctx context.Context, keys ...string) IntValuer {
 	}
 
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		result.SetVal(m.del(context.Background(), keys...))
+		unlock := m.lockApply()
+		defer unlock()
 
-		if m.syncer != nil {
-			m.syncToSlave(proto.Action_Del, keys...)
-		}
+		result.SetVal(m.del(context.Background(), keys...))
+		m.syncToSlave(proto.Action_Del, keys...)
 		return result
 	}
 
 	// 同步到主节点
-	rsp, err := m.syncToMaster(proto.Action_Del, keys...)
+	rsp, err := m.syncToMaster(ctx, proto.Action_Del, keys...)
 	if err != nil {
 		result.SetErr(err)
 		return result
@@ -226,18 +373,37 @@ func (m *Memory) Del(ctx context.Context, keys ...string) IntValuer {
 }
 
 func (m *Memory) del(ctx context.Context, keys ...string) int64 {
-	m.rwMutex.Lock()
-	defer m.rwMutex.Unlock()
-	cnt := int64(0)
-	for _, store := range m.storeList {
-		i, _ := store.Del(ctx, keys...)
-		cnt += i
-	}
+	cnt, _ := m.keyspace.Del(ctx, keys...)
 	return cnt
 }
 
 // Expire 设置某个key的存活时间
 func (m *Memory) Expire(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	return m.expireWith(ctx, key, ttl, expireModeNone)
+}
+
+// ExpireNX 只有key没有到期时间时才设置存活时间
+func (m *Memory) ExpireNX(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	return m.expireWith(ctx, key, ttl, expireModeNX)
+}
+
+// ExpireXX 只有key已经有到期时间时才设置存活时间
+func (m *Memory) ExpireXX(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	return m.expireWith(ctx, key, ttl, expireModeXX)
+}
+
+// ExpireGT 只有新的到期时间比原来的晚时才设置存活时间,没有到期时间的key不会被设置
+func (m *Memory) ExpireGT(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	return m.expireWith(ctx, key, ttl, expireModeGT)
+}
+
+// ExpireLT 只有新的到期时间比原来的早时才设置存活时间,没有到期时间的key也会被设置
+func (m *Memory) ExpireLT(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	return m.expireWith(ctx, key, ttl, expireModeLT)
+}
+
+// expireWith 满足条件时设置存活时间,条件作为同步数据的第三个参数
+func (m *Memory) expireWith(ctx context.Context, key string, ttl time.Duration, mode expireMode) BoolValuer {
 	result := &redis.BoolCmd{}
 	select {
 	case <-ctx.Done():
@@ -246,22 +412,29 @@ func (m *Memory) Expire(ctx context.Context, key string, ttl time.Duration) Bool
 	default:
 	}
 
+	dur, _ := marshalData(ttl)
+	values := []string{key, dur}
+	if mode != expireModeNone {
+		values = append(values, string(mode))
+	}
+
 	// 同步到本地并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		b, err := m.expire(ctx, key, ttl)
+		unlock := m.lockApply()
+		defer unlock()
+
+		b, err := m.expire(ctx, key, ttl, mode)
 		result.SetVal(b)
 		result.SetErr(err)
 
-		if m.syncer != nil {
-			dur, _ := marshalData(ttl)
-			m.syncToSlave(proto.Action_Expire, key, dur)
+		if err == nil {
+			m.syncToSlave(proto.Action_Expire, values...)
 		}
 		return result
 	}
 
 	// 同步到主节点
-	dur, _ := marshalData(ttl)
-	rsp, err := m.syncToMaster(proto.Action_Expire, key, dur)
+	rsp, err := m.syncToMaster(ctx, proto.Action_Expire, values...)
 	if err != nil {
 		result.SetErr(err)
 		return result
@@ -272,20 +445,8 @@ func (m *Memory) Expire(ctx context.Context, key string, ttl time.Duration) Bool
 	return result
 }
 
-func (m *Memory) expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
-	m.rwMutex.Lock()
-	defer m.rwMutex.Unlock()
-	for _, store := range m.storeList {
-		// Todo 错误收集
-		b, err := store.Expire(ctx, key, ttl)
-		if err != nil {
-			return false, err
-		}
-		if b {
-			return b, nil
-		}
-	}
-	return false, nil
+func (m *Memory) expire(ctx context.Context, key string, ttl time.Duration, mode expireMode) (bool, error) {
+	return m.keyspace.ExpireMode(ctx, key, ttl, mode)
 }
 
 // ExpireAt 设置某个key在指定时间内到期
@@ -300,11 +461,14 @@ func (m *Memory) ExpireAt(ctx context.Context, key string, at time.Time) BoolVal
 
 	// 设置到本地并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
 		b, err := m.expireAt(ctx, key, &at)
 		result.SetVal(b)
 		result.SetErr(err)
 
-		if m.syncer != nil {
+		if err == nil {
 			tm, _ := marshalData(at)
 			m.syncToSlave(proto.Action_ExpireAt, key, tm)
 		}
@@ -313,7 +477,7 @@ func (m *Memory) ExpireAt(ctx context.Context, key string, at time.Time) BoolVal
 
 	// 同步到主节点
 	tm, _ := marshalData(at)
-	rsp, err := m.syncToMaster(proto.Action_ExpireAt, key, tm)
+	rsp, err := m.syncToMaster(ctx, proto.Action_ExpireAt, key, tm)
 	if err != nil {
 		result.SetErr(err)
 		return result
@@ -326,33 +490,23 @@ func (m *Memory) ExpireAt(ctx context.Context, key string, at time.Time) BoolVal
 }
 
 func (m *Memory) expireAt(ctx context.Context, key string, at *time.Time) (bool, error) {
-	m.rwMutex.Lock()
-	defer m.rwMutex.Unlock()
-	for _, store := range m.storeList {
-		// Todo 错误收集
-		b, err := store.ExpireAt(ctx, key, *at)
-		if err != nil {
-			return false, err
-		}
-
-		if b {
-			return true, nil
-		}
-	}
-	return false, nil
+	return m.keyspace.ExpireAt(ctx, key, *at)
 }
 
 // Persist 删除key的过期时间,并设置成持久性
-// 注意,持久化后最长也也不会超过 ValueMaxTTL
+// 注意,设置了 MemoryConfig.MaxTTL 时,持久化后最长也不会超过 MaxTTL
 func (m *Memory) Persist(ctx context.Context, key string) BoolValuer {
 	result := &redis.BoolCmd{}
 	// 设置到本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
 		b, err := m.persist(ctx, key)
 		result.SetErr(err)
 		result.SetVal(b)
 
-		if m.syncer != nil {
+		if err == nil {
 			m.syncToSlave(proto.Action_Persist, key)
 		}
 
@@ -360,7 +514,7 @@ func (m *Memory) Persist(ctx context.Context, key string) BoolValuer {
 	}
 
 	// 同步到主节点
-	rsp, err := m.syncToMaster(proto.Action_Persist, key)
+	rsp, err := m.syncToMaster(ctx, proto.Action_Persist, key)
 	if err != nil {
 		result.SetErr(err)
 		return result
@@ -373,19 +527,40 @@ func (m *Memory) Persist(ctx context.Context, key string) BoolValuer {
 }
 
 func (m *Memory) persist(ctx context.Context, key string) (bool, error) {
-	m.rwMutex.Lock()
-	defer m.rwMutex.Unlock()
-	for _, store := range m.storeList {
-		// Todo 错误收集
-		b, err := store.Persist(ctx, key)
-		if err != nil {
-			return false, err
-		}
-		if b {
-			return b, nil
-		}
-	}
-	return false, nil
+	return m.keyspace.Persist(ctx, key)
+}
+
+// TTL 获取某个key的剩余存活时长,精确到秒
+// key不存在时返回 -2, key没有设置存活时长时返回 -1
+func (m *Memory) TTL(ctx context.Context, key string) DurationValuer {
+	result := redis.NewDurationCmd(ctx, time.Second)
+
+	ttl, err := m.keyspace.TTL(ctx, key)
+	result.SetVal(ttl)
+	result.SetErr(err)
+	return result
+}
+
+// PTTL 获取某个key的剩余存活时长,精确到毫秒
+// key不存在时返回 -2, key没有设置存活时长时返回 -1
+func (m *Memory) PTTL(ctx context.Context, key string) DurationValuer {
+	result := redis.NewDurationCmd(ctx, time.Millisecond)
+
+	ttl, err := m.keyspace.PTTL(ctx, key)
+	result.SetVal(ttl)
+	result.SetErr(err)
+	return result
+}
+
+// ExpireTime 获取某个key的到期时间,以距离 Unix 纪元的时长表示,精确到秒
+// key不存在时返回 -2, key没有设置存活时长时返回 -1
+func (m *Memory) ExpireTime(ctx context.Context, key string) DurationValuer {
+	result := redis.NewDurationCmd(ctx, time.Second)
+
+	at, err := m.keyspace.ExpireTime(ctx, key)
+	result.SetVal(at)
+	result.SetErr(err)
+	return result
 }
 
 // ================================================================================================
@@ -412,33 +587,34 @@ func (m *Memory) Set(ctx context.Context, key string, data interface{}, expirati
 	ttl, _ := marshalData(expiration)
 	// 设置到本地并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
 		err = m.set(ctx, key, value, expiration)
 		if err == nil {
 			val.SetVal("OK")
 		}
 		val.SetErr(err)
 
-		if m.syncer != nil && err == nil {
+		if err == nil {
 			m.syncToSlave(proto.Action_Set, key, value, ttl)
 		}
 
 		return val
 	}
 	// 同步到主节点
-	rsp, err := m.syncToMaster(proto.Action_Set, key, value, ttl)
+	rsp, err := m.syncToMaster(ctx, proto.Action_Set, key, value, ttl)
 	val.SetVal(rsp[0])
 	val.SetErr(err)
 	return val
 }
 
 func (m *Memory) set(ctx context.Context, key, value string, expiration time.Duration) error {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeString); err != nil {
-		return err
-	}
-
 	return m.ss.Set(ctx, key, value, expiration)
 }
 
@@ -462,11 +638,19 @@ func (m *Memory) SetNX(ctx context.Context, key string, data interface{}, expira
 	ttl, _ := marshalData(expiration)
 
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
 		nx, err := m.setNX(ctx, key, value, expiration)
 		val.SetVal(nx)
 		val.SetErr(err)
 
-		if m.syncer != nil && err == nil {
+		if err == nil {
 			m.syncToSlave(proto.Action_SetNX, key, value, ttl)
 		}
 
@@ -474,31 +658,19 @@ func (m *Memory) SetNX(ctx context.Context, key string, data interface{}, expira
 	}
 
 	// 同步到主节点
-	rsp, err := m.syncToMaster(proto.Action_SetNX, key, value, ttl)
+	rsp, err := m.syncToMaster(ctx, proto.Action_SetNX, key, value, ttl)
 	val.SetVal(rsp[0] == "1")
 	val.SetErr(err)
 	return val
 }
 
 func (m *Memory) setNX(ctx context.Context, key, data string, expiration time.Duration) (bool, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	if _, b := m.checkKeyExists(key); b {
-		return false, nil
-	}
-
 	return m.ss.SetNX(ctx, key, data, expiration)
 }
 
 // Get 获取数据
 func (m *Memory) Get(ctx context.Context, key string) StringValuer {
 	val := new(redis.StringCmd)
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeString); err != nil {
-		val.SetErr(err)
-		return val
-	}
-
 	v, err := m.ss.Get(ctx, key)
 	val.SetVal(v)
 	val.SetErr(translateErr(err))
@@ -514,21 +686,29 @@ func (m *Memory) MGet(ctx context.Context, keys ...string) SliceValuer {
 	return val
 }
 
-// ================================================================================================
-// ======================================== HASH ==================================================
-// ================================================================================================
+// Incr 将key中储存的数字加1
+func (m *Memory) Incr(ctx context.Context, key string) IntValuer {
+	return m.IncrBy(ctx, key, 1)
+}
 
-// HExists 检测field是否存在哈希表中
-func (m *Memory) HExists(ctx context.Context, key, field string) BoolValuer {
-	val := new(redis.BoolCmd)
-	cnt, err := m.hs.HExists(ctx, key, field)
-	val.SetVal(cnt)
-	val.SetErr(err)
-	return val
+// Decr 将key中储存的数字减1
+func (m *Memory) Decr(ctx context.Context, key string) IntValuer {
+	return m.IncrBy(ctx, key, -1)
 }
 
-// HDel 哈希表删除指定字段(fields)
-func (m *Memory) HDel(ctx context.Context, key string, fields ...string) IntValuer {
+// DecrBy 将key中储存的数字减去 decrement
+func (m *Memory) DecrBy(ctx context.Context, key string, decrement int64) IntValuer {
+	if decrement == math.MinInt64 {
+		val := new(redis.IntCmd)
+		val.SetErr(MemoryOverflow)
+		return val
+	}
+	return m.IncrBy(ctx, key, -decrement)
+}
+
+// IncrBy 将key中储存的数字加上增量 value,key不存在时先初始化为0
+// 主节点上执行后同步增量,各个节点按同步序号执行的结果一致
+func (m *Memory) IncrBy(ctx context.Context, key string, value int64) IntValuer {
 
 	val := new(redis.IntCmd)
 
@@ -537,327 +717,290 @@ func (m *Memory) HDel(ctx context.Context, key string, fields ...string) IntValu
 		return val
 	}
 
-	values := make([]string, 0, len(fields)+1)
-	values = append(values, key)
-	values = append(values, fields...)
+	incr, _ := marshalData(value)
 
-	// 设置到本地并同步到从节点
+	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		cnt, err := m.hDel(ctx, key, fields...)
-		val.SetVal(cnt)
-		val.SetErr(err)
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_HDel, values...)
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.incrBy(ctx, key, value)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_IncrBy, key, incr)
 		}
 		return val
 	}
 
-	// 同步到主节点
-	rsp, err := m.syncToMaster(proto.Action_HDel, values...)
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_IncrBy, key, incr)
+	val.SetErr(err)
 	if err == nil {
 		i, _ := strconv.ParseInt(rsp[0], 10, 64)
 		val.SetVal(i)
 	}
-
-	val.SetErr(err)
 	return val
 }
 
-func (m *Memory) hDel(ctx context.Context, key string, fields ...string) (int64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeHash); err != nil {
-		return 0, err
-	}
-
-	return m.hs.HDel(ctx, key, fields...)
+func (m *Memory) incrBy(ctx context.Context, key string, value int64) (int64, error) {
+	return m.ss.IncrBy(ctx, key, value)
 }
 
-// HSet 哈希表设置数据
-func (m *Memory) HSet(ctx context.Context, key string, data ...interface{}) IntValuer {
+// IncrByFloat 将key中储存的数字加上浮点数增量 value,key不存在时先初始化为0
+// 浮点数运算在不同节点上可能有误差,主节点执行后以 KeepTTL 的 Set 同步计算结果
+func (m *Memory) IncrByFloat(ctx context.Context, key string, value float64) FloatValuer {
 
-	val := new(redis.IntCmd)
+	val := new(redis.FloatCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	// 将参数列表释放成切片
-	dataSlice := sliceArgs(data)
-	if len(dataSlice)%2 != 0 {
-		val.SetErr(errors.New("the number of parameters is incorrect"))
-		return val
-	}
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
 
-	values := make([]string, len(dataSlice)+1, len(dataSlice)+1)
-	values[0] = key
-	var err error
-	for i := 0; i < len(dataSlice); i++ {
-		values[i+1], err = marshalData(dataSlice[i])
-		if err != nil {
+		if err := m.ensureCapacity(key); err != nil {
 			val.SetErr(err)
 			return val
 		}
-	}
 
-	// 如果没有同步器或者同步器是一个主节点
-	// 则可以直接在本地设置数据
-	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		i, err := m.hSet(ctx, key, values[1:]...)
-		val.SetVal(i)
-		val.SetErr(err)
+		v, err := m.incrByFloat(ctx, key, value)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
 
-		// 同步到从节点
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_HSet, values...)
+		if err == nil {
+			result, _ := marshalData(v)
+			ttl, _ := marshalData(KeepTTL)
+			m.syncToSlave(proto.Action_Set, key, result, ttl)
 		}
 		return val
 	}
 
-	// 剩下的是从节点操作
-	rsp, err := m.syncToMaster(proto.Action_HSet, values...)
+	// 访问主节点并返回数据
+	incr, _ := marshalData(value)
+	rsp, err := m.syncToMaster(ctx, proto.Action_IncrByFloat, key, incr)
+	val.SetErr(err)
 	if err == nil {
-		i, _ := strconv.ParseInt(rsp[0], 10, 64)
-		val.SetVal(i)
+		f, _ := strconv.ParseFloat(rsp[0], 64)
+		val.SetVal(f)
 	}
-	val.SetErr(err)
 	return val
 }
 
-func (m *Memory) hSet(ctx context.Context, key string, data ...string) (int64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeHash); err != nil {
-		return 0, err
-	}
-
-	if len(data)%2 != 0 {
-		return 0, errors.New("the number of parameters is incorrect")
-	}
-	return m.hs.HSet(ctx, key, data...)
+func (m *Memory) incrByFloat(ctx context.Context, key string, value float64) (float64, error) {
+	return m.ss.IncrByFloat(ctx, key, value)
 }
 
-// HSetNX 如果哈希表的field不存在,则设置成功
-func (m *Memory) HSetNX(ctx context.Context, key, field string, data interface{}) BoolValuer {
+// Append 将 value 追加到key原来的值的末尾
+// @return 追加之后字符串的长度
+func (m *Memory) Append(ctx context.Context, key, value string) IntValuer {
 
-	val := new(redis.BoolCmd)
+	val := new(redis.IntCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	value, err := marshalData(data)
-	if err != nil {
-		val.SetErr(err)
-		return val
-	}
-
-	// 设置到本地并同步到从节点
+	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		cnt, err := m.hSetNX(ctx, key, field, value)
-		val.SetVal(cnt)
-		val.SetErr(err)
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_HSet, key, field, value)
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
 		}
-		return val
-	}
 
-	// 剩下的是从节点同步到主节点
-	rsp, err := m.syncToMaster(proto.Action_HSetNx, key, field, value)
-	if err == nil && rsp[0] == "1" {
-		val.SetVal(true)
+		v, err := m.append(ctx, key, value)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_Append, key, value)
+		}
+		return val
 	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_Append, key, value)
 	val.SetErr(err)
+	if err == nil {
+		i, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(i)
+	}
 	return val
 }
 
-func (m *Memory) hSetNX(ctx context.Context, key, field, value string) (bool, error) {
-	m.rwMutex.RLock()
-
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeHash); err != nil {
-		return false, err
-	}
-
-	return m.hs.HSetNX(ctx, key, field, value)
+func (m *Memory) append(ctx context.Context, key, value string) (int64, error) {
+	return m.ss.Append(ctx, key, value)
 }
 
-// HGet 哈希表获取一个数据
-func (m *Memory) HGet(ctx context.Context, key string, field string) StringValuer {
+// GetRange 返回key中字符串值的子字符串,截取的范围由 start 和 end 决定(包括 start 和 end 在内)
+func (m *Memory) GetRange(ctx context.Context, key string, start, end int64) StringValuer {
 	val := new(redis.StringCmd)
-	v, err := m.hs.HGet(ctx, key, field)
-
+	v, err := m.ss.GetRange(ctx, key, start, end)
 	val.SetVal(v)
 	val.SetErr(translateErr(err))
 	return val
 }
 
-// HMGet 哈希表获取多个数据
-func (m *Memory) HMGet(ctx context.Context, key string, fields ...string) SliceValuer {
-	val := new(redis.SliceCmd)
-	v, err := m.hs.HMGet(ctx, key, fields...)
-	val.SetVal(v)
-	val.SetErr(err)
-	return val
-}
+// SetRange 用 value 覆写key中字符串值从偏移量 offset 开始的内容,不足的部分用零字节填充
+// @return 覆写之后字符串的长度
+func (m *Memory) SetRange(ctx context.Context, key string, offset int64, value string) IntValuer {
 
-// HKeys 哈希表获取某个Key的所有字段(field)
-func (m *Memory) HKeys(ctx context.Context, key string) StringSliceValuer {
-	val := new(redis.StringSliceCmd)
-	v, err := m.hs.HKeys(ctx, key)
-	val.SetVal(v)
-	val.SetErr(err)
-	return val
-}
+	val := new(redis.IntCmd)
 
-// HVals 哈希表获取所有值
-func (m *Memory) HVals(ctx context.Context, key string) StringSliceValuer {
-	val := new(redis.StringSliceCmd)
-	v, err := m.hs.HVals(ctx, key)
-	val.SetVal(v)
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	offsetStr, _ := marshalData(offset)
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.setRange(ctx, key, offset, value)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_SetRange, key, offsetStr, value)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_SetRange, key, offsetStr, value)
 	val.SetErr(err)
+	if err == nil {
+		i, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(i)
+	}
 	return val
 }
 
-// HGetAll 获取哈希表所有的数据,包括field跟value
-func (m *Memory) HGetAll(ctx context.Context, key string) MapStringStringValuer {
-	val := new(redis.MapStringStringCmd)
-	v, err := m.hs.HGetAll(ctx, key)
-	val.SetVal(v)
-	val.SetErr(err)
-	return val
+func (m *Memory) setRange(ctx context.Context, key string, offset int64, value string) (int64, error) {
+	return m.ss.SetRange(ctx, key, offset, value)
 }
 
-// HLen 哈希表所有字段的数量
-func (m *Memory) HLen(ctx context.Context, key string) IntValuer {
+// StrLen 返回key中字符串值的长度
+func (m *Memory) StrLen(ctx context.Context, key string) IntValuer {
 	val := new(redis.IntCmd)
-	v, err := m.hs.HLen(ctx, key)
+	v, err := m.ss.StrLen(ctx, key)
 	val.SetVal(v)
-	val.SetErr(err)
+	val.SetErr(translateErr(err))
 	return val
 }
 
-// ================================================================================================
-// ======================================== LIST ==================================================
-// ================================================================================================
-
-// LTrim 对一个列表进行修剪(trim)，就是说，让列表只保留指定区间内的元素，不在指定区间之内的元素都将被删除。
-// 举个例子，执行命令 LTRIM list 0 2 ，表示只保留列表 list 的前三个元素，其余元素全部删除。
-// 下标(index)参数 start 和 stop 都以 0 为底，也就是说，以 0 表示列表的第一个元素，以 1 表示列表的第二个元素，以此类推。
-// 你也可以使用负数下标，以 -1 表示列表的最后一个元素， -2 表示列表的倒数第二个元素，以此类推。
-func (m *Memory) LTrim(ctx context.Context, key string, start, stop int64) StatusValuer {
+// GetSet 设置key的值并返回原来的值,原来的存活时长会被清除
+func (m *Memory) GetSet(ctx context.Context, key string, data interface{}) StringValuer {
 
-	val := new(redis.StatusCmd)
+	val := new(redis.StringCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	startStr, _ := marshalData(start)
-	stopStr, _ := marshalData(stop)
+	value, err := marshalData(data)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
 
+	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		err := m.lTrim(ctx, key, start, stop)
-		if err == nil {
-			val.SetVal("OK")
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
 		}
-		val.SetErr(err)
 
-		if m.syncer != nil && err == nil {
+		v, err := m.getSet(ctx, key, value)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
 
-			m.syncToSlave(proto.Action_LTrim, key, startStr, stopStr)
+		// key不存在时同样设置了新的值
+		if err == nil || err == MemoryNil {
+			m.syncToSlave(proto.Action_GetSet, key, value)
 		}
 		return val
 	}
 
-	// 剩下的是从节点同步到主节点
-	rsp, err := m.syncToMaster(proto.Action_LTrim, key, startStr, stopStr)
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_GetSet, key, value)
+	if err == nil && len(rsp) > 1 && rsp[1] == "0" {
+		err = MemoryNil
+	}
 	val.SetVal(rsp[0])
-	val.SetErr(err)
+	val.SetErr(translateErr(err))
 	return val
 }
 
-func (m *Memory) lTrim(ctx context.Context, key string, start, stop int64) error {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeList); err != nil {
-		return err
-	}
-	return m.ls.LTrim(ctx, key, start, stop)
+func (m *Memory) getSet(ctx context.Context, key, value string) (string, error) {
+	return m.ss.GetSet(ctx, key, value)
 }
 
-// LPush 将数据推入到列表中
-func (m *Memory) LPush(ctx context.Context, key string, data ...interface{}) IntValuer {
+// GetDel 获取key的值并删除key
+func (m *Memory) GetDel(ctx context.Context, key string) StringValuer {
 
-	val := new(redis.IntCmd)
+	val := new(redis.StringCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	values := make([]string, len(data)+1, len(data)+1)
-	values[0] = key
-	var err error
-	for i := 0; i < len(data); i++ {
-		values[i+1], err = marshalData(data[i])
-		if err != nil {
-			val.SetErr(err)
-			return val
-		}
-	}
-
 	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		cnt, err := m.lPush(ctx, key, values[1:]...)
-		val.SetVal(cnt)
-		val.SetErr(err)
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LPush, values...)
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.getDel(ctx, key)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_Del, key)
 		}
 		return val
 	}
 
 	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LPush, values...)
-	val.SetErr(err)
-	if err == nil {
-		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
-		val.SetVal(cnt)
-	}
-	return val
-}
-
-func (m *Memory) lPush(ctx context.Context, key string, values ...string) (int64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeList); err != nil {
-		return 0, err
-	}
-	return m.ls.LPush(ctx, key, values...)
-}
-
-// LRang 提取列表范围内的数据
-func (m *Memory) LRang(ctx context.Context, key string, start, stop int64) StringSliceValuer {
-	val := new(redis.StringSliceCmd)
-	v, err := m.ls.LRang(ctx, key, start, stop)
-	val.SetVal(v)
+	rsp, err := m.syncToMaster(ctx, proto.Action_GetDel, key)
+	val.SetVal(rsp[0])
 	val.SetErr(translateErr(err))
 	return val
+}
 
+func (m *Memory) getDel(ctx context.Context, key string) (string, error) {
+	return m.ss.GetDel(ctx, key)
 }
 
-// LPop 推出列表尾的最后数据
-func (m *Memory) LPop(ctx context.Context, key string) StringValuer {
+// GetEx 获取key的值并设置存活时长
+// expiration 大于0时设置存活时长,等于0时移除存活时长,小于0时保持原有的存活时长
+func (m *Memory) GetEx(ctx context.Context, key string, expiration time.Duration) StringValuer {
 
 	val := new(redis.StringCmd)
 
@@ -866,169 +1009,2014 @@ func (m *Memory) LPop(ctx context.Context, key string) StringValuer {
 		return val
 	}
 
+	ttl, _ := marshalData(expiration)
+
 	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.lPop(ctx, key)
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.getEx(ctx, key, expiration)
 		val.SetVal(v)
 		val.SetErr(translateErr(err))
 
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LPop, key)
+		if err == nil && expiration >= 0 {
+			m.syncToSlave(proto.Action_GetEx, key, ttl)
 		}
 		return val
 	}
 
 	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LPop, key)
+	rsp, err := m.syncToMaster(ctx, proto.Action_GetEx, key, ttl)
 	val.SetVal(rsp[0])
-	val.SetErr(err)
+	val.SetErr(translateErr(err))
 	return val
 }
 
-func (m *Memory) lPop(ctx context.Context, key string) (string, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeList); err != nil {
-		return "", err
-	}
-
-	return m.ls.LPop(ctx, key)
+func (m *Memory) getEx(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	return m.ss.GetEx(ctx, key, expiration)
 }
 
-// LBPop 推出列表尾的最后数据
-func (m *Memory) LBPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer {
+// SetXX 只有key已经存在时才设置数据
+func (m *Memory) SetXX(ctx context.Context, key string, data interface{}, expiration time.Duration) BoolValuer {
 
-	val := new(redis.StringSliceCmd)
+	val := new(redis.BoolCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	values := make([]string, 0, len(keys)+1)
-	timeoutStr, _ := marshalData(timeout)
-	values = append(values, timeoutStr)
-	values = append(values, keys...)
+	value, err := marshalData(data)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+	ttl, _ := marshalData(expiration)
 
-	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.lBPop(ctx, timeout, keys...)
-		val.SetVal(v)
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		xx, err := m.setXX(ctx, key, value, expiration)
+		val.SetVal(xx)
 		val.SetErr(translateErr(err))
 
-		if m.syncer != nil && err == nil {
-			for _, k := range keys {
-				m.syncToSlave(proto.Action_LPop, k)
-			}
+		if xx {
+			m.syncToSlave(proto.Action_SetXX, key, value, ttl)
 		}
 		return val
 	}
 
-	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LBPop, values...)
-	val.SetVal(rsp)
+	// 同步到主节点
+	rsp, err := m.syncToMaster(ctx, proto.Action_SetXX, key, value, ttl)
+	val.SetVal(rsp[0] == "1")
 	val.SetErr(err)
 	return val
 }
 
-func (m *Memory) lBPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
-	m.rwMutex.RLock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeysAble(keys, driverStoreTypeList); err != nil {
-		m.rwMutex.RUnlock()
-		return nil, err
-	}
-	m.rwMutex.RUnlock()
-
-	return m.ls.LBPop(ctx, timeout, keys...)
+func (m *Memory) setXX(ctx context.Context, key, data string, expiration time.Duration) (bool, error) {
+	return m.ss.SetXX(ctx, key, data, expiration)
 }
 
-// LShift 推出列表头的第一个数据
-func (m *Memory) LShift(ctx context.Context, key string) StringValuer {
+// MSet 同时设置多个key的值
+// 接受以下格式的值：
+// MSet("key1", "value1", "key2", "value2")
+//
+// MSet([]string{"key1", "value1", "key2", "value2"})
+//
+// MSet(map[string]interface{}{"key1": "value1", "key2": "value2"})
+func (m *Memory) MSet(ctx context.Context, values ...interface{}) StatusValuer {
 
-	val := new(redis.StringCmd)
+	val := new(redis.StatusCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	// 设置本地,并同步到从节点
+	pairs, err := marshalPairs(values)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.lShift(ctx, key)
-		val.SetVal(v)
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(pairKeys(pairs)...); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		err = m.mSet(ctx, pairs...)
+		if err == nil {
+			val.SetVal("OK")
+		}
 		val.SetErr(translateErr(err))
 
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LShift, key)
+		if err == nil {
+			m.syncToSlave(proto.Action_MSet, pairs...)
 		}
 		return val
 	}
 
-	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LShift, key)
+	// 同步到主节点
+	rsp, err := m.syncToMaster(ctx, proto.Action_MSet, pairs...)
 	val.SetVal(rsp[0])
 	val.SetErr(err)
 	return val
 }
 
-func (m *Memory) lShift(ctx context.Context, key string) (string, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeList); err != nil {
-		return "", err
-	}
-
-	return m.ls.LShift(ctx, key)
-}
-
-// LLen 获取列表长度
-func (m *Memory) LLen(ctx context.Context, key string) IntValuer {
-	val := new(redis.IntCmd)
-	v, err := m.ls.LLen(ctx, key)
-	val.SetVal(v)
-	val.SetErr(err)
-	return val
+func (m *Memory) mSet(ctx context.Context, pairs ...string) error {
+	return m.ss.MSet(ctx, pairs...)
 }
 
-// ==============================================================
-// ======================= Sorted Set ===========================
-// ==============================================================
-
-// ZAdd 添加有序集合的元素
-func (m *Memory) ZAdd(ctx context.Context, key string, members ...Z) IntValuer {
+// MSetNX 只有所有的key都不存在时才同时设置多个key的值,参数格式同 MSet
+func (m *Memory) MSetNX(ctx context.Context, values ...interface{}) BoolValuer {
 
-	val := new(redis.IntCmd)
+	val := new(redis.BoolCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	values := make([]string, 0, len(members)*2+1)
-	values = append(values, key)
-	for _, member := range members {
-		mb, _ := marshalData(member.Member)
-		score, _ := marshalData(member.Score)
-		values = append(values, mb, score)
+	pairs, err := marshalPairs(values)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(pairKeys(pairs)...); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		nx, err := m.mSetNX(ctx, pairs...)
+		val.SetVal(nx)
+		val.SetErr(translateErr(err))
+
+		if nx {
+			m.syncToSlave(proto.Action_MSetNX, pairs...)
+		}
+		return val
+	}
+
+	// 同步到主节点
+	rsp, err := m.syncToMaster(ctx, proto.Action_MSetNX, pairs...)
+	val.SetVal(rsp[0] == "1")
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) mSetNX(ctx context.Context, pairs ...string) (bool, error) {
+	return m.ss.MSetNX(ctx, pairs...)
+}
+
+// ================================================================================================
+// ======================================== HASH ==================================================
+// ================================================================================================
+
+// HExists 检测field是否存在哈希表中
+func (m *Memory) HExists(ctx context.Context, key, field string) BoolValuer {
+	val := new(redis.BoolCmd)
+	cnt, err := m.hs.HExists(ctx, key, field)
+	val.SetVal(cnt)
+	val.SetErr(err)
+	return val
+}
+
+// HDel 哈希表删除指定字段(fields)
+func (m *Memory) HDel(ctx context.Context, key string, fields ...string) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	values := make([]string, 0, len(fields)+1)
+	values = append(values, key)
+	values = append(values, fields...)
+
+	// 设置到本地并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		cnt, err := m.hDel(ctx, key, fields...)
+		val.SetVal(cnt)
+		val.SetErr(err)
+		if err == nil {
+			m.syncToSlave(proto.Action_HDel, values...)
+		}
+		return val
+	}
+
+	// 同步到主节点
+	rsp, err := m.syncToMaster(ctx, proto.Action_HDel, values...)
+	if err == nil {
+		i, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(i)
+	}
+
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) hDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return m.hs.HDel(ctx, key, fields...)
+}
+
+// HSet 哈希表设置数据
+func (m *Memory) HSet(ctx context.Context, key string, data ...interface{}) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 将参数列表释放成切片
+	dataSlice := sliceArgs(data)
+	if len(dataSlice)%2 != 0 {
+		val.SetErr(errors.New("the number of parameters is incorrect"))
+		return val
+	}
+
+	values := make([]string, len(dataSlice)+1, len(dataSlice)+1)
+	values[0] = key
+	var err error
+	for i := 0; i < len(dataSlice); i++ {
+		values[i+1], err = marshalData(dataSlice[i])
+		if err != nil {
+			val.SetErr(err)
+			return val
+		}
+	}
+
+	// 如果没有同步器或者同步器是一个主节点
+	// 则可以直接在本地设置数据
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		i, err := m.hSet(ctx, key, values[1:]...)
+		val.SetVal(i)
+		val.SetErr(err)
+
+		// 同步到从节点
+		if err == nil {
+			m.syncToSlave(proto.Action_HSet, values...)
+		}
+		return val
+	}
+
+	// 剩下的是从节点操作
+	rsp, err := m.syncToMaster(ctx, proto.Action_HSet, values...)
+	if err == nil {
+		i, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(i)
+	}
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) hSet(ctx context.Context, key string, data ...string) (int64, error) {
+	if len(data)%2 != 0 {
+		return 0, errors.New("the number of parameters is incorrect")
+	}
+	return m.hs.HSet(ctx, key, data...)
+}
+
+// HSetNX 如果哈希表的field不存在,则设置成功
+func (m *Memory) HSetNX(ctx context.Context, key, field string, data interface{}) BoolValuer {
+
+	val := new(redis.BoolCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	value, err := marshalData(data)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置到本地并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		cnt, err := m.hSetNX(ctx, key, field, value)
+		val.SetVal(cnt)
+		val.SetErr(err)
+		// 字段已经存在时没有写入,不需要同步
+		if err == nil && cnt {
+			m.syncToSlave(proto.Action_HSet, key, field, value)
+		}
+		return val
+	}
+
+	// 剩下的是从节点同步到主节点
+	rsp, err := m.syncToMaster(ctx, proto.Action_HSetNx, key, field, value)
+	if err == nil && rsp[0] == "1" {
+		val.SetVal(true)
+	}
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) hSetNX(ctx context.Context, key, field, value string) (bool, error) {
+	return m.hs.HSetNX(ctx, key, field, value)
+}
+
+// HGet 哈希表获取一个数据
+func (m *Memory) HGet(ctx context.Context, key string, field string) StringValuer {
+	val := new(redis.StringCmd)
+	v, err := m.hs.HGet(ctx, key, field)
+
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// HMGet 哈希表获取多个数据
+func (m *Memory) HMGet(ctx context.Context, key string, fields ...string) SliceValuer {
+	val := new(redis.SliceCmd)
+	v, err := m.hs.HMGet(ctx, key, fields...)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// HKeys 哈希表获取某个Key的所有字段(field)
+func (m *Memory) HKeys(ctx context.Context, key string) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.hs.HKeys(ctx, key)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// HVals 哈希表获取所有值
+func (m *Memory) HVals(ctx context.Context, key string) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.hs.HVals(ctx, key)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// HGetAll 获取哈希表所有的数据,包括field跟value
+func (m *Memory) HGetAll(ctx context.Context, key string) MapStringStringValuer {
+	val := new(redis.MapStringStringCmd)
+	v, err := m.hs.HGetAll(ctx, key)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// HLen 哈希表所有字段的数量
+func (m *Memory) HLen(ctx context.Context, key string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.hs.HLen(ctx, key)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// HIncrBy 哈希表field的值加上增量 incr,field不存在时先初始化为0
+// 主节点上执行后同步增量,各个节点按同步序号执行的结果一致
+func (m *Memory) HIncrBy(ctx context.Context, key, field string, incr int64) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	data, _ := marshalData(incr)
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.hIncrBy(ctx, key, field, incr)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_HIncrBy, key, field, data)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_HIncrBy, key, field, data)
+	val.SetErr(err)
+	if err == nil {
+		i, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(i)
+	}
+	return val
+}
+
+func (m *Memory) hIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	return m.hs.HIncrBy(ctx, key, field, incr)
+}
+
+// HIncrByFloat 哈希表field的值加上浮点数增量 incr,field不存在时先初始化为0
+// 浮点数运算在不同节点上可能有误差,主节点执行后以 HSetKeepTTL 同步计算结果
+func (m *Memory) HIncrByFloat(ctx context.Context, key, field string, incr float64) FloatValuer {
+
+	val := new(redis.FloatCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.hIncrByFloat(ctx, key, field, incr)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			result, _ := marshalData(v)
+			m.syncToSlave(proto.Action_HSetKeepTTL, key, field, result)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	data, _ := marshalData(incr)
+	rsp, err := m.syncToMaster(ctx, proto.Action_HIncrByFloat, key, field, data)
+	val.SetErr(err)
+	if err == nil {
+		f, _ := strconv.ParseFloat(rsp[0], 64)
+		val.SetVal(f)
+	}
+	return val
+}
+
+func (m *Memory) hSetKeepTTL(ctx context.Context, key, field, value string) error {
+	return m.hs.HSetKeepTTL(ctx, key, field, value)
+}
+
+func (m *Memory) hIncrByFloat(ctx context.Context, key, field string, incr float64) (float64, error) {
+	return m.hs.HIncrByFloat(ctx, key, field, incr)
+}
+
+// HStrLen 哈希表field的值的长度
+func (m *Memory) HStrLen(ctx context.Context, key, field string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.hs.HStrLen(ctx, key, field)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// HRandField 随机返回哈希表中的字段
+// count 为正数时返回最多 count 个不重复的字段;为负数时返回 -count 个字段,字段可能重复
+func (m *Memory) HRandField(ctx context.Context, key string, count int) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.hs.HRandField(ctx, key, count)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// HScan 以游标的方式扫描哈希表,返回 字段1,值1,字段2,值2... 以及下一次扫描的游标
+func (m *Memory) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ScanValuer {
+	val := redis.NewScanCmd(ctx, nil)
+	v, next, err := m.hs.HScan(ctx, key, cursor, match, count)
+	val.SetVal(v, next)
+	val.SetErr(err)
+	return val
+}
+
+// HExpire 设置哈希表字段的存活时长
+// 每个字段的返回值: -2 字段不存在, 1 设置成功, 2 存活时长小于等于0,字段被删除
+func (m *Memory) HExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) IntSliceValuer {
+	ttl, _ := marshalData(int64(expiration))
+	return m.hExpire(ctx, proto.Action_HExpire, key, time.Now().Add(expiration), ttl, fields)
+}
+
+// HPExpire 设置哈希表字段的存活时长,内存驱动的精度跟 HExpire 一致
+func (m *Memory) HPExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) IntSliceValuer {
+	return m.HExpire(ctx, key, expiration, fields...)
+}
+
+// HExpireAt 设置哈希表字段的到期时间,返回值同 HExpire
+func (m *Memory) HExpireAt(ctx context.Context, key string, tm time.Time, fields ...string) IntSliceValuer {
+	at, _ := marshalData(tm.UnixNano())
+	return m.hExpire(ctx, proto.Action_HExpireAt, key, tm, at, fields)
+}
+
+// hExpire 设置哈希表字段的到期时间
+// 主节点以到期时间同步到从节点,从节点以 action 跟参数 arg 转发到主节点
+func (m *Memory) hExpire(ctx context.Context, action proto.Action, key string, at time.Time, arg string, fields []string) IntSliceValuer {
+
+	val := new(redis.IntSliceCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	if len(fields) == 0 {
+		val.SetErr(errors.New("the number of parameters is incorrect"))
+		return val
+	}
+
+	// 设置到本地并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.hExpireAt(ctx, key, at, fields...)
+		val.SetVal(v)
+		val.SetErr(err)
+
+		// 没有任何字段被修改时不需要同步
+		if err == nil && hasChanged(v) {
+			values := make([]string, 0, len(fields)+2)
+			values = append(values, key, strconv.FormatInt(at.UnixNano(), 10))
+			values = append(values, fields...)
+			m.syncToSlave(proto.Action_HExpireAt, values...)
+		}
+		return val
+	}
+
+	// 同步到主节点
+	values := make([]string, 0, len(fields)+2)
+	values = append(values, key, arg)
+	values = append(values, fields...)
+	rsp, err := m.syncToMaster(ctx, action, values...)
+	if err == nil {
+		val.SetVal(parseInts(rsp))
+	}
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) hExpireAt(ctx context.Context, key string, at time.Time, fields ...string) ([]int64, error) {
+	return m.hs.HExpireAt(ctx, key, at, fields...)
+}
+
+// HTTL 返回哈希表字段剩余的存活时长(秒)
+// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间
+func (m *Memory) HTTL(ctx context.Context, key string, fields ...string) IntSliceValuer {
+	val := new(redis.IntSliceCmd)
+	v, err := m.hs.HTTL(ctx, key, fields...)
+
+	result := make([]int64, len(v))
+	for i, d := range v {
+		if d < 0 {
+			result[i] = int64(d)
+			continue
+		}
+		// 跟redis一致,四舍五入到秒
+		result[i] = int64((d + time.Second/2) / time.Second)
+	}
+	val.SetVal(result)
+	val.SetErr(err)
+	return val
+}
+
+// HPersist 清除哈希表字段的到期时间
+// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间, 1 清除成功
+func (m *Memory) HPersist(ctx context.Context, key string, fields ...string) IntSliceValuer {
+
+	val := new(redis.IntSliceCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	if len(fields) == 0 {
+		val.SetErr(errors.New("the number of parameters is incorrect"))
+		return val
+	}
+
+	values := make([]string, 0, len(fields)+1)
+	values = append(values, key)
+	values = append(values, fields...)
+
+	// 设置到本地并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.hPersist(ctx, key, fields...)
+		val.SetVal(v)
+		val.SetErr(err)
+		if err == nil && hasChanged(v) {
+			m.syncToSlave(proto.Action_HPersist, values...)
+		}
+		return val
+	}
+
+	// 同步到主节点
+	rsp, err := m.syncToMaster(ctx, proto.Action_HPersist, values...)
+	if err == nil {
+		val.SetVal(parseInts(rsp))
+	}
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) hPersist(ctx context.Context, key string, fields ...string) ([]int64, error) {
+	return m.hs.HPersist(ctx, key, fields...)
+}
+
+// hasChanged 字段级操作的返回值中是否有字段被修改,大于0表示修改成功
+func hasChanged(results []int64) bool {
+	for _, r := range results {
+		if r > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ================================================================================================
+// ======================================== LIST ==================================================
+// ================================================================================================
+
+// LTrim 对一个列表进行修剪(trim)，就是说，让列表只保留指定区间内的元素，不在指定区间之内的元素都将被删除。
+// 举个例子，执行命令 LTRIM list 0 2 ，表示只保留列表 list 的前三个元素，其余元素全部删除。
+// 下标(index)参数 start 和 stop 都以 0 为底，也就是说，以 0 表示列表的第一个元素，以 1 表示列表的第二个元素，以此类推。
+// 你也可以使用负数下标，以 -1 表示列表的最后一个元素， -2 表示列表的倒数第二个元素，以此类推。
+func (m *Memory) LTrim(ctx context.Context, key string, start, stop int64) StatusValuer {
+
+	val := new(redis.StatusCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	startStr, _ := marshalData(start)
+	stopStr, _ := marshalData(stop)
+
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		err := m.lTrim(ctx, key, start, stop)
+		if err == nil {
+			val.SetVal("OK")
+		}
+		val.SetErr(err)
+
+		if m.syncer != nil && err == nil {
+
+			m.syncToSlave(proto.Action_LTrim, key, startStr, stopStr)
+		}
+		return val
+	}
+
+	// 剩下的是从节点同步到主节点
+	rsp, err := m.syncToMaster(ctx, proto.Action_LTrim, key, startStr, stopStr)
+	val.SetVal(rsp[0])
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) lTrim(ctx context.Context, key string, start, stop int64) error {
+	return m.ls.LTrim(ctx, key, start, stop)
+}
+
+// LPush 依次将数据推入到列表头部,返回推入后的列表长度
+func (m *Memory) LPush(ctx context.Context, key string, data ...interface{}) IntValuer {
+	return m.push(ctx, proto.Action_LPush, key, data...)
+}
+
+func (m *Memory) lPush(ctx context.Context, key string, values ...string) (int64, error) {
+	return m.ls.LPush(ctx, key, values...)
+}
+
+// RPush 依次将数据推入到列表尾部,返回推入后的列表长度
+func (m *Memory) RPush(ctx context.Context, key string, data ...interface{}) IntValuer {
+	return m.push(ctx, proto.Action_RPush, key, data...)
+}
+
+func (m *Memory) rPush(ctx context.Context, key string, values ...string) (int64, error) {
+	return m.ls.RPush(ctx, key, values...)
+}
+
+// push LPush 跟 RPush 的共同过程
+func (m *Memory) push(ctx context.Context, action proto.Action, key string, data ...interface{}) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	values := make([]string, len(data)+1, len(data)+1)
+	values[0] = key
+	var err error
+	for i := 0; i < len(data); i++ {
+		values[i+1], err = marshalData(data[i])
+		if err != nil {
+			val.SetErr(err)
+			return val
+		}
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		var cnt int64
+		if action == proto.Action_LPush {
+			cnt, err = m.lPush(ctx, key, values[1:]...)
+		} else {
+			cnt, err = m.rPush(ctx, key, values[1:]...)
+		}
+		val.SetVal(cnt)
+		val.SetErr(err)
+		if err == nil {
+			m.syncToSlave(action, values...)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, action, values...)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+// LRange 按从头部到尾部的顺序提取列表范围内的数据
+func (m *Memory) LRange(ctx context.Context, key string, start, stop int64) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.ls.LRange(ctx, key, start, stop)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+
+}
+
+// LPop 推出列表头部的第一个数据
+func (m *Memory) LPop(ctx context.Context, key string) StringValuer {
+	return m.pop(ctx, proto.Action_LPop, key)
+}
+
+func (m *Memory) lPop(ctx context.Context, key string) (string, error) {
+	return m.ls.LPop(ctx, key)
+}
+
+// RPop 推出列表尾部的最后一个数据
+func (m *Memory) RPop(ctx context.Context, key string) StringValuer {
+	return m.pop(ctx, proto.Action_RPop, key)
+}
+
+func (m *Memory) rPop(ctx context.Context, key string) (string, error) {
+	return m.ls.RPop(ctx, key)
+}
+
+// pop LPop 跟 RPop 的共同过程
+func (m *Memory) pop(ctx context.Context, action proto.Action, key string) StringValuer {
+
+	val := new(redis.StringCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		var v string
+		var err error
+		if action == proto.Action_LPop {
+			v, err = m.lPop(ctx, key)
+		} else {
+			v, err = m.rPop(ctx, key)
+		}
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(action, key)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, action, key)
+	val.SetVal(rsp[0])
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// LIndex 返回列表中下标对应的元素
+func (m *Memory) LIndex(ctx context.Context, key string, index int64) StringValuer {
+	val := new(redis.StringCmd)
+	v, err := m.ls.LIndex(ctx, key, index)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// LSet 替换列表中下标对应的元素,键不存在或者下标超出范围时返回错误
+func (m *Memory) LSet(ctx context.Context, key string, index int64, value interface{}) StatusValuer {
+
+	val := new(redis.StatusCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	indexStr, _ := marshalData(index)
+	data, err := marshalData(value)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		err := m.lSet(ctx, key, index, data)
+		if err == nil {
+			val.SetVal("OK")
+			m.syncToSlave(proto.Action_LSet, key, indexStr, data)
+		}
+		val.SetErr(err)
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_LSet, key, indexStr, data)
+	val.SetVal(rsp[0])
+	val.SetErr(err)
+	return val
+}
+
+func (m *Memory) lSet(ctx context.Context, key string, index int64, value string) error {
+	return m.ls.LSet(ctx, key, index, value)
+}
+
+// LRem 移除列表中与 value 相等的元素,返回被移除的数量
+// count > 0 从头部开始移除 count 个; count < 0 从尾部开始移除 |count| 个; count = 0 移除所有
+func (m *Memory) LRem(ctx context.Context, key string, count int64, value interface{}) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	countStr, _ := marshalData(count)
+	data, err := marshalData(value)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.lRem(ctx, key, count, data)
+		val.SetVal(v)
+		val.SetErr(err)
+
+		// 没有移除任何元素时不需要同步
+		if err == nil && v > 0 {
+			m.syncToSlave(proto.Action_LRem, key, countStr, data)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_LRem, key, countStr, data)
+	val.SetErr(err)
+	if err == nil {
+		i, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(i)
+	}
+	return val
+}
+
+func (m *Memory) lRem(ctx context.Context, key string, count int64, value string) (int64, error) {
+	return m.ls.LRem(ctx, key, count, value)
+}
+
+// LInsert 把 value 插入到列表中第一个 pivot 的前面(op 为 BEFORE)或者后面(op 为 AFTER),返回插入后的列表长度
+// 键不存在时返回 0,找不到 pivot 时返回 -1
+func (m *Memory) LInsert(ctx context.Context, key, op string, pivot, value interface{}) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	pivotStr, err := marshalData(pivot)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+	data, err := marshalData(value)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.lInsert(ctx, key, op, pivotStr, data)
+		val.SetVal(v)
+		val.SetErr(err)
+
+		// 没有插入时不需要同步
+		if err == nil && v > 0 {
+			m.syncToSlave(proto.Action_LInsert, key, op, pivotStr, data)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_LInsert, key, op, pivotStr, data)
+	val.SetErr(err)
+	if err == nil {
+		i, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(i)
+	}
+	return val
+}
+
+func (m *Memory) lInsert(ctx context.Context, key, op, pivot, value string) (int64, error) {
+	return m.ls.LInsert(ctx, key, op, pivot, value)
+}
+
+// LPos 返回列表中与 value 相等的元素的下标,没有匹配的元素时返回 Nil
+func (m *Memory) LPos(ctx context.Context, key string, value string, args LPosArgs) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.ls.LPos(ctx, key, value, args.Rank, 1, args.MaxLen)
+	if err == nil && len(v) == 0 {
+		err = MemoryNil
+	}
+	if err == nil {
+		val.SetVal(v[0])
+	}
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// LPosCount 返回列表中最多 count 个与 value 相等的元素的下标,count 为0时返回所有匹配元素的下标
+func (m *Memory) LPosCount(ctx context.Context, key string, value string, count int64, args LPosArgs) IntSliceValuer {
+	val := new(redis.IntSliceCmd)
+	v, err := m.ls.LPos(ctx, key, value, args.Rank, count, args.MaxLen)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// LMove 从 source 的 srcpos(LEFT/RIGHT) 推出一个元素,再推入 destination 的 destpos(LEFT/RIGHT),返回该元素
+func (m *Memory) LMove(ctx context.Context, source, destination, srcpos, destpos string) StringValuer {
+
+	val := new(redis.StringCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(destination); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.lMove(ctx, source, destination, srcpos, destpos)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_LMove, source, destination, srcpos, destpos)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_LMove, source, destination, srcpos, destpos)
+	val.SetVal(rsp[0])
+	val.SetErr(translateErr(err))
+	return val
+}
+
+func (m *Memory) lMove(ctx context.Context, source, destination, srcpos, destpos string) (string, error) {
+	return m.ls.LMove(ctx, source, destination, srcpos, destpos)
+}
+
+// RPopLPush 从 source 的尾部推出一个元素,再推入 destination 的头部,返回该元素
+func (m *Memory) RPopLPush(ctx context.Context, source, destination string) StringValuer {
+	return m.LMove(ctx, source, destination, "RIGHT", "LEFT")
+}
+
+// BLMove 同 LMove,source 为空时阻塞等待,直到超时或者有可移动的元素为止
+func (m *Memory) BLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) StringValuer {
+
+	val := new(redis.StringCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		v, err := m.bLMove(ctx, source, destination, srcpos, destpos, timeout)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+		return val
+	}
+
+	// 访问主节点并返回数据
+	timeoutStr, _ := marshalData(timeout)
+	rsp, err := m.syncToMaster(ctx, proto.Action_BLMove, timeoutStr, source, destination, srcpos, destpos)
+	val.SetVal(rsp[0])
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// bLMove 等待期间不持有锁,每次尝试移动时才锁住,移动跟同步到从节点在同一次加锁中完成,
+// 避免快照或者持久化日志的切换落在两者之间,导致移动被重复执行
+func (m *Memory) bLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) (string, error) {
+	srcLeft, err := listSide(srcpos)
+	if err != nil {
+		return "", err
+	}
+	destLeft, err := listSide(destpos)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	var moveErr error
+	err = m.ls.block(ctx, driverStoreTypeList, m.ls.evtSig, timeout, []string{source}, func() bool {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if moveErr = m.ensureCapacity(destination); moveErr != nil {
+			return true
+		}
+		result, moveErr = m.ls.tryMove(source, destination, srcLeft, destLeft)
+		if moveErr == nil {
+			m.syncToSlave(proto.Action_LMove, source, destination, srcpos, destpos)
+		}
+		// 只有 source 为空时才继续等待
+		return moveErr != MemoryNil
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, moveErr
+}
+
+// BLPop 移出并获取第一个非空列表的头部元素,返回 [key, 元素]
+// 如果列表都没有元素会阻塞列表直到等待超时或发现可弹出元素为止
+func (m *Memory) BLPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer {
+	return m.bPop(ctx, proto.Action_BLPop, timeout, keys...)
+}
+
+func (m *Memory) bLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return m.bPopWith(ctx, true, timeout, keys...)
+}
+
+// BRPop 同 BLPop,移出并获取列表的尾部元素
+func (m *Memory) BRPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer {
+	return m.bPop(ctx, proto.Action_BRPop, timeout, keys...)
+}
+
+func (m *Memory) bRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return m.bPopWith(ctx, false, timeout, keys...)
+}
+
+// bPopWith 等待期间不持有锁,每次尝试弹出时才锁住,弹出跟同步到从节点在同一次加锁中完成,
+// 避免快照或者持久化日志的切换落在两者之间,导致弹出被重复执行;只有弹出数据的那个key需要同步
+func (m *Memory) bPopWith(ctx context.Context, left bool, timeout time.Duration, keys ...string) ([]string, error) {
+	popAction := proto.Action_RPop
+	if left {
+		popAction = proto.Action_LPop
+	}
+
+	var result []string
+	err := m.ls.block(ctx, driverStoreTypeList, m.ls.evtSig, timeout, keys, func() bool {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if result = m.ls.popFirst(keys, left); result == nil {
+			return false
+		}
+		m.syncToSlave(popAction, result[0])
+		return true
+	})
+	if err != nil {
+		return []string{}, err
+	}
+	return result, nil
+}
+
+// bPop BLPop 跟 BRPop 的共同过程,弹出时以非阻塞的 LPop/RPop 同步到从节点
+func (m *Memory) bPop(ctx context.Context, action proto.Action, timeout time.Duration, keys ...string) StringSliceValuer {
+
+	val := new(redis.StringSliceCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	values := make([]string, 0, len(keys)+1)
+	timeoutStr, _ := marshalData(timeout)
+	values = append(values, timeoutStr)
+	values = append(values, keys...)
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		v, err := m.bPopWith(ctx, action == proto.Action_BLPop, timeout, keys...)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, action, values...)
+	val.SetVal(rsp)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// LLen 获取列表长度
+func (m *Memory) LLen(ctx context.Context, key string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.ls.LLen(ctx, key)
+	val.SetVal(v)
+	val.SetErr(err)
+	return val
+}
+
+// ==============================================================
+// ========================== Set ===============================
+// ==============================================================
+
+// SAdd 添加一个或多个成员到集合中,已经存在的成员将被忽略
+// @return 新增的成员数量
+func (m *Memory) SAdd(ctx context.Context, key string, members ...interface{}) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 将参数列表释放成切片
+	dataSlice := sliceArgs(members)
+	values := make([]string, 0, len(dataSlice)+1)
+	values = append(values, key)
+	for i := 0; i < len(dataSlice); i++ {
+		member, err := marshalData(dataSlice[i])
+		if err != nil {
+			val.SetErr(err)
+			return val
+		}
+		values = append(values, member)
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.sAdd(ctx, key, values[1:]...)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_SAdd, values...)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_SAdd, values...)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) sAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	return m.sets.SAdd(ctx, key, members...)
+}
+
+// SRem 移除集合中的一个或多个成员,不存在的成员将被忽略
+// @return 被成功移除的成员数量
+func (m *Memory) SRem(ctx context.Context, key string, members ...interface{}) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 将参数列表释放成切片
+	dataSlice := sliceArgs(members)
+	values := make([]string, 0, len(dataSlice)+1)
+	values = append(values, key)
+	for i := 0; i < len(dataSlice); i++ {
+		member, err := marshalData(dataSlice[i])
+		if err != nil {
+			val.SetErr(err)
+			return val
+		}
+		values = append(values, member)
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.sRem(ctx, key, values[1:]...)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_SRem, values...)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_SRem, values...)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) sRem(ctx context.Context, key string, members ...string) (int64, error) {
+	return m.sets.SRem(ctx, key, members...)
+}
+
+// SMembers 返回集合中的所有成员
+func (m *Memory) SMembers(ctx context.Context, key string) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sets.SMembers(ctx, key)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SIsMember 判断成员是否在集合中
+func (m *Memory) SIsMember(ctx context.Context, key string, member interface{}) BoolValuer {
+	val := new(redis.BoolCmd)
+	data, err := marshalData(member)
+	if err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	v, err := m.sets.SIsMember(ctx, key, data)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SMIsMember 判断多个成员是否在集合中,返回结果的顺序跟 members 一致
+func (m *Memory) SMIsMember(ctx context.Context, key string, members ...interface{}) BoolSliceValuer {
+	val := new(redis.BoolSliceCmd)
+
+	dataSlice := sliceArgs(members)
+	values := make([]string, 0, len(dataSlice))
+	for i := 0; i < len(dataSlice); i++ {
+		member, err := marshalData(dataSlice[i])
+		if err != nil {
+			val.SetErr(err)
+			return val
+		}
+		values = append(values, member)
+	}
+
+	v, err := m.sets.SMIsMember(ctx, key, values...)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SCard 返回集合的成员数量
+func (m *Memory) SCard(ctx context.Context, key string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.sets.SCard(ctx, key)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SPop 随机移除并返回集合中的一个成员,集合不存在时返回 errors.Nil
+func (m *Memory) SPop(ctx context.Context, key string) StringValuer {
+	val := new(redis.StringCmd)
+
+	v, err := m.sPopN(ctx, key, 1)
+	if err == nil && len(v) == 0 {
+		err = MemoryNil
+	}
+	if err == nil {
+		val.SetVal(v[0])
+	}
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SPopN 随机移除并返回集合中最多 count 个成员
+func (m *Memory) SPopN(ctx context.Context, key string, count int64) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+
+	v, err := m.sPopN(ctx, key, count)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// sPopN 弹出的成员是随机的,从节点不能重放弹出动作,只同步被弹出的成员
+func (m *Memory) sPopN(ctx context.Context, key string, count int64) ([]string, error) {
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return nil, err
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.sPop(ctx, key, count)
+		if err == nil && len(v) > 0 {
+			values := make([]string, 0, len(v)+1)
+			values = append(values, key)
+			values = append(values, v...)
+			m.syncToSlave(proto.Action_SRem, values...)
+		}
+		return v, err
+	}
+
+	// 访问主节点并返回数据
+	countStr, _ := marshalData(count)
+	rsp, err := m.syncToMaster(ctx, proto.Action_SPop, key, countStr)
+	if err == MemoryNil {
+		// 主节点没有弹出任何成员
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func (m *Memory) sPop(ctx context.Context, key string, count int64) ([]string, error) {
+	return m.sets.SPop(ctx, key, count)
+}
+
+// SRandMember 随机返回集合中的一个成员,集合不存在时返回 errors.Nil
+func (m *Memory) SRandMember(ctx context.Context, key string) StringValuer {
+	val := new(redis.StringCmd)
+	v, err := m.sets.SRandMember(ctx, key, 1)
+	if err == nil && len(v) == 0 {
+		err = MemoryNil
+	}
+	if err == nil {
+		val.SetVal(v[0])
+	}
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SRandMemberN 随机返回集合中的成员
+// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+func (m *Memory) SRandMemberN(ctx context.Context, key string, count int64) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sets.SRandMember(ctx, key, count)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SInter 返回多个集合的交集
+func (m *Memory) SInter(ctx context.Context, keys ...string) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sets.SInter(ctx, keys...)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SUnion 返回多个集合的并集
+func (m *Memory) SUnion(ctx context.Context, keys ...string) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sets.SUnion(ctx, keys...)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SDiff 返回第一个集合跟其他集合的差集
+func (m *Memory) SDiff(ctx context.Context, keys ...string) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sets.SDiff(ctx, keys...)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// SInterStore 把多个集合的交集保存到 destination 中
+// @return 结果集合的成员数量
+func (m *Memory) SInterStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	return m.sStoreWith(ctx, proto.Action_SInterStore, destination, keys...)
+}
+
+// SUnionStore 把多个集合的并集保存到 destination 中
+// @return 结果集合的成员数量
+func (m *Memory) SUnionStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	return m.sStoreWith(ctx, proto.Action_SUnionStore, destination, keys...)
+}
+
+// SDiffStore 把第一个集合跟其他集合的差集保存到 destination 中
+// @return 结果集合的成员数量
+func (m *Memory) SDiffStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	return m.sStoreWith(ctx, proto.Action_SDiffStore, destination, keys...)
+}
+
+// sStoreWith 执行集合运算并保存结果,同步时只需要同步动作跟参数
+func (m *Memory) sStoreWith(ctx context.Context, action proto.Action, destination string, keys ...string) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	values := make([]string, 0, len(keys)+1)
+	values = append(values, destination)
+	values = append(values, keys...)
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(destination); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.sStore(ctx, action, destination, keys...)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(action, values...)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, action, values...)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) sStore(ctx context.Context, action proto.Action, destination string, keys ...string) (int64, error) {
+	switch action {
+	case proto.Action_SInterStore:
+		return m.sets.SInterStore(ctx, destination, keys...)
+	case proto.Action_SUnionStore:
+		return m.sets.SUnionStore(ctx, destination, keys...)
+	case proto.Action_SDiffStore:
+		return m.sets.SDiffStore(ctx, destination, keys...)
+	}
+	return 0, errors.New("unknown action")
+}
+
+// ==============================================================
+// ======================= Sorted Set ===========================
+// ==============================================================
+
+// ZAdd 添加有序集合的元素
+func (m *Memory) ZAdd(ctx context.Context, key string, members ...Z) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	values := make([]string, 0, len(members)*2+1)
+	values = append(values, key)
+	values = append(values, zMemberValues(members)...)
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.zAdd(ctx, key, values[1:]...)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_ZAdd, values...)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_ZAdd, values...)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) zAdd(ctx context.Context, key string, values ...string) (int64, error) {
+	members, err := parseZMembers(values)
+	if err != nil {
+		return 0, err
+	}
+	return m.sts.ZAdd(ctx, key, members...)
+}
+
+// zMemberValues 把成员转换成同步使用的 [member, score, ...]
+func zMemberValues(members []Z) []string {
+	values := make([]string, 0, len(members)*2)
+	for _, member := range members {
+		mb, _ := marshalData(member.Member)
+		score, _ := marshalData(member.Score)
+		values = append(values, mb, score)
+	}
+	return values
+}
+
+// parseZMembers 解析同步使用的 [member, score, ...]
+func parseZMembers(values []string) ([]SZ, error) {
+	if len(values)%2 != 0 {
+		return nil, errors.New("params number error")
+	}
+
+	members := make([]SZ, 0, len(values)/2)
+	for i := 0; i < len(values)/2; i++ {
+		f, _ := strconv.ParseFloat(values[i*2+1], 64)
+		members = append(members, SZ{Member: values[i*2], Score: f})
+	}
+	return members, nil
+}
+
+// ZCard 获取有序集合的元素数量
+func (m *Memory) ZCard(ctx context.Context, key string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.sts.ZCard(ctx, key)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZCount 返回有序集 key 中， score 值在 min 和 max 之间(默认包括 score 值等于 min 或 max )的成员的数量。
+func (m *Memory) ZCount(ctx context.Context, key, min, max string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.sts.ZCount(ctx, key, min, max)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZIncrBy 为有序集 key 的成员 member 的 score 值加上增量 increment 。
+// 可以通过传递一个负数值 increment ，让 score 减去相应的值，比如 ZINCRBY key -5 member ，就是让 member 的 score 值减去 5
+// @return member 成员的新 score 值
+func (m *Memory) ZIncrBy(ctx context.Context, key string, increment float64, member string) FloatValuer {
+
+	val := new(redis.FloatCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	incrementStr, _ := marshalData(increment)
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.zIncrBy(ctx, key, increment, member)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_ZIncrBy, key, incrementStr, member)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_ZIncrBy, key, incrementStr, member)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseFloat(rsp[0], 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) zIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return m.sts.ZIncrBy(ctx, key, increment, member)
+}
+
+// ZRange 返回有序集 key 中，指定区间内的成员。
+// 其中成员的位置按 score 值递增(从小到大)来排序。
+// 具有相同 score 值的成员按字典序(lexicographical order )来排列。
+// 如果你需要成员按 score 值递减(从大到小)来排列，请使用 ZREVRANGE 命令。
+// 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
+// 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
+func (m *Memory) ZRange(ctx context.Context, key string, start, stop int64) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sts.ZRange(ctx, key, start, stop)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZRangeWithScores 同 ZRange, 返回的成员带上 score 值
+func (m *Memory) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ZSliceValuer {
+	val := new(redis.ZSliceCmd)
+	v, err := m.sts.ZRangeWithScores(ctx, key, start, stop)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。有序集成员按 score 值递增(从小到大)次序排列。
+// 具有相同 score 值的成员按字典序(lexicographical order)来排列(该属性是有序集提供的，不需要额外的计算)。
+// 可选的 LIMIT 参数指定返回结果的数量及区间(就像SQL中的 SELECT LIMIT offset, count )，注意当 offset 很大时，定位 offset 的操作可能需要遍历整个有序集，此过程最坏复杂度为 O(N) 时间。
+func (m *Memory) ZRangeByScore(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
+	o := &ZRangeBy{
+		Min:    opt.Min,
+		Max:    opt.Max,
+		Offset: opt.Offset,
+		Count:  opt.Count,
+	}
+	val := new(redis.StringSliceCmd)
+	v, err := m.sts.ZRangeByScore(ctx, key, o)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递增(从小到大)顺序排列。
+// 排名以 0 为底，也就是说， score 值最小的成员排名为 0 。
+func (m *Memory) ZRank(ctx context.Context, key, member string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.sts.ZRank(ctx, key, member)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZRem 移除有序集 key 中的一个或多个成员，不存在的成员将被忽略。
+// @return 被成功移除的成员的数量，不包括被忽略的成员
+func (m *Memory) ZRem(ctx context.Context, key string, members ...interface{}) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	values := make([]string, 0, len(members)+1)
+	values = append(values, key)
+	for i := 0; i < len(members); i++ {
+		member, err := marshalData(members[i])
+		if err != nil {
+			val.SetErr(err)
+			return val
+		}
+		values = append(values, member)
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.zRem(ctx, key, values[1:]...)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_ZRem, values...)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_ZRem, values...)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) zRem(ctx context.Context, key string, members ...string) (int64, error) {
+	return m.sts.ZRem(ctx, key, members...)
+}
+
+// ZRemRangeByRank 移除有序集 key 中，指定排名(rank)区间内的所有成员。
+// 区间分别以下标参数 start 和 stop 指出，包含 start 和 stop 在内。
+// 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
+// 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
+func (m *Memory) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+	startStr, _ := marshalData(start)
+	stopStr, _ := marshalData(stop)
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.zRemRangeByRank(ctx, key, start, stop)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_ZRemRangeByRank, key, startStr, stopStr)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_ZRemRangeByRank, key, startStr, stopStr)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) zRemRangeByRank(ctx context.Context, key string, start, stop int64) (int64, error) {
+	return m.sts.ZRemRangeByRank(ctx, key, start, stop)
+}
+
+// ZRemRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。
+// 有序集成员按 score 值递增(从小到大)次序排列。
+func (m *Memory) ZRemRangeByScore(ctx context.Context, key, min, max string) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
+	}
+
+	// 设置本地,并同步到从节点
+	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.zRemRangeByScore(ctx, key, min, max)
+		val.SetVal(v)
+		val.SetErr(translateErr(err))
+
+		if err == nil {
+			m.syncToSlave(proto.Action_ZRemRangeByScore, key, min, max)
+		}
+		return val
+	}
+
+	// 访问主节点并返回数据
+	rsp, err := m.syncToMaster(ctx, proto.Action_ZRemRangeByScore, key, min, max)
+	val.SetErr(err)
+	if err == nil {
+		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
+		val.SetVal(cnt)
+	}
+	return val
+}
+
+func (m *Memory) zRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	return m.sts.ZRemRangeByScore(ctx, key, min, max)
+}
+
+// ZRevRange 返回有序集 key 中，指定区间内的成员。
+// 其中成员的位置按 score 值递减(从大到小)来排列。
+// 具有相同 score 值的成员按字典序的逆序(reverse lexicographical order)排列。
+func (m *Memory) ZRevRange(ctx context.Context, key string, start, stop int64) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sts.ZRevRange(ctx, key, start, stop)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZRevRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递减(从大到小)排序。
+// 排名以 0 为底，也就是说， score 值最大的成员排名为 0 。
+func (m *Memory) ZRevRank(ctx context.Context, key, member string) IntValuer {
+	val := new(redis.IntCmd)
+	v, err := m.sts.ZRevRank(ctx, key, member)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZScore 返回有序集 key 中，成员 member 的 score 值。
+// 如果 member 元素不是有序集 key 的成员，或 key 不存在，返回 nil 。
+func (m *Memory) ZScore(ctx context.Context, key, member string) FloatValuer {
+	val := new(redis.FloatCmd)
+	v, err := m.sts.ZScore(ctx, key, member)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZAddNX 只添加新成员,不更新已经存在的成员
+func (m *Memory) ZAddNX(ctx context.Context, key string, members ...Z) IntValuer {
+	return m.ZAddArgs(ctx, key, ZAddArgs{NX: true, Members: members})
+}
+
+// ZAddXX 只更新已经存在的成员,不添加新成员
+func (m *Memory) ZAddXX(ctx context.Context, key string, members ...Z) IntValuer {
+	return m.ZAddArgs(ctx, key, ZAddArgs{XX: true, Members: members})
+}
+
+// ZAddGT 新分数大于原来的分数时才更新,新成员照常添加
+func (m *Memory) ZAddGT(ctx context.Context, key string, members ...Z) IntValuer {
+	return m.ZAddArgs(ctx, key, ZAddArgs{GT: true, Members: members})
+}
+
+// ZAddLT 新分数小于原来的分数时才更新,新成员照常添加
+func (m *Memory) ZAddLT(ctx context.Context, key string, members ...Z) IntValuer {
+	return m.ZAddArgs(ctx, key, ZAddArgs{LT: true, Members: members})
+}
+
+// ZAddArgs 按 NX/XX/GT/LT/CH 条件添加成员,设置 Ch 时返回值包括分数被更新的成员数量
+func (m *Memory) ZAddArgs(ctx context.Context, key string, args ZAddArgs) IntValuer {
+
+	val := new(redis.IntCmd)
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		val.SetErr(err)
+		return val
 	}
 
+	values := zAddArgsValues(key, args)
+
 	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.zAdd(ctx, key, values[1:]...)
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.zAddArgs(ctx, key, values[1:]...)
 		val.SetVal(v)
 		val.SetErr(translateErr(err))
 
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LShift, values...)
+		if err == nil {
+			m.syncToSlave(proto.Action_ZAddArgs, values...)
 		}
 		return val
 	}
 
 	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LShift, values...)
+	rsp, err := m.syncToMaster(ctx, proto.Action_ZAddArgs, values...)
 	val.SetErr(err)
 	if err == nil {
 		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
@@ -1037,49 +3025,30 @@ func (m *Memory) ZAdd(ctx context.Context, key string, members ...Z) IntValuer {
 	return val
 }
 
-func (m *Memory) zAdd(ctx context.Context, key string, values ...string) (int64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeSortedSet); err != nil {
-		return 0, err
-	}
+// zAddArgsValues ZAddArgs 跟 ZAddArgsIncr 同步的参数 [key, flag, member, score, ...]
+func zAddArgsValues(key string, args ZAddArgs) []string {
+	values := make([]string, 0, len(args.Members)*2+2)
+	values = append(values, key, strconv.Itoa(int(zAddFlags(args))))
+	values = append(values, zMemberValues(args.Members)...)
+	return values
+}
 
-	if len(values)%2 > 1 {
-		return 0, errors.New("params number error")
+// zAddArgs values 为 [flag, member, score, ...]
+func (m *Memory) zAddArgs(ctx context.Context, key string, values ...string) (int64, error) {
+	flag, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0, err
 	}
-
-	members := make([]SZ, 0, len(values)/2)
-	for i := 0; i < len(values)/2; i++ {
-		f, _ := strconv.ParseFloat(values[i*2+1], 64)
-		members = append(members, SZ{Member: values[i*2], Score: f})
+	members, err := parseZMembers(values[1:])
+	if err != nil {
+		return 0, err
 	}
-
-	return m.sts.ZAdd(ctx, key, members...)
-}
-
-// ZCard 获取有序集合的元素数量
-func (m *Memory) ZCard(ctx context.Context, key string) IntValuer {
-	val := new(redis.IntCmd)
-	v, err := m.sts.ZCard(ctx, key)
-	val.SetVal(v)
-	val.SetErr(translateErr(err))
-	return val
-}
-
-// ZCount 返回有序集 key 中， score 值在 min 和 max 之间(默认包括 score 值等于 min 或 max )的成员的数量。
-func (m *Memory) ZCount(ctx context.Context, key, min, max string) IntValuer {
-	val := new(redis.IntCmd)
-	v, err := m.sts.ZCount(ctx, key, min, max)
-	val.SetVal(v)
-	val.SetErr(translateErr(err))
-	return val
+	return m.sts.ZAddArgs(ctx, key, zAddFlag(flag), members...)
 }
 
-// ZIncrBy 为有序集 key 的成员 member 的 score 值加上增量 increment 。
-// 可以通过传递一个负数值 increment ，让 score 减去相应的值，比如 ZINCRBY key -5 member ，就是让 member 的 score 值减去 5
-// @return member 成员的新 score 值
-func (m *Memory) ZIncrBy(ctx context.Context, key string, increment float64, member string) FloatValuer {
+// ZAddArgsIncr 同 ZAddArgs,在原来的分数上增加,只能有一个成员;条件不满足时返回 errors.Nil
+// 浮点数运算在不同节点上可能有误差,以 ZAdd 同步计算结果
+func (m *Memory) ZAddArgsIncr(ctx context.Context, key string, args ZAddArgs) FloatValuer {
 
 	val := new(redis.FloatCmd)
 
@@ -1088,188 +3057,339 @@ func (m *Memory) ZIncrBy(ctx context.Context, key string, increment float64, mem
 		return val
 	}
 
-	incrementStr, _ := marshalData(increment)
+	if len(args.Members) != 1 {
+		val.SetErr(MemoryZAddIncrPair)
+		return val
+	}
+
+	values := zAddArgsValues(key, args)
 
 	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.zIncrBy(ctx, key, increment, member)
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(key); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.zAddIncr(ctx, key, values[1:]...)
 		val.SetVal(v)
 		val.SetErr(translateErr(err))
 
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LShift, key, incrementStr, member)
+		if err == nil {
+			score, _ := marshalData(v)
+			m.syncToSlave(proto.Action_ZAdd, key, values[2], score)
 		}
 		return val
 	}
 
 	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LShift, key, incrementStr, member)
-	val.SetErr(err)
+	rsp, err := m.syncToMaster(ctx, proto.Action_ZAddIncr, values...)
+	val.SetErr(translateErr(err))
 	if err == nil {
-		cnt, _ := strconv.ParseFloat(rsp[0], 64)
-		val.SetVal(cnt)
+		f, _ := strconv.ParseFloat(rsp[0], 64)
+		val.SetVal(f)
 	}
 	return val
 }
 
-func (m *Memory) zIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeSortedSet); err != nil {
+// zAddIncr values 为 [flag, member, increment]
+func (m *Memory) zAddIncr(ctx context.Context, key string, values ...string) (float64, error) {
+	flag, err := strconv.Atoi(values[0])
+	if err != nil {
 		return 0, err
 	}
+	members, err := parseZMembers(values[1:])
+	if err != nil {
+		return 0, err
+	}
+	if len(members) != 1 {
+		return 0, MemoryZAddIncrPair
+	}
+	return m.sts.ZAddIncr(ctx, key, zAddFlag(flag), members[0])
+}
 
-	return m.sts.ZIncrBy(ctx, key, increment, member)
+// ZRangeByScoreWithScores 同 ZRangeByScore, 返回的成员带上 score 值
+func (m *Memory) ZRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ZSliceValuer {
+	val := new(redis.ZSliceCmd)
+	v, err := m.sts.ZRangeByScoreWithScores(ctx, key, opt)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
 }
 
-// ZRange 返回有序集 key 中，指定区间内的成员。
-// 其中成员的位置按 score 值递增(从小到大)来排序。
-// 具有相同 score 值的成员按字典序(lexicographical order )来排列。
-// 如果你需要成员按 score 值递减(从大到小)来排列，请使用 ZREVRANGE 命令。
-// 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
-// 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
-func (m *Memory) ZRange(ctx context.Context, key string, start, stop int64) StringSliceValuer {
+// ZRangeByLex 返回成员在字典序区间内的成员,区间以 "[" 或 "(" 开头,"-" "+" 表示无穷小跟无穷大
+// 只有所有成员的 score 值相同时结果才有意义
+func (m *Memory) ZRangeByLex(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
 	val := new(redis.StringSliceCmd)
-	v, err := m.sts.ZRange(ctx, key, start, stop)
+	v, err := m.sts.ZRangeByLex(ctx, key, opt)
 	val.SetVal(v)
 	val.SetErr(translateErr(err))
 	return val
 }
 
-// ZRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。有序集成员按 score 值递增(从小到大)次序排列。
-// 具有相同 score 值的成员按字典序(lexicographical order)来排列(该属性是有序集提供的，不需要额外的计算)。
-// 可选的 LIMIT 参数指定返回结果的数量及区间(就像SQL中的 SELECT LIMIT offset, count )，注意当 offset 很大时，定位 offset 的操作可能需要遍历整个有序集，此过程最坏复杂度为 O(N) 时间。
-func (m *Memory) ZRangeByScore(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
-	o := &ZRangeBy{
-		Min:    opt.Min,
-		Max:    opt.Max,
-		Offset: opt.Offset,
-		Count:  opt.Count,
-	}
+// ZRevRangeWithScores 同 ZRevRange, 返回的成员带上 score 值
+func (m *Memory) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ZSliceValuer {
+	val := new(redis.ZSliceCmd)
+	v, err := m.sts.ZRevRangeWithScores(ctx, key, start, stop)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZRevRangeByScore 同 ZRangeByScore,成员按 score 值递减(从大到小)排列
+func (m *Memory) ZRevRangeByScore(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
 	val := new(redis.StringSliceCmd)
-	v, err := m.sts.ZRangeByScore(ctx, key, o)
+	v, err := m.sts.ZRevRangeByScore(ctx, key, opt)
 	val.SetVal(v)
 	val.SetErr(translateErr(err))
 	return val
 }
 
-// ZRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递增(从小到大)顺序排列。
-// 排名以 0 为底，也就是说， score 值最小的成员排名为 0 。
-func (m *Memory) ZRank(ctx context.Context, key, member string) IntValuer {
-	val := new(redis.IntCmd)
-	v, err := m.sts.ZRank(ctx, key, member)
+// ZRevRangeByScoreWithScores 同 ZRevRangeByScore, 返回的成员带上 score 值
+func (m *Memory) ZRevRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ZSliceValuer {
+	val := new(redis.ZSliceCmd)
+	v, err := m.sts.ZRevRangeByScoreWithScores(ctx, key, opt)
 	val.SetVal(v)
 	val.SetErr(translateErr(err))
 	return val
 }
 
-// ZRem 移除有序集 key 中的一个或多个成员，不存在的成员将被忽略。
-// @return 被成功移除的成员的数量，不包括被忽略的成员
-func (m *Memory) ZRem(ctx context.Context, key string, members ...interface{}) IntValuer {
+// ZRevRangeByLex 同 ZRangeByLex,成员按字典序的逆序排列
+func (m *Memory) ZRevRangeByLex(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sts.ZRevRangeByLex(ctx, key, opt)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
 
-	val := new(redis.IntCmd)
+// ZMScore 返回多个成员的 score 值,不存在的成员返回0
+func (m *Memory) ZMScore(ctx context.Context, key string, members ...string) FloatSliceValuer {
+	val := new(redis.FloatSliceCmd)
+	v, err := m.sts.ZMScore(ctx, key, members...)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZPopMin 移除并返回 score 值最小的 count 个成员,不传 count 时为1
+func (m *Memory) ZPopMin(ctx context.Context, key string, count ...int64) ZSliceValuer {
+	return m.zPopWith(ctx, proto.Action_ZPopMin, key, count...)
+}
+
+// ZPopMax 移除并返回 score 值最大的 count 个成员,不传 count 时为1
+func (m *Memory) ZPopMax(ctx context.Context, key string, count ...int64) ZSliceValuer {
+	return m.zPopWith(ctx, proto.Action_ZPopMax, key, count...)
+}
+
+// zPopWith ZPopMin 跟 ZPopMax 的共同过程,弹出的结果是确定的,同步时只需要同步动作跟参数
+func (m *Memory) zPopWith(ctx context.Context, action proto.Action, key string, count ...int64) ZSliceValuer {
+
+	val := new(redis.ZSliceCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
 
-	values := make([]string, 0, len(members)+1)
-	values = append(values, key)
-	for i := 0; i < len(members); i++ {
-		member, err := marshalData(members[i])
-		if err != nil {
-			val.SetErr(err)
-			return val
-		}
-		values = append(values, member)
+	// 跟 go-redis 保持一致
+	n := int64(1)
+	switch len(count) {
+	case 0:
+	case 1:
+		n = count[0]
+	default:
+		panic("too many arguments")
 	}
+	countStr, _ := marshalData(n)
 
 	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.zRem(ctx, key, values[1:]...)
+		unlock := m.lockApply()
+		defer unlock()
+
+		v, err := m.zPop(ctx, action, key, n)
 		val.SetVal(v)
 		val.SetErr(translateErr(err))
 
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LShift, values...)
+		if err == nil && len(v) > 0 {
+			m.syncToSlave(action, key, countStr)
 		}
 		return val
 	}
 
 	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LShift, values...)
-	val.SetErr(err)
+	rsp, err := m.syncToMaster(ctx, action, key, countStr)
+	if err == MemoryNil {
+		// 主节点没有弹出任何成员
+		val.SetVal([]Z{})
+		return val
+	}
+	val.SetErr(translateErr(err))
 	if err == nil {
-		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
-		val.SetVal(cnt)
+		members, _ := parseZMembers(rsp)
+		v := make([]Z, 0, len(members))
+		for _, member := range members {
+			v = append(v, Z{Score: member.Score, Member: member.Member})
+		}
+		val.SetVal(v)
 	}
 	return val
 }
 
-func (m *Memory) zRem(ctx context.Context, key string, members ...string) (int64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeSortedSet); err != nil {
-		return 0, err
+func (m *Memory) zPop(ctx context.Context, action proto.Action, key string, count int64) ([]Z, error) {
+	if action == proto.Action_ZPopMax {
+		return m.sts.ZPopMax(ctx, key, count)
 	}
+	return m.sts.ZPopMin(ctx, key, count)
+}
 
-	return m.sts.ZRem(ctx, key, members...)
+// BZPopMin 从第一个非空的有序集合中移除并返回 score 值最小的成员
+// 有序集合都为空时阻塞等待,直到超时或者有可弹出的成员为止;timeout 为0时一直阻塞
+func (m *Memory) BZPopMin(ctx context.Context, timeout time.Duration, keys ...string) ZWithKeyValuer {
+	return m.bZPop(ctx, proto.Action_BZPopMin, timeout, keys...)
 }
 
-// ZRemRangeByRank 移除有序集 key 中，指定排名(rank)区间内的所有成员。
-// 区间分别以下标参数 start 和 stop 指出，包含 start 和 stop 在内。
-// 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
-// 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
-func (m *Memory) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) IntValuer {
+// BZPopMax 同 BZPopMin,移除并返回 score 值最大的成员
+func (m *Memory) BZPopMax(ctx context.Context, timeout time.Duration, keys ...string) ZWithKeyValuer {
+	return m.bZPop(ctx, proto.Action_BZPopMax, timeout, keys...)
+}
 
-	val := new(redis.IntCmd)
+// bZPop BZPopMin 跟 BZPopMax 的共同过程,弹出时以 ZRem 同步到从节点
+func (m *Memory) bZPop(ctx context.Context, action proto.Action, timeout time.Duration, keys ...string) ZWithKeyValuer {
+
+	val := new(redis.ZWithKeyCmd)
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		val.SetErr(err)
 		return val
 	}
-	startStr, _ := marshalData(start)
-	stopStr, _ := marshalData(stop)
+
+	values := make([]string, 0, len(keys)+1)
+	timeoutStr, _ := marshalData(timeout)
+	values = append(values, timeoutStr)
+	values = append(values, keys...)
 
 	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.zRemRangeByRank(ctx, key, start, stop)
+		v, err := m.bZPopWith(ctx, action, timeout, keys...)
 		val.SetVal(v)
 		val.SetErr(translateErr(err))
-
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LShift, key, startStr, stopStr)
-		}
 		return val
 	}
 
 	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LShift, key, startStr, stopStr)
-	val.SetErr(err)
-	if err == nil {
-		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
-		val.SetVal(cnt)
+	rsp, err := m.syncToMaster(ctx, action, values...)
+	val.SetErr(translateErr(err))
+	if err == nil && len(rsp) == 3 {
+		score, _ := strconv.ParseFloat(rsp[2], 64)
+		val.SetVal(&ZWithKey{Z: Z{Score: score, Member: rsp[1]}, Key: rsp[0]})
 	}
 	return val
 }
 
-func (m *Memory) zRemRangeByRank(ctx context.Context, key string, start, stop int64) (int64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeSortedSet); err != nil {
-		return 0, err
+// bZPopWith 等待期间不持有锁,每次尝试弹出时才锁住,弹出跟以 ZRem 同步到从节点在同一次加锁中完成,
+// 避免快照或者持久化日志的切换落在两者之间
+func (m *Memory) bZPopWith(ctx context.Context, action proto.Action, timeout time.Duration, keys ...string) (*ZWithKey, error) {
+	var result *ZWithKey
+	err := m.sts.block(ctx, driverStoreTypeSortedSet, m.sts.evtSig, timeout, keys, func() bool {
+		unlock := m.lockApply()
+		defer unlock()
+
+		if result = m.sts.popFirst(keys, action == proto.Action_BZPopMax); result == nil {
+			return false
+		}
+		member, _ := marshalData(result.Member)
+		m.syncToSlave(proto.Action_ZRem, result.Key, member)
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	return m.sts.ZRemRangeByRank(ctx, key, start, stop)
+// ZRandMember 随机返回有序集合中的成员
+// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+func (m *Memory) ZRandMember(ctx context.Context, key string, count int) StringSliceValuer {
+	val := new(redis.StringSliceCmd)
+	v, err := m.sts.ZRandMember(ctx, key, count)
+	members := make([]string, 0, len(v))
+	for _, z := range v {
+		members = append(members, z.Member.(string))
+	}
+	val.SetVal(members)
+	val.SetErr(translateErr(err))
+	return val
 }
 
-// ZRemRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。
-// 有序集成员按 score 值递增(从小到大)次序排列。
-func (m *Memory) ZRemRangeByScore(ctx context.Context, key, min, max string) IntValuer {
+// ZRandMemberWithScores 同 ZRandMember, 返回的成员带上 score 值
+func (m *Memory) ZRandMemberWithScores(ctx context.Context, key string, count int) ZSliceValuer {
+	val := new(redis.ZSliceCmd)
+	v, err := m.sts.ZRandMember(ctx, key, count)
+	val.SetVal(v)
+	val.SetErr(translateErr(err))
+	return val
+}
+
+// ZUnionStore 把多个有序集合的并集保存到 destination 中,返回结果的成员数量
+// 成员的 score 值乘以对应的权重后按 Aggregate(SUM/MIN/MAX) 合并,keys 中也可以是普通集合,成员的 score 值视为1
+func (m *Memory) ZUnionStore(ctx context.Context, destination string, store *ZStore) IntValuer {
+	return m.zStoreWith(ctx, proto.Action_ZUnionStore, zStoreValues(destination, store))
+}
+
+// ZInterStore 把多个有序集合的交集保存到 destination 中,返回结果的成员数量,score 值的计算同 ZUnionStore
+func (m *Memory) ZInterStore(ctx context.Context, destination string, store *ZStore) IntValuer {
+	return m.zStoreWith(ctx, proto.Action_ZInterStore, zStoreValues(destination, store))
+}
+
+// ZDiffStore 把第一个有序集合跟其他有序集合的差集保存到 destination 中,返回结果的成员数量
+func (m *Memory) ZDiffStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	values := make([]string, 0, len(keys)+1)
+	values = append(values, destination)
+	values = append(values, keys...)
+	return m.zStoreWith(ctx, proto.Action_ZDiffStore, values)
+}
+
+// zStoreValues ZUnionStore 跟 ZInterStore 同步的参数 [destination, aggregate, numkeys, key..., weight...]
+func zStoreValues(destination string, store *ZStore) []string {
+	values := make([]string, 0, len(store.Keys)+len(store.Weights)+3)
+	values = append(values, destination, store.Aggregate, strconv.Itoa(len(store.Keys)))
+	values = append(values, store.Keys...)
+	for _, weight := range store.Weights {
+		w, _ := marshalData(weight)
+		values = append(values, w)
+	}
+	return values
+}
+
+// parseZStoreValues 解析 zStoreValues 生成的参数
+func parseZStoreValues(values []string) (*ZStore, error) {
+	if len(values) < 3 {
+		return nil, MemorySyntaxError
+	}
+
+	numKeys, err := strconv.Atoi(values[2])
+	if err != nil || numKeys < 0 || len(values) < numKeys+3 {
+		return nil, MemorySyntaxError
+	}
+
+	store := &ZStore{Aggregate: values[1], Keys: values[3 : numKeys+3]}
+	for _, w := range values[numKeys+3:] {
+		weight, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			return nil, MemoryNotFloat
+		}
+		store.Weights = append(store.Weights, weight)
+	}
+	return store, nil
+}
+
+// zStoreWith 执行有序集合运算并保存结果,同步时只需要同步动作跟参数,values[0] 为 destination
+func (m *Memory) zStoreWith(ctx context.Context, action proto.Action, values []string) IntValuer {
 
 	val := new(redis.IntCmd)
 
@@ -1280,18 +3400,26 @@ func (m *Memory) ZRemRangeByScore(ctx context.Context, key, min, max string) Int
 
 	// 设置本地,并同步到从节点
 	if m.syncer == nil || (m.syncer != nil && m.syncer.isMaster) {
-		v, err := m.zRemRangeByScore(ctx, key, min, max)
+		unlock := m.lockApply()
+		defer unlock()
+
+		if err := m.ensureCapacity(values[0]); err != nil {
+			val.SetErr(err)
+			return val
+		}
+
+		v, err := m.zStore(ctx, action, values...)
 		val.SetVal(v)
 		val.SetErr(translateErr(err))
 
-		if m.syncer != nil && err == nil {
-			m.syncToSlave(proto.Action_LShift, key, min, max)
+		if err == nil {
+			m.syncToSlave(action, values...)
 		}
 		return val
 	}
 
 	// 访问主节点并返回数据
-	rsp, err := m.syncToMaster(proto.Action_LShift, key, min, max)
+	rsp, err := m.syncToMaster(ctx, action, values...)
 	val.SetErr(err)
 	if err == nil {
 		cnt, _ := strconv.ParseInt(rsp[0], 10, 64)
@@ -1300,44 +3428,17 @@ func (m *Memory) ZRemRangeByScore(ctx context.Context, key, min, max string) Int
 	return val
 }
 
-func (m *Memory) zRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
-	m.rwMutex.RLock()
-	defer m.rwMutex.RUnlock()
-	// 检测该Key是否被其他类型用了
-	if _, err := m.checkKeyAble(key, driverStoreTypeSortedSet); err != nil {
-		return 0, err
+func (m *Memory) zStore(ctx context.Context, action proto.Action, values ...string) (int64, error) {
+	if action == proto.Action_ZDiffStore {
+		return m.sts.ZDiffStore(ctx, values[0], values[1:]...)
 	}
 
-	return m.sts.ZRemRangeByScore(ctx, key, min, max)
-}
-
-// ZRevRange 返回有序集 key 中，指定区间内的成员。
-// 其中成员的位置按 score 值递减(从大到小)来排列。
-// 具有相同 score 值的成员按字典序的逆序(reverse lexicographical order)排列。
-func (m *Memory) ZRevRange(ctx context.Context, key string, start, stop int64) StringSliceValuer {
-	val := new(redis.StringSliceCmd)
-	v, err := m.sts.ZRevRange(ctx, key, start, stop)
-	val.SetVal(v)
-	val.SetErr(translateErr(err))
-	return val
-}
-
-// ZRevRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递减(从大到小)排序。
-// 排名以 0 为底，也就是说， score 值最大的成员排名为 0 。
-func (m *Memory) ZRevRank(ctx context.Context, key, member string) IntValuer {
-	val := new(redis.IntCmd)
-	v, err := m.sts.ZRevRank(ctx, key, member)
-	val.SetVal(v)
-	val.SetErr(translateErr(err))
-	return val
-}
-
-// ZScore 返回有序集 key 中，成员 member 的 score 值。
-// 如果 member 元素不是有序集 key 的成员，或 key 不存在，返回 nil 。
-func (m *Memory) ZScore(ctx context.Context, key, member string) FloatValuer {
-	val := new(redis.FloatCmd)
-	v, err := m.sts.ZScore(ctx, key, member)
-	val.SetVal(v)
-	val.SetErr(translateErr(err))
-	return val
+	store, err := parseZStoreValues(values)
+	if err != nil {
+		return 0, err
+	}
+	if action == proto.Action_ZInterStore {
+		return m.sts.ZInterStore(ctx, values[0], store)
+	}
+	return m.sts.ZUnionStore(ctx, values[0], store)
 }
@@ -0,0 +1,472 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver/proto"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/11 10:20
+  @describe :
+*/
+
+// applyAction 在本地执行一个同步动作,返回执行结果
+// 从节点接收同步数据以及启动时重放日志都通过该方法执行,不会再同步到其他节点
+func (m *Memory) applyAction(action proto.Action, values ...string) ([]string, error) {
+	var err error
+	result := make([]string, 0, 1)
+	switch action {
+	case proto.Action_Del:
+		val := m.del(context.Background(), values...)
+		data, _ := marshalData(val)
+		result = append(result, data)
+	case proto.Action_Expire:
+		var i int64
+		i, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			// 第三个参数为设置的条件,如 NX,XX,GT,LT
+			mode := expireModeNone
+			if len(values) > 2 {
+				mode = expireMode(values[2])
+			}
+
+			var b bool
+			b, err = m.expire(context.Background(), values[0], time.Duration(i), mode)
+			if b {
+				result = append(result, "1")
+			}
+		}
+	case proto.Action_ExpireAt:
+		var t time.Time
+		t, err = time.Parse(time.RFC3339Nano, values[1])
+		if err == nil {
+			var b bool
+			b, err = m.expireAt(context.Background(), values[0], &t)
+			if b {
+				result = append(result, "1")
+			}
+		}
+	case proto.Action_Persist:
+		var b bool
+		b, err = m.persist(context.Background(), values[0])
+		if b {
+			result = append(result, "1")
+		}
+	case proto.Action_Set:
+		var i int64
+		i, err = strconv.ParseInt(values[2], 10, 64)
+		if err == nil {
+			err = m.set(context.Background(), values[0], values[1], time.Duration(i))
+			if err == nil {
+				result = append(result, "OK")
+			}
+		}
+	case proto.Action_SetNX:
+		var i int64
+		i, err = strconv.ParseInt(values[2], 10, 64)
+		if err == nil {
+			var b bool
+			b, err = m.setNX(context.Background(), values[0], values[1], time.Duration(i))
+			if b {
+				result = append(result, "1")
+			}
+		}
+	case proto.Action_IncrBy:
+		var incr, i int64
+		incr, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			i, err = m.incrBy(context.Background(), values[0], incr)
+		}
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_IncrByFloat:
+		var incr, f float64
+		incr, err = strconv.ParseFloat(values[1], 64)
+		if err == nil {
+			f, err = m.incrByFloat(context.Background(), values[0], incr)
+		}
+		if err == nil {
+			data, _ := marshalData(f)
+			result = append(result, data)
+		}
+	case proto.Action_Append:
+		var i int64
+		i, err = m.append(context.Background(), values[0], values[1])
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_SetRange:
+		var offset, i int64
+		offset, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			i, err = m.setRange(context.Background(), values[0], offset, values[2])
+		}
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_GetSet:
+		// key不存在时同样设置了新的值,第二个结果表示原来的key是否存在
+		var v string
+		v, err = m.getSet(context.Background(), values[0], values[1])
+		if err == nil {
+			result = append(result, v, "1")
+		} else if err == MemoryNil {
+			err = nil
+			result = append(result, "", "0")
+		}
+	case proto.Action_GetDel:
+		var v string
+		v, err = m.getDel(context.Background(), values[0])
+		if err == nil {
+			result = append(result, v)
+		}
+	case proto.Action_GetEx:
+		var i int64
+		i, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			var v string
+			v, err = m.getEx(context.Background(), values[0], time.Duration(i))
+			if err == nil {
+				result = append(result, v)
+			}
+		}
+	case proto.Action_SetXX:
+		var i int64
+		i, err = strconv.ParseInt(values[2], 10, 64)
+		if err == nil {
+			var b bool
+			b, err = m.setXX(context.Background(), values[0], values[1], time.Duration(i))
+			if b {
+				result = append(result, "1")
+			}
+		}
+	case proto.Action_MSet:
+		err = m.mSet(context.Background(), values...)
+		if err == nil {
+			result = append(result, "OK")
+		}
+	case proto.Action_MSetNX:
+		var b bool
+		b, err = m.mSetNX(context.Background(), values...)
+		if b {
+			result = append(result, "1")
+		}
+	case proto.Action_HDel:
+		var i int64
+		i, err = m.hDel(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_HSet:
+		var i int64
+		i, err = m.hSet(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_HSetNx:
+		var b bool
+		b, err = m.hSetNX(context.Background(), values[0], values[1], values[2])
+		if b {
+			result = append(result, "1")
+		}
+	case proto.Action_HIncrBy:
+		var incr, i int64
+		incr, err = strconv.ParseInt(values[2], 10, 64)
+		if err == nil {
+			i, err = m.hIncrBy(context.Background(), values[0], values[1], incr)
+		}
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_HIncrByFloat:
+		var incr, f float64
+		incr, err = strconv.ParseFloat(values[2], 64)
+		if err == nil {
+			f, err = m.hIncrByFloat(context.Background(), values[0], values[1], incr)
+		}
+		if err == nil {
+			data, _ := marshalData(f)
+			result = append(result, data)
+		}
+	case proto.Action_HSetKeepTTL:
+		err = m.hSetKeepTTL(context.Background(), values[0], values[1], values[2])
+		if err == nil {
+			result = append(result, "OK")
+		}
+	case proto.Action_HExpire, proto.Action_HExpireAt:
+		var i int64
+		i, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			// HExpire 的参数为相对的存活时长,HExpireAt 的参数为到期时间
+			at := time.Unix(0, i)
+			if action == proto.Action_HExpire {
+				at = time.Now().Add(time.Duration(i))
+			}
+
+			var v []int64
+			v, err = m.hExpireAt(context.Background(), values[0], at, values[2:]...)
+			for _, r := range v {
+				data, _ := marshalData(r)
+				result = append(result, data)
+			}
+		}
+	case proto.Action_HPersist:
+		var v []int64
+		v, err = m.hPersist(context.Background(), values[0], values[1:]...)
+		for _, r := range v {
+			data, _ := marshalData(r)
+			result = append(result, data)
+		}
+	case proto.Action_LPush:
+		var i int64
+		i, err = m.lPush(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_RPush:
+		var i int64
+		i, err = m.rPush(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_LPop:
+		var v string
+		v, err = m.lPop(context.Background(), values[0])
+		if err == nil {
+			result = append(result, v)
+		}
+	case proto.Action_RPop:
+		var v string
+		v, err = m.rPop(context.Background(), values[0])
+		if err == nil {
+			result = append(result, v)
+		}
+	case proto.Action_LTrim:
+		var start, stop int64
+		start, err = strconv.ParseInt(values[1], 10, 64)
+		stop, err = strconv.ParseInt(values[2], 10, 64)
+		err = m.lTrim(context.Background(), values[0], start, stop)
+		if err == nil {
+			result = append(result, "OK")
+		}
+	case proto.Action_LSet:
+		var index int64
+		index, err = strconv.ParseInt(values[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		err = m.lSet(context.Background(), values[0], index, values[2])
+		if err == nil {
+			result = append(result, "OK")
+		}
+	case proto.Action_LRem:
+		var count, i int64
+		count, err = strconv.ParseInt(values[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		i, err = m.lRem(context.Background(), values[0], count, values[2])
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_LInsert:
+		var i int64
+		i, err = m.lInsert(context.Background(), values[0], values[1], values[2], values[3])
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_LMove:
+		var v string
+		v, err = m.lMove(context.Background(), values[0], values[1], values[2], values[3])
+		if err == nil {
+			result = append(result, v)
+		}
+	case proto.Action_BLMove:
+		i, _ := strconv.ParseInt(values[0], 10, 64)
+		var v string
+		v, err = m.bLMove(context.Background(), values[1], values[2], values[3], values[4], time.Duration(i))
+		if err == nil {
+			result = append(result, v)
+		}
+	case proto.Action_BLPop:
+		i, _ := strconv.ParseInt(values[0], 10, 64)
+		var v []string
+		v, err = m.bLPop(context.Background(), time.Duration(i), values[1:]...)
+		if err == nil {
+			result = v
+		}
+	case proto.Action_BRPop:
+		i, _ := strconv.ParseInt(values[0], 10, 64)
+		var v []string
+		v, err = m.bRPop(context.Background(), time.Duration(i), values[1:]...)
+		if err == nil {
+			result = v
+		}
+	case proto.Action_SAdd:
+		var i int64
+		i, err = m.sAdd(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_SRem:
+		var i int64
+		i, err = m.sRem(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_SPop:
+		var count int64
+		count, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			var v []string
+			v, err = m.sPop(context.Background(), values[0], count)
+			// 返回结果为空时会被填充成空字符串,没有弹出成员时需要返回 MemoryNil 来区分
+			if err == nil && len(v) == 0 {
+				err = MemoryNil
+			}
+			if err == nil {
+				result = v
+			}
+		}
+	case proto.Action_SInterStore, proto.Action_SUnionStore, proto.Action_SDiffStore:
+		var i int64
+		i, err = m.sStore(context.Background(), action, values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_ZAdd:
+		var i int64
+		i, err = m.zAdd(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_ZIncrBy:
+		var increment, f float64
+		increment, err = strconv.ParseFloat(values[1], 64)
+		if err == nil {
+			f, err = m.zIncrBy(context.Background(), values[0], increment, values[2])
+		}
+		if err == nil {
+			data, _ := marshalData(f)
+			result = append(result, data)
+		}
+	case proto.Action_ZRem:
+		var i int64
+		i, err = m.zRem(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_ZRemRangeByRank:
+		var start, stop, i int64
+		start, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			stop, err = strconv.ParseInt(values[2], 10, 64)
+		}
+		if err == nil {
+			i, err = m.zRemRangeByRank(context.Background(), values[0], start, stop)
+		}
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_ZRemRangeByScore:
+		var i int64
+		i, err = m.zRemRangeByScore(context.Background(), values[0], values[1], values[2])
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_ZAddArgs:
+		var i int64
+		i, err = m.zAddArgs(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	case proto.Action_ZAddIncr:
+		var f float64
+		f, err = m.zAddIncr(context.Background(), values[0], values[1:]...)
+		if err == nil {
+			data, _ := marshalData(f)
+			result = append(result, data)
+		}
+	case proto.Action_ZPopMin, proto.Action_ZPopMax:
+		var count int64
+		count, err = strconv.ParseInt(values[1], 10, 64)
+		if err == nil {
+			var v []Z
+			v, err = m.zPop(context.Background(), action, values[0], count)
+			// 返回结果为空时会被填充成空字符串,没有弹出成员时需要返回 MemoryNil 来区分
+			if err == nil && len(v) == 0 {
+				err = MemoryNil
+			}
+			if err == nil {
+				result = zMemberValues(v)
+			}
+		}
+	case proto.Action_BZPopMin, proto.Action_BZPopMax:
+		i, _ := strconv.ParseInt(values[0], 10, 64)
+		var v *ZWithKey
+		v, err = m.bZPopWith(context.Background(), action, time.Duration(i), values[1:]...)
+		if err == nil {
+			result = append([]string{v.Key}, zMemberValues([]Z{v.Z})...)
+		}
+	case proto.Action_ZUnionStore, proto.Action_ZInterStore, proto.Action_ZDiffStore:
+		var i int64
+		i, err = m.zStore(context.Background(), action, values...)
+		if err == nil {
+			data, _ := marshalData(i)
+			result = append(result, data)
+		}
+	default:
+		err = errors.New("unknown action")
+	}
+
+	return result, err
+}
+
+// actionGrows 判断动作是否可能新增键或者增加占用的字节数,用于写入前检测容量
+func actionGrows(action proto.Action) bool {
+	switch action {
+	case proto.Action_Set, proto.Action_SetNX,
+		proto.Action_IncrBy, proto.Action_IncrByFloat, proto.Action_Append, proto.Action_SetRange,
+		proto.Action_GetSet, proto.Action_SetXX, proto.Action_MSet, proto.Action_MSetNX,
+		proto.Action_HSet, proto.Action_HSetNx, proto.Action_HIncrBy, proto.Action_HIncrByFloat, proto.Action_HSetKeepTTL,
+		proto.Action_LPush, proto.Action_RPush, proto.Action_LSet, proto.Action_LInsert, proto.Action_LMove,
+		proto.Action_SAdd, proto.Action_SInterStore, proto.Action_SUnionStore, proto.Action_SDiffStore,
+		proto.Action_ZAdd, proto.Action_ZIncrBy, proto.Action_ZAddArgs, proto.Action_ZAddIncr,
+		proto.Action_ZUnionStore, proto.Action_ZInterStore, proto.Action_ZDiffStore:
+		return true
+	}
+	return false
+}
+
+// actionBlocks 判断动作是否会阻塞等待,阻塞的动作在等待期间不能持有 applyMutex
+func actionBlocks(action proto.Action) bool {
+	switch action {
+	case proto.Action_BLPop, proto.Action_BRPop, proto.Action_BLMove, proto.Action_BZPopMin, proto.Action_BZPopMax:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,425 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	utils "github.com/jerbe/go-utils"
+
+	v3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/10 09:12
+  @describe :
+*/
+
+// ClusterEventType 集群事件类型
+type ClusterEventType int
+
+const (
+	// ClusterEventPut 节点加入或者节点信息发生变化
+	ClusterEventPut ClusterEventType = iota + 1
+
+	// ClusterEventDelete 节点离开集群
+	ClusterEventDelete
+
+	// ClusterEventLeader 主节点发生变化
+	ClusterEventLeader
+)
+
+// String 返回事件类型的名称
+func (t ClusterEventType) String() string {
+	switch t {
+	case ClusterEventPut:
+		return "PUT"
+	case ClusterEventDelete:
+		return "DELETE"
+	case ClusterEventLeader:
+		return "LEADER"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ClusterEvent 集群事件
+type ClusterEvent struct {
+	// Type 事件类型
+	Type ClusterEventType
+
+	// ID 发生事件的节点ID
+	ID string
+
+	// Addr 发生事件的节点同步服务地址,ClusterEventDelete 跟 ClusterEventLeader 事件可能为空
+	Addr string
+}
+
+// Cluster 集群成员发现跟选主
+// 内存驱动通过该接口得知集群中有哪些节点,以及哪个节点是主节点
+type Cluster interface {
+	// ID 返回本节点在集群中的ID
+	ID() string
+
+	// Join 加入集群,addr 为本节点的同步服务地址
+	// 集群中节点的加入、离开以及主节点变化都会通过 handler 通知,包括本节点自己
+	Join(ctx context.Context, addr string, handler func(ClusterEvent)) error
+
+	// Leave 离开集群
+	Leave(ctx context.Context) error
+}
+
+// ================================================================================================
+// ====================================== ETCD ====================================================
+// ================================================================================================
+
+var (
+	_ Cluster = new(etcdCluster)
+)
+
+// etcdCluster 基于ETCD服务发现跟选举策略的集群
+type etcdCluster struct {
+	// cli 连接etcd的客户端
+	cli *v3.Client
+
+	// serverPrefix etcd服务发现用的前缀, [jcache/[自定义前缀]/server] 如 /jcache/mycache/server
+	serverPrefix string
+
+	// electionPrefix etcd选举用的前缀
+	electionPrefix string
+
+	// id 用于etcd服务发现的服务器ID,
+	// 为 [serverPrefix]/[主机名]/[随机字符串] 如 "/jcache/mycache/server/mypc/a8bc8def8a98z232"
+	id string
+
+	// addr 本节点的同步服务地址
+	addr string
+
+	// handler 集群事件处理函数
+	handler func(ClusterEvent)
+
+	// cancel 取消集群中运行的协程
+	cancel context.CancelFunc
+
+	// session,elect 当前参与选举的会话,离开集群时主动放弃主节点身份
+	session *concurrency.Session
+	elect   *concurrency.Election
+
+	// isClosed 指示已经离开集群
+	isClosed bool
+
+	mutex sync.Mutex
+}
+
+// NewEtcdCluster 返回一个基于ETCD服务发现跟选举策略的集群
+// prefix 为业务名前缀,用于隔离不同业务
+func NewEtcdCluster(cfg EtcdConfig, prefix string) (Cluster, error) {
+	prefix = strings.TrimPrefix(prefix, "/")
+	if prefix == "" {
+		return nil, errors.New("prefix nil")
+	}
+
+	cli, err := v3.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DialTimeout > 0 {
+		ctx := cfg.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		timeOutCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
+		_, err := cli.Status(timeOutCtx, cfg.Endpoints[0])
+		cancel()
+		if err != nil {
+			cli.Close()
+			return nil, err
+		}
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	serverPrefix := fmt.Sprintf("%s/%s/server", etcdPrefix, prefix)
+
+	return &etcdCluster{
+		cli:            cli,
+		serverPrefix:   serverPrefix,
+		electionPrefix: fmt.Sprintf("%s/%s/election", etcdPrefix, prefix),
+		id:             fmt.Sprintf("%s/%s/%s", serverPrefix, utils.Hostname(), strconv.FormatInt(rand.Int63(), 16)),
+	}, nil
+}
+
+// ID 返回本节点在集群中的ID
+func (c *etcdCluster) ID() string {
+	return c.id
+}
+
+// Join 加入集群
+func (c *etcdCluster) Join(ctx context.Context, addr string, handler func(ClusterEvent)) error {
+	if handler == nil {
+		return errors.New("handler nil")
+	}
+
+	c.addr = addr
+	c.handler = handler
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+
+	var err error
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	// 进行etcd注册
+	err = c.register(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 进行etcd监听
+	err = c.watching(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 进行选主监控
+	err = c.election(ctx)
+	return err
+}
+
+// Leave 离开集群
+func (c *etcdCluster) Leave(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.isClosed {
+		return nil
+	}
+	c.isClosed = true
+
+	// 主动放弃主节点身份并撤销会话,让其他节点尽快重新选主,需要在取消协程之前执行
+	if c.elect != nil {
+		c.elect.Resign(ctx)
+	}
+	if c.session != nil {
+		c.session.Close()
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	// 主动删除注册信息,让其他节点尽快感知
+	if c.id != "" {
+		c.cli.Delete(ctx, c.id)
+	}
+	return c.cli.Close()
+}
+
+// closed 判断是否已经离开集群
+func (c *etcdCluster) closed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.isClosed
+}
+
+// tryElection 尝试进行选举,当异常退出选举时有用
+func (c *etcdCluster) tryElection(ctx context.Context) {
+	var err error
+	err = c.election(ctx)
+	for err != nil {
+		log.Println("*etcdCluster.tryElection")
+		if utils.ContextIsDone(ctx) != nil {
+			return
+		}
+
+		time.Sleep(time.Second * 5)
+		err = c.election(ctx)
+		if err == nil {
+			return
+		}
+	}
+}
+
+// election 选举
+func (c *etcdCluster) election(ctx context.Context) error {
+	session, err := concurrency.NewSession(c.cli, concurrency.WithTTL(10))
+	if err != nil {
+		return err
+	}
+	election := concurrency.NewElection(session, c.electionPrefix)
+
+	c.mutex.Lock()
+	c.session, c.elect = session, election
+	c.mutex.Unlock()
+
+	go func() {
+		var r bool
+		observerCh := election.Observe(ctx)
+		defer func() {
+			if o := recover(); o != nil {
+				log.Printf("election is panic. reason:[%v]", o)
+			}
+			session.Close()
+			if r && ctx.Err() == nil {
+				log.Printf("election was exit, but not closed, register again.  Local:[%s]", c.id)
+				go c.tryElection(ctx)
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-session.Done():
+				r = !c.closed()
+				return
+			case rsp, ok := <-observerCh:
+				if ok {
+					c.handler(ClusterEvent{Type: ClusterEventLeader, ID: string(rsp.Kvs[0].Value)})
+				} else {
+					r = !c.closed()
+					return
+				}
+			}
+		}
+	}()
+
+	errCh := make(chan error)
+	go func() {
+		e := election.Campaign(ctx, c.id)
+		defer func() {
+			close(errCh)
+		}()
+		if e != nil {
+			errCh <- e
+			session.Close()
+			return
+		}
+
+		c.handler(ClusterEvent{Type: ClusterEventLeader, ID: c.id, Addr: c.addr})
+	}()
+
+	time.Sleep(time.Second)
+	select {
+	case err = <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// tryRegister 尝试注册服务,当异常退出时有用
+func (c *etcdCluster) tryRegister(ctx context.Context) {
+	var err error
+	err = c.register(ctx)
+	for err != nil {
+		log.Println("*etcdCluster.tryRegister")
+		if utils.ContextIsDone(ctx) != nil {
+			return
+		}
+
+		time.Sleep(time.Second * 5)
+		err = c.register(ctx)
+		if err == nil {
+			return
+		}
+	}
+}
+
+// register 注册服务
+func (c *etcdCluster) register(ctx context.Context) error {
+	lease, err := c.cli.Grant(ctx, 10)
+	if err != nil {
+		return err
+	}
+
+	kv := v3.NewKV(c.cli)
+	_, err = kv.Put(ctx, c.id, c.addr, v3.WithLease(lease.ID))
+	if err != nil {
+		return err
+	}
+
+	var aliveResp <-chan *v3.LeaseKeepAliveResponse
+	aliveResp, err = c.cli.KeepAlive(ctx, lease.ID)
+	if err != nil {
+		return err
+	}
+
+	go func(alive <-chan *v3.LeaseKeepAliveResponse) {
+		var r bool
+		defer func() {
+			if o := recover(); o != nil {
+				log.Printf("election is panic. reason:[%v]", o)
+			}
+			if r && ctx.Err() == nil {
+				log.Printf("keepalive chan was close, but not closed, register again.  Local:[%s]", c.id)
+				go c.tryRegister(ctx)
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-alive:
+				if !ok {
+					r = !c.closed()
+					return
+				}
+			}
+		}
+	}(aliveResp)
+
+	return nil
+}
+
+// watching 监控集群节点
+func (c *etcdCluster) watching(ctx context.Context) (err error) {
+	var response *v3.GetResponse
+	response, err = c.cli.Get(ctx, c.serverPrefix, v3.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range response.Kvs {
+		c.handler(ClusterEvent{Type: ClusterEventPut, ID: string(kv.Key), Addr: string(kv.Value)})
+	}
+
+	watchCh := c.cli.Watch(ctx, c.serverPrefix, v3.WithPrefix(), v3.WithRev(response.Header.Revision+1))
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case w, ok := <-watchCh:
+				if !ok {
+					log.Println("cluster watching chan was closed")
+					return
+				}
+				for _, event := range w.Events {
+					k := string(event.Kv.Key)
+					v := string(event.Kv.Value)
+					log.Printf("[Event] local:[%s], type:[%v], key:[%s], value:[%s]", c.id, event.Type, k, v)
+					switch event.Type {
+					case v3.EventTypePut:
+						c.handler(ClusterEvent{Type: ClusterEventPut, ID: k, Addr: v})
+					case v3.EventTypeDelete:
+						c.handler(ClusterEvent{Type: ClusterEventDelete, ID: k})
+					}
+				}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/10 14:36
+  @describe :
+*/
+
+const (
+	// DefaultStaticProbeInterval 静态集群探测节点的默认间隔
+	DefaultStaticProbeInterval = time.Second
+
+	// DefaultStaticDialTimeout 静态集群探测节点的默认超时时间
+	DefaultStaticDialTimeout = time.Millisecond * 500
+)
+
+var (
+	_ Cluster = new(staticCluster)
+)
+
+// StaticPeer 静态集群中的节点
+type StaticPeer struct {
+	// ID 节点ID,集群内唯一
+	ID string
+
+	// Addr 节点同步服务地址,如 "192.168.1.2:9000"
+	Addr string
+}
+
+// StaticClusterConfig 静态集群配置
+type StaticClusterConfig struct {
+	// ID 本节点的ID
+	ID string
+
+	// Peers 集群中的所有节点,可以包含本节点,本节点会被忽略
+	Peers []StaticPeer
+
+	// ProbeInterval 探测节点是否可达的间隔,默认为 DefaultStaticProbeInterval
+	ProbeInterval time.Duration
+
+	// DialTimeout 探测节点的超时时间,默认为 DefaultStaticDialTimeout
+	DialTimeout time.Duration
+}
+
+// staticCluster 基于固定节点列表的集群
+// 定时通过TCP连接探测其他节点是否可达,所有可达的节点(包括本节点)中ID最小的节点为主节点
+type staticCluster struct {
+	cfg StaticClusterConfig
+
+	// peers 除本节点外的其他节点
+	peers []StaticPeer
+
+	// handler 集群事件处理函数
+	handler func(ClusterEvent)
+
+	// reachable 当前可达的节点
+	reachable map[string]bool
+
+	// leader 当前主节点ID
+	leader string
+
+	// cancel 停止探测
+	cancel context.CancelFunc
+
+	// done 探测协程已退出
+	done chan struct{}
+
+	mutex sync.Mutex
+}
+
+// NewStaticCluster 返回一个基于固定节点列表的集群,不依赖任何外部服务
+func NewStaticCluster(cfg StaticClusterConfig) (Cluster, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("id nil")
+	}
+
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = DefaultStaticProbeInterval
+	}
+
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultStaticDialTimeout
+	}
+
+	peers := make([]StaticPeer, 0, len(cfg.Peers))
+	exists := make(map[string]bool)
+	for _, peer := range cfg.Peers {
+		if peer.ID == cfg.ID {
+			continue
+		}
+		if peer.ID == "" || peer.Addr == "" {
+			return nil, errors.New("peer id or addr nil")
+		}
+		if exists[peer.ID] {
+			return nil, errors.New("peer id duplicated")
+		}
+		exists[peer.ID] = true
+		peers = append(peers, peer)
+	}
+
+	return &staticCluster{
+		cfg:       cfg,
+		peers:     peers,
+		reachable: make(map[string]bool),
+	}, nil
+}
+
+// ID 返回本节点在集群中的ID
+func (c *staticCluster) ID() string {
+	return c.cfg.ID
+}
+
+// Join 加入集群
+// 会先同步进行一次探测,保证返回时已经确定了主节点
+func (c *staticCluster) Join(ctx context.Context, addr string, handler func(ClusterEvent)) error {
+	if handler == nil {
+		return errors.New("handler nil")
+	}
+
+	c.mutex.Lock()
+	if c.cancel != nil {
+		c.mutex.Unlock()
+		return errors.New("already joined")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.handler = handler
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	c.mutex.Unlock()
+
+	c.probe(ctx)
+
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.cfg.ProbeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.probe(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Leave 离开集群
+func (c *staticCluster) Leave(ctx context.Context) error {
+	c.mutex.Lock()
+	cancel, done := c.cancel, c.done
+	c.mutex.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// probe 探测所有节点,并通知节点跟主节点的变化
+func (c *staticCluster) probe(ctx context.Context) {
+	reachable := make(map[string]bool, len(c.peers))
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, peer := range c.peers {
+		wg.Add(1)
+		go func(peer StaticPeer) {
+			defer wg.Done()
+			dialer := net.Dialer{Timeout: c.cfg.DialTimeout}
+			conn, err := dialer.DialContext(ctx, "tcp", peer.Addr)
+			if err != nil {
+				return
+			}
+			conn.Close()
+			mutex.Lock()
+			reachable[peer.ID] = true
+			mutex.Unlock()
+		}(peer)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	events := make([]ClusterEvent, 0)
+	for _, peer := range c.peers {
+		if reachable[peer.ID] && !c.reachable[peer.ID] {
+			events = append(events, ClusterEvent{Type: ClusterEventPut, ID: peer.ID, Addr: peer.Addr})
+		}
+		if !reachable[peer.ID] && c.reachable[peer.ID] {
+			events = append(events, ClusterEvent{Type: ClusterEventDelete, ID: peer.ID})
+		}
+	}
+	c.reachable = reachable
+
+	// 所有可达的节点中ID最小的为主节点
+	ids := []string{c.cfg.ID}
+	for id := range reachable {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if leader := ids[0]; leader != c.leader {
+		log.Printf("[Leader] local:[%s], leader:[%s]", c.cfg.ID, leader)
+		c.leader = leader
+		events = append(events, ClusterEvent{Type: ClusterEventLeader, ID: leader})
+	}
+
+	for _, event := range events {
+		c.handler(event)
+	}
+}
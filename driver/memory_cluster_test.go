@@ -0,0 +1,119 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/10 16:05
+  @describe :
+*/
+
+// freePort 返回一个本机可用的端口
+func freePort(t *testing.T) int {
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listen.Close()
+	return listen.Addr().(*net.TCPAddr).Port
+}
+
+func TestNewStaticCluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	port1, port2 := freePort(t), freePort(t)
+	peers := []StaticPeer{
+		{ID: "node-1", Addr: fmt.Sprintf("127.0.0.1:%d", port1)},
+		{ID: "node-2", Addr: fmt.Sprintf("127.0.0.1:%d", port2)},
+	}
+
+	newNode := func(id string, port int) *Memory {
+		cluster, err := NewStaticCluster(StaticClusterConfig{ID: id, Peers: peers, ProbeInterval: time.Millisecond * 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mem, err := NewMemoryWithConfig(MemoryConfig{Port: port, Username: "root", Password: "root", Cluster: cluster, Context: ctx})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mem.(*Memory)
+	}
+
+	waitFor := func(cond func() bool) bool {
+		deadline := time.Now().Add(time.Second * 5)
+		for time.Now().Before(deadline) {
+			if cond() {
+				return true
+			}
+			time.Sleep(time.Millisecond * 50)
+		}
+		return false
+	}
+
+	// 先启动的节点写入数据,后启动的节点需要通过全量同步拿到
+	node1 := newNode("node-1", port1)
+	if !node1.syncer.isMaster {
+		t.Fatalf("node-1 should be master")
+	}
+	if err := node1.Set(ctx, "snapshot", "value", time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	node2 := newNode("node-2", port2)
+	if node2.syncer.isMaster {
+		t.Fatalf("node-2 should not be master")
+	}
+	if !waitFor(func() bool { return node2.Get(ctx, "snapshot").Val() == "value" }) {
+		t.Fatalf("snapshot was not synced to node-2")
+	}
+
+	// 主节点等待发现从节点
+	if !waitFor(func() bool {
+		node1.syncer.rwMutex.RLock()
+		defer node1.syncer.rwMutex.RUnlock()
+		_, ok := node1.syncer.slaveEndpoints["node-2"]
+		return ok
+	}) {
+		t.Fatalf("node-1 did not discover node-2")
+	}
+
+	// 从节点写入的数据会转发到主节点
+	if err := node2.Set(ctx, "from-slave", "1", time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := node1.Get(ctx, "from-slave").Val(); got != "1" {
+		t.Errorf("node-1 Get() got = %v, want 1", got)
+	}
+
+	// 主节点写入的数据会复制到从节点
+	if err := node1.HSet(ctx, "from-master", "f", "v").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !waitFor(func() bool { return node2.HGet(ctx, "from-master", "f").Val() == "v" }) {
+		t.Fatalf("replication was not synced to node-2")
+	}
+	if !waitFor(func() bool { return node2.Get(ctx, "from-slave").Val() == "1" }) {
+		t.Fatalf("forwarded write was not synced to node-2")
+	}
+	// 关闭后释放同步服务的端口
+	for _, node := range []*Memory{node2, node1} {
+		if err := node.Close(ctx); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}
+	for _, port := range []int{port1, port2} {
+		listen, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			t.Errorf("port %d not released: %v", port, err)
+			continue
+		}
+		listen.Close()
+	}
+}
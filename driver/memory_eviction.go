@@ -0,0 +1,343 @@
+package driver
+
+import (
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/15 09:40
+  @describe : 内存驱动的容量限制跟淘汰策略
+*/
+
+// MemoryOOM 超出容量限制并且不能淘汰数据时,写操作返回的错误
+var MemoryOOM = errors.New("OOM command not allowed when used memory > 'maxmemory'")
+
+// DefaultEvictionSamples 每次淘汰时默认抽样的键数量
+const DefaultEvictionSamples = 5
+
+// EvictionPolicy 超出容量限制时的淘汰策略
+type EvictionPolicy int
+
+const (
+	// EvictNoEviction 不淘汰数据,超出容量限制时写操作返回 MemoryOOM,默认策略
+	EvictNoEviction EvictionPolicy = iota
+
+	// EvictLRU 淘汰最久没有被访问的键
+	EvictLRU
+
+	// EvictLFU 淘汰访问频率最低的键,访问频率会随着空闲时间衰减
+	EvictLFU
+
+	// EvictVolatileTTL 淘汰最快到期的键,没有到期时间的键不会被淘汰
+	EvictVolatileTTL
+
+	// EvictRandom 随机淘汰
+	EvictRandom
+)
+
+// String 返回策略名称
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictNoEviction:
+		return "NoEviction"
+	case EvictLRU:
+		return "LRU"
+	case EvictLFU:
+		return "LFU"
+	case EvictVolatileTTL:
+		return "VolatileTTL"
+	case EvictRandom:
+		return "Random"
+	}
+	return "Unknown"
+}
+
+// EvictReason 键被移除的原因
+type EvictReason int
+
+const (
+	// EvictReasonExpired 到期后被清理
+	EvictReasonExpired EvictReason = iota
+
+	// EvictReasonMaxEntries 键的数量超出限制被淘汰
+	EvictReasonMaxEntries
+
+	// EvictReasonMaxBytes 占用的字节数超出限制被淘汰
+	EvictReasonMaxBytes
+)
+
+// String 返回原因名称
+func (r EvictReason) String() string {
+	switch r {
+	case EvictReasonExpired:
+		return "Expired"
+	case EvictReasonMaxEntries:
+		return "MaxEntries"
+	case EvictReasonMaxBytes:
+		return "MaxBytes"
+	}
+	return "Unknown"
+}
+
+// MemoryStats 内存驱动的统计数据
+type MemoryStats struct {
+	// Entries 键的数量,包括已经过期但还没有被清理的键
+	Entries int64
+
+	// Bytes 键跟数值占用的字节数估算值
+	Bytes int64
+
+	// Evictions 因为超出容量限制被淘汰的键数量
+	Evictions int64
+
+	// Expirations 到期后被清理的键数量
+	Expirations int64
+
+	// Rejections 因为超出容量限制被拒绝的写操作数量
+	Rejections int64
+}
+
+// keyspaceLimits 键空间的容量限制
+type keyspaceLimits struct {
+	maxEntries int64
+	maxBytes   int64
+	policy     EvictionPolicy
+	samples    int
+}
+
+// newKeyspaceLimits 根据配置返回容量限制
+func newKeyspaceLimits(cfg *MemoryConfig) keyspaceLimits {
+	limits := keyspaceLimits{
+		maxEntries: cfg.MaxEntries,
+		maxBytes:   cfg.MaxBytes,
+		policy:     cfg.EvictionPolicy,
+		samples:    cfg.EvictionSamples,
+	}
+	if limits.samples <= 0 {
+		limits.samples = DefaultEvictionSamples
+	}
+	return limits
+}
+
+// enabled 是否设置了容量限制
+func (l *keyspaceLimits) enabled() bool {
+	return l.maxEntries > 0 || l.maxBytes > 0
+}
+
+// evictionCandidate 抽样得到的淘汰候选
+type evictionCandidate struct {
+	key   string
+	shard *keyspaceShard
+	value expireable
+}
+
+// stats 返回键空间的统计数据
+func (ks *keyspace) stats() MemoryStats {
+	stats := MemoryStats{
+		Evictions:   atomic.LoadInt64(&ks.evictions),
+		Expirations: atomic.LoadInt64(&ks.expirations),
+		Rejections:  atomic.LoadInt64(&ks.rejections),
+	}
+	for _, sh := range ks.shards {
+		stats.Entries += atomic.LoadInt64(&sh.entries)
+		stats.Bytes += atomic.LoadInt64(&sh.bytes)
+	}
+	return stats
+}
+
+// evicted 记录并回调被移除的键
+func (ks *keyspace) evicted(key string, reason EvictReason) {
+	if reason == EvictReasonExpired {
+		atomic.AddInt64(&ks.expirations, 1)
+	} else {
+		atomic.AddInt64(&ks.evictions, 1)
+	}
+
+	if ks.onEvict != nil {
+		ks.onEvict(key, reason)
+	}
+}
+
+// overLimit 判断写入 keys 前是否超出了容量限制
+// 键的数量会算上即将新增的键,字节数只判断当前是否已经超出
+func (ks *keyspace) overLimit(keys ...string) (EvictReason, bool) {
+	stats := ks.stats()
+
+	if ks.limits.maxEntries > 0 {
+		added := int64(0)
+		for _, key := range keys {
+			if ks.value(key) == nil {
+				added++
+			}
+		}
+		if stats.Entries+added > ks.limits.maxEntries {
+			return EvictReasonMaxEntries, true
+		}
+	}
+
+	if ks.limits.maxBytes > 0 && stats.Bytes > ks.limits.maxBytes {
+		return EvictReasonMaxBytes, true
+	}
+	return 0, false
+}
+
+// ensureCapacity 写入 keys 前检测容量,超出限制时按淘汰策略淘汰其他的键
+// 不淘汰的策略或者找不到可以淘汰的键时返回 MemoryOOM
+// evict 在键被淘汰后回调,用于同步到从节点,调用方不能持有分片的锁
+func (ks *keyspace) ensureCapacity(evict func(key string), keys ...string) error {
+	if !ks.limits.enabled() {
+		return nil
+	}
+
+	exclude := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		exclude[key] = struct{}{}
+	}
+
+	for {
+		reason, over := ks.overLimit(keys...)
+		if !over {
+			return nil
+		}
+
+		if ks.limits.policy == EvictNoEviction {
+			atomic.AddInt64(&ks.rejections, 1)
+			return MemoryOOM
+		}
+
+		candidate, expired := ks.pickVictim(exclude)
+		if candidate == nil {
+			atomic.AddInt64(&ks.rejections, 1)
+			return MemoryOOM
+		}
+
+		if !ks.removeCandidate(candidate) {
+			// 抽样之后键已经被修改,重新抽样
+			continue
+		}
+
+		if expired {
+			reason = EvictReasonExpired
+		}
+		ks.evicted(candidate.key, reason)
+		if evict != nil {
+			evict(candidate.key)
+		}
+	}
+}
+
+// sample 从不同的分片中各抽取一个键,最多抽取 n 个
+func (ks *keyspace) sample(n int, exclude map[string]struct{}) []*evictionCandidate {
+	result := make([]*evictionCandidate, 0, n)
+	start := rand.Intn(len(ks.shards))
+	for i := 0; i < len(ks.shards) && len(result) < n; i++ {
+		sh := ks.shards[(start+i)%len(ks.shards)]
+		sh.rwMutex.RLock()
+		// map 的遍历顺序是随机的,取第一个即可
+		for k, v := range sh.values {
+			if _, ok := exclude[k]; ok {
+				continue
+			}
+			result = append(result, &evictionCandidate{key: k, shard: sh, value: v})
+			break
+		}
+		sh.rwMutex.RUnlock()
+	}
+	return result
+}
+
+// pickVictim 根据淘汰策略从抽样中选出一个要淘汰的键
+// 抽样中有已经过期的键时优先淘汰,并返回true
+func (ks *keyspace) pickVictim(exclude map[string]struct{}) (*evictionCandidate, bool) {
+	candidates := ks.sample(ks.limits.samples, exclude)
+
+	var (
+		victim *evictionCandidate
+		best   float64
+		now    = time.Now()
+	)
+	for _, c := range candidates {
+		if c.value.IsExpire() {
+			return c, true
+		}
+
+		var score float64
+		accessAt, freq := c.value.accessInfo()
+		switch ks.limits.policy {
+		case EvictLRU:
+			score = float64(accessAt)
+		case EvictLFU:
+			// 访问频率按空闲的分钟数衰减
+			idle := now.Sub(time.Unix(0, accessAt)) / time.Minute
+			score = float64(freq) / float64(1+idle)
+		case EvictVolatileTTL:
+			at := c.value.ExpireTime()
+			if at == nil {
+				continue
+			}
+			score = float64(at.UnixNano())
+		case EvictRandom:
+			return c, false
+		}
+
+		if victim == nil || score < best {
+			victim, best = c, score
+		}
+	}
+
+	// 抽样中全部是没有到期时间的键时,从过期队列中找出最快到期的键,避免还有可以淘汰的键时返回 MemoryOOM
+	if victim == nil && ks.limits.policy == EvictVolatileTTL {
+		victim = ks.earliestExpiring(exclude)
+		if victim != nil {
+			return victim, victim.value.IsExpire()
+		}
+	}
+	return victim, false
+}
+
+// earliestExpiring 遍历所有分片的过期队列,返回最快到期的键,没有设置到期时间的键时返回nil
+func (ks *keyspace) earliestExpiring(exclude map[string]struct{}) *evictionCandidate {
+	var (
+		victim *evictionCandidate
+		best   int64
+	)
+	for _, sh := range ks.shards {
+		sh.rwMutex.RLock()
+		for _, item := range sh.expires {
+			if item.field || (victim != nil && item.at >= best) {
+				continue
+			}
+			if _, ok := exclude[item.key]; ok {
+				continue
+			}
+
+			// 过期队列中可能有失效的记录,以键当前的到期时间为准
+			v, ok := sh.values[item.key]
+			if !ok {
+				continue
+			}
+			if at := v.ExpireTime(); at == nil || at.UnixNano() != item.at {
+				continue
+			}
+			victim, best = &evictionCandidate{key: item.key, shard: sh, value: v}, item.at
+		}
+		sh.rwMutex.RUnlock()
+	}
+	return victim
+}
+
+// removeCandidate 删除抽样得到的键,键在抽样之后被替换或删除时返回false
+func (ks *keyspace) removeCandidate(c *evictionCandidate) bool {
+	c.shard.rwMutex.Lock()
+	defer c.shard.rwMutex.Unlock()
+
+	if v, ok := c.shard.values[c.key]; !ok || v != c.value {
+		return false
+	}
+	c.shard.remove(c.key)
+	return true
+}
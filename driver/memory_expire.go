@@ -0,0 +1,221 @@
+package driver
+
+import (
+	"container/heap"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/15 16:20
+  @describe : 内存驱动的主动过期,每个分片使用按到期时间排列的最小堆
+*/
+
+const (
+	// DefaultExpirePrecision 默认的主动过期检测间隔,过期的键最迟在到期后的这个时长内被清理
+	DefaultExpirePrecision = time.Millisecond * 100
+
+	// expireBatch 每次检测时每个分片最多清理的键数量,避免长时间持有分片的锁
+	expireBatch = 128
+
+	// expireCompactMin 过期队列至少有这么多失效的记录时才会重建
+	expireCompactMin = 1024
+
+	// expireRestartDelay 主动过期检测异常退出后,等待这个时长再重新启动,避免持续异常时空转
+	expireRestartDelay = time.Second
+)
+
+// expireItem 过期队列中的一条记录
+type expireItem struct {
+	key string
+
+	// at 加入队列时的到期时间(纳秒)
+	at int64
+
+	// field 是否是字段的到期时间,到期时只清理过期的字段
+	field bool
+}
+
+// fieldExpireable 字段可以单独设置到期时间的数值,如哈希表
+type fieldExpireable interface {
+	// expireFields 删除已经过期的字段,返回删除后是否没有任何字段
+	expireFields(now int64) bool
+
+	// nextFieldExpire 返回最早的字段到期时间(纳秒),没有字段设置到期时间时返回0
+	nextFieldExpire() int64
+
+	// rescheduleFields 最早的字段到期时间跟上次加入过期队列时不一样时返回新的到期时间(纳秒)跟true
+	rescheduleFields() (int64, bool)
+
+	// fieldExpireTimes 导出没有过期的字段的到期时间
+	fieldExpireTimes() map[string]int64
+}
+
+// expireQueue 按到期时间从小到大排列的最小堆
+// 键被删除或者到期时间被修改后,原来的记录不会马上移除,出队时再跟当前的到期时间比较
+type expireQueue []expireItem
+
+func (q expireQueue) Len() int            { return len(q) }
+func (q expireQueue) Less(i, j int) bool  { return q[i].at < q[j].at }
+func (q expireQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *expireQueue) Push(x interface{}) { *q = append(*q, x.(expireItem)) }
+func (q *expireQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// schedule 把键的到期时间加入过期队列,到期时间没有变化时不重复加入,调用方需要持有写锁
+func (sh *keyspaceShard) schedule(key string, v expireable) {
+	if at, ok := v.reschedule(); ok {
+		heap.Push(&sh.expires, expireItem{key: key, at: at})
+	}
+	if fv, ok := v.(fieldExpireable); ok {
+		if at, ok := fv.rescheduleFields(); ok {
+			heap.Push(&sh.expires, expireItem{key: key, at: at, field: true})
+		}
+	}
+	sh.compactExpires()
+}
+
+// compactExpires 失效的记录太多时根据当前的数据重建过期队列,调用方需要持有写锁
+func (sh *keyspaceShard) compactExpires() {
+	if len(sh.expires) < expireCompactMin || len(sh.expires) < 2*len(sh.values) {
+		return
+	}
+
+	queue := make(expireQueue, 0, len(sh.values))
+	for k, v := range sh.values {
+		if at := v.ExpireTime(); at != nil {
+			queue = append(queue, expireItem{key: k, at: at.UnixNano()})
+		}
+		if fv, ok := v.(fieldExpireable); ok {
+			if at := fv.nextFieldExpire(); at > 0 {
+				queue = append(queue, expireItem{key: k, at: at, field: true})
+			}
+		}
+	}
+	heap.Init(&queue)
+	sh.expires = queue
+}
+
+// expire 清理到期的键,最多清理 limit 个,返回被清理的键以及是否还有到期的键没有清理
+func (sh *keyspaceShard) expire(now int64, limit int) ([]string, bool) {
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	expired := make([]string, 0)
+	for len(sh.expires) > 0 && sh.expires[0].at <= now {
+		if len(expired) >= limit {
+			return expired, true
+		}
+
+		item := heap.Pop(&sh.expires).(expireItem)
+		v, ok := sh.values[item.key]
+		if !ok {
+			continue
+		}
+
+		// 字段到期时只清理过期的字段,所有字段都过期时删除整个键
+		if item.field {
+			fv, ok := v.(fieldExpireable)
+			if !ok || fv.nextFieldExpire() != item.at {
+				continue
+			}
+			if !fv.expireFields(now) {
+				// 不能调用 store,清理过期字段不算一次访问
+				atomic.AddInt64(&sh.bytes, v.charge())
+				sh.schedule(item.key, v)
+				continue
+			}
+			sh.remove(item.key)
+			expired = append(expired, item.key)
+			continue
+		}
+
+		// 到期时间已经被修改,以新的记录为准
+		at := v.ExpireTime()
+		if at == nil || at.UnixNano() != item.at {
+			continue
+		}
+
+		sh.remove(item.key)
+		expired = append(expired, item.key)
+	}
+	return expired, false
+}
+
+// activeExpire 依次检测每个分片,清理到期的键,返回是否还有到期的键没有清理
+func (ks *keyspace) activeExpire() bool {
+	now := time.Now().UnixNano()
+	pending := false
+	for _, sh := range ks.shards {
+		expired, more := sh.expire(now, expireBatch)
+		pending = pending || more
+
+		// 回调在锁外执行,避免回调中操作缓存导致死锁
+		for _, k := range expired {
+			ks.evicted(k, EvictReasonExpired)
+		}
+	}
+	return pending
+}
+
+// setExpirePrecision 设置主动过期检测的间隔,小于等于0时使用 DefaultExpirePrecision
+func (ks *keyspace) setExpirePrecision(d time.Duration) {
+	if d <= 0 {
+		d = DefaultExpirePrecision
+	}
+	atomic.StoreInt64(&ks.expirePrecision, int64(d))
+}
+
+// activeExpireLoop 定时清理到期的键,上一次没有清理完时马上再检测一次,键空间关闭后退出
+func (ks *keyspace) activeExpireLoop() {
+	defer func() {
+		if obj := recover(); obj != nil {
+			log.Printf("[ActiveExpire] panic: %v, restart after %v", obj, expireRestartDelay)
+			go ks.restartActiveExpire()
+		}
+	}()
+
+	for {
+		if ks.activeExpire() {
+			select {
+			case <-ks.closed:
+				return
+			default:
+				continue
+			}
+		}
+
+		timer := time.NewTimer(time.Duration(atomic.LoadInt64(&ks.expirePrecision)))
+		select {
+		case <-ks.closed:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// restartActiveExpire 等待 expireRestartDelay 后重新启动主动过期检测,等待期间键空间关闭时直接退出
+func (ks *keyspace) restartActiveExpire() {
+	timer := time.NewTimer(expireRestartDelay)
+	select {
+	case <-ks.closed:
+		timer.Stop()
+		return
+	case <-timer.C:
+	}
+	ks.activeExpireLoop()
+}
+
+// close 停止主动过期检测
+func (ks *keyspace) close() {
+	ks.closeOnce.Do(func() {
+		close(ks.closed)
+	})
+}
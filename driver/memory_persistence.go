@@ -0,0 +1,674 @@
+package driver
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver/proto"
+
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/11 14:02
+  @describe :
+*/
+
+const (
+	// DefaultSnapshotInterval 默认生成快照的间隔
+	DefaultSnapshotInterval = time.Minute * 5
+
+	// persistenceVersion 持久化文件格式版本
+	persistenceVersion uint32 = 1
+
+	// persistenceSnapshotMagic 快照文件的魔数
+	persistenceSnapshotMagic = "JCACHESN"
+
+	// persistenceAofMagic 日志文件的魔数
+	persistenceAofMagic = "JCACHEAO"
+
+	// persistenceSnapshotName 快照文件名
+	persistenceSnapshotName = "jcache.snapshot"
+
+	// persistenceAofFormat 日志文件名格式,按代数区分
+	persistenceAofFormat = "jcache-%d.aof"
+
+	// persistenceMaxRecordSize 单条记录的最大长度,超过则认为文件已损坏
+	persistenceMaxRecordSize = 1 << 30
+)
+
+var (
+	// errPersistenceCorrupt 持久化文件已损坏
+	errPersistenceCorrupt = errors.New("persistence: file corrupt")
+)
+
+// PersistenceSync 日志写入磁盘的策略
+type PersistenceSync int
+
+const (
+	// PersistenceSyncEverySecond 每秒写入磁盘一次,默认策略
+	PersistenceSyncEverySecond PersistenceSync = iota
+
+	// PersistenceSyncAlways 每次写入日志都写入磁盘
+	PersistenceSyncAlways
+
+	// PersistenceSyncNo 由操作系统决定什么时候写入磁盘
+	PersistenceSyncNo
+)
+
+// PersistenceConfig 持久化配置
+// 持久化由定时生成的全量快照跟记录每次写操作的日志组成,启动时先加载快照,再重放快照之后的日志
+//
+// 快照文件格式: [魔数 8字节][版本 4字节][日志代数 8字节][生成时间 8字节] + 记录...
+// 日志文件格式: [魔数 8字节][版本 4字节][日志代数 8字节] + 记录...
+// 记录格式: [数据长度 4字节][crc32校验码 4字节][数据]
+type PersistenceConfig struct {
+	// Dir 持久化文件存放的目录
+	Dir string
+
+	// SnapshotInterval 生成快照的间隔,默认为 DefaultSnapshotInterval
+	SnapshotInterval time.Duration
+
+	// Sync 日志写入磁盘的策略,默认为 PersistenceSyncEverySecond
+	Sync PersistenceSync
+}
+
+// memoryPersistence 内存驱动的持久化
+type memoryPersistence struct {
+	cfg PersistenceConfig
+
+	// memory 内存驱动器
+	memory *Memory
+
+	// mutex 日志文件锁
+	mutex sync.Mutex
+
+	// snapshotMutex 防止同时生成多个快照
+	snapshotMutex sync.Mutex
+
+	// gen 当前写入的日志代数,每次生成快照后递增
+	gen uint64
+
+	// file 当前写入的日志文件
+	file *os.File
+
+	// writer 当前日志文件的缓冲
+	writer *bufio.Writer
+
+	// dirty 指示有数据还没有写入磁盘
+	dirty bool
+
+	// closed 持久化已经关闭
+	closed chan struct{}
+
+	closeOnce sync.Once
+
+	wg sync.WaitGroup
+}
+
+// newMemoryPersistence 返回内存驱动的持久化,并将磁盘中的数据恢复到内存驱动中
+func newMemoryPersistence(ctx context.Context, cfg PersistenceConfig, memory *Memory) (*memoryPersistence, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("persistence dir nil")
+	}
+
+	if cfg.SnapshotInterval <= 0 {
+		cfg.SnapshotInterval = DefaultSnapshotInterval
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	p := &memoryPersistence{
+		cfg:    cfg,
+		memory: memory,
+		closed: make(chan struct{}),
+	}
+
+	gen, err := p.recover()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.openAof(gen); err != nil {
+		return nil, err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	p.wg.Add(1)
+	go p.run(ctx)
+
+	return p, nil
+}
+
+// run 定时刷盘跟生成快照
+func (p *memoryPersistence) run(ctx context.Context) {
+	defer p.wg.Done()
+
+	syncTicker := time.NewTicker(time.Second)
+	defer syncTicker.Stop()
+
+	snapshotTicker := time.NewTicker(p.cfg.SnapshotInterval)
+	defer snapshotTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			go p.Close()
+			return
+		case <-p.closed:
+			return
+		case <-syncTicker.C:
+			if p.cfg.Sync == PersistenceSyncEverySecond {
+				if err := p.flush(true); err != nil {
+					log.Printf("[Persistence] sync aof failure. reason:[%v]", err)
+				}
+			}
+		case <-snapshotTicker.C:
+			if err := p.snapshot(); err != nil {
+				log.Printf("[Persistence] snapshot failure. reason:[%v]", err)
+			}
+		}
+	}
+}
+
+// append 将写操作追加到日志中
+func (p *memoryPersistence) append(action proto.Action, values ...string) {
+	payload, err := encodeAofRecord(time.Now(), &proto.SyncRequest{Action: action, Values: values})
+	if err != nil {
+		log.Printf("[Persistence] encode aof record failure. action:[%v], reason:[%v]", action, err)
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.writer == nil {
+		return
+	}
+
+	if err = writeRecord(p.writer, payload); err != nil {
+		log.Printf("[Persistence] write aof failure. action:[%v], reason:[%v]", action, err)
+		return
+	}
+	p.dirty = true
+
+	if p.cfg.Sync == PersistenceSyncAlways {
+		if err = p.flushLocked(true); err != nil {
+			log.Printf("[Persistence] sync aof failure. reason:[%v]", err)
+		}
+	}
+}
+
+// flush 将缓冲的日志写入文件,sync 为真时同时写入磁盘
+func (p *memoryPersistence) flush(sync bool) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.flushLocked(sync)
+}
+
+// flushLocked 同 flush,需要持有锁
+func (p *memoryPersistence) flushLocked(sync bool) error {
+	if p.writer == nil || !p.dirty {
+		return nil
+	}
+
+	if err := p.writer.Flush(); err != nil {
+		return err
+	}
+
+	if sync {
+		if err := p.file.Sync(); err != nil {
+			return err
+		}
+		p.dirty = false
+	}
+	return nil
+}
+
+// openAof 打开指定代数的日志文件,已存在的文件会在末尾继续追加
+func (p *memoryPersistence) openAof(gen uint64) error {
+	path := filepath.Join(p.cfg.Dir, fmt.Sprintf(persistenceAofFormat, gen))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if info.Size() == 0 {
+		if err = writeHeader(writer, persistenceAofMagic, gen); err == nil {
+			err = writer.Flush()
+		}
+		if err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.gen = gen
+	p.file = file
+	p.writer = writer
+	p.dirty = true
+	return nil
+}
+
+// rotate 关闭当前日志文件,并开始写入下一代日志,返回新的日志代数
+func (p *memoryPersistence) rotate() (uint64, error) {
+	p.mutex.Lock()
+	if err := p.flushLocked(true); err != nil {
+		p.mutex.Unlock()
+		return 0, err
+	}
+	gen := p.gen + 1
+	if p.file != nil {
+		p.file.Close()
+	}
+	p.file, p.writer = nil, nil
+	p.mutex.Unlock()
+
+	return gen, p.openAof(gen)
+}
+
+// snapshot 生成全量快照,完成后删除快照之前的日志
+func (p *memoryPersistence) snapshot() error {
+	p.snapshotMutex.Lock()
+	defer p.snapshotMutex.Unlock()
+
+	// 阻止新的写入,保证快照跟日志的切分点一致
+	p.memory.applyMutex.Lock()
+	entries := p.memory.entries()
+	gen, err := p.rotate()
+	p.memory.applyMutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err = writeSnapshot(p.cfg.Dir, gen, entries); err != nil {
+		return err
+	}
+
+	// 快照之前的日志已经没有用了
+	gens, err := listAofGens(p.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	for _, g := range gens {
+		if g < gen {
+			os.Remove(filepath.Join(p.cfg.Dir, fmt.Sprintf(persistenceAofFormat, g)))
+		}
+	}
+
+	log.Printf("[Persistence] snapshot completed. gen:[%d], keys:[%d]", gen, len(entries))
+	return nil
+}
+
+// recover 加载快照并重放之后的日志,返回需要继续写入的日志代数
+func (p *memoryPersistence) recover() (uint64, error) {
+	gen, entries, err := readSnapshot(filepath.Join(p.cfg.Dir, persistenceSnapshotName))
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	// 跳过已经过期的数据
+	now := time.Now()
+	valid := make([]*storeEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ExpireAt != nil && !entry.ExpireAt.After(now) {
+			continue
+		}
+		valid = append(valid, entry)
+	}
+	p.memory.loadEntries(valid)
+
+	gens, err := listAofGens(p.cfg.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	next := gen
+	for _, g := range gens {
+		// 快照之前的日志已经包含在快照中
+		if g < gen {
+			continue
+		}
+		cnt, err := p.replayAof(filepath.Join(p.cfg.Dir, fmt.Sprintf(persistenceAofFormat, g)))
+		if err != nil {
+			return 0, err
+		}
+		log.Printf("[Persistence] replay aof completed. gen:[%d], records:[%d]", g, cnt)
+		next = g
+	}
+
+	return next, nil
+}
+
+// replayAof 重放日志文件,末尾不完整的记录会被截掉
+func (p *memoryPersistence) replayAof(path string) (int, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if _, err = readHeader(reader, persistenceAofMagic); err != nil {
+		// 文件头都不完整,说明创建文件时就中断了,直接清空
+		log.Printf("[Persistence] aof header corrupt, truncate it. file:[%s], reason:[%v]", path, err)
+		return 0, file.Truncate(0)
+	}
+
+	offset := int64(len(persistenceAofMagic) + 4 + 8)
+	cnt := 0
+	for {
+		payload, n, err := readRecord(reader)
+		if err == io.EOF {
+			return cnt, nil
+		}
+		if err != nil {
+			log.Printf("[Persistence] aof tail corrupt, truncate it. file:[%s], offset:[%d], reason:[%v]", path, offset, err)
+			return cnt, file.Truncate(offset)
+		}
+
+		ts, req, err := decodeAofRecord(payload)
+		if err != nil {
+			log.Printf("[Persistence] aof record corrupt, truncate it. file:[%s], offset:[%d], reason:[%v]", path, offset, err)
+			return cnt, file.Truncate(offset)
+		}
+
+		// 执行失败的操作在写入日志前也是失败的,不影响结果
+		p.memory.applyAction(req.Action, rebaseAction(ts, req.Action, req.Values)...)
+		offset += n
+		cnt++
+	}
+}
+
+// Close 关闭持久化,将剩余的日志写入磁盘
+func (p *memoryPersistence) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		p.wg.Wait()
+
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		err = p.flushLocked(true)
+		if p.file != nil {
+			if e := p.file.Close(); err == nil {
+				err = e
+			}
+		}
+		p.file, p.writer = nil, nil
+	})
+	return err
+}
+
+// rebaseAction 将日志中相对的存活时间换算成重放时刻剩余的存活时间
+func rebaseAction(ts time.Time, action proto.Action, values []string) []string {
+	idx := -1
+	switch action {
+	case proto.Action_Expire, proto.Action_GetEx, proto.Action_HExpire:
+		idx = 1
+	case proto.Action_Set, proto.Action_SetNX, proto.Action_SetXX:
+		idx = 2
+	}
+
+	if idx < 0 || idx >= len(values) {
+		return values
+	}
+
+	i, err := strconv.ParseInt(values[idx], 10, 64)
+	if err != nil || i <= 0 {
+		return values
+	}
+
+	// 已经过期的设置成最短的存活时间,让其在重放后立即过期
+	remain := time.Duration(i) - time.Since(ts)
+	if remain <= 0 {
+		remain = 1
+	}
+
+	result := make([]string, len(values))
+	copy(result, values)
+	result[idx] = strconv.FormatInt(int64(remain), 10)
+	return result
+}
+
+// listAofGens 返回目录中所有日志文件的代数,从小到大排列
+func listAofGens(dir string) ([]uint64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	gens := make([]uint64, 0)
+	for _, f := range files {
+		var gen uint64
+		if f.IsDir() {
+			continue
+		}
+		if _, err := fmt.Sscanf(f.Name(), persistenceAofFormat, &gen); err != nil {
+			continue
+		}
+		if f.Name() != fmt.Sprintf(persistenceAofFormat, gen) {
+			continue
+		}
+		gens = append(gens, gen)
+	}
+	sort.Slice(gens, func(i, j int) bool { return gens[i] < gens[j] })
+	return gens, nil
+}
+
+// writeSnapshot 写入快照文件,先写入临时文件再替换,避免中途失败损坏已有的快照
+func writeSnapshot(dir string, gen uint64, entries []*storeEntry) error {
+	path := filepath.Join(dir, persistenceSnapshotName)
+	tmp := path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	writer := bufio.NewWriter(file)
+	err = writeHeader(writer, persistenceSnapshotMagic, gen)
+	if err == nil {
+		err = binary.Write(writer, binary.BigEndian, time.Now().UnixNano())
+	}
+
+	for i := 0; err == nil && i < len(entries); i++ {
+		var payload []byte
+		payload, err = protobuf.Marshal(entries[i].toProto())
+		if err == nil {
+			err = writeRecord(writer, payload)
+		}
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	// 确保文件替换的结果写入磁盘
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// readSnapshot 读取快照文件,返回快照对应的日志代数跟数据
+// 末尾不完整的记录会被忽略
+func readSnapshot(path string) (uint64, []*storeEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	gen, err := readHeader(reader, persistenceSnapshotMagic)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var createdAt int64
+	if err = binary.Read(reader, binary.BigEndian, &createdAt); err != nil {
+		return 0, nil, errPersistenceCorrupt
+	}
+
+	entries := make([]*storeEntry, 0)
+	for {
+		payload, _, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("[Persistence] snapshot tail corrupt, ignore it. file:[%s], keys:[%d], reason:[%v]", path, len(entries), err)
+			break
+		}
+
+		entry := new(proto.SnapshotEntry)
+		if err = protobuf.Unmarshal(payload, entry); err != nil {
+			log.Printf("[Persistence] snapshot record corrupt, ignore it. file:[%s], keys:[%d], reason:[%v]", path, len(entries), err)
+			break
+		}
+		entries = append(entries, newStoreEntryFromProto(entry))
+	}
+
+	return gen, entries, nil
+}
+
+// writeHeader 写入文件头
+func writeHeader(w io.Writer, magic string, gen uint64) error {
+	if _, err := io.WriteString(w, magic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, persistenceVersion); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, gen)
+}
+
+// readHeader 读取并校验文件头,返回日志代数
+func readHeader(r io.Reader, magic string) (uint64, error) {
+	buf := make([]byte, len(magic)+4+8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, errPersistenceCorrupt
+	}
+
+	if string(buf[:len(magic)]) != magic {
+		return 0, errPersistenceCorrupt
+	}
+
+	version := binary.BigEndian.Uint32(buf[len(magic):])
+	if version != persistenceVersion {
+		return 0, fmt.Errorf("persistence: unsupported version %d", version)
+	}
+
+	return binary.BigEndian.Uint64(buf[len(magic)+4:]), nil
+}
+
+// writeRecord 写入一条记录
+func writeRecord(w io.Writer, payload []byte) error {
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint32(head, uint32(len(payload)))
+	binary.BigEndian.PutUint32(head[4:], crc32.ChecksumIEEE(payload))
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readRecord 读取一条记录,返回记录数据跟记录总长度
+// 正好读到文件末尾时返回 io.EOF,记录不完整或者校验失败时返回 errPersistenceCorrupt
+func readRecord(r io.Reader) ([]byte, int64, error) {
+	head := make([]byte, 8)
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF {
+		return nil, 0, io.EOF
+	}
+	if err != nil || n != len(head) {
+		return nil, 0, errPersistenceCorrupt
+	}
+
+	size := binary.BigEndian.Uint32(head)
+	if size > persistenceMaxRecordSize {
+		return nil, 0, errPersistenceCorrupt
+	}
+
+	payload := make([]byte, size)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return nil, 0, errPersistenceCorrupt
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(head[4:]) {
+		return nil, 0, errPersistenceCorrupt
+	}
+
+	return payload, int64(len(head)) + int64(size), nil
+}
+
+// encodeAofRecord 编码日志记录,格式为 [写入时间 8字节] + SyncRequest
+func encodeAofRecord(ts time.Time, req *proto.SyncRequest) ([]byte, error) {
+	data, err := protobuf.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(payload, uint64(ts.UnixNano()))
+	copy(payload[8:], data)
+	return payload, nil
+}
+
+// decodeAofRecord 解码日志记录
+func decodeAofRecord(payload []byte) (time.Time, *proto.SyncRequest, error) {
+	if len(payload) < 8 {
+		return time.Time{}, nil, errPersistenceCorrupt
+	}
+
+	ts := time.Unix(0, int64(binary.BigEndian.Uint64(payload)))
+	req := new(proto.SyncRequest)
+	if err := protobuf.Unmarshal(payload[8:], req); err != nil {
+		return time.Time{}, nil, err
+	}
+	return ts, req, nil
+}
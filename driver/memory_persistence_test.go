@@ -0,0 +1,236 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver/proto"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/11 17:40
+  @describe :
+*/
+
+func newTestPersistenceMemory(t *testing.T, dir string) *Memory {
+	mem, err := NewMemoryWithConfig(MemoryConfig{Persistence: &PersistenceConfig{Dir: dir, SnapshotInterval: time.Hour}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mem.(*Memory)
+}
+
+func TestMemory_Persistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	mem := newTestPersistenceMemory(t, dir)
+	mem.Set(ctx, "string", "value", time.Hour)
+	mem.Set(ctx, "expired", "value", time.Millisecond)
+	mem.HSet(ctx, "hash", "f1", "v1", "f2", "v2")
+	mem.HSet(ctx, "session", "token", "t", "user", "u")
+	mem.HExpire(ctx, "session", time.Hour, "token")
+	mem.LPush(ctx, "list", "a", "b", "c")
+	mem.ZAdd(ctx, "zset", Z{Score: 1, Member: "a"}, Z{Score: 2, Member: "b"})
+	mem.SAdd(ctx, "set", "a", "b", "c")
+
+	// 快照之后的写入只存在于日志中
+	if err := mem.persistence.snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	mem.HDel(ctx, "hash", "f1")
+	mem.HExpire(ctx, "session", time.Millisecond, "user")
+	mem.HSet(ctx, "session", "counter", "1")
+	mem.HExpire(ctx, "session", time.Hour, "counter")
+	mem.HIncrByFloat(ctx, "session", "counter", 0.5)
+	mem.LPop(ctx, "list")
+	mem.RPush(ctx, "list", "d")
+	mem.LSet(ctx, "list", 0, "bb")
+	mem.LMove(ctx, "list", "moved", "RIGHT", "LEFT")
+	mem.ZIncrBy(ctx, "zset", 10, "a")
+	popped := mem.SPop(ctx, "set").Val()
+	mem.SUnionStore(ctx, "union", "set", "zset-missing")
+	mem.Set(ctx, "after", "snapshot", time.Hour)
+	mem.IncrBy(ctx, "counter", 3)
+	mem.IncrByFloat(ctx, "float", 0.1)
+	float := mem.IncrByFloat(ctx, "float", 0.2).Val()
+	mem.Append(ctx, "string", "-appended")
+	mem.MSet(ctx, "m1", "v1", "m2", "v2")
+	mem.GetDel(ctx, "m2")
+	if err := mem.persistence.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 10)
+	mem = newTestPersistenceMemory(t, dir)
+	defer mem.persistence.Close()
+
+	if got := mem.Get(ctx, "string").Val(); got != "value-appended" {
+		t.Errorf("Get(string) got = %v, want value-appended", got)
+	}
+	if ttl := mem.ss.value("string").ExpireTime(); ttl == nil || time.Until(*ttl) > time.Hour || time.Until(*ttl) < time.Minute*59 {
+		t.Errorf("string expire time got = %v", ttl)
+	}
+	if got := mem.Get(ctx, "after").Val(); got != "snapshot" {
+		t.Errorf("Get(after) got = %v, want snapshot", got)
+	}
+
+	// 计数器以计算后的结果写入日志,并保持原来的存活时长
+	if got := mem.Get(ctx, "counter").Val(); got != "3" {
+		t.Errorf("Get(counter) got = %v, want 3", got)
+	}
+	if got := mem.IncrByFloat(ctx, "float", 0).Val(); got != float {
+		t.Errorf("IncrByFloat(float) got = %v, want %v", got, float)
+	}
+	if got := mem.Get(ctx, "m1").Val(); got != "v1" {
+		t.Errorf("Get(m1) got = %v, want v1", got)
+	}
+	if got := mem.Exists(ctx, "m2").Val(); got != 0 {
+		t.Errorf("Exists(m2) got = %v, want 0", got)
+	}
+	if got := mem.Exists(ctx, "expired").Val(); got != 0 {
+		t.Errorf("Exists(expired) got = %v, want 0", got)
+	}
+	if got := mem.HGetAll(ctx, "hash").Val(); !reflect.DeepEqual(got, map[string]string{"f2": "v2"}) {
+		t.Errorf("HGetAll(hash) got = %v", got)
+	}
+
+	// 字段的到期时间分别从快照跟日志中恢复
+	if got := mem.HGetAll(ctx, "session").Val(); !reflect.DeepEqual(got, map[string]string{"token": "t", "counter": "1.5"}) {
+		t.Errorf("HGetAll(session) got = %v", got)
+	}
+	if got := mem.HTTL(ctx, "session", "token", "counter").Val(); !reflect.DeepEqual(got, []int64{3600, 3600}) {
+		t.Errorf("HTTL(session) got = %v, want [3600 3600]", got)
+	}
+	if got := mem.LRange(ctx, "list", 0, -1).Val(); !reflect.DeepEqual(got, []string{"bb", "a"}) {
+		t.Errorf("LRange(list) got = %v, want [bb a]", got)
+	}
+	if got := mem.LRange(ctx, "moved", 0, -1).Val(); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("LRange(moved) got = %v, want [d]", got)
+	}
+	if got := mem.LLen(ctx, "list").Val(); got != 2 {
+		t.Errorf("LLen(list) got = %v, want 2", got)
+	}
+	if got := mem.ZScore(ctx, "zset", "a").Val(); got != 11 {
+		t.Errorf("ZScore(zset, a) got = %v, want 11", got)
+	}
+
+	// 随机弹出的成员以移除的方式写入日志,重放后的结果跟弹出后一致
+	if got := mem.SCard(ctx, "set").Val(); got != 2 {
+		t.Errorf("SCard(set) got = %v, want 2", got)
+	}
+	if got := mem.SIsMember(ctx, "set", popped).Val(); got {
+		t.Errorf("SIsMember(set, %v) got = %v, want false", popped, got)
+	}
+	if got := mem.SCard(ctx, "union").Val(); got != 2 {
+		t.Errorf("SCard(union) got = %v, want 2", got)
+	}
+
+	// 快照之前的日志已经被删除
+	gens, err := listAofGens(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gens, []uint64{1}) {
+		t.Errorf("listAofGens() got = %v, want [1]", gens)
+	}
+}
+
+func TestMemory_Persistence_TruncatedTail(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	mem := newTestPersistenceMemory(t, dir)
+	for i := 0; i < 10; i++ {
+		mem.Set(ctx, fmt.Sprintf("key:%d", i), strconv.Itoa(i), time.Hour)
+	}
+	if err := mem.persistence.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写入最后一条记录时进程退出
+	path := filepath.Join(dir, fmt.Sprintf(persistenceAofFormat, 0))
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	mem = newTestPersistenceMemory(t, dir)
+	for i := 0; i < 9; i++ {
+		if got := mem.Get(ctx, fmt.Sprintf("key:%d", i)).Val(); got != strconv.Itoa(i) {
+			t.Errorf("Get(key:%d) got = %v, want %d", i, got, i)
+		}
+	}
+	if got := mem.Exists(ctx, "key:9").Val(); got != 0 {
+		t.Errorf("Exists(key:9) got = %v, want 0", got)
+	}
+
+	// 截掉损坏的记录后可以继续追加
+	mem.Set(ctx, "key:9", "9", time.Hour)
+	if err = mem.persistence.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mem = newTestPersistenceMemory(t, dir)
+	defer mem.persistence.Close()
+	if got := mem.Get(ctx, "key:9").Val(); got != "9" {
+		t.Errorf("Get(key:9) got = %v, want 9", got)
+	}
+}
+
+func Test_rebaseAction(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		ts     time.Time
+		action proto.Action
+		values []string
+		check  func(values []string) bool
+	}{
+		{
+			name:   "未过期",
+			ts:     now.Add(-time.Minute),
+			action: proto.Action_Set,
+			values: []string{"key", "value", strconv.FormatInt(int64(time.Hour), 10)},
+			check: func(values []string) bool {
+				i, _ := strconv.ParseInt(values[2], 10, 64)
+				return time.Duration(i) <= time.Minute*59 && time.Duration(i) > time.Minute*58
+			},
+		},
+		{
+			name:   "已过期",
+			ts:     now.Add(-time.Hour * 2),
+			action: proto.Action_Expire,
+			values: []string{"key", strconv.FormatInt(int64(time.Hour), 10)},
+			check: func(values []string) bool {
+				return values[1] == "1"
+			},
+		},
+		{
+			name:   "不需要换算",
+			ts:     now.Add(-time.Hour * 2),
+			action: proto.Action_HSet,
+			values: []string{"key", "field", "100"},
+			check: func(values []string) bool {
+				return values[2] == "100"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rebaseAction(tt.ts, tt.action, tt.values); !tt.check(got) {
+				t.Errorf("rebaseAction() got = %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package driver
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/9 21:37
+  @describe :
+*/
+
+const (
+	// DefaultSyncMaxLag 从节点最多可以落后的同步数据条数,超过后会被标记成过期并重新全量同步
+	DefaultSyncMaxLag = 1 << 14
+
+	// syncReplicaTimeout 单次同步到从节点的超时时间
+	syncReplicaTimeout = time.Second * 5
+
+	// syncReplicaMinRetry 同步失败后的最短重试间隔
+	syncReplicaMinRetry = time.Millisecond * 100
+
+	// syncReplicaMaxRetry 同步失败后的最长重试间隔
+	syncReplicaMaxRetry = time.Second * 10
+)
+
+// syncerReplica 从节点同步队列
+// 主节点的每条同步数据都按序号顺序放入队列,由单独的协程按顺序投递,失败时进行重试,
+// 投递成功后记录已确认的序号,当积压超过 maxLag 时标记成过期,并通知从节点重新进行全量同步
+type syncerReplica struct {
+	// endpoint 从节点终端
+	endpoint *syncerEndpoint
+
+	// mutex 队列锁
+	mutex sync.Mutex
+
+	// queue 待投递的同步数据
+	queue []*proto.SyncRequest
+
+	// ackSeq 从节点已确认的同步序号
+	ackSeq int64
+
+	// stale 指示从节点已经落后太多,需要重新全量同步
+	stale bool
+
+	// maxLag 最多可以积压的同步数据条数
+	maxLag int
+
+	// notify 有新数据需要投递
+	notify chan struct{}
+
+	// closed 队列已经关闭
+	closed chan struct{}
+
+	closeOnce sync.Once
+}
+
+// newSyncerReplica 返回一个新的从节点同步队列,并开始投递
+func newSyncerReplica(endpoint *syncerEndpoint, maxLag int) *syncerReplica {
+	if maxLag <= 0 {
+		maxLag = DefaultSyncMaxLag
+	}
+	r := &syncerReplica{
+		endpoint: endpoint,
+		queue:    make([]*proto.SyncRequest, 0),
+		maxLag:   maxLag,
+		notify:   make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// push 将同步数据放入队列
+func (r *syncerReplica) push(req *proto.SyncRequest) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// 已经过期的从节点会在全量同步中拿到这些数据,不需要再放入队列
+	if r.stale {
+		return
+	}
+
+	if len(r.queue) >= r.maxLag {
+		log.Printf("[Replica] slave is stale. target:[%s], ack:[%d], lag:[%d]", r.endpoint.ID(), r.ackSeq, len(r.queue))
+		r.markStale()
+		return
+	}
+
+	r.queue = append(r.queue, req)
+	r.wakeup()
+}
+
+// requestFullSync 丢弃积压的数据,通知从节点重新进行全量同步
+func (r *syncerReplica) requestFullSync() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.markStale()
+}
+
+// markStale 标记成过期,队列中只保留全量同步的通知,需要持有锁
+func (r *syncerReplica) markStale() {
+	r.stale = true
+	for i := range r.queue {
+		r.queue[i] = nil
+	}
+	r.queue = append(r.queue[:0], &proto.SyncRequest{Action: proto.Action_FullSync})
+	r.wakeup()
+}
+
+// wakeup 通知投递协程有新数据
+func (r *syncerReplica) wakeup() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// reset 从节点完成全量快照后重置队列,快照序号之前的数据已经包含在快照中
+func (r *syncerReplica) reset(seq int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.queue = r.queue[:0]
+	r.stale = false
+	r.ackSeq = seq
+}
+
+// AckSeq 返回从节点已确认的同步序号
+func (r *syncerReplica) AckSeq() int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.ackSeq
+}
+
+// IsStale 判断从节点是否已经过期
+func (r *syncerReplica) IsStale() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.stale
+}
+
+// Len 返回积压的同步数据条数
+func (r *syncerReplica) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.queue)
+}
+
+// head 返回队列中的第一条数据
+func (r *syncerReplica) head() *proto.SyncRequest {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.queue) == 0 {
+		return nil
+	}
+	return r.queue[0]
+}
+
+// ack 确认某条数据已经投递成功
+func (r *syncerReplica) ack(req *proto.SyncRequest) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// 期间队列可能被重置过了
+	if len(r.queue) == 0 || r.queue[0] != req {
+		return
+	}
+	r.queue[0] = nil
+	r.queue = r.queue[1:]
+	if req.Seq > r.ackSeq {
+		r.ackSeq = req.Seq
+	}
+}
+
+// run 按顺序投递队列中的数据
+func (r *syncerReplica) run() {
+	retry := syncReplicaMinRetry
+	for {
+		req := r.head()
+		if req == nil {
+			select {
+			case <-r.closed:
+				return
+			case <-r.notify:
+				continue
+			}
+		}
+
+		err := r.deliver(req)
+		if err == nil {
+			r.ack(req)
+			retry = syncReplicaMinRetry
+			continue
+		}
+
+		log.Printf("[Replica] sync to slave failure, retry after %s. target:[%s], seq:[%d], reason:[%v]", retry, r.endpoint.ID(), req.Seq, err)
+		select {
+		case <-r.closed:
+			return
+		case <-time.After(retry):
+		}
+
+		if retry *= 2; retry > syncReplicaMaxRetry {
+			retry = syncReplicaMaxRetry
+		}
+	}
+}
+
+// deliver 投递一条数据到从节点
+// 只有网络类的错误需要重试,从节点执行数据返回的错误跟主节点执行结果一致,视为投递成功
+func (r *syncerReplica) deliver(req *proto.SyncRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), syncReplicaTimeout)
+	defer cancel()
+
+	_, err := r.endpoint.cli.Slave(ctx, req)
+	if err == nil {
+		return nil
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.Unauthenticated, codes.ResourceExhausted:
+		return err
+	default:
+		return nil
+	}
+}
+
+// drain 等待积压的数据投递完成,从节点已经过期或者 ctx 结束时不再等待
+func (r *syncerReplica) drain(ctx context.Context) error {
+	ticker := time.NewTicker(time.Millisecond * 10)
+	defer ticker.Stop()
+	for {
+		r.mutex.Lock()
+		done := len(r.queue) == 0 || r.stale
+		r.mutex.Unlock()
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.closed:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close 关闭同步队列
+func (r *syncerReplica) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+	})
+}
@@ -4,20 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
 	"net"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jerbe/jcache/v2/driver/proto"
 
 	utils "github.com/jerbe/go-utils"
 
-	v3 "go.etcd.io/etcd/client/v3"
-	"go.etcd.io/etcd/client/v3/concurrency"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -33,10 +31,16 @@ import (
 
 const (
 	etcdPrefix = "/jcache"
+
+	// snapshotBatchSize 全量同步时每批发送的键数量
+	snapshotBatchSize = 128
 )
 
 var (
 	_ proto.SyncerServer = new(syncerServer)
+
+	// errNoMasterEndpoint 还没有确定主节点
+	errNoMasterEndpoint = errors.New("master endpoint not found")
 )
 
 type syncerServer struct {
@@ -60,114 +64,8 @@ func (s *syncerServer) sync(ctx context.Context, in *proto.SyncRequest) (*proto.
 		return nil, errors.New("syncerServer: syncer.memory is nil")
 	}
 
-	var err error
-	switch in.Action {
-	case proto.Action_Del:
-		val := memory.del(context.Background(), in.Values...)
-		data, _ := marshalData(val)
-		rsp.Value = append(rsp.Value, data)
-	case proto.Action_Expire:
-		var i int64
-		i, err = strconv.ParseInt(in.Values[1], 10, 64)
-		if err == nil {
-			var b bool
-			b, err = memory.expire(context.Background(), in.Values[0], time.Duration(i))
-			if b {
-				rsp.Value = append(rsp.Value, "1")
-			}
-		}
-	case proto.Action_ExpireAt:
-		var t time.Time
-		t, err = time.Parse(time.RFC3339Nano, in.Values[1])
-		if err == nil {
-			var b bool
-			b, err = memory.expireAt(context.Background(), in.Values[0], &t)
-			if b {
-				rsp.Value = append(rsp.Value, "1")
-			}
-		}
-	case proto.Action_Persist:
-		var b bool
-		b, err = memory.persist(context.Background(), in.Values[0])
-		if b {
-			rsp.Value = append(rsp.Value, "1")
-		}
-	case proto.Action_Set:
-		var i int64
-		i, err = strconv.ParseInt(in.Values[2], 10, 64)
-		if err == nil {
-			err = memory.set(context.Background(), in.Values[0], in.Values[1], time.Duration(i))
-			if err == nil {
-				rsp.Value = append(rsp.Value, "OK")
-			}
-		}
-	case proto.Action_SetNX:
-		var i int64
-		i, err = strconv.ParseInt(in.Values[2], 10, 64)
-		if err == nil {
-			var b bool
-			b, err = memory.setNX(context.Background(), in.Values[0], in.Values[1], time.Duration(i))
-			if b {
-				rsp.Value = append(rsp.Value, "1")
-			}
-		}
-	case proto.Action_HDel:
-		var i int64
-		i, err = memory.hDel(context.Background(), in.Values[0], in.Values[1:]...)
-		if err == nil {
-			data, _ := marshalData(i)
-			rsp.Value = append(rsp.Value, data)
-		}
-	case proto.Action_HSet:
-		var i int64
-		i, err = memory.hSet(context.Background(), in.Values[0], in.Values[1:]...)
-		if err == nil {
-			data, _ := marshalData(i)
-			rsp.Value = append(rsp.Value, data)
-		}
-	case proto.Action_HSetNx:
-		var b bool
-		b, err = memory.hSetNX(context.Background(), in.Values[0], in.Values[1], in.Values[2])
-		if b {
-			rsp.Value = append(rsp.Value, "1")
-		}
-	case proto.Action_LPush:
-		var i int64
-		i, err = memory.lPush(context.Background(), in.Values[0], in.Values[1:]...)
-		if err == nil {
-			data, _ := marshalData(i)
-			rsp.Value = append(rsp.Value, data)
-		}
-	case proto.Action_LPop:
-		var v string
-		v, err = memory.lPop(context.Background(), in.Values[0])
-		if err == nil {
-			rsp.Value = append(rsp.Value, v)
-		}
-	case proto.Action_LShift:
-		var v string
-		v, err = memory.lShift(context.Background(), in.Values[0])
-		if err == nil {
-			rsp.Value = append(rsp.Value, v)
-		}
-	case proto.Action_LTrim:
-		var start, stop int64
-		start, err = strconv.ParseInt(in.Values[1], 10, 64)
-		stop, err = strconv.ParseInt(in.Values[2], 10, 64)
-		err = memory.lTrim(context.Background(), in.Values[0], start, stop)
-		if err == nil {
-			rsp.Value = append(rsp.Value, "OK")
-		}
-	case proto.Action_LBPop:
-		i, _ := strconv.ParseInt(in.Values[0], 10, 64)
-		var v []string
-		v, err = memory.lBPop(context.Background(), time.Duration(i), in.Values...)
-		if err == nil {
-			rsp.Value = v
-		}
-	default:
-		err = errors.New("unknown action")
-	}
+	values, err := memory.applyAction(in.Action, in.Values...)
+	rsp.Value = values
 
 	if err != nil {
 		var statusCode codes.Code
@@ -194,7 +92,42 @@ func (s *syncerServer) Slave(ctx context.Context, in *proto.SyncRequest) (*proto
 		err := errors.New("is master")
 		return rsp, err
 	}
+
+	// 主节点通知需要重新全量同步
+	if in.Action == proto.Action_FullSync {
+		go s.syncer.tryFullSync(s.syncer.ctx)
+		rsp = new(proto.SyncResponse)
+		rsp.Value = append(rsp.Value, "")
+		return rsp, nil
+	}
+
+	// 正在进行全量同步或者数据已经执行过,则不需要立即执行
+	if s.syncer.deferSync(in) {
+		rsp = new(proto.SyncResponse)
+		rsp.Value = append(rsp.Value, "")
+		return rsp, nil
+	}
+
+	rsp, err := s.apply(ctx, in)
+	s.syncer.ackSync(in)
+	return rsp, err
+}
+
+// apply 从节点执行同步数据,执行成功后写入持久化日志
+func (s *syncerServer) apply(ctx context.Context, in *proto.SyncRequest) (*proto.SyncResponse, error) {
+	memory := s.syncer.memory
+	if memory == nil {
+		return s.sync(ctx, in)
+	}
+
+	// 执行跟写入日志的过程中不能生成快照
+	unlock := memory.lockApply()
+	defer unlock()
+
 	rsp, err := s.sync(ctx, in)
+	if err == nil && memory.persistence != nil {
+		memory.persistence.append(in.Action, in.Values...)
+	}
 	return rsp, err
 }
 
@@ -207,14 +140,124 @@ func (s *syncerServer) Master(ctx context.Context, in *proto.SyncRequest) (*prot
 		return rsp, err
 	}
 
+	if s.syncer.memory == nil {
+		rsp = new(proto.SyncResponse)
+		return rsp, errors.New("syncerServer: syncer.memory is nil")
+	}
+
+	// 阻塞动作不能在等待期间持有锁,否则会阻止快照以及其他写入
+	// 阻塞动作在每次尝试时自行加锁,并在同一次加锁中同步到从节点
+	if actionBlocks(in.Action) {
+		return s.sync(ctx, in)
+	}
+
+	// 执行跟同步到从节点的过程中不能生成快照
+	unlock := s.syncer.memory.lockApply()
+	defer unlock()
+
+	// 字段的存活时长换算成到期时间再执行,各个节点以相同的到期时间同步
+	if in.Action == proto.Action_HExpire && len(in.Values) > 1 {
+		i, err := strconv.ParseInt(in.Values[1], 10, 64)
+		if err != nil {
+			return new(proto.SyncResponse), err
+		}
+		values := make([]string, len(in.Values))
+		copy(values, in.Values)
+		values[1] = strconv.FormatInt(time.Now().Add(time.Duration(i)).UnixNano(), 10)
+		in = &proto.SyncRequest{Action: proto.Action_HExpireAt, Values: values}
+	}
+
+	// 会写入数据的动作需要先检测容量
+	if actionGrows(in.Action) && len(in.Values) > 0 {
+		keys := in.Values[:1]
+		if in.Action == proto.Action_MSet || in.Action == proto.Action_MSetNX {
+			keys = pairKeys(in.Values)
+		}
+		if in.Action == proto.Action_LMove {
+			keys = in.Values[1:2]
+		}
+		if err := s.syncer.memory.ensureCapacity(keys...); err != nil {
+			return new(proto.SyncResponse), status.New(codes.ResourceExhausted, err.Error()).Err()
+		}
+	}
+
 	rsp, err := s.sync(ctx, in)
-	// 如果是服务端接收到同步数据,需要同步到其他从节点
+	// 如果是服务端接收到同步数据,需要写入持久化日志并同步到其他从节点
 	if err == nil && s.syncer.isMaster {
-		s.syncer.syncToSlaves(in.Action, in.Values...)
+		s.replicate(in, rsp)
 	}
 	return rsp, err
 }
 
+// replicate 把主节点执行成功的数据写入持久化日志并同步到其他从节点
+// 结果不确定的动作需要先转换成可以在各个节点重复执行的动作
+func (s *syncerServer) replicate(in *proto.SyncRequest, rsp *proto.SyncResponse) {
+	action, values := in.Action, in.Values
+
+	// 浮点数运算在不同节点上可能有误差,同步计算结果
+	if action == proto.Action_IncrByFloat {
+		ttl, _ := marshalData(KeepTTL)
+		action, values = proto.Action_Set, []string{in.Values[0], rsp.Value[0], ttl}
+	}
+
+	if action == proto.Action_HIncrByFloat {
+		action, values = proto.Action_HSetKeepTTL, []string{in.Values[0], in.Values[1], rsp.Value[0]}
+	}
+
+	// 参数为 [key, flag, member, increment]
+	if action == proto.Action_ZAddIncr {
+		action, values = proto.Action_ZAdd, []string{in.Values[0], in.Values[2], rsp.Value[0]}
+	}
+
+	// 字段已经存在时没有写入,不需要同步
+	if action == proto.Action_HSetNx && rsp.Value[0] != "1" {
+		return
+	}
+
+	// 取出并删除只需要同步删除
+	if action == proto.Action_GetDel {
+		action, values = proto.Action_Del, in.Values[:1]
+	}
+
+	// 随机弹出的成员只能以移除的方式同步
+	if action == proto.Action_SPop {
+		action, values = proto.Action_SRem, append([]string{in.Values[0]}, rsp.Value...)
+	}
+	s.syncer.memory.syncToSlave(action, values...)
+}
+
+// Snapshot 主节点生成全量数据快照,并分批返回给请求的从节点
+func (s *syncerServer) Snapshot(in *proto.SnapshotRequest, stream proto.Syncer_SnapshotServer) error {
+	if !s.syncer.isMaster {
+		return errors.New("not master")
+	}
+
+	if s.syncer.memory == nil {
+		return errors.New("syncerServer: syncer.memory is nil")
+	}
+
+	seq, entries := s.syncer.snapshot(in.Id)
+	log.Printf("[Snapshot] local:[%s], target:[%s], seq:[%d], keys:[%d]", s.syncer.nodeID, in.Id, seq, len(entries))
+
+	rsp := &proto.SnapshotResponse{Seq: seq}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, entry.toProto())
+		if len(rsp.Entries) < snapshotBatchSize {
+			continue
+		}
+		if err := stream.Send(rsp); err != nil {
+			return err
+		}
+		rsp = &proto.SnapshotResponse{Seq: seq}
+	}
+
+	// 剩余的数据,或者没有任何数据时也需要告知快照序号
+	if len(rsp.Entries) > 0 || len(entries) == 0 {
+		return stream.Send(rsp)
+	}
+	return nil
+}
+
 // grpcAuthUnaryInterceptor 认证凭证
 func grpcAuthUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 	server, ok := info.Server.(*syncerServer)
@@ -222,24 +265,47 @@ func grpcAuthUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.U
 		return handler(ctx, req)
 	}
 
-	if server.syncer.username == "" && server.syncer.password == "" {
-		return handler(ctx, req)
+	if err := server.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// grpcAuthStreamInterceptor 流式接口的认证凭证
+func grpcAuthStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	server, ok := srv.(*syncerServer)
+	if !ok {
+		return handler(srv, ss)
+	}
+
+	if err := server.authenticate(ss.Context()); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// authenticate 校验请求中的用户名跟密码
+func (s *syncerServer) authenticate(ctx context.Context) error {
+	if s.syncer.username == "" && s.syncer.password == "" {
+		return nil
 	}
 
-	m, ok := metadata.FromIncomingContext(ctx)
-	if server.syncer.username != "" {
-		if val, ok := m["username"]; !ok || (len(val) == 0 || (val[0] != server.syncer.username)) {
-			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	m, _ := metadata.FromIncomingContext(ctx)
+	if s.syncer.username != "" {
+		if val, ok := m["username"]; !ok || (len(val) == 0 || (val[0] != s.syncer.username)) {
+			return status.Error(codes.Unauthenticated, "unauthenticated")
 		}
 	}
 
-	if server.syncer.password != "" {
-		if val, ok := m["password"]; !ok || (len(val) == 0 || (val[0] != server.syncer.password)) {
-			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	if s.syncer.password != "" {
+		if val, ok := m["password"]; !ok || (len(val) == 0 || (val[0] != s.syncer.password)) {
+			return status.Error(codes.Unauthenticated, "unauthenticated")
 		}
 	}
 
-	return handler(ctx, req)
+	return nil
 }
 
 type syncerEndpointCredential struct {
@@ -264,6 +330,9 @@ type syncerEndpoint struct {
 	cli      proto.SyncerClient
 	conn     *grpc.ClientConn
 	isMaster bool
+
+	// replica 同步到该终端的有序队列
+	replica *syncerReplica
 }
 
 // ID 获取终端的ID
@@ -292,6 +361,9 @@ func (e *syncerEndpoint) Password() string {
 
 // Close 关闭终端
 func (e *syncerEndpoint) Close() error {
+	if e.replica != nil {
+		e.replica.Close()
+	}
 	return e.conn.Close()
 }
 
@@ -307,6 +379,9 @@ type syncerEndpointOptions struct {
 
 	// Password 鉴权密码
 	Password string
+
+	// MaxLag 同步队列最多可以积压的数据条数
+	MaxLag int
 }
 
 // newSyncerEndpoint 返回新终端
@@ -334,6 +409,7 @@ GRPC_CLI:
 		conn:     conn,
 		isMaster: false,
 	}
+	endpoint.replica = newSyncerReplica(endpoint, opt.MaxLag)
 
 	return endpoint, nil
 }
@@ -349,29 +425,25 @@ type memorySyncer struct {
 	// password 鉴权密码
 	password string
 
-	// serverID 当前服务ID ["主机名/随机字符串"], 如 "mypc/a8bc8def8a98z232"
-	serverID string
-
 	// grpcSvr grpc服务实例
-	grpcSvr proto.SyncerServer
+	grpcSvr *syncerServer
 
-	// etcdCli 连接etcd的客户端
-	etcdCli *v3.Client
+	// server grpc服务器
+	server *grpc.Server
 
-	// etcdServerPrefix etcd服务发现用的前缀, [jcache/[自定义前缀]/server] 如 /jcache/mycache/server
-	etcdServerPrefix string
+	// cluster 集群成员发现跟选主
+	cluster Cluster
 
-	// etcdElectionPrefix etcd选举用的前缀
-	etcdElectionPrefix string
+	// nodeID 当前节点在集群中的ID,由 cluster 分配
+	nodeID string
 
-	// etcdServerID 用于etcd服务发现的服务器ID,
-	// 为 [etcdServerPrefix]/[serverID] 如 "/jcache/mycache/server/mypc/a8bc8def8a98z232"
-	etcdServerID string
+	// leaderID 当前主节点的ID
+	leaderID string
 
 	// isMaster 指示当前节点是否是主节点
 	isMaster bool
 
-	// slaveEndpoints 从节点终端, 以各节点的 etcdServerID 为键
+	// slaveEndpoints 从节点终端, 以各节点的 nodeID 为键
 	slaveEndpoints map[string]*syncerEndpoint
 
 	// masterEndpoint 主节点终端，该终端节点不会出现在 slaveEndpoints 中
@@ -385,17 +457,40 @@ type memorySyncer struct {
 
 	// isClosed 指示该节点已经被关闭
 	isClosed bool
+
+	// seq 主节点的同步序号,每次同步到从节点时递增
+	seq int64
+
+	// syncMutex 全量同步状态锁
+	syncMutex sync.Mutex
+
+	// syncing 指示当前节点正在从主节点进行全量同步
+	syncing bool
+
+	// resync 指示全量同步期间又收到了全量同步的通知,完成后需要再同步一次
+	resync bool
+
+	// pending 全量同步期间收到的增量数据,待快照加载完成后再执行
+	pending []*proto.SyncRequest
+
+	// appliedSeq 从节点已执行的同步序号,小于等于该序号的增量数据已经执行过或者包含在快照中
+	appliedSeq int64
+
+	// maxLag 从节点同步队列最多可以积压的数据条数
+	maxLag int
+
+	// ctx 同步器的上下文
+	ctx context.Context
+
+	// cancel 取消同步器的上下文,停止同步器中运行的协程
+	cancel context.CancelFunc
 }
 
 // newMemorySyncer 初始化一个内存同步器
-func newMemorySyncer(cfg *MemoryConfig) (*memorySyncer, error) {
-	prefix := strings.TrimPrefix(cfg.Prefix, "/")
+// 加入集群前就需要绑定内存驱动,加入集群后可能立即需要生成快照或者进行全量同步
+func newMemorySyncer(cfg *MemoryConfig, memory *Memory) (*memorySyncer, error) {
 	port := cfg.Port
 
-	if prefix == "" {
-		return nil, errors.New("prefix nil")
-	}
-
 	if port <= 0 {
 		return nil, errors.New("listen port zero")
 	}
@@ -407,32 +502,51 @@ func newMemorySyncer(cfg *MemoryConfig) (*memorySyncer, error) {
 		return nil, errors.New("password not set")
 	}
 
-	//grpc.
-	etcdCli, err := v3.New(cfg.EtcdConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	if cfg.EtcdConfig.DialTimeout > 0 {
-		ctx := cfg.Context
-		if ctx == nil {
-			ctx = context.Background()
-		}
-		timeOutCtx, cancel := context.WithTimeout(ctx, cfg.EtcdConfig.DialTimeout)
-		_, err := etcdCli.Status(timeOutCtx, cfg.EtcdConfig.Endpoints[0])
-		cancel()
+	// 未指定集群时使用ETCD集群
+	cluster := cfg.Cluster
+	if cluster == nil {
+		var err error
+		cluster, err = NewEtcdCluster(cfg.EtcdConfig, cfg.Prefix)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	// 初始化同步服务器
+	var ctx context.Context
+	if cfg.Context == nil {
+		ctx = context.TODO()
+	} else {
+		ctx = cfg.Context
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	syncer := &memorySyncer{
+		ctx:            ctx,
+		port:           port,
+		username:       username,
+		password:       password,
+		cluster:        cluster,
+		nodeID:         cluster.ID(),
+		slaveEndpoints: make(map[string]*syncerEndpoint),
+		maxLag:         cfg.SyncMaxLag,
+		cancel:         cancel,
+	}
+	syncer.setMemory(memory)
+
+	// 初始化同步服务器,服务启动前需要先绑定同步器,避免收到请求时同步器为空
 	listen, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
+		cancel()
 		return nil, err
 	}
-	grpcSvr := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcAuthUnaryInterceptor))
-	svr := &syncerServer{}
+	grpcSvr := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcAuthUnaryInterceptor),
+		grpc.ChainStreamInterceptor(grpcAuthStreamInterceptor),
+	)
+	svr := &syncerServer{syncer: syncer}
+	syncer.grpcSvr = svr
+	syncer.server = grpcSvr
 	proto.RegisterSyncerServer(grpcSvr, svr)
 
 	// 运行同步服务器
@@ -454,37 +568,10 @@ func newMemorySyncer(cfg *MemoryConfig) (*memorySyncer, error) {
 
 	err = runGrpcSvr()
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	serverID := fmt.Sprintf("%s/%s", utils.Hostname(), strconv.FormatInt(rand.Int63(), 16))
-	serverPrefix := fmt.Sprintf("%s/%s/server", etcdPrefix, prefix)
-	electionPrefix := fmt.Sprintf("%s/%s/election", etcdPrefix, prefix)
-
-	syncer := &memorySyncer{
-		port:               port,
-		username:           username,
-		password:           password,
-		serverID:           serverID,
-		grpcSvr:            svr,
-		etcdCli:            etcdCli,
-		etcdServerPrefix:   serverPrefix,
-		etcdElectionPrefix: electionPrefix,
-		etcdServerID:       fmt.Sprintf("%s/%s", serverPrefix, serverID),
-		slaveEndpoints:     make(map[string]*syncerEndpoint),
-	}
-
-	svr.syncer = syncer
-
-	var ctx context.Context
-	if cfg.Context == nil {
-		ctx = context.TODO()
-	} else {
-		ctx = cfg.Context
-	}
-
-	ctx, cancel := context.WithCancel(ctx)
 	defer func() {
 		if err != nil {
 			cancel()
@@ -501,20 +588,8 @@ func newMemorySyncer(cfg *MemoryConfig) (*memorySyncer, error) {
 		}
 	}()
 
-	// 进行etcd注册
-	err = syncer.register(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	// 进行etcd监听
-	err = syncer.watchingEndpoints(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	// 进行选主监控
-	err = syncer.election(ctx)
+	// 加入集群,集群的节点变化跟选主结果通过事件通知
+	err = cluster.Join(ctx, fmt.Sprintf("%s:%d", utils.LocalIPv4(), port), syncer.onClusterEvent)
 	if err != nil {
 		return nil, err
 	}
@@ -522,297 +597,178 @@ func newMemorySyncer(cfg *MemoryConfig) (*memorySyncer, error) {
 	return syncer, nil
 }
 
-// tryElection 尝试进行选举,当异常退出选举时有用
-func (s *memorySyncer) tryElection(ctx context.Context) {
-	var err error
-	err = s.election(ctx)
-	for err != nil {
-		log.Println("*memorySyncer.tryElection")
-		if utils.ContextIsDone(ctx) != nil {
-			return
-		}
-
-		time.Sleep(time.Second * 5)
-		err = s.election(ctx)
-		if err == nil {
-			return
-		}
+// onClusterEvent 处理集群事件
+func (s *memorySyncer) onClusterEvent(event ClusterEvent) {
+	log.Printf("[Cluster] local:[%s], type:[%v], id:[%s], addr:[%s]", s.nodeID, event.Type, event.ID, event.Addr)
+	switch event.Type {
+	case ClusterEventPut:
+		s.addEndpoint(event.ID, event.Addr)
+	case ClusterEventDelete:
+		s.removeEndpoint(event.ID)
+	case ClusterEventLeader:
+		s.changeLeader(event.ID)
 	}
 }
 
-// election 选举
-func (s *memorySyncer) election(ctx context.Context) error {
-	session, err := concurrency.NewSession(s.etcdCli, concurrency.WithTTL(10))
-	if err != nil {
-		return err
+// addEndpoint 添加节点终端
+func (s *memorySyncer) addEndpoint(id, addr string) {
+	// 如果是本机,则跳过
+	if id == s.nodeID || id == "" {
+		return
 	}
-	election := concurrency.NewElection(session, s.etcdElectionPrefix)
 
-	go func() {
-		var r bool
-		observerCh := election.Observe(ctx)
-		defer func() {
-			if o := recover(); o != nil {
-				log.Printf("election is panic. reason:[%v]", o)
-			}
-			session.Close()
-			if r && ctx.Err() == nil {
-				log.Printf("election was exit, but not closed, register again.  Local:[%s]", s.etcdServerID)
-				go s.tryElection(ctx)
-			}
-		}()
+	opt := syncerEndpointOptions{
+		ID:       id,
+		Target:   addr,
+		Username: s.username,
+		Password: s.password,
+		MaxLag:   s.maxLag,
+	}
+	endpoint, err := newSyncerEndpoint(opt)
+	if err != nil {
+		log.Printf("new endpoint error. target:[%s]. reason:[%v]", addr, err)
+		return
+	}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-session.Done():
-				r = !s.isClosed
-				return
-			case rsp, ok := <-observerCh:
-				if ok {
-					v := string(rsp.Kvs[0].Value)
-					s.rwMutex.Lock()
-
-					// 如果新主终端在目标终端列表中,则需要在列表中删除
-					// 并且需要设置主终端
-					if e, ok := s.slaveEndpoints[v]; ok {
-						delete(s.slaveEndpoints, v)
-
-						// 如果该key不是本地节点的key,则设置成主节点
-						if s.etcdServerID != v {
-							s.masterEndpoint = e
-						}
-					}
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
 
-					if v == s.etcdServerID {
-						s.isMaster = true
-					}
-					s.rwMutex.Unlock()
-				} else {
-					r = !s.isClosed
-					return
-				}
-			}
+	// 主节点的信息晚于选主结果到达
+	if s.leaderID == id {
+		if s.masterEndpoint != nil {
+			s.masterEndpoint.Close()
 		}
-	}()
-
-	errCh := make(chan error)
-	go func() {
-		e := election.Campaign(ctx, s.etcdServerID)
-		defer func() {
-			close(errCh)
-		}()
-		if e != nil {
-			errCh <- e
-			session.Close()
-			return
+		endpoint.isMaster = true
+		s.masterEndpoint = endpoint
+		if !s.isMaster {
+			go s.tryFullSync(s.ctx)
 		}
+		return
+	}
 
-		s.isMaster = true
-		log.Printf("[Leader] 成为主节点. local:[%s]", s.etcdServerID)
-	}()
+	if old, ok := s.slaveEndpoints[id]; ok {
+		old.Close()
+	}
+	s.slaveEndpoints[id] = endpoint
 
-	time.Sleep(time.Second)
-	select {
-	case err = <-errCh:
-		return err
-	default:
-		return nil
+	// 新加入的从节点可能错过了快照之后的增量数据,通知其重新全量同步
+	if s.isMaster {
+		endpoint.replica.requestFullSync()
 	}
 }
 
-// tryRegister 尝试注册服务,当异常退出时有用
-func (s *memorySyncer) tryRegister(ctx context.Context) {
-	var err error
-	err = s.register(ctx)
-	for err != nil {
-		log.Println("*memorySyncer.tryRegister")
-		if utils.ContextIsDone(ctx) != nil {
-			return
-		}
+// removeEndpoint 移除节点终端
+func (s *memorySyncer) removeEndpoint(id string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
 
-		time.Sleep(time.Second * 5)
-		err = s.register(ctx)
-		if err == nil {
-			return
-		}
+	if endpoint, ok := s.slaveEndpoints[id]; ok {
+		func() {
+			defer func() {
+				if obj := recover(); obj != nil {
+					log.Printf("close conn has fail. reason:[%v]", obj)
+				}
+			}()
+			endpoint.Close()
+		}()
 	}
-}
 
-// register 注册服务
-func (s *memorySyncer) register(ctx context.Context) error {
-	lease, err := s.etcdCli.Grant(ctx, 10)
-	if err != nil {
-		return err
-	}
+	delete(s.slaveEndpoints, id)
 
-	kv := v3.NewKV(s.etcdCli)
-	_, err = kv.Put(ctx, s.etcdServerID, fmt.Sprintf("%s:%d", utils.LocalIPv4(), s.port), v3.WithLease(lease.ID))
-	if err != nil {
-		return err
+	// 移除先前主节点,等待选主完成
+	if s.masterEndpoint != nil && s.masterEndpoint.ID() == id {
+		s.masterEndpoint.Close()
+		s.masterEndpoint = nil
 	}
 
-	var aliveResp <-chan *v3.LeaseKeepAliveResponse
-	aliveResp, err = s.etcdCli.KeepAlive(ctx, lease.ID)
-	if err != nil {
-		return err
+	// 节点断开以后,肯定不能成为主节点
+	if id == s.nodeID {
+		s.isMaster = false
 	}
-
-	go func(alive <-chan *v3.LeaseKeepAliveResponse) {
-		var r bool
-		defer func() {
-			if o := recover(); o != nil {
-				log.Printf("election is panic. reason:[%v]", o)
-			}
-			if r && ctx.Err() == nil {
-				log.Printf("keepalive chan was close, but not closed, register again.  Local:[%s]", s.etcdServerID)
-				go s.tryRegister(ctx)
-			}
-		}()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case _, ok := <-alive:
-				if !ok {
-					r = !s.isClosed
-					return
-				}
-			}
-		}
-	}(aliveResp)
-
-	return nil
 }
 
-// watchingEndpoints 监控终端
-func (s *memorySyncer) watchingEndpoints(ctx context.Context) (err error) {
-	var response *v3.GetResponse
-	response, err = s.etcdCli.Get(ctx, s.etcdServerPrefix, v3.WithPrefix())
-	if err != nil {
-		return err
+// changeLeader 主节点发生变化
+func (s *memorySyncer) changeLeader(id string) {
+	s.rwMutex.Lock()
+	if id == s.leaderID {
+		s.rwMutex.Unlock()
+		return
 	}
+	s.leaderID = id
 
-	if response.Count > 0 {
-		s.rwMutex.Lock()
-		for _, kv := range response.Kvs {
-			k := string(kv.Key)
-			v := string(kv.Value)
-
-			if k == s.etcdServerID {
-				continue
-			}
-
-			opt := syncerEndpointOptions{
-				ID:       k,
-				Target:   v,
-				Username: s.username,
-				Password: s.password,
-			}
-			endpoint, err := newSyncerEndpoint(opt)
-			if err == nil {
-				s.slaveEndpoints[k] = endpoint
-			}
-		}
-		s.rwMutex.Unlock()
+	// 先前的主节点变成从节点
+	if s.masterEndpoint != nil {
+		s.masterEndpoint.isMaster = false
+		s.slaveEndpoints[s.masterEndpoint.ID()] = s.masterEndpoint
+		s.masterEndpoint = nil
 	}
 
-	watchCh := s.etcdCli.Watch(ctx, s.etcdServerPrefix, v3.WithPrefix())
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case w, ok := <-watchCh:
-				if !ok {
-					log.Println("syncer watchingEndpoints chan was closed")
-					return
-				}
-				s.rwMutex.Lock()
-				for _, event := range w.Events {
-					k := string(event.Kv.Key)
-					v := string(event.Kv.Value)
-					log.Printf("[Event] local:[%s], type:[%v], key:[%s], value:[%s]", s.etcdServerID, event.Type, k, v)
-					if event.Type == v3.EventTypePut {
-						// 如果Key是本机,则跳过
-						if k == s.etcdServerID {
-							continue
-						}
-						opt := syncerEndpointOptions{
-							ID:       k,
-							Target:   v,
-							Username: s.username,
-							Password: s.password,
-						}
-						endpoint, err := newSyncerEndpoint(opt)
-						if err != nil {
-							log.Printf("new endpoint error. target:[%s]. reason:[%v]", v, err)
-							continue
-						}
-						s.slaveEndpoints[k] = endpoint
-					}
+	// 如果新主终端在目标终端列表中,则需要在列表中删除
+	// 并且需要设置主终端
+	if e, ok := s.slaveEndpoints[id]; ok {
+		delete(s.slaveEndpoints, id)
+		e.isMaster = true
+		s.masterEndpoint = e
+	}
 
-					if event.Type == v3.EventTypeDelete {
-						if endpoint, ok := s.slaveEndpoints[k]; ok {
-							func() {
-								defer func() {
-									if obj := recover(); obj != nil {
-										log.Printf("close conn has fail. reason:[%v]", obj)
-									}
-								}()
-								endpoint.conn.Close()
-							}()
-						}
-
-						delete(s.slaveEndpoints, k)
-
-						// 移除先前主节点,等待选主完成
-						if s.masterEndpoint != nil && s.masterEndpoint.ID() == k {
-							s.masterEndpoint = nil
-						}
-
-						// 节点断开以后,肯定不能成为主节点
-						if k == s.etcdServerID {
-							s.isMaster = false
-						}
-					}
-				}
-				s.rwMutex.Unlock()
-			}
-		}
-	}()
+	s.isMaster = id == s.nodeID
+	if s.isMaster {
+		log.Printf("[Leader] 成为主节点. local:[%s]", s.nodeID)
+	}
+	needSync := !s.isMaster && s.masterEndpoint != nil
+	s.rwMutex.Unlock()
 
-	return nil
+	// 主节点发生变化,需要从新的主节点拉取全量数据
+	if needSync {
+		go s.tryFullSync(s.ctx)
+	}
 }
 
 // syncToSlaves 同步数据到从节点
+// 每条数据都会分配一个递增的序号,并放入各个从节点的有序队列中等待投递
 func (s *memorySyncer) syncToSlaves(action proto.Action, values ...string) {
 	s.rwMutex.RLock()
 	defer s.rwMutex.RUnlock()
-	req := &proto.SyncRequest{Action: action, Values: values}
+	req := &proto.SyncRequest{Action: action, Values: values, Seq: atomic.AddInt64(&s.seq, 1)}
 	for _, endpoint := range s.slaveEndpoints {
-		if !endpoint.isMaster {
-			go endpoint.cli.Slave(context.TODO(), req)
+		if !endpoint.isMaster && endpoint.replica != nil {
+			endpoint.replica.push(req)
 		}
 	}
 }
 
 // syncToMaster 同步数据到主节点
-func (s *memorySyncer) syncToMaster(action proto.Action, values ...string) ([]string, error) {
+// 阻塞动作的请求可能一直等待,请求期间不能持有锁,否则 Shutdown 会一直等到请求结束;
+// Shutdown 关闭主节点终端时正在进行的请求会被取消
+func (s *memorySyncer) syncToMaster(ctx context.Context, action proto.Action, values ...string) ([]string, error) {
 	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	master := s.masterEndpoint
+	isMaster := s.isMaster
+	s.rwMutex.RUnlock()
+
 	empty := make([]string, 1)
-	if !s.isMaster && s.masterEndpoint != nil {
+	if !isMaster && master != nil {
 		req := &proto.SyncRequest{Action: action, Values: values}
-		rsp, err := s.masterEndpoint.cli.Master(context.TODO(), req)
+		rsp, err := master.cli.Master(ctx, req)
 		if err != nil {
+			// 调用方的 ctx 结束时返回 ctx 的错误
+			if ctx.Err() != nil {
+				return empty, ctx.Err()
+			}
 			stat := status.Convert(err)
 			switch stat.Code() {
 			case codes.NotFound:
 				err = MemoryNil
+			case codes.ResourceExhausted:
+				err = MemoryOOM
 			default:
 				err = errors.New(stat.Message())
+				for _, e := range memoryErrors {
+					if e.Error() == stat.Message() {
+						err = e
+						break
+					}
+				}
 			}
 			return empty, err
 		}
@@ -821,18 +777,248 @@ func (s *memorySyncer) syncToMaster(action proto.Action, values ...string) ([]st
 	return empty, nil
 }
 
+// snapshot 生成全量数据快照,返回快照对应的同步序号跟数据
+// 同时重置请求节点的同步队列,快照之后的数据会继续按顺序投递
+func (s *memorySyncer) snapshot(id string) (int64, []*storeEntry) {
+	// 阻止新的写入,保证快照跟同步序号一致
+	s.memory.applyMutex.Lock()
+	defer s.memory.applyMutex.Unlock()
+
+	seq := atomic.LoadInt64(&s.seq)
+
+	s.rwMutex.RLock()
+	if endpoint, ok := s.slaveEndpoints[id]; ok && endpoint.replica != nil {
+		endpoint.replica.reset(seq)
+	}
+	s.rwMutex.RUnlock()
+
+	return seq, s.memory.entries()
+}
+
+// deferSync 判断增量数据是否需要延后执行或者丢弃
+// 正在全量同步时缓存起来等待快照加载完成;已经执行过或者已经包含在快照中的直接丢弃
+func (s *memorySyncer) deferSync(in *proto.SyncRequest) bool {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	if s.syncing {
+		s.pending = append(s.pending, in)
+		return true
+	}
+
+	return in.Seq > 0 && in.Seq <= s.appliedSeq
+}
+
+// ackSync 记录从节点已执行的同步序号
+func (s *memorySyncer) ackSync(in *proto.SyncRequest) {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+	if in.Seq > s.appliedSeq {
+		s.appliedSeq = in.Seq
+	}
+}
+
+// tryFullSync 尝试进行全量同步,失败时会进行重试
+func (s *memorySyncer) tryFullSync(ctx context.Context) {
+	s.syncMutex.Lock()
+	if s.syncing {
+		// 正在同步中,等本次完成后再同步一次
+		s.resync = true
+		s.syncMutex.Unlock()
+		return
+	}
+	s.syncing = true
+	s.pending = nil
+	s.syncMutex.Unlock()
+
+	retry := 3
+	err := s.fullSync(ctx)
+
+	// 还没有主节点时不需要重试,确定主节点后会再次触发全量同步
+	for err != nil && err != errNoMasterEndpoint && retry > 0 {
+		log.Printf("full sync failure. local:[%s], reason:[%v]", s.nodeID, err)
+		if utils.ContextIsDone(ctx) != nil {
+			break
+		}
+
+		retry--
+		time.Sleep(time.Second * 5)
+		err = s.fullSync(ctx)
+	}
+
+	if err == nil {
+		s.syncMutex.Lock()
+		resync := s.resync
+		s.resync = false
+		s.syncMutex.Unlock()
+		if resync {
+			s.tryFullSync(ctx)
+		}
+		return
+	}
+
+	// 放弃全量同步,将已缓存的增量数据执行掉,避免数据继续堆积
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+	for _, req := range s.pending {
+		s.grpcSvr.apply(ctx, req)
+	}
+	s.syncing = false
+	s.resync = false
+	s.pending = nil
+}
+
+// fullSync 从主节点拉取全量数据快照并加载,加载完成后再执行期间收到的增量数据
+func (s *memorySyncer) fullSync(ctx context.Context) error {
+	s.rwMutex.RLock()
+	master := s.masterEndpoint
+	s.rwMutex.RUnlock()
+
+	if master == nil {
+		return errNoMasterEndpoint
+	}
+
+	if s.memory == nil {
+		return errors.New("memory not set")
+	}
+
+	stream, err := master.cli.Snapshot(ctx, &proto.SnapshotRequest{Id: s.nodeID})
+	if err != nil {
+		return err
+	}
+
+	var seq int64
+	entries := make([]*storeEntry, 0)
+	for {
+		rsp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		seq = rsp.Seq
+		for _, entry := range rsp.Entries {
+			entries = append(entries, newStoreEntryFromProto(entry))
+		}
+	}
+
+	s.memory.loadEntries(entries)
+
+	// 本地的持久化数据已经被替换,需要重新生成快照
+	if s.memory.persistence != nil {
+		if err := s.memory.persistence.snapshot(); err != nil {
+			log.Printf("[FullSync] persistence snapshot failure. local:[%s], reason:[%v]", s.nodeID, err)
+		}
+	}
+
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	// 只执行快照之后的增量数据
+	s.appliedSeq = seq
+	for _, req := range s.pending {
+		if req.Seq > s.appliedSeq {
+			s.grpcSvr.apply(ctx, req)
+			s.appliedSeq = req.Seq
+		}
+	}
+	s.syncing = false
+	s.pending = nil
+
+	log.Printf("[FullSync] local:[%s], master:[%s], seq:[%d], keys:[%d]", s.nodeID, master.ID(), seq, len(entries))
+	return nil
+}
+
+// toProto 转换成全量同步的传输数据
+func (e *storeEntry) toProto() *proto.SnapshotEntry {
+	entry := &proto.SnapshotEntry{
+		Key:    e.Key,
+		Type:   string(e.Type),
+		Values: e.Values,
+	}
+	if e.ExpireAt != nil {
+		entry.ExpireAt = e.ExpireAt.UnixNano()
+	}
+	entry.FieldExpires = e.FieldExpires
+	return entry
+}
+
+// newStoreEntryFromProto 从全量同步的传输数据中还原
+func newStoreEntryFromProto(in *proto.SnapshotEntry) *storeEntry {
+	entry := &storeEntry{
+		Key:          in.Key,
+		Type:         driverStoreType(in.Type),
+		Values:       in.Values,
+		FieldExpires: in.FieldExpires,
+	}
+	if in.ExpireAt > 0 {
+		t := time.Unix(0, in.ExpireAt)
+		entry.ExpireAt = &t
+	}
+	return entry
+}
+
 func (s *memorySyncer) setMemory(memory *Memory) {
 	s.memory = memory
 	memory.syncer = s
 }
 
+// Close 关闭同步器,最多等待5秒
 func (s *memorySyncer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// Shutdown 关闭同步器
+// 先等待从节点的同步队列投递完成,再离开集群并放弃主节点身份,最后关闭grpc服务跟所有连接
+// ctx 结束时不再等待,直接关闭
+func (s *memorySyncer) Shutdown(ctx context.Context) error {
+	s.rwMutex.Lock()
+	if s.isClosed {
+		s.rwMutex.Unlock()
+		return nil
+	}
 	s.isClosed = true
+	replicas := make([]*syncerReplica, 0, len(s.slaveEndpoints))
+	for _, endpoint := range s.slaveEndpoints {
+		if endpoint.replica != nil {
+			replicas = append(replicas, endpoint.replica)
+		}
+	}
+	s.rwMutex.Unlock()
 
-	if s.etcdCli != nil {
-		s.etcdCli.Close()
+	var err error
+	for _, replica := range replicas {
+		if e := replica.drain(ctx); e != nil && err == nil {
+			err = e
+		}
 	}
 
+	// 离开集群时可能还在处理集群事件,不能持有锁
+	if s.cluster != nil {
+		if e := s.cluster.Leave(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	// 等待正在处理的请求完成,ctx 结束时强制关闭
+	if s.server != nil {
+		stopped := make(chan struct{})
+		go func() {
+			s.server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			s.server.Stop()
+		}
+	}
+
+	s.rwMutex.Lock()
 	if s.masterEndpoint != nil {
 		s.masterEndpoint.Close()
 	}
@@ -840,5 +1026,10 @@ func (s *memorySyncer) Close() error {
 	for _, endpoint := range s.slaveEndpoints {
 		endpoint.Close()
 	}
-	return nil
+	s.rwMutex.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return err
 }
@@ -0,0 +1,397 @@
+package driver
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/8 10:21
+  @describe :
+*/
+
+// newTestSyncerServer 返回一个不启动网络服务的同步服务,用于直接测试数据同步逻辑
+func newTestSyncerServer() (*syncerServer, *Memory) {
+	mem := NewMemory().(*Memory)
+	syncer := &memorySyncer{memory: mem}
+	return &syncerServer{syncer: syncer}, mem
+}
+
+func Test_syncerServer_sync_SortedSet(t *testing.T) {
+	ctx := context.Background()
+	srv, mem := newTestSyncerServer()
+
+	tests := []struct {
+		name string
+		req  *proto.SyncRequest
+		want []string
+	}{
+		{
+			name: "ZAdd",
+			req:  &proto.SyncRequest{Action: proto.Action_ZAdd, Values: []string{"zset", "a", "1", "b", "2", "c", "3", "d", "4"}},
+			want: []string{"4"},
+		},
+		{
+			name: "ZIncrBy",
+			req:  &proto.SyncRequest{Action: proto.Action_ZIncrBy, Values: []string{"zset", "10", "a"}},
+			want: []string{"11"},
+		},
+		{
+			name: "ZRem",
+			req:  &proto.SyncRequest{Action: proto.Action_ZRem, Values: []string{"zset", "b", "x"}},
+			want: []string{"1"},
+		},
+		{
+			name: "ZRemRangeByScore",
+			req:  &proto.SyncRequest{Action: proto.Action_ZRemRangeByScore, Values: []string{"zset", "3", "3"}},
+			want: []string{"1"},
+		},
+		{
+			name: "ZRemRangeByRank",
+			req:  &proto.SyncRequest{Action: proto.Action_ZRemRangeByRank, Values: []string{"zset", "0", "0"}},
+			want: []string{"1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rsp, err := srv.sync(ctx, tt.req)
+			if err != nil {
+				t.Errorf("sync() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(rsp.Value, tt.want) {
+				t.Errorf("sync() got = %v, want %v", rsp.Value, tt.want)
+			}
+		})
+	}
+
+	if got := mem.ZRange(ctx, "zset", 0, -1).Val(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("ZRange() got = %v, want [a]", got)
+	}
+	if got := mem.ZScore(ctx, "zset", "a").Val(); got != 11 {
+		t.Errorf("ZScore() got = %v, want 11", got)
+	}
+}
+
+func Test_syncerServer_Master_SPop(t *testing.T) {
+	ctx := context.Background()
+	srv, mem := newTestSyncerServer()
+	srv.syncer.isMaster = true
+	mem.SAdd(ctx, "set", "a", "b")
+
+	rsp, err := srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_SPop, Values: []string{"set", "5"}})
+	if err != nil {
+		t.Fatalf("Master() error = %v", err)
+	}
+	sort.Strings(rsp.Value)
+	if !reflect.DeepEqual(rsp.Value, []string{"a", "b"}) {
+		t.Errorf("Master() got = %v, want [a b]", rsp.Value)
+	}
+
+	// 没有弹出成员时返回 NotFound,从节点据此返回空结果
+	_, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_SPop, Values: []string{"set", "1"}})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Master() error = %v, want NotFound", err)
+	}
+}
+
+func Test_syncerServer_Master_Hash(t *testing.T) {
+	ctx := context.Background()
+	srv, mem := newTestSyncerServer()
+	srv.syncer.isMaster = true
+	mem.syncer = srv.syncer
+	mem.HSet(ctx, "hash", "f1", "v1")
+	seq := atomic.LoadInt64(&srv.syncer.seq)
+
+	// 字段已经存在时没有写入,不需要同步
+	rsp, err := srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_HSetNx, Values: []string{"hash", "f1", "x"}})
+	if err != nil || rsp.Value[0] == "1" {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+	if got := atomic.LoadInt64(&srv.syncer.seq); got != seq {
+		t.Errorf("seq got = %v, want %v", got, seq)
+	}
+
+	rsp, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_HIncrByFloat, Values: []string{"hash", "f2", "1.5"}})
+	if err != nil || rsp.Value[0] != "1.5" {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+	if got := atomic.LoadInt64(&srv.syncer.seq); got != seq+1 {
+		t.Errorf("seq got = %v, want %v", got, seq+1)
+	}
+	if got := mem.HGet(ctx, "hash", "f2").Val(); got != "1.5" {
+		t.Errorf("HGet() got = %v, want 1.5", got)
+	}
+
+	// 转发的存活时长换算成到期时间后执行
+	ttl, _ := marshalData(int64(time.Hour))
+	rsp, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_HExpire, Values: []string{"hash", ttl, "f2", "missing"}})
+	if err != nil || !reflect.DeepEqual(rsp.Value, []string{"1", "-2"}) {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+	if got := mem.HTTL(ctx, "hash", "f2").Val(); !reflect.DeepEqual(got, []int64{3600}) {
+		t.Errorf("HTTL() got = %v, want [3600]", got)
+	}
+}
+
+func Test_syncerServer_Master_List(t *testing.T) {
+	ctx := context.Background()
+	srv, mem := newTestSyncerServer()
+	srv.syncer.isMaster = true
+	mem.syncer = srv.syncer
+	mem.RPush(ctx, "list", "a", "b", "c")
+	seq := atomic.LoadInt64(&srv.syncer.seq)
+
+	timeout, _ := marshalData(time.Second)
+	rsp, err := srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_BLPop, Values: []string{timeout, "missing", "list"}})
+	if err != nil || !reflect.DeepEqual(rsp.Value, []string{"list", "a"}) {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+
+	rsp, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_BLMove, Values: []string{timeout, "list", "dst", "RIGHT", "LEFT"}})
+	if err != nil || !reflect.DeepEqual(rsp.Value, []string{"c"}) {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+	if got := atomic.LoadInt64(&srv.syncer.seq); got != seq+2 {
+		t.Errorf("seq got = %v, want %v", got, seq+2)
+	}
+	if got := mem.LRange(ctx, "list", 0, -1).Val(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("LRange(list) got = %v, want [b]", got)
+	}
+	if got := mem.LRange(ctx, "dst", 0, -1).Val(); !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("LRange(dst) got = %v, want [c]", got)
+	}
+
+	// 超时没有弹出时不需要同步
+	timeout, _ = marshalData(time.Millisecond * 10)
+	if _, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_BRPop, Values: []string{timeout, "missing"}}); err == nil {
+		t.Errorf("Master() error = nil, want timeout")
+	}
+	if got := atomic.LoadInt64(&srv.syncer.seq); got != seq+2 {
+		t.Errorf("seq got = %v, want %v", got, seq+2)
+	}
+}
+
+func Test_syncerServer_Master_SortedSet(t *testing.T) {
+	ctx := context.Background()
+	srv, mem := newTestSyncerServer()
+	srv.syncer.isMaster = true
+	mem.syncer = srv.syncer
+	mem.ZAdd(ctx, "z", Z{Member: "a", Score: 1}, Z{Member: "b", Score: 2})
+	seq := atomic.LoadInt64(&srv.syncer.seq)
+
+	timeout, _ := marshalData(time.Second)
+	rsp, err := srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_BZPopMin, Values: []string{timeout, "missing", "z"}})
+	if err != nil || !reflect.DeepEqual(rsp.Value, []string{"z", "a", "1"}) {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+
+	// 条件不满足时没有写入,不需要同步
+	_, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_ZAddIncr, Values: []string{"z", strconv.Itoa(int(zAddNX)), "b", "1"}})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Master() error = %v, want NotFound", err)
+	}
+	rsp, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_ZAddIncr, Values: []string{"z", strconv.Itoa(int(zAddXX)), "b", "1.5"}})
+	if err != nil || !reflect.DeepEqual(rsp.Value, []string{"3.5"}) {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+
+	rsp, err = srv.Master(ctx, &proto.SyncRequest{Action: proto.Action_ZUnionStore, Values: zStoreValues("out", &ZStore{Keys: []string{"z"}, Weights: []float64{2}})})
+	if err != nil || !reflect.DeepEqual(rsp.Value, []string{"1"}) {
+		t.Fatalf("Master() got = %v, error = %v", rsp.Value, err)
+	}
+	if got := atomic.LoadInt64(&srv.syncer.seq); got != seq+3 {
+		t.Errorf("seq got = %v, want %v", got, seq+3)
+	}
+	if got := mem.ZRangeWithScores(ctx, "out", 0, -1).Val(); !reflect.DeepEqual(got, []Z{{Member: "b", Score: 7}}) {
+		t.Errorf("ZRangeWithScores(out) got = %v, want [{7 b}]", got)
+	}
+}
+
+func Test_memorySyncer_snapshot_BLPop(t *testing.T) {
+	ctx := context.Background()
+	srv, mem := newTestSyncerServer()
+	srv.syncer.isMaster = true
+	mem.syncer = srv.syncer
+
+	const n = 500
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = strconv.Itoa(i)
+	}
+	mem.RPush(ctx, "list", items...)
+	base := atomic.LoadInt64(&srv.syncer.seq)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			if err := mem.BLPop(ctx, time.Second, "list").Err(); err != nil {
+				t.Errorf("BLPop() error = %v", err)
+				return
+			}
+		}
+	}()
+
+	// 快照中的列表长度加上快照序号之前同步的弹出数量,必须等于原来的长度
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		seq, entries := srv.syncer.snapshot("")
+		length := 0
+		for _, entry := range entries {
+			if entry.Key == "list" {
+				length = len(entry.Values)
+			}
+		}
+		if popped := int(seq - base); length+popped != n {
+			t.Fatalf("snapshot list length = %v, popped = %v, want sum %v", length, popped, n)
+		}
+	}
+}
+
+// testSyncerClient 记录收到的同步数据,前 failures 次投递返回不可用错误
+type testSyncerClient struct {
+	proto.SyncerClient
+
+	mutex    sync.Mutex
+	failures int
+	received []int64
+}
+
+func (c *testSyncerClient) Slave(ctx context.Context, in *proto.SyncRequest, opts ...grpc.CallOption) (*proto.SyncResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	c.received = append(c.received, in.Seq)
+	return &proto.SyncResponse{}, nil
+}
+
+func (c *testSyncerClient) Received() []int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]int64(nil), c.received...)
+}
+
+func Test_syncerReplica(t *testing.T) {
+	waitFor := func(cond func() bool) bool {
+		deadline := time.Now().Add(time.Second * 3)
+		for time.Now().Before(deadline) {
+			if cond() {
+				return true
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		return false
+	}
+
+	t.Run("按顺序投递并重试", func(t *testing.T) {
+		cli := &testSyncerClient{failures: 2}
+		replica := newSyncerReplica(&syncerEndpoint{cli: cli, options: &syncerEndpointOptions{}}, 10)
+		defer replica.Close()
+
+		for i := int64(1); i <= 5; i++ {
+			replica.push(&proto.SyncRequest{Action: proto.Action_Set, Seq: i})
+		}
+
+		if !waitFor(func() bool { return replica.AckSeq() == 5 }) {
+			t.Fatalf("AckSeq() got = %v, want 5", replica.AckSeq())
+		}
+		if got := cli.Received(); !reflect.DeepEqual(got, []int64{1, 2, 3, 4, 5}) {
+			t.Errorf("received got = %v, want [1 2 3 4 5]", got)
+		}
+	})
+
+	t.Run("积压过多标记过期", func(t *testing.T) {
+		cli := &testSyncerClient{failures: 1 << 10}
+		replica := newSyncerReplica(&syncerEndpoint{cli: cli, options: &syncerEndpointOptions{}}, 3)
+		defer replica.Close()
+
+		for i := int64(1); i <= 5; i++ {
+			replica.push(&proto.SyncRequest{Action: proto.Action_Set, Seq: i})
+		}
+
+		if !replica.IsStale() {
+			t.Fatalf("IsStale() got = false, want true")
+		}
+		if head := replica.head(); head == nil || head.Action != proto.Action_FullSync || replica.Len() != 1 {
+			t.Errorf("queue got = %v, want only FullSync", head)
+		}
+
+		replica.reset(5)
+		if replica.IsStale() || replica.Len() != 0 || replica.AckSeq() != 5 {
+			t.Errorf("reset() got stale = %v, len = %v, ack = %v", replica.IsStale(), replica.Len(), replica.AckSeq())
+		}
+	})
+}
+
+// testBlockingMasterClient 转发到主节点的请求一直阻塞到 ctx 结束
+type testBlockingMasterClient struct {
+	proto.SyncerClient
+
+	entered chan struct{}
+}
+
+func (c *testBlockingMasterClient) Master(ctx context.Context, in *proto.SyncRequest, opts ...grpc.CallOption) (*proto.SyncResponse, error) {
+	close(c.entered)
+	<-ctx.Done()
+	return nil, status.Error(codes.Canceled, ctx.Err().Error())
+}
+
+func Test_memorySyncer_syncToMaster_blocking(t *testing.T) {
+	mem := NewMemory().(*Memory)
+	cli := &testBlockingMasterClient{entered: make(chan struct{})}
+	mem.syncer = &memorySyncer{memory: mem, masterEndpoint: &syncerEndpoint{cli: cli}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		result <- mem.BLPop(ctx, 0, "list").Err()
+	}()
+	<-cli.entered
+
+	// 转发的请求进行中时不能持有锁,否则 Shutdown 会一直等待
+	locked := make(chan struct{})
+	go func() {
+		mem.syncer.rwMutex.Lock()
+		mem.syncer.rwMutex.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatalf("rwMutex is held during forwarded request")
+	}
+
+	// 调用方的 ctx 结束时请求随之结束
+	cancel()
+	select {
+	case err := <-result:
+		if err != context.Canceled {
+			t.Errorf("BLPop() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("BLPop() not canceled")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"math/rand"
 	"os"
 	"os/signal"
+	"reflect"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
@@ -113,8 +115,8 @@ func TestNewDistributeMemory(t *testing.T) {
 	})
 	fns = append(fns, func(cache Cache) {
 		start := time.Now()
-		val := cache.LShift(context.Background(), randKey())
-		log.Println(time.Now().Sub(start), "cache.LShift(context.Background(), randKey())", val)
+		val := cache.RPop(context.Background(), randKey())
+		log.Println(time.Now().Sub(start), "cache.RPop(context.Background(), randKey())", val)
 	})
 	fns = append(fns, func(cache Cache) {
 		start := time.Now()
@@ -123,11 +125,11 @@ func TestNewDistributeMemory(t *testing.T) {
 	})
 	fns = append(fns, func(cache Cache) {
 		start := time.Now()
-		val := cache.LBPop(context.Background(), time.Second*10, randKey())
-		log.Println(time.Now().Sub(start), "cache.LBPop(context.Background(), time.Second*10, randKey())", val)
+		val := cache.BRPop(context.Background(), time.Second*10, randKey())
+		log.Println(time.Now().Sub(start), "cache.BRPop(context.Background(), time.Second*10, randKey())", val)
 	})
 
-	sig := make(chan os.Signal)
+	sig := make(chan os.Signal, 1)
 	//for i := 0; i < 10; i++ {
 	go func() {
 		ticker := time.NewTicker(time.Second)
@@ -138,7 +140,7 @@ func TestNewDistributeMemory(t *testing.T) {
 				log.Println("ticking...")
 				//cache := mems[rand.Intn(len(mems))]
 				//go func() {
-				//	log.Println("cache.LBPop(context.Background(), time.Second*5, randKey())", cache.LBPop(context.Background(), time.Second*10, randKey()))
+				//	log.Println("cache.BRPop(context.Background(), time.Second*5, randKey())", cache.BRPop(context.Background(), time.Second*10, randKey()))
 				//}()
 				//
 				//log.Println("cache.LTrim(context.Background(), randKey(), rand.Int63n(10), rand.Int63n(10))", cache.LTrim(context.Background(), randKey(), rand.Int63n(10), rand.Int63n(10)))
@@ -165,7 +167,7 @@ func TestNewDistributeMemory(t *testing.T) {
 
 }
 
-func TestLBPop(t *testing.T) {
+func TestBRPop(t *testing.T) {
 	count := int64(2)
 	mems := make([]Cache, 0)
 	for i := 0; i < int(count); i++ {
@@ -321,12 +323,12 @@ func TestLBPop(t *testing.T) {
 
 	funcSlice = append(funcSlice, func(cache Cache, id int, key, field, value string) {
 		start := time.Now()
-		val := cache.LShift(context.Background(), key)
+		val := cache.RPop(context.Background(), key)
 		args := []interface{}{
 			time.Now().Sub(start),
 			id, key, field, value, val,
 		}
-		log.Printf("LShift:[dur:%s,id:%d,key:%s,field:%s,value:%s,resule:%s]", args...)
+		log.Printf("RPop:[dur:%s,id:%d,key:%s,field:%s,value:%s,resule:%s]", args...)
 	})
 
 	funcSlice = append(funcSlice, func(cache Cache, id int, key, field, value string) {
@@ -341,12 +343,12 @@ func TestLBPop(t *testing.T) {
 
 	funcSlice = append(funcSlice, func(cache Cache, id int, key, field, value string) {
 		start := time.Now()
-		val := cache.LBPop(context.Background(), time.Second*10, key)
+		val := cache.BRPop(context.Background(), time.Second*10, key)
 		args := []interface{}{
 			time.Now().Sub(start),
 			id, key, field, value, val,
 		}
-		log.Printf("LBPop:[dur:%s,id:%d,key:%s,field:%s,value:%s,resule:%s]", args...)
+		log.Printf("BRPop:[dur:%s,id:%d,key:%s,field:%s,value:%s,resule:%s]", args...)
 	})
 
 	timer := time.NewTicker(time.Second)
@@ -359,3 +361,488 @@ func TestLBPop(t *testing.T) {
 	}
 
 }
+
+func TestMemory_loadEntries(t *testing.T) {
+	ctx := context.Background()
+	src := NewMemory().(*Memory)
+	src.Set(ctx, "string", "value", time.Minute)
+	src.HSet(ctx, "hash", "f1", "v1", "f2", "v2")
+	src.LPush(ctx, "list", "a", "b", "c")
+	src.ZAdd(ctx, "zset", Z{Member: "m1", Score: 1}, Z{Member: "m2", Score: 2.5})
+	src.SAdd(ctx, "set", "a", "b")
+
+	dst := NewMemory().(*Memory)
+	dst.Set(ctx, "stale", "value", time.Minute)
+	dst.loadEntries(src.entries())
+
+	if v := dst.Exists(ctx, "stale").Val(); v != 0 {
+		t.Errorf("loadEntries() stale key exists = %v, want 0", v)
+	}
+	if v := dst.Get(ctx, "string").Val(); v != "value" {
+		t.Errorf("loadEntries() string = %v, want value", v)
+	}
+	if v := dst.HGetAll(ctx, "hash").Val(); !reflect.DeepEqual(v, map[string]string{"f1": "v1", "f2": "v2"}) {
+		t.Errorf("loadEntries() hash = %v", v)
+	}
+	if v := dst.LRange(ctx, "list", 0, -1).Val(); !reflect.DeepEqual(v, src.LRange(ctx, "list", 0, -1).Val()) {
+		t.Errorf("loadEntries() list = %v", v)
+	}
+	if v := dst.ZScore(ctx, "zset", "m2").Val(); v != 2.5 {
+		t.Errorf("loadEntries() zset score = %v, want 2.5", v)
+	}
+	if v := dst.SMIsMember(ctx, "set", "a", "b", "c").Val(); !reflect.DeepEqual(v, []bool{true, true, false}) {
+		t.Errorf("loadEntries() set = %v", v)
+	}
+
+	srcAt := src.ss.value("string").ExpireTime()
+	dstAt := dst.ss.value("string").ExpireTime()
+	if srcAt == nil || dstAt == nil || !srcAt.Equal(*dstAt) {
+		t.Errorf("loadEntries() expireAt = %v, want %v", dstAt, srcAt)
+	}
+}
+
+func TestMemory_TTL(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory()
+	mem.Set(ctx, "string", "value", time.Minute)
+	mem.ZAdd(ctx, "zset", Z{Score: 2, Member: "b"}, Z{Score: 1, Member: "a"})
+	mem.Expire(ctx, "zset", time.Hour)
+
+	if ttl := mem.TTL(ctx, "string").Val(); ttl > time.Minute || ttl < time.Second*59 {
+		t.Errorf("TTL(string) got = %v", ttl)
+	}
+	if ttl := mem.TTL(ctx, "zset").Val(); ttl > time.Hour || ttl < time.Minute*59 {
+		t.Errorf("TTL(zset) got = %v", ttl)
+	}
+	if ttl := mem.TTL(ctx, "not-exists").Val(); ttl != -2 {
+		t.Errorf("TTL(not-exists) got = %v, want -2", ttl)
+	}
+
+	// TTL 跟 redis 一样精确到秒,不足一秒时为0
+	mem.Set(ctx, "short", "value", time.Millisecond*500)
+	if ttl := mem.TTL(ctx, "short").Val(); ttl != 0 {
+		t.Errorf("TTL(short) got = %v, want 0", ttl)
+	}
+	if ttl := mem.PTTL(ctx, "short").Val(); ttl <= 0 || ttl > time.Millisecond*500 {
+		t.Errorf("PTTL(short) got = %v, want (0, 500ms]", ttl)
+	}
+	if ttl := mem.TTL(ctx, "string").Val(); ttl%time.Second != 0 {
+		t.Errorf("TTL(string) got = %v, want whole seconds", ttl)
+	}
+
+	// 存活时长不是正数时直接删除
+	for _, ttl := range []time.Duration{-time.Nanosecond, KeepTTL, 0} {
+		mem.Set(ctx, "deleted", "value", 0)
+		if got := mem.Expire(ctx, "deleted", ttl).Val(); !got {
+			t.Errorf("Expire(%v) got = %v, want true", ttl, got)
+		}
+		if got := mem.Exists(ctx, "deleted").Val(); got != 0 {
+			t.Errorf("Exists() after Expire(%v) got = %v, want 0", ttl, got)
+		}
+	}
+
+	want := []Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}}
+	if got := mem.ZRangeWithScores(ctx, "zset", 0, -1).Val(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ZRangeWithScores() got = %v, want %v", got, want)
+	}
+}
+
+func TestMemory_WrongType(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory()
+	mem.Set(ctx, "key", "value", time.Minute)
+
+	if err := mem.HSet(ctx, "key", "field", "value").Err(); err != MemoryWrongType {
+		t.Errorf("HSet() err = %v, want %v", err, MemoryWrongType)
+	}
+	if err := mem.LPush(ctx, "key", "item").Err(); err != MemoryWrongType {
+		t.Errorf("LPush() err = %v, want %v", err, MemoryWrongType)
+	}
+	if cnt := mem.Exists(ctx, "key").Val(); cnt != 1 {
+		t.Errorf("Exists() got = %d, want 1", cnt)
+	}
+
+	mem.HSet(ctx, "hash", "field", "value")
+	if err := mem.Get(ctx, "hash").Err(); err != MemoryWrongType {
+		t.Errorf("Get() err = %v, want %v", err, MemoryWrongType)
+	}
+	if got := mem.MGet(ctx, "key", "hash").Val(); !reflect.DeepEqual(got, []interface{}{"value", nil}) {
+		t.Errorf("MGet() got = %v", got)
+	}
+
+	// 并发写入不同类型的同一个键,最终只能有一种类型写入成功
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("race-%d", i)
+		var errs [2]error
+		done := make(chan struct{}, 2)
+		go func() {
+			errs[0] = mem.Set(ctx, key, "value", time.Minute).Err()
+			done <- struct{}{}
+		}()
+		go func() {
+			errs[1] = mem.HSet(ctx, key, "field", "value").Err()
+			done <- struct{}{}
+		}()
+		<-done
+		<-done
+
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Fatalf("key %s got errs = %v", key, errs)
+		}
+		if cnt := mem.Del(ctx, key).Val(); cnt != 1 {
+			t.Fatalf("Del(%s) got = %d, want 1", key, cnt)
+		}
+	}
+}
+
+func TestMemory_Shards(t *testing.T) {
+	ctx := context.Background()
+	mem := newMemory(8)
+
+	keys := make([]string, 32)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	// 多个键的操作以不同的顺序并发执行,不能出现死锁
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wg := make(chan struct{}, 8)
+		for i := 0; i < 8; i++ {
+			go func(i int) {
+				defer func() { wg <- struct{}{} }()
+				ks := make([]string, len(keys))
+				copy(ks, keys)
+				rand.Shuffle(len(ks), func(a, b int) { ks[a], ks[b] = ks[b], ks[a] })
+				for j := 0; j < 200; j++ {
+					mem.Set(ctx, ks[j%len(ks)], i, time.Minute)
+					mem.MGet(ctx, ks...)
+					mem.Exists(ctx, ks...)
+					mem.Del(ctx, ks[:4]...)
+				}
+			}(i)
+		}
+		for i := 0; i < 8; i++ {
+			<-wg
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 10):
+		t.Fatal("multi-key operations deadlocked")
+	}
+
+	for _, key := range keys {
+		mem.Set(ctx, key, key, time.Minute)
+	}
+	if cnt := mem.Exists(ctx, keys...).Val(); cnt != int64(len(keys)) {
+		t.Errorf("Exists() got = %d, want %d", cnt, len(keys))
+	}
+	got := mem.MGet(ctx, keys...).Val()
+	for i, key := range keys {
+		if got[i] != key {
+			t.Errorf("MGet()[%d] got = %v, want %v", i, got[i], key)
+		}
+	}
+	if cnt := mem.Del(ctx, keys...).Val(); cnt != int64(len(keys)) {
+		t.Errorf("Del() got = %d, want %d", cnt, len(keys))
+	}
+}
+
+func TestMemory_Eviction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("LRU", func(t *testing.T) {
+		mem := newMemory(4)
+		evicted := make(map[string]EvictReason)
+		mem.keyspace.limits = newKeyspaceLimits(&MemoryConfig{MaxEntries: 10, EvictionPolicy: EvictLRU})
+		mem.keyspace.onEvict = func(key string, reason EvictReason) {
+			evicted[key] = reason
+		}
+
+		for i := 0; i < 30; i++ {
+			if err := mem.Set(ctx, fmt.Sprintf("key-%d", i), i, time.Minute).Err(); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if stats := mem.Stats(); stats.Entries > 10 {
+				t.Fatalf("Stats().Entries got = %d, want <= 10", stats.Entries)
+			}
+		}
+
+		stats := mem.Stats()
+		if stats.Entries != 10 || stats.Evictions != 20 {
+			t.Errorf("Stats() got = %+v, want Entries=10 Evictions=20", stats)
+		}
+		if len(evicted) != 20 {
+			t.Errorf("OnEvict called %d times, want 20", len(evicted))
+		}
+		for key, reason := range evicted {
+			if reason != EvictReasonMaxEntries {
+				t.Errorf("OnEvict(%s) reason got = %v, want %v", key, reason, EvictReasonMaxEntries)
+			}
+		}
+		// 最后写入的键不能被淘汰
+		if v := mem.Get(ctx, "key-29").Val(); v != "29" {
+			t.Errorf("Get() got = %v, want 29", v)
+		}
+	})
+
+	t.Run("VolatileTTL", func(t *testing.T) {
+		mem := newMemory(4)
+		mem.keyspace.limits = newKeyspaceLimits(&MemoryConfig{MaxEntries: 100, EvictionPolicy: EvictVolatileTTL})
+
+		// 大部分键没有到期时间,抽样很难抽到可以淘汰的键
+		for i := 0; i < 98; i++ {
+			mem.Set(ctx, fmt.Sprintf("forever-%d", i), i, 0)
+		}
+		mem.Set(ctx, "later", "value", time.Hour)
+		mem.Set(ctx, "sooner", "value", time.Minute)
+
+		for i := 0; i < 2; i++ {
+			if err := mem.Set(ctx, fmt.Sprintf("new-%d", i), i, 0).Err(); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+		}
+		if cnt := mem.Exists(ctx, "sooner", "later").Val(); cnt != 0 {
+			t.Errorf("Exists() got = %d, want 0", cnt)
+		}
+
+		// 没有可以淘汰的键时返回 MemoryOOM
+		if err := mem.Set(ctx, "new-2", 2, 0).Err(); err != MemoryOOM {
+			t.Errorf("Set() error = %v, want %v", err, MemoryOOM)
+		}
+		if stats := mem.Stats(); stats.Entries != 100 || stats.Evictions != 2 || stats.Rejections != 1 {
+			t.Errorf("Stats() got = %+v, want Entries=100 Evictions=2 Rejections=1", stats)
+		}
+	})
+
+	t.Run("MaxBytes", func(t *testing.T) {
+		mem := newMemory(4)
+		mem.keyspace.limits = newKeyspaceLimits(&MemoryConfig{MaxBytes: 1024, EvictionPolicy: EvictRandom})
+
+		for i := 0; i < 100; i++ {
+			mem.HSet(ctx, fmt.Sprintf("hash-%d", i), "field", fmt.Sprintf("%064d", i))
+		}
+		if stats := mem.Stats(); stats.Bytes > 1024+128 || stats.Evictions == 0 {
+			t.Errorf("Stats() got = %+v, want Bytes <= %d and Evictions > 0", stats, 1024+128)
+		}
+
+		mem.keyspace.flush()
+		if stats := mem.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+			t.Errorf("Stats() after flush got = %+v, want empty", stats)
+		}
+	})
+
+	t.Run("NoEviction", func(t *testing.T) {
+		mem := newMemory(4)
+		mem.keyspace.limits = newKeyspaceLimits(&MemoryConfig{MaxEntries: 2})
+
+		mem.Set(ctx, "a", 1, time.Minute)
+		mem.ZAdd(ctx, "b", Z{Member: "m", Score: 1})
+		if err := mem.LPush(ctx, "c", 1).Err(); err != MemoryOOM {
+			t.Errorf("LPush() error = %v, want %v", err, MemoryOOM)
+		}
+		// 覆盖已有的键不会新增键
+		if err := mem.Set(ctx, "a", 2, time.Minute).Err(); err != nil {
+			t.Errorf("Set() error = %v", err)
+		}
+		if stats := mem.Stats(); stats.Entries != 2 || stats.Rejections != 1 {
+			t.Errorf("Stats() got = %+v, want Entries=2 Rejections=1", stats)
+		}
+	})
+}
+
+func TestMemory_Persist(t *testing.T) {
+	ctx := context.Background()
+
+	mem := newMemory(4)
+	mem.Set(ctx, "forever", "value", 0)
+	mem.Set(ctx, "string", "value", time.Minute)
+	mem.Expire(ctx, "string", time.Hour*24*30)
+
+	if ttl := mem.TTL(ctx, "forever").Val(); ttl != -1 {
+		t.Errorf("TTL(forever) got = %v, want -1", ttl)
+	}
+	// 没有设置最长存活时长时不会被限制
+	if ttl := mem.TTL(ctx, "string").Val(); ttl < time.Hour*24*29 {
+		t.Errorf("TTL(string) got = %v, want about 30 days", ttl)
+	}
+	if ok := mem.Persist(ctx, "string").Val(); !ok {
+		t.Errorf("Persist(string) got = %v, want true", ok)
+	}
+	if ttl := mem.TTL(ctx, "string").Val(); ttl != -1 {
+		t.Errorf("TTL(string) after Persist got = %v, want -1", ttl)
+	}
+
+	// 设置了最长存活时长时,持久化跟更长的存活时长都会被限制
+	capped := newMemory(4)
+	capped.keyspace.setMaxTTL(time.Hour)
+	capped.Set(ctx, "forever", "value", 0)
+	capped.HSet(ctx, "hash", "field", "value")
+	capped.Set(ctx, "string", "value", time.Minute)
+	capped.Expire(ctx, "string", time.Hour*24)
+	for _, key := range []string{"forever", "hash", "string"} {
+		if ttl := capped.TTL(ctx, key).Val(); ttl > time.Hour || ttl < time.Minute*59 {
+			t.Errorf("TTL(%s) got = %v, want about 1 hour", key, ttl)
+		}
+	}
+	capped.Persist(ctx, "string")
+	if ttl := capped.TTL(ctx, "string").Val(); ttl > time.Hour || ttl < time.Minute*59 {
+		t.Errorf("TTL(string) after Persist got = %v, want about 1 hour", ttl)
+	}
+}
+
+func TestMemory_ActiveExpire(t *testing.T) {
+	ctx := context.Background()
+	mem := newMemory(4)
+	mem.keyspace.setExpirePrecision(time.Millisecond * 10)
+
+	for i := 0; i < 1000; i++ {
+		mem.Set(ctx, fmt.Sprintf("key-%d", i), i, time.Millisecond*20)
+	}
+	mem.Set(ctx, "forever", "value", 0)
+	mem.Set(ctx, "extended", "value", time.Millisecond*20)
+	mem.Expire(ctx, "extended", time.Minute)
+
+	// 过期清理的同时并发读取,不能出现数据竞争
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			mem.Get(ctx, fmt.Sprintf("key-%d", i))
+			mem.TTL(ctx, fmt.Sprintf("key-%d", i))
+		}
+	}()
+	<-done
+
+	deadline := time.Now().Add(time.Second)
+	for mem.Stats().Entries > 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	stats := mem.Stats()
+	if stats.Entries != 2 || stats.Expirations != 1000 {
+		t.Errorf("Stats() got = %+v, want Entries=2 Expirations=1000", stats)
+	}
+	if got := mem.Exists(ctx, "forever", "extended").Val(); got != 2 {
+		t.Errorf("Exists() got = %v, want 2", got)
+	}
+}
+
+func TestMemory_ActiveExpire_Panic(t *testing.T) {
+	ctx := context.Background()
+	mem := newMemory(4)
+	mem.keyspace.setExpirePrecision(time.Millisecond * 10)
+
+	var panicked int32
+	mem.keyspace.onEvict = func(key string, reason EvictReason) {
+		if atomic.CompareAndSwapInt32(&panicked, 0, 1) {
+			panic("evict callback")
+		}
+	}
+	mem.Set(ctx, "first", "value", time.Millisecond*10)
+	time.Sleep(time.Millisecond * 100)
+	if atomic.LoadInt32(&panicked) != 1 {
+		t.Fatal("OnEvict not called")
+	}
+
+	// 异常后等待一段时间再重新启动,期间到期的键在重新启动后被清理
+	mem.Set(ctx, "second", "value", time.Millisecond*10)
+	time.Sleep(time.Millisecond * 100)
+	if stats := mem.Stats(); stats.Entries != 1 {
+		t.Errorf("Stats().Entries before restart got = %d, want 1", stats.Entries)
+	}
+
+	deadline := time.Now().Add(expireRestartDelay * 3)
+	for mem.Stats().Entries > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if stats := mem.Stats(); stats.Entries != 0 {
+		t.Errorf("Stats().Entries got = %d, want 0", stats.Entries)
+	}
+}
+
+func TestMemory_Close(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	mem := newTestPersistenceMemory(t, dir)
+	mem.Set(ctx, "string", "value", time.Hour)
+	if err := mem.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	select {
+	case <-mem.keyspace.closed:
+	default:
+		t.Error("keyspace expire loop not stopped")
+	}
+	if err := mem.Close(ctx); err != nil {
+		t.Errorf("Close() again error = %v", err)
+	}
+
+	// 关闭时剩余的日志已经写入磁盘
+	mem = newTestPersistenceMemory(t, dir)
+	defer mem.Close(ctx)
+	if got := mem.Get(ctx, "string").Val(); got != "value" {
+		t.Errorf("Get(string) got = %v, want value", got)
+	}
+}
+
+func TestMemory_ExpireMode(t *testing.T) {
+	ctx := context.Background()
+	mem := newMemory(4)
+	mem.Set(ctx, "forever", "value", 0)
+	mem.Set(ctx, "volatile", "value", time.Minute)
+
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context, key string, ttl time.Duration) BoolValuer
+		key  string
+		ttl  time.Duration
+		want bool
+	}{
+		{name: "NX 没有到期时间", fn: mem.ExpireNX, key: "forever", ttl: time.Hour, want: true},
+		{name: "NX 已有到期时间", fn: mem.ExpireNX, key: "volatile", ttl: time.Hour, want: false},
+		{name: "XX 已有到期时间", fn: mem.ExpireXX, key: "volatile", ttl: time.Minute * 2, want: true},
+		{name: "GT 更晚", fn: mem.ExpireGT, key: "volatile", ttl: time.Minute * 3, want: true},
+		{name: "GT 更早", fn: mem.ExpireGT, key: "volatile", ttl: time.Minute, want: false},
+		{name: "LT 更早", fn: mem.ExpireLT, key: "volatile", ttl: time.Minute, want: true},
+		{name: "LT 更晚", fn: mem.ExpireLT, key: "volatile", ttl: time.Hour, want: false},
+		{name: "XX 不存在", fn: mem.ExpireXX, key: "not-exists", ttl: time.Minute, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(ctx, tt.key, tt.ttl).Val(); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 没有到期时间的key, GT 不会设置, LT 会设置
+	mem.Persist(ctx, "forever")
+	if got := mem.ExpireGT(ctx, "forever", time.Hour).Val(); got {
+		t.Errorf("ExpireGT(forever) got = %v, want false", got)
+	}
+	if got := mem.ExpireLT(ctx, "forever", time.Hour).Val(); !got {
+		t.Errorf("ExpireLT(forever) got = %v, want true", got)
+	}
+
+	if ttl := mem.PTTL(ctx, "volatile").Val(); ttl > time.Minute || ttl < time.Second*59 || ttl%time.Millisecond != 0 {
+		t.Errorf("PTTL(volatile) got = %v", ttl)
+	}
+	at := mem.ExpireTime(ctx, "forever").Val()
+	if want := time.Duration(time.Now().Add(time.Hour).Unix()) * time.Second; at < want-time.Second*2 || at > want {
+		t.Errorf("ExpireTime(forever) got = %v, want about %v", at, want)
+	}
+	mem.Persist(ctx, "forever")
+	for key, want := range map[string]time.Duration{"forever": -1, "not-exists": -2} {
+		if got := mem.PTTL(ctx, key).Val(); got != want {
+			t.Errorf("PTTL(%s) got = %v, want %v", key, got, want)
+		}
+		if got := mem.ExpireTime(ctx, key).Val(); got != want {
+			t.Errorf("ExpireTime(%s) got = %v, want %v", key, got, want)
+		}
+	}
+}
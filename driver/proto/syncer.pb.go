@@ -38,53 +38,194 @@ const (
 	Action_ExpireAt Action = 2
 	Action_Persist  Action = 3
 	// String
-	Action_Set   Action = 21
-	Action_SetNX Action = 22
+	Action_Set         Action = 21
+	Action_SetNX       Action = 22
+	Action_IncrBy      Action = 23
+	Action_IncrByFloat Action = 24
+	Action_Append      Action = 25
+	Action_SetRange    Action = 26
+	Action_GetSet      Action = 27
+	Action_GetDel      Action = 28
+	Action_GetEx       Action = 29
+	Action_SetXX       Action = 30
+	Action_MSet        Action = 31
+	Action_MSetNX      Action = 32
 	// Hash
-	Action_HDel   Action = 40
-	Action_HSet   Action = 41
-	Action_HSetNx Action = 42
+	Action_HDel         Action = 40
+	Action_HSet         Action = 41
+	Action_HSetNx       Action = 42
+	Action_HIncrBy      Action = 43
+	Action_HIncrByFloat Action = 44
+	Action_HExpire      Action = 45
+	Action_HExpireAt    Action = 46
+	Action_HPersist     Action = 47
+	// HSetKeepTTL 设置字段的值并保持字段的到期时间,用于同步 HIncrByFloat 的计算结果
+	Action_HSetKeepTTL Action = 48
 	// List
-	Action_LPush  Action = 60
-	Action_LPop   Action = 61
-	Action_LShift Action = 62
-	Action_LTrim  Action = 63
-	Action_LBPop  Action = 64
+	// 编号跟旧版本保持一致: 61 原名 LPop(推出尾部), 62 原名 LShift(推出头部), 64 原名 LBPop
+	Action_LPush   Action = 60
+	Action_RPop    Action = 61
+	Action_LPop    Action = 62
+	Action_LTrim   Action = 63
+	Action_BRPop   Action = 64
+	Action_RPush   Action = 65
+	Action_LSet    Action = 66
+	Action_LRem    Action = 67
+	Action_LInsert Action = 68
+	Action_LMove   Action = 69
+	// 60-69 已经用完,剩余的列表动作从 90 开始
+	Action_BLPop  Action = 90
+	Action_BLMove Action = 91
+	// Set
+	Action_SAdd        Action = 70
+	Action_SRem        Action = 71
+	Action_SPop        Action = 72
+	Action_SInterStore Action = 73
+	Action_SUnionStore Action = 74
+	Action_SDiffStore  Action = 75
+	// SortedSet
+	Action_ZAdd             Action = 80
+	Action_ZIncrBy          Action = 81
+	Action_ZRem             Action = 82
+	Action_ZRemRangeByRank  Action = 83
+	Action_ZRemRangeByScore Action = 84
+	// ZAddArgs 的第二个参数是 NX/XX/GT/LT/CH 条件
+	Action_ZAddArgs Action = 85
+	Action_ZAddIncr Action = 86
+	Action_ZPopMin  Action = 87
+	Action_ZPopMax  Action = 88
+	Action_BZPopMin Action = 89
+	// 80-89 已经用完,剩余的有序集合动作从 92 开始
+	Action_ZUnionStore Action = 92
+	Action_ZInterStore Action = 93
+	Action_ZDiffStore  Action = 94
+	Action_BZPopMax    Action = 95
+	// Control
+	// FullSync 通知从节点重新进行全量同步
+	Action_FullSync Action = 100
 )
 
 // Enum value maps for Action.
 var (
 	Action_name = map[int32]string{
-		0:  "Del",
-		1:  "Expire",
-		2:  "ExpireAt",
-		3:  "Persist",
-		21: "Set",
-		22: "SetNX",
-		40: "HDel",
-		41: "HSet",
-		42: "HSetNx",
-		60: "LPush",
-		61: "LPop",
-		62: "LShift",
-		63: "LTrim",
-		64: "LBPop",
+		0:   "Del",
+		1:   "Expire",
+		2:   "ExpireAt",
+		3:   "Persist",
+		21:  "Set",
+		22:  "SetNX",
+		23:  "IncrBy",
+		24:  "IncrByFloat",
+		25:  "Append",
+		26:  "SetRange",
+		27:  "GetSet",
+		28:  "GetDel",
+		29:  "GetEx",
+		30:  "SetXX",
+		31:  "MSet",
+		32:  "MSetNX",
+		40:  "HDel",
+		41:  "HSet",
+		42:  "HSetNx",
+		43:  "HIncrBy",
+		44:  "HIncrByFloat",
+		45:  "HExpire",
+		46:  "HExpireAt",
+		47:  "HPersist",
+		48:  "HSetKeepTTL",
+		60:  "LPush",
+		61:  "RPop",
+		62:  "LPop",
+		63:  "LTrim",
+		64:  "BRPop",
+		65:  "RPush",
+		66:  "LSet",
+		67:  "LRem",
+		68:  "LInsert",
+		69:  "LMove",
+		90:  "BLPop",
+		91:  "BLMove",
+		70:  "SAdd",
+		71:  "SRem",
+		72:  "SPop",
+		73:  "SInterStore",
+		74:  "SUnionStore",
+		75:  "SDiffStore",
+		80:  "ZAdd",
+		81:  "ZIncrBy",
+		82:  "ZRem",
+		83:  "ZRemRangeByRank",
+		84:  "ZRemRangeByScore",
+		85:  "ZAddArgs",
+		86:  "ZAddIncr",
+		87:  "ZPopMin",
+		88:  "ZPopMax",
+		89:  "BZPopMin",
+		92:  "ZUnionStore",
+		93:  "ZInterStore",
+		94:  "ZDiffStore",
+		95:  "BZPopMax",
+		100: "FullSync",
 	}
 	Action_value = map[string]int32{
-		"Del":      0,
-		"Expire":   1,
-		"ExpireAt": 2,
-		"Persist":  3,
-		"Set":      21,
-		"SetNX":    22,
-		"HDel":     40,
-		"HSet":     41,
-		"HSetNx":   42,
-		"LPush":    60,
-		"LPop":     61,
-		"LShift":   62,
-		"LTrim":    63,
-		"LBPop":    64,
+		"Del":              0,
+		"Expire":           1,
+		"ExpireAt":         2,
+		"Persist":          3,
+		"Set":              21,
+		"SetNX":            22,
+		"IncrBy":           23,
+		"IncrByFloat":      24,
+		"Append":           25,
+		"SetRange":         26,
+		"GetSet":           27,
+		"GetDel":           28,
+		"GetEx":            29,
+		"SetXX":            30,
+		"MSet":             31,
+		"MSetNX":           32,
+		"HDel":             40,
+		"HSet":             41,
+		"HSetNx":           42,
+		"HIncrBy":          43,
+		"HIncrByFloat":     44,
+		"HExpire":          45,
+		"HExpireAt":        46,
+		"HPersist":         47,
+		"HSetKeepTTL":      48,
+		"LPush":            60,
+		"RPop":             61,
+		"LPop":             62,
+		"LTrim":            63,
+		"BRPop":            64,
+		"RPush":            65,
+		"LSet":             66,
+		"LRem":             67,
+		"LInsert":          68,
+		"LMove":            69,
+		"BLPop":            90,
+		"BLMove":           91,
+		"SAdd":             70,
+		"SRem":             71,
+		"SPop":             72,
+		"SInterStore":      73,
+		"SUnionStore":      74,
+		"SDiffStore":       75,
+		"ZAdd":             80,
+		"ZIncrBy":          81,
+		"ZRem":             82,
+		"ZRemRangeByRank":  83,
+		"ZRemRangeByScore": 84,
+		"ZAddArgs":         85,
+		"ZAddIncr":         86,
+		"ZPopMin":          87,
+		"ZPopMax":          88,
+		"BZPopMin":         89,
+		"ZUnionStore":      92,
+		"ZInterStore":      93,
+		"ZDiffStore":       94,
+		"BZPopMax":         95,
+		"FullSync":         100,
 	}
 )
 
@@ -123,6 +264,8 @@ type SyncRequest struct {
 
 	Action Action   `protobuf:"varint,1,opt,name=action,proto3,enum=jcache.driver.proto.Action" json:"action,omitempty"`
 	Values []string `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	// seq 主节点分配的同步序号
+	Seq int64 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
 }
 
 func (x *SyncRequest) Reset() {
@@ -171,6 +314,13 @@ func (x *SyncRequest) GetValues() []string {
 	return nil
 }
 
+func (x *SyncRequest) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 // RunResponse 执行返回参数
 type SyncResponse struct {
 	state         protoimpl.MessageState
@@ -219,42 +369,300 @@ func (x *SyncResponse) GetValue() []string {
 	return nil
 }
 
+// SnapshotRequest 全量同步请求参数
+type SnapshotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// id 请求全量同步的节点ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncer_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_syncer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SnapshotRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// SnapshotEntry 全量同步的单个键数据
+type SnapshotEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// type 存储类型,String/Hash/List/Set/SortedSet
+	Type   string   `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Values []string `protobuf:"bytes,3,rep,name=values,proto3" json:"values,omitempty"`
+	// expire_at 到期时间,UnixNano,0表示没有到期时间
+	ExpireAt int64 `protobuf:"varint,4,opt,name=expire_at,json=expireAt,proto3" json:"expire_at,omitempty"`
+	// field_expires 哈希表字段的到期时间,UnixNano
+	FieldExpires map[string]int64 `protobuf:"bytes,5,rep,name=field_expires,json=fieldExpires,proto3" json:"field_expires,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *SnapshotEntry) Reset() {
+	*x = SnapshotEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncer_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotEntry) ProtoMessage() {}
+
+func (x *SnapshotEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotEntry.ProtoReflect.Descriptor instead.
+func (*SnapshotEntry) Descriptor() ([]byte, []int) {
+	return file_syncer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SnapshotEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SnapshotEntry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SnapshotEntry) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *SnapshotEntry) GetExpireAt() int64 {
+	if x != nil {
+		return x.ExpireAt
+	}
+	return 0
+}
+
+func (x *SnapshotEntry) GetFieldExpires() map[string]int64 {
+	if x != nil {
+		return x.FieldExpires
+	}
+	return nil
+}
+
+// SnapshotResponse 全量同步返回参数,以分批的方式流式返回
+type SnapshotResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// seq 快照对应的同步序号,序号大于该值的增量数据需要在快照后执行
+	Seq     int64            `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Entries []*SnapshotEntry `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *SnapshotResponse) Reset() {
+	*x = SnapshotResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncer_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotResponse) ProtoMessage() {}
+
+func (x *SnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotResponse.ProtoReflect.Descriptor instead.
+func (*SnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_syncer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SnapshotResponse) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SnapshotResponse) GetEntries() []*SnapshotEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
 var File_syncer_proto protoreflect.FileDescriptor
 
 var file_syncer_proto_rawDesc = []byte{
 	0x0a, 0x0c, 0x73, 0x79, 0x6e, 0x63, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x13,
 	0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x22, 0x5a, 0x0a, 0x0b, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x6f, 0x74, 0x6f, 0x22, 0x6c, 0x0a, 0x0b, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65,
 	0x73, 0x74, 0x12, 0x33, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76,
 	0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
 	0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22,
-	0x24, 0x0a, 0x0c, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x2a, 0xa3, 0x01, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x07, 0x0a, 0x03, 0x44, 0x65, 0x6c, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x45, 0x78, 0x70,
-	0x69, 0x72, 0x65, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41,
-	0x74, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x65, 0x72, 0x73, 0x69, 0x73, 0x74, 0x10, 0x03,
-	0x12, 0x07, 0x0a, 0x03, 0x53, 0x65, 0x74, 0x10, 0x15, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x65, 0x74,
-	0x4e, 0x58, 0x10, 0x16, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x44, 0x65, 0x6c, 0x10, 0x28, 0x12, 0x08,
-	0x0a, 0x04, 0x48, 0x53, 0x65, 0x74, 0x10, 0x29, 0x12, 0x0a, 0x0a, 0x06, 0x48, 0x53, 0x65, 0x74,
-	0x4e, 0x78, 0x10, 0x2a, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x50, 0x75, 0x73, 0x68, 0x10, 0x3c, 0x12,
-	0x08, 0x0a, 0x04, 0x4c, 0x50, 0x6f, 0x70, 0x10, 0x3d, 0x12, 0x0a, 0x0a, 0x06, 0x4c, 0x53, 0x68,
-	0x69, 0x66, 0x74, 0x10, 0x3e, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x54, 0x72, 0x69, 0x6d, 0x10, 0x3f,
-	0x12, 0x09, 0x0a, 0x05, 0x4c, 0x42, 0x50, 0x6f, 0x70, 0x10, 0x40, 0x32, 0xa9, 0x01, 0x0a, 0x06,
-	0x53, 0x79, 0x6e, 0x63, 0x65, 0x72, 0x12, 0x4e, 0x0a, 0x05, 0x53, 0x6c, 0x61, 0x76, 0x65, 0x12,
-	0x20, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x21, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65,
-	0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4f, 0x0a, 0x06, 0x4d, 0x61, 0x73, 0x74, 0x65, 0x72,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12,
+	0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65,
+	0x71, 0x22, 0x24, 0x0a, 0x0c, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x21, 0x0a, 0x0f, 0x53, 0x6e, 0x61, 0x70, 0x73,
+	0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x86, 0x02, 0x0a, 0x0d, 0x53,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x12,
+	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x12, 0x59, 0x0a, 0x0d, 0x66, 0x69, 0x65, 0x6c, 0x64,
+	0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x34,
+	0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x45, 0x78, 0x70, 0x69, 0x72,
+	0x65, 0x73, 0x1a, 0x3f, 0x0a, 0x11, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x45, 0x78, 0x70, 0x69, 0x72,
+	0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x62, 0x0a, 0x10, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x3c, 0x0a, 0x07, 0x65, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x6a, 0x63, 0x61,
+	0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07,
+	0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x2a, 0xf2, 0x05, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x07, 0x0a, 0x03, 0x44, 0x65, 0x6c, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x45,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x45, 0x78, 0x70, 0x69, 0x72,
+	0x65, 0x41, 0x74, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x65, 0x72, 0x73, 0x69, 0x73, 0x74,
+	0x10, 0x03, 0x12, 0x07, 0x0a, 0x03, 0x53, 0x65, 0x74, 0x10, 0x15, 0x12, 0x09, 0x0a, 0x05, 0x53,
+	0x65, 0x74, 0x4e, 0x58, 0x10, 0x16, 0x12, 0x0a, 0x0a, 0x06, 0x49, 0x6e, 0x63, 0x72, 0x42, 0x79,
+	0x10, 0x17, 0x12, 0x0f, 0x0a, 0x0b, 0x49, 0x6e, 0x63, 0x72, 0x42, 0x79, 0x46, 0x6c, 0x6f, 0x61,
+	0x74, 0x10, 0x18, 0x12, 0x0a, 0x0a, 0x06, 0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x10, 0x19, 0x12,
+	0x0c, 0x0a, 0x08, 0x53, 0x65, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x10, 0x1a, 0x12, 0x0a, 0x0a,
+	0x06, 0x47, 0x65, 0x74, 0x53, 0x65, 0x74, 0x10, 0x1b, 0x12, 0x0a, 0x0a, 0x06, 0x47, 0x65, 0x74,
+	0x44, 0x65, 0x6c, 0x10, 0x1c, 0x12, 0x09, 0x0a, 0x05, 0x47, 0x65, 0x74, 0x45, 0x78, 0x10, 0x1d,
+	0x12, 0x09, 0x0a, 0x05, 0x53, 0x65, 0x74, 0x58, 0x58, 0x10, 0x1e, 0x12, 0x08, 0x0a, 0x04, 0x4d,
+	0x53, 0x65, 0x74, 0x10, 0x1f, 0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x53, 0x65, 0x74, 0x4e, 0x58, 0x10,
+	0x20, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x44, 0x65, 0x6c, 0x10, 0x28, 0x12, 0x08, 0x0a, 0x04, 0x48,
+	0x53, 0x65, 0x74, 0x10, 0x29, 0x12, 0x0a, 0x0a, 0x06, 0x48, 0x53, 0x65, 0x74, 0x4e, 0x78, 0x10,
+	0x2a, 0x12, 0x0b, 0x0a, 0x07, 0x48, 0x49, 0x6e, 0x63, 0x72, 0x42, 0x79, 0x10, 0x2b, 0x12, 0x10,
+	0x0a, 0x0c, 0x48, 0x49, 0x6e, 0x63, 0x72, 0x42, 0x79, 0x46, 0x6c, 0x6f, 0x61, 0x74, 0x10, 0x2c,
+	0x12, 0x0b, 0x0a, 0x07, 0x48, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x10, 0x2d, 0x12, 0x0d, 0x0a,
+	0x09, 0x48, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x10, 0x2e, 0x12, 0x0c, 0x0a, 0x08,
+	0x48, 0x50, 0x65, 0x72, 0x73, 0x69, 0x73, 0x74, 0x10, 0x2f, 0x12, 0x0f, 0x0a, 0x0b, 0x48, 0x53,
+	0x65, 0x74, 0x4b, 0x65, 0x65, 0x70, 0x54, 0x54, 0x4c, 0x10, 0x30, 0x12, 0x09, 0x0a, 0x05, 0x4c,
+	0x50, 0x75, 0x73, 0x68, 0x10, 0x3c, 0x12, 0x08, 0x0a, 0x04, 0x52, 0x50, 0x6f, 0x70, 0x10, 0x3d,
+	0x12, 0x08, 0x0a, 0x04, 0x4c, 0x50, 0x6f, 0x70, 0x10, 0x3e, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x54,
+	0x72, 0x69, 0x6d, 0x10, 0x3f, 0x12, 0x09, 0x0a, 0x05, 0x42, 0x52, 0x50, 0x6f, 0x70, 0x10, 0x40,
+	0x12, 0x09, 0x0a, 0x05, 0x52, 0x50, 0x75, 0x73, 0x68, 0x10, 0x41, 0x12, 0x08, 0x0a, 0x04, 0x4c,
+	0x53, 0x65, 0x74, 0x10, 0x42, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x52, 0x65, 0x6d, 0x10, 0x43, 0x12,
+	0x0b, 0x0a, 0x07, 0x4c, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x10, 0x44, 0x12, 0x09, 0x0a, 0x05,
+	0x4c, 0x4d, 0x6f, 0x76, 0x65, 0x10, 0x45, 0x12, 0x09, 0x0a, 0x05, 0x42, 0x4c, 0x50, 0x6f, 0x70,
+	0x10, 0x5a, 0x12, 0x0a, 0x0a, 0x06, 0x42, 0x4c, 0x4d, 0x6f, 0x76, 0x65, 0x10, 0x5b, 0x12, 0x08,
+	0x0a, 0x04, 0x53, 0x41, 0x64, 0x64, 0x10, 0x46, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x52, 0x65, 0x6d,
+	0x10, 0x47, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x50, 0x6f, 0x70, 0x10, 0x48, 0x12, 0x0f, 0x0a, 0x0b,
+	0x53, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x10, 0x49, 0x12, 0x0f, 0x0a,
+	0x0b, 0x53, 0x55, 0x6e, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x10, 0x4a, 0x12, 0x0e,
+	0x0a, 0x0a, 0x53, 0x44, 0x69, 0x66, 0x66, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x10, 0x4b, 0x12, 0x08,
+	0x0a, 0x04, 0x5a, 0x41, 0x64, 0x64, 0x10, 0x50, 0x12, 0x0b, 0x0a, 0x07, 0x5a, 0x49, 0x6e, 0x63,
+	0x72, 0x42, 0x79, 0x10, 0x51, 0x12, 0x08, 0x0a, 0x04, 0x5a, 0x52, 0x65, 0x6d, 0x10, 0x52, 0x12,
+	0x13, 0x0a, 0x0f, 0x5a, 0x52, 0x65, 0x6d, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x42, 0x79, 0x52, 0x61,
+	0x6e, 0x6b, 0x10, 0x53, 0x12, 0x14, 0x0a, 0x10, 0x5a, 0x52, 0x65, 0x6d, 0x52, 0x61, 0x6e, 0x67,
+	0x65, 0x42, 0x79, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x10, 0x54, 0x12, 0x0c, 0x0a, 0x08, 0x5a, 0x41,
+	0x64, 0x64, 0x41, 0x72, 0x67, 0x73, 0x10, 0x55, 0x12, 0x0c, 0x0a, 0x08, 0x5a, 0x41, 0x64, 0x64,
+	0x49, 0x6e, 0x63, 0x72, 0x10, 0x56, 0x12, 0x0b, 0x0a, 0x07, 0x5a, 0x50, 0x6f, 0x70, 0x4d, 0x69,
+	0x6e, 0x10, 0x57, 0x12, 0x0b, 0x0a, 0x07, 0x5a, 0x50, 0x6f, 0x70, 0x4d, 0x61, 0x78, 0x10, 0x58,
+	0x12, 0x0c, 0x0a, 0x08, 0x42, 0x5a, 0x50, 0x6f, 0x70, 0x4d, 0x69, 0x6e, 0x10, 0x59, 0x12, 0x0f,
+	0x0a, 0x0b, 0x5a, 0x55, 0x6e, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x10, 0x5c, 0x12,
+	0x0f, 0x0a, 0x0b, 0x5a, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x10, 0x5d,
+	0x12, 0x0e, 0x0a, 0x0a, 0x5a, 0x44, 0x69, 0x66, 0x66, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x10, 0x5e,
+	0x12, 0x0c, 0x0a, 0x08, 0x42, 0x5a, 0x50, 0x6f, 0x70, 0x4d, 0x61, 0x78, 0x10, 0x5f, 0x12, 0x0c,
+	0x0a, 0x08, 0x46, 0x75, 0x6c, 0x6c, 0x53, 0x79, 0x6e, 0x63, 0x10, 0x64, 0x32, 0x86, 0x02, 0x0a,
+	0x06, 0x53, 0x79, 0x6e, 0x63, 0x65, 0x72, 0x12, 0x4e, 0x0a, 0x05, 0x53, 0x6c, 0x61, 0x76, 0x65,
 	0x12, 0x20, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72,
 	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65,
 	0x73, 0x74, 0x1a, 0x21, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76,
 	0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x3b, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4f, 0x0a, 0x06, 0x4d, 0x61, 0x73, 0x74, 0x65,
+	0x72, 0x12, 0x20, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65,
+	0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69,
+	0x76, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5b, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x12, 0x24, 0x2e, 0x6a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x64, 0x72,
+	0x69, 0x76, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73,
+	0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x6a, 0x63, 0x61,
+	0x63, 0x68, 0x65, 0x2e, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -270,23 +678,31 @@ func file_syncer_proto_rawDescGZIP() []byte {
 }
 
 var file_syncer_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_syncer_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_syncer_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_syncer_proto_goTypes = []interface{}{
-	(Action)(0),          // 0: jcache.driver.proto.Action
-	(*SyncRequest)(nil),  // 1: jcache.driver.proto.SyncRequest
-	(*SyncResponse)(nil), // 2: jcache.driver.proto.SyncResponse
+	(Action)(0),              // 0: jcache.driver.proto.Action
+	(*SyncRequest)(nil),      // 1: jcache.driver.proto.SyncRequest
+	(*SyncResponse)(nil),     // 2: jcache.driver.proto.SyncResponse
+	(*SnapshotRequest)(nil),  // 3: jcache.driver.proto.SnapshotRequest
+	(*SnapshotEntry)(nil),    // 4: jcache.driver.proto.SnapshotEntry
+	(*SnapshotResponse)(nil), // 5: jcache.driver.proto.SnapshotResponse
+	nil,                      // 6: jcache.driver.proto.SnapshotEntry.FieldExpiresEntry
 }
 var file_syncer_proto_depIdxs = []int32{
 	0, // 0: jcache.driver.proto.SyncRequest.action:type_name -> jcache.driver.proto.Action
-	1, // 1: jcache.driver.proto.Syncer.Slave:input_type -> jcache.driver.proto.SyncRequest
-	1, // 2: jcache.driver.proto.Syncer.Master:input_type -> jcache.driver.proto.SyncRequest
-	2, // 3: jcache.driver.proto.Syncer.Slave:output_type -> jcache.driver.proto.SyncResponse
-	2, // 4: jcache.driver.proto.Syncer.Master:output_type -> jcache.driver.proto.SyncResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	6, // 1: jcache.driver.proto.SnapshotEntry.field_expires:type_name -> jcache.driver.proto.SnapshotEntry.FieldExpiresEntry
+	4, // 2: jcache.driver.proto.SnapshotResponse.entries:type_name -> jcache.driver.proto.SnapshotEntry
+	1, // 3: jcache.driver.proto.Syncer.Slave:input_type -> jcache.driver.proto.SyncRequest
+	1, // 4: jcache.driver.proto.Syncer.Master:input_type -> jcache.driver.proto.SyncRequest
+	3, // 5: jcache.driver.proto.Syncer.Snapshot:input_type -> jcache.driver.proto.SnapshotRequest
+	2, // 6: jcache.driver.proto.Syncer.Slave:output_type -> jcache.driver.proto.SyncResponse
+	2, // 7: jcache.driver.proto.Syncer.Master:output_type -> jcache.driver.proto.SyncResponse
+	5, // 8: jcache.driver.proto.Syncer.Snapshot:output_type -> jcache.driver.proto.SnapshotResponse
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_syncer_proto_init() }
@@ -319,6 +735,42 @@ func file_syncer_proto_init() {
 				return nil
 			}
 		}
+		file_syncer_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncer_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncer_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -326,7 +778,7 @@ func file_syncer_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_syncer_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   2,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -355,6 +807,7 @@ const _ = grpc.SupportPackageIsVersion6
 type SyncerClient interface {
 	Slave(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncResponse, error)
 	Master(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncResponse, error)
+	Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (Syncer_SnapshotClient, error)
 }
 
 type syncerClient struct {
@@ -383,10 +836,43 @@ func (c *syncerClient) Master(ctx context.Context, in *SyncRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *syncerClient) Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (Syncer_SnapshotClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Syncer_serviceDesc.Streams[0], "/jcache.driver.proto.Syncer/Snapshot", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &syncerSnapshotClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Syncer_SnapshotClient interface {
+	Recv() (*SnapshotResponse, error)
+	grpc.ClientStream
+}
+
+type syncerSnapshotClient struct {
+	grpc.ClientStream
+}
+
+func (x *syncerSnapshotClient) Recv() (*SnapshotResponse, error) {
+	m := new(SnapshotResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // SyncerServer is the server API for Syncer service.
 type SyncerServer interface {
 	Slave(context.Context, *SyncRequest) (*SyncResponse, error)
 	Master(context.Context, *SyncRequest) (*SyncResponse, error)
+	Snapshot(*SnapshotRequest, Syncer_SnapshotServer) error
 }
 
 // UnimplementedSyncerServer can be embedded to have forward compatible implementations.
@@ -399,6 +885,9 @@ func (*UnimplementedSyncerServer) Slave(context.Context, *SyncRequest) (*SyncRes
 func (*UnimplementedSyncerServer) Master(context.Context, *SyncRequest) (*SyncResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Master not implemented")
 }
+func (*UnimplementedSyncerServer) Snapshot(*SnapshotRequest, Syncer_SnapshotServer) error {
+	return status.Errorf(codes.Unimplemented, "method Snapshot not implemented")
+}
 
 func RegisterSyncerServer(s *grpc.Server, srv SyncerServer) {
 	s.RegisterService(&_Syncer_serviceDesc, srv)
@@ -440,6 +929,27 @@ func _Syncer_Master_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Syncer_Snapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SnapshotRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SyncerServer).Snapshot(m, &syncerSnapshotServer{stream})
+}
+
+type Syncer_SnapshotServer interface {
+	Send(*SnapshotResponse) error
+	grpc.ServerStream
+}
+
+type syncerSnapshotServer struct {
+	grpc.ServerStream
+}
+
+func (x *syncerSnapshotServer) Send(m *SnapshotResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Syncer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "jcache.driver.proto.Syncer",
 	HandlerType: (*SyncerServer)(nil),
@@ -453,6 +963,12 @@ var _Syncer_serviceDesc = grpc.ServiceDesc{
 			Handler:    _Syncer_Master_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Snapshot",
+			Handler:       _Syncer_Snapshot_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "syncer.proto",
 }
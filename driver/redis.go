@@ -89,6 +89,11 @@ func NewRedis(opt *RedisOptions) Cache {
 	panic(errors.New("redis driver: invalid options 'Client' and 'Config' is nil"))
 }
 
+// Close 关闭redis客户端的连接池,通过 NewRedisOptionsWithClient 传入的客户端也会被关闭
+func (r *Redis) Close(ctx context.Context) error {
+	return r.cli.Close()
+}
+
 func NewRedisString(opt *RedisOptions) String {
 	return NewRedis(opt)
 }
@@ -97,7 +102,7 @@ func NewRedisHashDriver(opt *RedisOptions) Hash {
 	return NewRedis(opt)
 }
 
-func NewRedisListDriver(opt *RedisOptions) Hash {
+func NewRedisListDriver(opt *RedisOptions) List {
 	return NewRedis(opt)
 }
 
@@ -127,6 +132,34 @@ func (r *Redis) Expire(ctx context.Context, key string, ttl time.Duration) BoolV
 	return cmd
 }
 
+// ExpireNX 只有key没有到期时间时才设置存活时间
+func (r *Redis) ExpireNX(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	cmd := r.cli.ExpireNX(ctx, key, ttl)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ExpireXX 只有key已经有到期时间时才设置存活时间
+func (r *Redis) ExpireXX(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	cmd := r.cli.ExpireXX(ctx, key, ttl)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ExpireGT 只有新的到期时间比原来的晚时才设置存活时间
+func (r *Redis) ExpireGT(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	cmd := r.cli.ExpireGT(ctx, key, ttl)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ExpireLT 只有新的到期时间比原来的早时才设置存活时间
+func (r *Redis) ExpireLT(ctx context.Context, key string, ttl time.Duration) BoolValuer {
+	cmd := r.cli.ExpireLT(ctx, key, ttl)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
 // ExpireAt 设置某个key在指定时间内到期
 func (r *Redis) ExpireAt(ctx context.Context, key string, at time.Time) BoolValuer {
 	cmd := r.cli.ExpireAt(ctx, key, at)
@@ -141,6 +174,28 @@ func (r *Redis) Persist(ctx context.Context, key string) BoolValuer {
 	return cmd
 }
 
+// TTL 获取某个key的剩余存活时长
+// key不存在时返回 -2, key没有设置存活时长时返回 -1
+func (r *Redis) TTL(ctx context.Context, key string) DurationValuer {
+	cmd := r.cli.TTL(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// PTTL 获取某个key的剩余存活时长,精确到毫秒
+func (r *Redis) PTTL(ctx context.Context, key string) DurationValuer {
+	cmd := r.cli.PTTL(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ExpireTime 获取某个key的到期时间,以距离 Unix 纪元的时长表示,精确到秒
+func (r *Redis) ExpireTime(ctx context.Context, key string) DurationValuer {
+	cmd := r.cli.ExpireTime(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
 // ============================
 // ========= String ===========
 // ============================
@@ -173,6 +228,111 @@ func (r *Redis) MGet(ctx context.Context, keys ...string) SliceValuer {
 	return cmd
 }
 
+// Incr 将key中储存的数字加1
+func (r *Redis) Incr(ctx context.Context, key string) IntValuer {
+	cmd := r.cli.Incr(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// IncrBy 将key中储存的数字加上增量 value
+func (r *Redis) IncrBy(ctx context.Context, key string, value int64) IntValuer {
+	cmd := r.cli.IncrBy(ctx, key, value)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// IncrByFloat 将key中储存的数字加上浮点数增量 value
+func (r *Redis) IncrByFloat(ctx context.Context, key string, value float64) FloatValuer {
+	cmd := r.cli.IncrByFloat(ctx, key, value)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// Decr 将key中储存的数字减1
+func (r *Redis) Decr(ctx context.Context, key string) IntValuer {
+	cmd := r.cli.Decr(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// DecrBy 将key中储存的数字减去 decrement
+func (r *Redis) DecrBy(ctx context.Context, key string, decrement int64) IntValuer {
+	cmd := r.cli.DecrBy(ctx, key, decrement)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// Append 将 value 追加到key原来的值的末尾
+func (r *Redis) Append(ctx context.Context, key, value string) IntValuer {
+	cmd := r.cli.Append(ctx, key, value)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// GetRange 返回key中字符串值的子字符串
+func (r *Redis) GetRange(ctx context.Context, key string, start, end int64) StringValuer {
+	cmd := r.cli.GetRange(ctx, key, start, end)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SetRange 用 value 覆写key中字符串值从偏移量 offset 开始的内容
+func (r *Redis) SetRange(ctx context.Context, key string, offset int64, value string) IntValuer {
+	cmd := r.cli.SetRange(ctx, key, offset, value)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// StrLen 返回key中字符串值的长度
+func (r *Redis) StrLen(ctx context.Context, key string) IntValuer {
+	cmd := r.cli.StrLen(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// GetSet 设置key的值并返回原来的值
+func (r *Redis) GetSet(ctx context.Context, key string, data interface{}) StringValuer {
+	cmd := r.cli.GetSet(ctx, key, data)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// GetDel 获取key的值并删除key
+func (r *Redis) GetDel(ctx context.Context, key string) StringValuer {
+	cmd := r.cli.GetDel(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// GetEx 获取key的值并设置存活时长
+func (r *Redis) GetEx(ctx context.Context, key string, ttl time.Duration) StringValuer {
+	cmd := r.cli.GetEx(ctx, key, ttl)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SetXX 只有key已经存在时才设置数据
+func (r *Redis) SetXX(ctx context.Context, key string, data interface{}, ttl time.Duration) BoolValuer {
+	cmd := r.cli.SetXX(ctx, key, data, ttl)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// MSet 同时设置多个key的值
+func (r *Redis) MSet(ctx context.Context, values ...interface{}) StatusValuer {
+	cmd := r.cli.MSet(ctx, values...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// MSetNX 只有所有的key都不存在时才同时设置多个key的值
+func (r *Redis) MSetNX(ctx context.Context, values ...interface{}) BoolValuer {
+	cmd := r.cli.MSetNX(ctx, values...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
 // ============================
 // ========== Hash ============
 // ============================
@@ -247,6 +407,85 @@ func (r *Redis) HLen(ctx context.Context, key string) IntValuer {
 	return cmd
 }
 
+// HIncrBy 哈希表field的值加上增量 incr
+func (r *Redis) HIncrBy(ctx context.Context, key, field string, incr int64) IntValuer {
+	cmd := r.cli.HIncrBy(ctx, key, field, incr)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// HIncrByFloat 哈希表field的值加上浮点数增量 incr
+func (r *Redis) HIncrByFloat(ctx context.Context, key, field string, incr float64) FloatValuer {
+	cmd := r.cli.HIncrByFloat(ctx, key, field, incr)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// HStrLen 哈希表field的值的长度
+// go-redis 没有提供该命令,直接执行原始命令
+func (r *Redis) HStrLen(ctx context.Context, key, field string) IntValuer {
+	cmd := redis.NewIntCmd(ctx, "hstrlen", key, field)
+	_ = r.cli.Process(ctx, cmd)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// HRandField 随机返回哈希表中的字段
+func (r *Redis) HRandField(ctx context.Context, key string, count int) StringSliceValuer {
+	cmd := r.cli.HRandField(ctx, key, count)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// HScan 以游标的方式扫描哈希表
+func (r *Redis) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) ScanValuer {
+	cmd := r.cli.HScan(ctx, key, cursor, match, count)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// hFieldsCmd 执行 redis 7.4 的字段存活时长命令,格式为 command key [args...] FIELDS numfields field...
+// go-redis 没有提供这些命令,直接执行原始命令
+func (r *Redis) hFieldsCmd(ctx context.Context, command, key string, args []interface{}, fields []string) IntSliceValuer {
+	cmdArgs := make([]interface{}, 0, len(args)+len(fields)+4)
+	cmdArgs = append(cmdArgs, command, key)
+	cmdArgs = append(cmdArgs, args...)
+	cmdArgs = append(cmdArgs, "fields", len(fields))
+	for _, field := range fields {
+		cmdArgs = append(cmdArgs, field)
+	}
+
+	cmd := redis.NewIntSliceCmd(ctx, cmdArgs...)
+	_ = r.cli.Process(ctx, cmd)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// HExpire 设置哈希表字段的存活时长,精确到秒
+func (r *Redis) HExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) IntSliceValuer {
+	return r.hFieldsCmd(ctx, "hexpire", key, []interface{}{int64(expiration / time.Second)}, fields)
+}
+
+// HPExpire 设置哈希表字段的存活时长,精确到毫秒
+func (r *Redis) HPExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) IntSliceValuer {
+	return r.hFieldsCmd(ctx, "hpexpire", key, []interface{}{int64(expiration / time.Millisecond)}, fields)
+}
+
+// HExpireAt 设置哈希表字段的到期时间
+func (r *Redis) HExpireAt(ctx context.Context, key string, tm time.Time, fields ...string) IntSliceValuer {
+	return r.hFieldsCmd(ctx, "hpexpireat", key, []interface{}{tm.UnixNano() / int64(time.Millisecond)}, fields)
+}
+
+// HTTL 返回哈希表字段剩余的存活时长(秒)
+func (r *Redis) HTTL(ctx context.Context, key string, fields ...string) IntSliceValuer {
+	return r.hFieldsCmd(ctx, "httl", key, nil, fields)
+}
+
+// HPersist 清除哈希表字段的到期时间
+func (r *Redis) HPersist(ctx context.Context, key string, fields ...string) IntSliceValuer {
+	return r.hFieldsCmd(ctx, "hpersist", key, nil, fields)
+}
+
 // ============================
 // ========== List ============
 // ============================
@@ -261,37 +500,114 @@ func (r *Redis) LTrim(ctx context.Context, key string, start, stop int64) Status
 	return cmd
 }
 
-// LPush 将数据推入到列表中
+// LPush 依次将数据推入到列表头部,返回推入后的列表长度
 func (r *Redis) LPush(ctx context.Context, key string, data ...interface{}) IntValuer {
 	cmd := r.cli.LPush(ctx, key, data)
 	cmd.SetErr(translateErr(cmd.Err()))
 	return cmd
 }
 
-// LRang 提取列表范围内的数据
-func (r *Redis) LRang(ctx context.Context, key string, start, stop int64) StringSliceValuer {
+// RPush 依次将数据推入到列表尾部,返回推入后的列表长度
+func (r *Redis) RPush(ctx context.Context, key string, data ...interface{}) IntValuer {
+	cmd := r.cli.RPush(ctx, key, data)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// LRange 按从头部到尾部的顺序提取列表范围内的数据
+func (r *Redis) LRange(ctx context.Context, key string, start, stop int64) StringSliceValuer {
 	cmd := r.cli.LRange(ctx, key, start, stop)
 	cmd.SetErr(translateErr(cmd.Err()))
 	return cmd
 }
 
-// LPop 推出列表尾的最后数据
+// LPop 推出列表头部的第一个数据
 func (r *Redis) LPop(ctx context.Context, key string) StringValuer {
+	cmd := r.cli.LPop(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// RPop 推出列表尾部的最后一个数据
+func (r *Redis) RPop(ctx context.Context, key string) StringValuer {
 	cmd := r.cli.RPop(ctx, key)
 	cmd.SetErr(translateErr(cmd.Err()))
 	return cmd
 }
 
-// LBPop 推出列表尾的最后数据
-func (r *Redis) LBPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer {
-	cmd := r.cli.BRPop(ctx, timeout, keys...)
+// LIndex 返回列表中下标对应的元素
+func (r *Redis) LIndex(ctx context.Context, key string, index int64) StringValuer {
+	cmd := r.cli.LIndex(ctx, key, index)
 	cmd.SetErr(translateErr(cmd.Err()))
 	return cmd
 }
 
-// LShift 推出列表头的第一个数据
-func (r *Redis) LShift(ctx context.Context, key string) StringValuer {
-	cmd := r.cli.LPop(ctx, key)
+// LSet 替换列表中下标对应的元素
+func (r *Redis) LSet(ctx context.Context, key string, index int64, value interface{}) StatusValuer {
+	cmd := r.cli.LSet(ctx, key, index, value)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// LRem 移除列表中与 value 相等的元素,返回被移除的数量
+func (r *Redis) LRem(ctx context.Context, key string, count int64, value interface{}) IntValuer {
+	cmd := r.cli.LRem(ctx, key, count, value)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// LInsert 把 value 插入到列表中第一个 pivot 的前面或者后面
+func (r *Redis) LInsert(ctx context.Context, key, op string, pivot, value interface{}) IntValuer {
+	cmd := r.cli.LInsert(ctx, key, op, pivot, value)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// LPos 返回列表中与 value 相等的元素的下标
+func (r *Redis) LPos(ctx context.Context, key string, value string, args LPosArgs) IntValuer {
+	cmd := r.cli.LPos(ctx, key, value, args)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// LPosCount 返回列表中最多 count 个与 value 相等的元素的下标
+func (r *Redis) LPosCount(ctx context.Context, key string, value string, count int64, args LPosArgs) IntSliceValuer {
+	cmd := r.cli.LPosCount(ctx, key, value, count, args)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// LMove 从 source 推出一个元素,再推入 destination
+func (r *Redis) LMove(ctx context.Context, source, destination, srcpos, destpos string) StringValuer {
+	cmd := r.cli.LMove(ctx, source, destination, srcpos, destpos)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// RPopLPush 从 source 的尾部推出一个元素,再推入 destination 的头部
+func (r *Redis) RPopLPush(ctx context.Context, source, destination string) StringValuer {
+	cmd := r.cli.RPopLPush(ctx, source, destination)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// BLMove 同 LMove,source 为空时阻塞等待
+func (r *Redis) BLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) StringValuer {
+	cmd := r.cli.BLMove(ctx, source, destination, srcpos, destpos, timeout)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// BLPop 移出并获取第一个非空列表的头部元素,列表都为空时阻塞等待
+func (r *Redis) BLPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer {
+	cmd := r.cli.BLPop(ctx, timeout, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// BRPop 移出并获取第一个非空列表的尾部元素,列表都为空时阻塞等待
+func (r *Redis) BRPop(ctx context.Context, timeout time.Duration, keys ...string) StringSliceValuer {
+	cmd := r.cli.BRPop(ctx, timeout, keys...)
 	cmd.SetErr(translateErr(cmd.Err()))
 	return cmd
 }
@@ -303,6 +619,122 @@ func (r *Redis) LLen(ctx context.Context, key string) IntValuer {
 	return cmd
 }
 
+// ==============================================================
+// ========================== Set ===============================
+// ==============================================================
+
+// SAdd 添加一个或多个成员到集合中
+func (r *Redis) SAdd(ctx context.Context, key string, members ...interface{}) IntValuer {
+	cmd := r.cli.SAdd(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SRem 移除集合中的一个或多个成员
+func (r *Redis) SRem(ctx context.Context, key string, members ...interface{}) IntValuer {
+	cmd := r.cli.SRem(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SMembers 返回集合中的所有成员
+func (r *Redis) SMembers(ctx context.Context, key string) StringSliceValuer {
+	cmd := r.cli.SMembers(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SIsMember 判断成员是否在集合中
+func (r *Redis) SIsMember(ctx context.Context, key string, member interface{}) BoolValuer {
+	cmd := r.cli.SIsMember(ctx, key, member)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SMIsMember 判断多个成员是否在集合中
+func (r *Redis) SMIsMember(ctx context.Context, key string, members ...interface{}) BoolSliceValuer {
+	cmd := r.cli.SMIsMember(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SCard 返回集合的成员数量
+func (r *Redis) SCard(ctx context.Context, key string) IntValuer {
+	cmd := r.cli.SCard(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SPop 随机移除并返回集合中的一个成员
+func (r *Redis) SPop(ctx context.Context, key string) StringValuer {
+	cmd := r.cli.SPop(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SPopN 随机移除并返回集合中最多 count 个成员
+func (r *Redis) SPopN(ctx context.Context, key string, count int64) StringSliceValuer {
+	cmd := r.cli.SPopN(ctx, key, count)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SRandMember 随机返回集合中的一个成员
+func (r *Redis) SRandMember(ctx context.Context, key string) StringValuer {
+	cmd := r.cli.SRandMember(ctx, key)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SRandMemberN 随机返回集合中的成员
+func (r *Redis) SRandMemberN(ctx context.Context, key string, count int64) StringSliceValuer {
+	cmd := r.cli.SRandMemberN(ctx, key, count)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SInter 返回多个集合的交集
+func (r *Redis) SInter(ctx context.Context, keys ...string) StringSliceValuer {
+	cmd := r.cli.SInter(ctx, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SUnion 返回多个集合的并集
+func (r *Redis) SUnion(ctx context.Context, keys ...string) StringSliceValuer {
+	cmd := r.cli.SUnion(ctx, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SDiff 返回第一个集合跟其他集合的差集
+func (r *Redis) SDiff(ctx context.Context, keys ...string) StringSliceValuer {
+	cmd := r.cli.SDiff(ctx, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SInterStore 把多个集合的交集保存到 destination 中
+func (r *Redis) SInterStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	cmd := r.cli.SInterStore(ctx, destination, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SUnionStore 把多个集合的并集保存到 destination 中
+func (r *Redis) SUnionStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	cmd := r.cli.SUnionStore(ctx, destination, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// SDiffStore 把第一个集合跟其他集合的差集保存到 destination 中
+func (r *Redis) SDiffStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	cmd := r.cli.SDiffStore(ctx, destination, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
 // ==============================================================
 // ======================= Sorted Set ===========================
 // ==============================================================
@@ -349,6 +781,13 @@ func (r *Redis) ZRange(ctx context.Context, key string, start, stop int64) Strin
 	return cmd
 }
 
+// ZRangeWithScores 同 ZRange, 返回的成员带上 score 值
+func (r *Redis) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ZSliceValuer {
+	cmd := r.cli.ZRangeWithScores(ctx, key, start, stop)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
 // ZRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。有序集成员按 score 值递增(从小到大)次序排列。
 // 具有相同 score 值的成员按字典序(lexicographical order)来排列(该属性是有序集提供的，不需要额外的计算)。
 // 可选的 LIMIT 参数指定返回结果的数量及区间(就像SQL中的 SELECT LIMIT offset, count )，注意当 offset 很大时，定位 offset 的操作可能需要遍历整个有序集，此过程最坏复杂度为 O(N) 时间。
@@ -422,3 +861,159 @@ func (r *Redis) ZScore(ctx context.Context, key, member string) FloatValuer {
 	cmd.SetErr(translateErr(cmd.Err()))
 	return cmd
 }
+
+// ZAddNX 只添加新成员,不更新已经存在的成员
+func (r *Redis) ZAddNX(ctx context.Context, key string, members ...Z) IntValuer {
+	cmd := r.cli.ZAddNX(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZAddXX 只更新已经存在的成员,不添加新成员
+func (r *Redis) ZAddXX(ctx context.Context, key string, members ...Z) IntValuer {
+	cmd := r.cli.ZAddXX(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZAddGT 新分数大于原来的分数时才更新,新成员照常添加
+func (r *Redis) ZAddGT(ctx context.Context, key string, members ...Z) IntValuer {
+	cmd := r.cli.ZAddGT(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZAddLT 新分数小于原来的分数时才更新,新成员照常添加
+func (r *Redis) ZAddLT(ctx context.Context, key string, members ...Z) IntValuer {
+	cmd := r.cli.ZAddLT(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZAddArgs 按 NX/XX/GT/LT/CH 条件添加成员,设置 Ch 时返回值包括分数被更新的成员数量
+func (r *Redis) ZAddArgs(ctx context.Context, key string, args ZAddArgs) IntValuer {
+	cmd := r.cli.ZAddArgs(ctx, key, args)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZAddArgsIncr 同 ZAddArgs,在原来的分数上增加,只能有一个成员;条件不满足时返回 errors.Nil
+func (r *Redis) ZAddArgsIncr(ctx context.Context, key string, args ZAddArgs) FloatValuer {
+	cmd := r.cli.ZAddArgsIncr(ctx, key, args)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRangeByScoreWithScores 同 ZRangeByScore, 返回的成员带上 score 值
+func (r *Redis) ZRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ZSliceValuer {
+	cmd := r.cli.ZRangeByScoreWithScores(ctx, key, opt)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRangeByLex 返回成员在字典序区间内的成员,区间以 "[" 或 "(" 开头,"-" "+" 表示无穷小跟无穷大
+func (r *Redis) ZRangeByLex(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
+	cmd := r.cli.ZRangeByLex(ctx, key, opt)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRevRangeWithScores 同 ZRevRange, 返回的成员带上 score 值
+func (r *Redis) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ZSliceValuer {
+	cmd := r.cli.ZRevRangeWithScores(ctx, key, start, stop)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRevRangeByScore 同 ZRangeByScore,成员按 score 值递减(从大到小)排列
+func (r *Redis) ZRevRangeByScore(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
+	cmd := r.cli.ZRevRangeByScore(ctx, key, opt)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRevRangeByScoreWithScores 同 ZRevRangeByScore, 返回的成员带上 score 值
+func (r *Redis) ZRevRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ZSliceValuer {
+	cmd := r.cli.ZRevRangeByScoreWithScores(ctx, key, opt)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRevRangeByLex 同 ZRangeByLex,成员按字典序的逆序排列
+func (r *Redis) ZRevRangeByLex(ctx context.Context, key string, opt *ZRangeBy) StringSliceValuer {
+	cmd := r.cli.ZRevRangeByLex(ctx, key, opt)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZMScore 返回多个成员的 score 值,不存在的成员返回0
+func (r *Redis) ZMScore(ctx context.Context, key string, members ...string) FloatSliceValuer {
+	cmd := r.cli.ZMScore(ctx, key, members...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZPopMin 移除并返回 score 值最小的 count 个成员,不传 count 时为1
+func (r *Redis) ZPopMin(ctx context.Context, key string, count ...int64) ZSliceValuer {
+	cmd := r.cli.ZPopMin(ctx, key, count...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZPopMax 移除并返回 score 值最大的 count 个成员,不传 count 时为1
+func (r *Redis) ZPopMax(ctx context.Context, key string, count ...int64) ZSliceValuer {
+	cmd := r.cli.ZPopMax(ctx, key, count...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// BZPopMin 从第一个非空的有序集合中移除并返回 score 值最小的成员
+// 有序集合都为空时阻塞等待,直到超时或者有可弹出的成员为止;timeout 为0时一直阻塞
+func (r *Redis) BZPopMin(ctx context.Context, timeout time.Duration, keys ...string) ZWithKeyValuer {
+	cmd := r.cli.BZPopMin(ctx, timeout, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// BZPopMax 同 BZPopMin,移除并返回 score 值最大的成员
+func (r *Redis) BZPopMax(ctx context.Context, timeout time.Duration, keys ...string) ZWithKeyValuer {
+	cmd := r.cli.BZPopMax(ctx, timeout, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRandMember 随机返回有序集合中的成员
+// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+func (r *Redis) ZRandMember(ctx context.Context, key string, count int) StringSliceValuer {
+	cmd := r.cli.ZRandMember(ctx, key, count)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZRandMemberWithScores 同 ZRandMember, 返回的成员带上 score 值
+func (r *Redis) ZRandMemberWithScores(ctx context.Context, key string, count int) ZSliceValuer {
+	cmd := r.cli.ZRandMemberWithScores(ctx, key, count)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZUnionStore 把多个有序集合的并集保存到 destination 中,返回结果的成员数量
+func (r *Redis) ZUnionStore(ctx context.Context, destination string, store *ZStore) IntValuer {
+	cmd := r.cli.ZUnionStore(ctx, destination, store)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZInterStore 把多个有序集合的交集保存到 destination 中,返回结果的成员数量
+func (r *Redis) ZInterStore(ctx context.Context, destination string, store *ZStore) IntValuer {
+	cmd := r.cli.ZInterStore(ctx, destination, store)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
+
+// ZDiffStore 把第一个有序集合跟其他有序集合的差集保存到 destination 中,返回结果的成员数量
+func (r *Redis) ZDiffStore(ctx context.Context, destination string, keys ...string) IntValuer {
+	cmd := r.cli.ZDiffStore(ctx, destination, keys...)
+	cmd.SetErr(translateErr(cmd.Err()))
+	return cmd
+}
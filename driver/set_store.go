@@ -0,0 +1,513 @@
+package driver
+
+import (
+	"context"
+	"math/rand"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/16 10:05
+  @describe : 集合类型的存储器
+*/
+
+// setValue 集合值
+type setValue struct {
+	expireValue
+
+	// value 集合成员
+	value map[string]struct{}
+
+	// bytes 所有成员的字节数
+	bytes int64
+}
+
+func newSetValue() *setValue {
+	return &setValue{
+		expireValue: expireValue{},
+		value:       make(map[string]struct{}),
+	}
+}
+
+// Type 返回数值的存储类型
+func (v *setValue) Type() driverStoreType {
+	return driverStoreTypeSet
+}
+
+// size 数值占用的字节数估算值
+func (v *setValue) size() int64 {
+	return v.bytes
+}
+
+// charge 记录当前占用的字节数,返回跟上次记录时的差值
+func (v *setValue) charge() int64 {
+	return v.chargeSize(v.size())
+}
+
+// add 添加成员,返回是否新增的成员
+func (v *setValue) add(member string) bool {
+	if _, ok := v.value[member]; ok {
+		return false
+	}
+	v.bytes += int64(len(member))
+	v.value[member] = struct{}{}
+	return true
+}
+
+// rem 移除成员,返回成员是否存在
+func (v *setValue) rem(member string) bool {
+	if _, ok := v.value[member]; !ok {
+		return false
+	}
+	v.bytes -= int64(len(member))
+	delete(v.value, member)
+	return true
+}
+
+// has 判断成员是否存在
+func (v *setValue) has(member string) bool {
+	_, ok := v.value[member]
+	return ok
+}
+
+// members 返回所有成员
+func (v *setValue) members() []string {
+	result := make([]string, 0, len(v.value))
+	for member := range v.value {
+		result = append(result, member)
+	}
+	return result
+}
+
+// random 随机返回最多 count 个不重复的成员
+func (v *setValue) random(count int64) []string {
+	members := v.members()
+	if count >= int64(len(members)) {
+		return members
+	}
+
+	// 只打乱前 count 个位置
+	for i := 0; int64(i) < count; i++ {
+		j := i + rand.Intn(len(members)-i)
+		members[i], members[j] = members[j], members[i]
+	}
+	return members[:count]
+}
+
+// dump 导出数值
+func (v *setValue) dump() []string {
+	return v.members()
+}
+
+type setStore struct {
+	baseStore
+}
+
+func newSetStore(ks *keyspace) *setStore {
+	return &setStore{
+		baseStore: baseStore{keyspace: ks},
+	}
+}
+
+// restore 根据导出的数据恢复某个键
+func (s *setStore) restore(entry *storeEntry) {
+	val := newSetValue()
+	for _, member := range entry.Values {
+		val.add(member)
+	}
+	val.SetExpireAt(entry.ExpireAt)
+	s.baseStore.restore(entry.Key, val)
+}
+
+// SAdd 添加一个或多个成员到集合中,已经存在的成员将被忽略
+// @return 新增的成员数量
+func (s *setStore) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*setValue)
+		if !ok {
+			val = newSetValue()
+		}
+
+		newCnt := int64(0)
+		for _, member := range members {
+			if val.add(member) {
+				newCnt++
+			}
+		}
+
+		// 没有成员的集合不需要保存
+		if len(val.value) == 0 {
+			return 0, nil
+		}
+		sh.store(key, val)
+		return newCnt, nil
+	}
+}
+
+// SRem 移除集合中的一个或多个成员,不存在的成员将被忽略,集合为空时删除该键
+// @return 被成功移除的成员数量
+func (s *setStore) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*setValue)
+		if !ok {
+			return 0, nil
+		}
+
+		affectsCnt := int64(0)
+		for _, member := range members {
+			if val.rem(member) {
+				affectsCnt++
+			}
+		}
+		if len(val.value) == 0 {
+			sh.remove(key)
+		} else {
+			sh.store(key, val)
+		}
+		return affectsCnt, nil
+	}
+}
+
+// SPop 随机移除并返回集合中最多 count 个成员,集合为空时删除该键
+func (s *setStore) SPop(ctx context.Context, key string, count int64) ([]string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*setValue)
+		if !ok || count <= 0 {
+			return []string{}, nil
+		}
+
+		members := val.random(count)
+		for _, member := range members {
+			val.rem(member)
+		}
+		if len(val.value) == 0 {
+			sh.remove(key)
+		} else {
+			sh.store(key, val)
+		}
+		return members, nil
+	}
+}
+
+// SRandMember 随机返回集合中的成员,不会移除成员
+// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+func (s *setStore) SRandMember(ctx context.Context, key string, count int64) ([]string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*setValue)
+		if !ok || count == 0 {
+			return []string{}, nil
+		}
+
+		if count > 0 {
+			return val.random(count), nil
+		}
+
+		members := val.members()
+		result := make([]string, -count)
+		for i := range result {
+			result[i] = members[rand.Intn(len(members))]
+		}
+		return result, nil
+	}
+}
+
+// SMembers 返回集合中的所有成员
+func (s *setStore) SMembers(ctx context.Context, key string) ([]string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*setValue)
+		if !ok {
+			return []string{}, nil
+		}
+		return val.members(), nil
+	}
+}
+
+// SIsMember 判断成员是否在集合中
+func (s *setStore) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return false, err
+		}
+		val, ok := v.(*setValue)
+		if !ok {
+			return false, nil
+		}
+		return val.has(member), nil
+	}
+}
+
+// SMIsMember 判断多个成员是否在集合中,返回结果的顺序跟 members 一致
+func (s *setStore) SMIsMember(ctx context.Context, key string, members ...string) ([]bool, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		result := make([]bool, len(members))
+
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.(*setValue)
+		if !ok {
+			return result, nil
+		}
+
+		for i, member := range members {
+			result[i] = val.has(member)
+		}
+		return result, nil
+	}
+}
+
+// SCard 返回集合的成员数量
+func (s *setStore) SCard(ctx context.Context, key string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		v, err := sh.lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return 0, err
+		}
+		val, ok := v.(*setValue)
+		if !ok {
+			return 0, nil
+		}
+		return int64(len(val.value)), nil
+	}
+}
+
+// setOp 多个集合之间的运算
+type setOp int
+
+const (
+	setOpInter setOp = iota
+	setOpUnion
+	setOpDiff
+)
+
+// compute 计算多个集合运算后的结果,调用方需要持有所有键所在分片的锁
+// 不存在的键视为空集合,任意一个键的类型不是集合时返回 MemoryWrongType
+func (s *setStore) compute(op setOp, keys ...string) (map[string]struct{}, error) {
+	sets := make([]*setValue, len(keys))
+	for i, key := range keys {
+		v, err := s.shard(key).lookup(key, driverStoreTypeSet)
+		if err != nil {
+			return nil, err
+		}
+		sets[i], _ = v.(*setValue)
+	}
+
+	result := make(map[string]struct{})
+	if len(sets) == 0 {
+		return result, nil
+	}
+
+	switch op {
+	case setOpInter:
+		for _, val := range sets {
+			// 有一个是空集合,交集就是空集合
+			if val == nil {
+				return result, nil
+			}
+		}
+		for member := range sets[0].value {
+			in := true
+			for _, val := range sets[1:] {
+				if !val.has(member) {
+					in = false
+					break
+				}
+			}
+			if in {
+				result[member] = struct{}{}
+			}
+		}
+	case setOpUnion:
+		for _, val := range sets {
+			if val == nil {
+				continue
+			}
+			for member := range val.value {
+				result[member] = struct{}{}
+			}
+		}
+	case setOpDiff:
+		if sets[0] == nil {
+			return result, nil
+		}
+		for member := range sets[0].value {
+			in := false
+			for _, val := range sets[1:] {
+				if val != nil && val.has(member) {
+					in = true
+					break
+				}
+			}
+			if !in {
+				result[member] = struct{}{}
+			}
+		}
+	}
+	return result, nil
+}
+
+// readOp 加读锁后计算多个集合运算后的结果
+func (s *setStore) readOp(ctx context.Context, op setOp, keys ...string) ([]string, error) {
+	unlock := s.lockShards(false, keys...)
+	defer unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		members, err := s.compute(op, keys...)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]string, 0, len(members))
+		for member := range members {
+			result = append(result, member)
+		}
+		return result, nil
+	}
+}
+
+// storeOp 计算多个集合运算后的结果并保存到 destination 中
+// destination 原来的数值会被覆盖,结果为空集合时删除 destination
+func (s *setStore) storeOp(ctx context.Context, op setOp, destination string, keys ...string) (int64, error) {
+	locks := make([]string, 0, len(keys)+1)
+	locks = append(locks, destination)
+	locks = append(locks, keys...)
+	unlock := s.lockShards(true, locks...)
+	defer unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		members, err := s.compute(op, keys...)
+		if err != nil {
+			return 0, err
+		}
+
+		sh := s.shard(destination)
+		sh.remove(destination)
+		if len(members) == 0 {
+			return 0, nil
+		}
+
+		val := newSetValue()
+		for member := range members {
+			val.add(member)
+		}
+		sh.store(destination, val)
+		return int64(len(val.value)), nil
+	}
+}
+
+// SInter 返回多个集合的交集
+func (s *setStore) SInter(ctx context.Context, keys ...string) ([]string, error) {
+	return s.readOp(ctx, setOpInter, keys...)
+}
+
+// SUnion 返回多个集合的并集
+func (s *setStore) SUnion(ctx context.Context, keys ...string) ([]string, error) {
+	return s.readOp(ctx, setOpUnion, keys...)
+}
+
+// SDiff 返回第一个集合跟其他集合的差集
+func (s *setStore) SDiff(ctx context.Context, keys ...string) ([]string, error) {
+	return s.readOp(ctx, setOpDiff, keys...)
+}
+
+// SInterStore 把多个集合的交集保存到 destination 中
+// @return 结果集合的成员数量
+func (s *setStore) SInterStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	return s.storeOp(ctx, setOpInter, destination, keys...)
+}
+
+// SUnionStore 把多个集合的并集保存到 destination 中
+// @return 结果集合的成员数量
+func (s *setStore) SUnionStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	return s.storeOp(ctx, setOpUnion, destination, keys...)
+}
+
+// SDiffStore 把第一个集合跟其他集合的差集保存到 destination 中
+// @return 结果集合的成员数量
+func (s *setStore) SDiffStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	return s.storeOp(ctx, setOpDiff, destination, keys...)
+}
@@ -0,0 +1,192 @@
+package driver
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/16 11:30
+  @describe :
+*/
+
+func Test_setStore_SAdd(t *testing.T) {
+	s := newSetStore(newKeyspace())
+	s.SAdd(context.Background(), "key", "a", "b")
+	type args struct {
+		ctx     context.Context
+		key     string
+		members []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "已存在的成员不计数",
+			args: args{
+				ctx:     context.Background(),
+				key:     "key",
+				members: []string{"a", "b", "c"},
+			},
+			want: 1,
+		},
+		{
+			name: "重复的成员只计一次",
+			args: args{
+				ctx:     context.Background(),
+				key:     "new",
+				members: []string{"a", "a", "b"},
+			},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SAdd(tt.args.ctx, tt.args.key, tt.args.members...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SAdd() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SAdd() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_setStore_SRem(t *testing.T) {
+	ctx := context.Background()
+	s := newSetStore(newKeyspace())
+	s.SAdd(ctx, "key", "a", "b", "c")
+
+	if got, _ := s.SRem(ctx, "key", "a", "x"); got != 1 {
+		t.Errorf("SRem() got = %v, want 1", got)
+	}
+	if got, _ := s.SRem(ctx, "key", "b", "c"); got != 2 {
+		t.Errorf("SRem() got = %v, want 2", got)
+	}
+
+	// 集合为空时删除该键
+	if v := s.value("key"); v != nil {
+		t.Errorf("SRem() empty set still exists: %v", v)
+	}
+}
+
+func Test_setStore_SPop(t *testing.T) {
+	ctx := context.Background()
+	s := newSetStore(newKeyspace())
+	s.SAdd(ctx, "key", "a", "b", "c")
+
+	popped, err := s.SPop(ctx, "key", 2)
+	if err != nil || len(popped) != 2 {
+		t.Fatalf("SPop() got = %v, err = %v", popped, err)
+	}
+	for _, member := range popped {
+		if ok, _ := s.SIsMember(ctx, "key", member); ok {
+			t.Errorf("SPop() member %v still exists", member)
+		}
+	}
+
+	rest, _ := s.SPop(ctx, "key", 10)
+	if len(rest) != 1 {
+		t.Errorf("SPop() got = %v, want 1 member", rest)
+	}
+	if v := s.value("key"); v != nil {
+		t.Errorf("SPop() empty set still exists: %v", v)
+	}
+}
+
+func Test_setStore_SRandMember(t *testing.T) {
+	ctx := context.Background()
+	s := newSetStore(newKeyspace())
+	s.SAdd(ctx, "key", "a", "b", "c")
+
+	got, _ := s.SRandMember(ctx, "key", 5)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("SRandMember(5) got = %v", got)
+	}
+
+	// 负数时成员可以重复
+	got, _ = s.SRandMember(ctx, "key", -5)
+	if len(got) != 5 {
+		t.Errorf("SRandMember(-5) got = %v, want 5 members", got)
+	}
+	if n, _ := s.SCard(ctx, "key"); n != 3 {
+		t.Errorf("SRandMember() SCard = %v, want 3", n)
+	}
+}
+
+func Test_setStore_Op(t *testing.T) {
+	ctx := context.Background()
+	s := newSetStore(newKeyspace())
+	s.SAdd(ctx, "a", "1", "2", "3")
+	s.SAdd(ctx, "b", "2", "3", "4")
+	s.SAdd(ctx, "c", "3", "5")
+
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context, keys ...string) ([]string, error)
+		keys []string
+		want []string
+	}{
+		{name: "交集", fn: s.SInter, keys: []string{"a", "b", "c"}, want: []string{"3"}},
+		{name: "交集包含不存在的键", fn: s.SInter, keys: []string{"a", "missing"}, want: []string{}},
+		{name: "并集", fn: s.SUnion, keys: []string{"a", "b", "missing"}, want: []string{"1", "2", "3", "4"}},
+		{name: "差集", fn: s.SDiff, keys: []string{"a", "b"}, want: []string{"1"}},
+		{name: "差集第一个键不存在", fn: s.SDiff, keys: []string{"missing", "a"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(ctx, tt.keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	newStringStore(s.keyspace).Set(ctx, "str", "v", KeepTTL)
+	if _, err := s.SUnion(ctx, "a", "str"); err != MemoryWrongType {
+		t.Errorf("SUnion() err = %v, want %v", err, MemoryWrongType)
+	}
+}
+
+func Test_setStore_SInterStore(t *testing.T) {
+	ctx := context.Background()
+	s := newSetStore(newKeyspace())
+	s.SAdd(ctx, "a", "1", "2", "3")
+	s.SAdd(ctx, "b", "2", "3", "4")
+	s.SAdd(ctx, "dest", "x")
+
+	if n, err := s.SInterStore(ctx, "dest", "a", "b"); err != nil || n != 2 {
+		t.Errorf("SInterStore() got = %v, err = %v", n, err)
+	}
+	got, _ := s.SMembers(ctx, "dest")
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"2", "3"}) {
+		t.Errorf("SInterStore() dest = %v", got)
+	}
+
+	// 目标键可以是参与运算的键
+	if n, _ := s.SDiffStore(ctx, "a", "a", "b"); n != 1 {
+		t.Errorf("SDiffStore() got = %v, want 1", n)
+	}
+
+	// 结果为空时删除目标键
+	if n, _ := s.SInterStore(ctx, "dest", "a", "missing"); n != 0 {
+		t.Errorf("SInterStore() got = %v, want 0", n)
+	}
+	if v := s.value("dest"); v != nil {
+		t.Errorf("SInterStore() empty dest still exists: %v", v)
+	}
+}
@@ -0,0 +1,504 @@
+package driver
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/14 10:30
+  @describe : 有序集合使用的跳表,参考 redis 的 zskiplist 实现
+*/
+
+const (
+	// skipListMaxLevel 跳表的最大层数,足够容纳 2^64 个元素
+	skipListMaxLevel = 32
+
+	// skipListP 节点层数每增加一层的概率
+	skipListP = 0.25
+)
+
+// skipListLevel 跳表节点的某一层
+type skipListLevel struct {
+	// forward 该层的下一个节点
+	forward *skipListNode
+
+	// span 到该层下一个节点之间跨越的节点数,用于计算排名
+	span int
+}
+
+// skipListNode 跳表节点
+type skipListNode struct {
+	// Member 成员信息
+	Member string
+
+	// Score 分数值
+	Score float64
+
+	// backward 第0层的上一个节点
+	backward *skipListNode
+
+	level []skipListLevel
+}
+
+// before 判断节点是否排在 (score, member) 之前
+// 先按分数从小到大,分数相同时按成员的字典序
+func (n *skipListNode) before(score float64, member string) bool {
+	return n.Score < score || (n.Score == score && n.Member < member)
+}
+
+// skipList 跳表,按分数从小到大排列
+// 排名(rank)从1开始,0表示不存在
+type skipList struct {
+	header *skipListNode
+	tail   *skipListNode
+
+	// length 节点数量
+	length int
+
+	// level 当前最大层数
+	level int
+}
+
+// newSkipList 返回一个空的跳表
+func newSkipList() *skipList {
+	return &skipList{
+		header: &skipListNode{level: make([]skipListLevel, skipListMaxLevel)},
+		level:  1,
+	}
+}
+
+// randomLevel 随机生成新节点的层数
+func randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Float64() < skipListP {
+		level++
+	}
+	return level
+}
+
+// String 返回跳表的字符串表示,用于调试
+func (sl *skipList) String() string {
+	var b strings.Builder
+	rank := 0
+	for x := sl.header.level[0].forward; x != nil; x = x.level[0].forward {
+		fmt.Fprintf(&b, "(%d)%s:%0.2f ", rank, x.Member, x.Score)
+		rank++
+	}
+	return fmt.Sprintf("[%s]", b.String())
+}
+
+// insert 插入一个节点,调用方需要保证成员不存在
+func (sl *skipList) insert(score float64, member string) *skipListNode {
+	var update [skipListMaxLevel]*skipListNode
+	var rank [skipListMaxLevel]int
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		if i != sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = sl.header
+			update[i].level[i].span = sl.length
+		}
+		sl.level = level
+	}
+
+	x = &skipListNode{Member: member, Score: score, level: make([]skipListLevel, level)}
+	for i := 0; i < level; i++ {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = (rank[0] - rank[i]) + 1
+	}
+
+	// 更高的层跨越了新节点
+	for i := level; i < sl.level; i++ {
+		update[i].level[i].span++
+	}
+
+	if update[0] != sl.header {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		sl.tail = x
+	}
+	sl.length++
+	return x
+}
+
+// deleteNode 删除节点,update 为每一层中排在该节点之前的节点
+func (sl *skipList) deleteNode(x *skipListNode, update []*skipListNode) {
+	for i := 0; i < sl.level; i++ {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
+	}
+
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		sl.tail = x.backward
+	}
+
+	for sl.level > 1 && sl.header.level[sl.level-1].forward == nil {
+		sl.level--
+	}
+	sl.length--
+}
+
+// findUpdate 查找每一层中排在 (score, member) 之前的最后一个节点
+func (sl *skipList) findUpdate(score float64, member string) []*skipListNode {
+	update := make([]*skipListNode, skipListMaxLevel)
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	return update
+}
+
+// delete 删除指定的节点,不存在时返回false
+func (sl *skipList) delete(score float64, member string) bool {
+	update := sl.findUpdate(score, member)
+	x := update[0].level[0].forward
+	if x != nil && x.Score == score && x.Member == member {
+		sl.deleteNode(x, update)
+		return true
+	}
+	return false
+}
+
+// updateScore 更新节点的分数,调用方需要保证节点存在
+// 新的分数不改变节点位置时直接修改,否则删除后重新插入
+func (sl *skipList) updateScore(score float64, member string, newScore float64) *skipListNode {
+	update := sl.findUpdate(score, member)
+	x := update[0].level[0].forward
+
+	if (x.backward == nil || x.backward.before(newScore, member)) &&
+		(x.level[0].forward == nil || !x.level[0].forward.before(newScore, member)) {
+		x.Score = newScore
+		return x
+	}
+
+	sl.deleteNode(x, update)
+	return sl.insert(newScore, member)
+}
+
+// rank 返回节点的排名,从1开始,不存在时返回0
+func (sl *skipList) rank(score float64, member string) int {
+	rank := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil &&
+			(x.level[i].forward.before(score, member) || (x.level[i].forward.Score == score && x.level[i].forward.Member == member)) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+
+		if x != sl.header && x.Member == member {
+			return rank
+		}
+	}
+	return 0
+}
+
+// byRank 返回指定排名的节点,排名从1开始,不存在时返回nil
+func (sl *skipList) byRank(rank int) *skipListNode {
+	traversed := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		if traversed == rank && x != sl.header {
+			return x
+		}
+	}
+	return nil
+}
+
+// scoreRange 分数区间
+type scoreRange struct {
+	min, max float64
+
+	// minex,maxex 是否排除边界值
+	minex, maxex bool
+}
+
+// parseScoreRange 解析分数区间,支持 "(" 前缀表示开区间,以及 "-inf","+inf"
+func parseScoreRange(min, max string) (*scoreRange, error) {
+	r := new(scoreRange)
+	var err error
+
+	if strings.HasPrefix(min, "(") {
+		r.minex = true
+		min = min[1:]
+	}
+	if r.min, err = strconv.ParseFloat(min, 64); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(max, "(") {
+		r.maxex = true
+		max = max[1:]
+	}
+	if r.max, err = strconv.ParseFloat(max, 64); err != nil {
+		return nil, err
+	}
+
+	if math.IsNaN(r.min) || math.IsNaN(r.max) {
+		return nil, strconv.ErrSyntax
+	}
+	return r, nil
+}
+
+// gteMin 分数是否满足区间下限
+func (r *scoreRange) gteMin(score float64) bool {
+	if r.minex {
+		return score > r.min
+	}
+	return score >= r.min
+}
+
+// lteMax 分数是否满足区间上限
+func (r *scoreRange) lteMax(score float64) bool {
+	if r.maxex {
+		return score < r.max
+	}
+	return score <= r.max
+}
+
+// isInRange 跳表中是否有节点在区间内
+func (sl *skipList) isInRange(r *scoreRange) bool {
+	if r.min > r.max || (r.min == r.max && (r.minex || r.maxex)) {
+		return false
+	}
+
+	if sl.tail == nil || !r.gteMin(sl.tail.Score) {
+		return false
+	}
+
+	first := sl.header.level[0].forward
+	if first == nil || !r.lteMax(first.Score) {
+		return false
+	}
+	return true
+}
+
+// firstInRange 返回区间内的第一个节点,没有时返回nil
+func (sl *skipList) firstInRange(r *scoreRange) *skipListNode {
+	if !sl.isInRange(r) {
+		return nil
+	}
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !r.gteMin(x.level[i].forward.Score) {
+			x = x.level[i].forward
+		}
+	}
+
+	x = x.level[0].forward
+	if !r.lteMax(x.Score) {
+		return nil
+	}
+	return x
+}
+
+// lastInRange 返回区间内的最后一个节点,没有时返回nil
+func (sl *skipList) lastInRange(r *scoreRange) *skipListNode {
+	if !sl.isInRange(r) {
+		return nil
+	}
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && r.lteMax(x.level[i].forward.Score) {
+			x = x.level[i].forward
+		}
+	}
+
+	if !r.gteMin(x.Score) {
+		return nil
+	}
+	return x
+}
+
+// deleteRangeByScore 删除分数在区间内的所有节点,每删除一个节点回调一次 fn,返回删除的数量
+func (sl *skipList) deleteRangeByScore(r *scoreRange, fn func(member string)) int {
+	update := make([]*skipListNode, skipListMaxLevel)
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !r.gteMin(x.level[i].forward.Score) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	removed := 0
+	x = x.level[0].forward
+	for x != nil && r.lteMax(x.Score) {
+		next := x.level[0].forward
+		sl.deleteNode(x, update)
+		fn(x.Member)
+		removed++
+		x = next
+	}
+	return removed
+}
+
+// deleteRangeByRank 删除排名在 [start, stop] 之间的所有节点,每删除一个节点回调一次 fn,排名从1开始,返回删除的数量
+func (sl *skipList) deleteRangeByRank(start, stop int, fn func(member string)) int {
+	update := make([]*skipListNode, skipListMaxLevel)
+	traversed := 0
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span < start {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	removed := 0
+	traversed++
+	x = x.level[0].forward
+	for x != nil && traversed <= stop {
+		next := x.level[0].forward
+		sl.deleteNode(x, update)
+		fn(x.Member)
+		removed++
+		traversed++
+		x = next
+	}
+	return removed
+}
+
+// lexBound 字典序区间的边界
+type lexBound struct {
+	value string
+
+	// ex 是否排除边界值
+	ex bool
+
+	// inf 为 -1 时表示 "-" 无穷小,为 1 时表示 "+" 无穷大
+	inf int
+}
+
+// lexRange 字典序区间
+type lexRange struct {
+	min, max lexBound
+}
+
+// parseLexBound 解析字典序区间的边界,"[" 前缀表示闭区间,"(" 前缀表示开区间,"-","+" 表示无穷小跟无穷大
+func parseLexBound(s string) (lexBound, error) {
+	switch {
+	case s == "-":
+		return lexBound{inf: -1}, nil
+	case s == "+":
+		return lexBound{inf: 1}, nil
+	case strings.HasPrefix(s, "("):
+		return lexBound{value: s[1:], ex: true}, nil
+	case strings.HasPrefix(s, "["):
+		return lexBound{value: s[1:]}, nil
+	}
+	return lexBound{}, MemoryInvalidLexRange
+}
+
+// parseLexRange 解析字典序区间
+func parseLexRange(min, max string) (*lexRange, error) {
+	r := new(lexRange)
+	var err error
+	if r.min, err = parseLexBound(min); err != nil {
+		return nil, err
+	}
+	if r.max, err = parseLexBound(max); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// gteMin 成员是否满足区间下限
+func (r *lexRange) gteMin(member string) bool {
+	switch r.min.inf {
+	case -1:
+		return true
+	case 1:
+		return false
+	}
+	if r.min.ex {
+		return member > r.min.value
+	}
+	return member >= r.min.value
+}
+
+// lteMax 成员是否满足区间上限
+func (r *lexRange) lteMax(member string) bool {
+	switch r.max.inf {
+	case 1:
+		return true
+	case -1:
+		return false
+	}
+	if r.max.ex {
+		return member < r.max.value
+	}
+	return member <= r.max.value
+}
+
+// firstInLexRange 返回字典序区间内的第一个节点,没有时返回nil
+// 跟 redis 一样只按成员比较,所有节点的分数相同时结果才有意义
+func (sl *skipList) firstInLexRange(r *lexRange) *skipListNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !r.gteMin(x.level[i].forward.Member) {
+			x = x.level[i].forward
+		}
+	}
+
+	x = x.level[0].forward
+	if x == nil || !r.lteMax(x.Member) {
+		return nil
+	}
+	return x
+}
+
+// lastInLexRange 返回字典序区间内的最后一个节点,没有时返回nil
+func (sl *skipList) lastInLexRange(r *lexRange) *skipListNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && r.lteMax(x.level[i].forward.Member) {
+			x = x.level[i].forward
+		}
+	}
+
+	if x == sl.header || !r.gteMin(x.Member) {
+		return nil
+	}
+	return x
+}
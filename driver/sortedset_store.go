@@ -2,11 +2,10 @@ package driver
 
 import (
 	"context"
-	"fmt"
-	"sort"
+	"math"
+	"math/rand"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	utils "github.com/jerbe/go-utils"
@@ -22,279 +21,454 @@ type SZ struct {
 	Score  float64
 }
 
-type sortedSetRankList []*sortedSetData
+// sortedSetValue 可排序集合值
+type sortedSetValue struct {
+	expireValue
 
-func (l sortedSetRankList) String() string {
-	var s string
-	for _, data := range l {
-		//s += fmt.Sprintf("{%d:%+v}, ", i, data)
-		s += fmt.Sprintf("(%d)%s:%0.2f ", data.Rank, data.Member, data.Score)
-		//s += fmt.Sprintf("%s ", data.Member)
-	}
-	return fmt.Sprintf("[%s]", s)
+	// rankList 按分数排序的跳表
+	rankList *skipList
+
+	// mapping 成员到分数的映射
+	mapping map[string]float64
+
+	// bytes 所有成员跟分数的字节数
+	bytes int64
 }
 
-type sortedSetData struct {
-	// Member 成员信息
-	Member string
+// sortedSetScoreSize 每个成员的分数占用的字节数
+const sortedSetScoreSize = 8
 
-	// Score 分数值
-	Score float64
+// size 数值占用的字节数估算值
+func (v *sortedSetValue) size() int64 {
+	return v.bytes
+}
 
-	// Rank 排名
-	Rank int
+// charge 记录当前占用的字节数,返回跟上次记录时的差值
+func (v *sortedSetValue) charge() int64 {
+	return v.chargeSize(v.size())
 }
 
-// sortedSetValue 可排序集合值
-type sortedSetValue struct {
-	expireValue
+// add 添加或者更新成员的分数,返回是否新增的成员
+func (v *sortedSetValue) add(member string, score float64) bool {
+	old, ok := v.mapping[member]
+	if ok {
+		if old != score {
+			v.rankList.updateScore(old, member, score)
+			v.mapping[member] = score
+		}
+		return false
+	}
 
-	// rankList 排名顺序列表
-	rankList sortedSetRankList
+	v.rankList.insert(score, member)
+	v.mapping[member] = score
+	v.bytes += int64(len(member)) + sortedSetScoreSize
+	return true
+}
 
-	// mapping 字典映射
-	mapping map[string]*sortedSetData
+// remove 删除成员,返回成员是否存在
+func (v *sortedSetValue) remove(member string) bool {
+	score, ok := v.mapping[member]
+	if !ok {
+		return false
+	}
+
+	v.rankList.delete(score, member)
+	v.forget(member)
+	return true
 }
 
-// Refresh 重新排序,并刷新排行
-func (v *sortedSetValue) Refresh() {
-	v.sortRank()
+// zAddFlag ZAdd 的条件,跟 redis 的 NX/XX/GT/LT/CH 选项对应
+type zAddFlag int
+
+const (
+	// zAddNX 只添加新成员,不更新已经存在的成员
+	zAddNX zAddFlag = 1 << iota
+
+	// zAddXX 只更新已经存在的成员,不添加新成员
+	zAddXX
+
+	// zAddGT 新分数大于原来的分数时才更新
+	zAddGT
 
-	for i, data := range v.rankList {
-		data.Rank = i
+	// zAddLT 新分数小于原来的分数时才更新
+	zAddLT
+
+	// zAddCH 返回值包括分数被更新的成员数量
+	zAddCH
+)
+
+// has 是否带有某个条件
+func (f zAddFlag) has(flag zAddFlag) bool {
+	return f&flag != 0
+}
+
+// zAddFlags 把 ZAddArgs 的条件转换成 zAddFlag,跟 go-redis 一样 NX 优先于 XX/GT/LT,GT 优先于 LT
+func zAddFlags(args ZAddArgs) zAddFlag {
+	var flag zAddFlag
+	if args.NX {
+		flag |= zAddNX
+	} else {
+		if args.XX {
+			flag |= zAddXX
+		}
+		if args.GT {
+			flag |= zAddGT
+		} else if args.LT {
+			flag |= zAddLT
+		}
 	}
+	if args.Ch {
+		flag |= zAddCH
+	}
+	return flag
 }
 
-func (v *sortedSetValue) sortRank() {
-	// 官方排序法
-	sort.SliceStable(v.rankList, func(i, j int) bool {
-		if v.rankList[i].Score < v.rankList[j].Score {
-			return true
+// zAddResult 按条件添加单个成员的结果
+type zAddResult int
+
+const (
+	// zAddSkipped 条件不满足,没有添加也没有更新
+	zAddSkipped zAddResult = iota
+
+	// zAddAdded 新增了成员
+	zAddAdded
+
+	// zAddUpdated 更新了成员的分数
+	zAddUpdated
+
+	// zAddUnchanged 成员已经存在,分数没有变化
+	zAddUnchanged
+)
+
+// addIf 按 flag 的条件添加成员或者更新成员的分数,incr 为 true 时在原来的分数上增加 score
+// 返回成员最终的分数以及添加的结果
+func (v *sortedSetValue) addIf(flag zAddFlag, incr bool, member string, score float64) (float64, zAddResult, error) {
+	old, ok := v.mapping[member]
+	if !ok {
+		if flag.has(zAddXX) {
+			return 0, zAddSkipped, nil
 		}
+		v.add(member, score)
+		return score, zAddAdded, nil
+	}
+
+	if flag.has(zAddNX) {
+		return old, zAddSkipped, nil
+	}
 
-		if v.rankList[i].Score == v.rankList[j].Score && v.rankList[i].Member < v.rankList[j].Member {
-			return true
+	if incr {
+		score += old
+		if math.IsNaN(score) {
+			return old, zAddSkipped, MemoryScoreNaN
 		}
+	}
 
-		return false
-	})
+	if (flag.has(zAddGT) && score <= old) || (flag.has(zAddLT) && score >= old) {
+		return old, zAddSkipped, nil
+	}
+
+	if score == old {
+		return old, zAddUnchanged, nil
+	}
+	v.add(member, score)
+	return score, zAddUpdated, nil
+}
+
+// forget 从映射中删除已经从跳表中删除的成员
+func (v *sortedSetValue) forget(member string) {
+	delete(v.mapping, member)
+	v.bytes -= int64(len(member)) + sortedSetScoreSize
 }
 
-// Set 设置数据
+// Set 设置数据,返回新增成员的数量
 func (v *sortedSetValue) Set(m []SZ) int64 {
 	newCnt := int64(0)
-	rankLen := len(v.rankList)
 	for _, z := range m {
-		if data, ok := v.mapping[z.Member]; ok {
-			data.Score = z.Score
-		} else {
-			data := &sortedSetData{Member: z.Member, Score: z.Score, Rank: rankLen}
-			v.mapping[z.Member] = data
-			v.rankList = append(v.rankList, data)
-			rankLen++
+		if v.add(z.Member, z.Score) {
 			newCnt++
 		}
 	}
-
-	// 刷新排行
-	v.Refresh()
 	return newCnt
 }
 
+// rangeBy 从 first 开始依次遍历节点,直到 in 返回 false 为止,rev 为 true 时反向遍历
+// offset,count 跟 ZRangeBy 的 LIMIT 参数一致,offset 为负数或者设置了 offset 但 count 为0时没有结果,count 为负数时不限制数量
+func (v *sortedSetValue) rangeBy(first *skipListNode, in func(x *skipListNode) bool, offset, count int64, rev bool, fn func(x *skipListNode)) {
+	limit := offset != 0 || count != 0
+
+	// LIMIT参数限定为正数
+	if first == nil || offset < 0 || (limit && count == 0) {
+		return
+	}
+
+	// 通过排名直接定位偏移后的节点,不需要逐个遍历
+	x := first
+	if offset > 0 {
+		rank := v.rankList.rank(x.Score, x.Member)
+		if rev {
+			rank -= int(offset)
+		} else {
+			rank += int(offset)
+		}
+		x = v.rankList.byRank(rank)
+	}
+
+	for n := int64(0); x != nil && in(x); n++ {
+		if limit && count >= 0 && n >= count {
+			break
+		}
+		fn(x)
+		if rev {
+			x = x.backward
+		} else {
+			x = x.level[0].forward
+		}
+	}
+}
+
+// Type 返回数值的存储类型
+func (v *sortedSetValue) Type() driverStoreType {
+	return driverStoreTypeSortedSet
+}
+
+// dump 导出数值
+func (v *sortedSetValue) dump() []string {
+	result := make([]string, 0, v.rankList.length*2)
+	for x := v.rankList.header.level[0].forward; x != nil; x = x.level[0].forward {
+		result = append(result, x.Member, strconv.FormatFloat(x.Score, 'f', -1, 64))
+	}
+	return result
+}
+
+// rangeIndex 将 start,stop 转换成从0开始的有效索引,区间无效时返回false
+func (v *sortedSetValue) rangeIndex(start, stop int64) (int64, int64, bool) {
+	listLen := int64(v.rankList.length)
+
+	if start < 0 {
+		start = listLen + start
+	}
+	if stop < 0 {
+		stop = listLen + stop
+	}
+
+	if listLen == 0 || stop < start || start >= listLen || stop < 0 {
+		return 0, 0, false
+	}
+
+	// 提取正确的索引位置
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= listLen {
+		stop = listLen - 1
+	}
+	return start, stop, true
+}
+
 // newSortSetValue 返回一个新的有序集合数值对象指针
 func newSortSetValue() *sortedSetValue {
-	defaultExpireAt := time.Now().Add(ValueMaxTTL)
 	return &sortedSetValue{
-		expireValue: expireValue{
-			expireAt: &defaultExpireAt,
-			expired:  false,
-		},
-		mapping:  make(map[string]*sortedSetData),
-		rankList: make([]*sortedSetData, 0),
+		expireValue: expireValue{},
+		mapping:     make(map[string]float64),
+		rankList:    newSkipList(),
 	}
 }
 
 type sortedSetStore struct {
 	baseStore
+
+	// evtSig 有序集合写入成员时发布键名,用于唤醒阻塞弹出
+	evtSig *utils.PubSub
 }
 
-func newSortSetStore() *sortedSetStore {
-	ticker := time.NewTicker(time.Second * 10)
-	store := &sortedSetStore{
-		baseStore: baseStore{
-			values:       make(map[string]expireable),
-			rwMutex:      sync.RWMutex{},
-			expireTicker: ticker,
-		},
+func newSortSetStore(ks *keyspace) *sortedSetStore {
+	return &sortedSetStore{
+		baseStore: baseStore{keyspace: ks},
+		evtSig:    utils.NewPubSub(),
 	}
-
-	go store.checkExpireTick()
-	return store
 }
 
-func (s *sortedSetStore) Type() driverStoreType {
-	return driverStoreTypeSortedSet
+// restore 根据导出的数据恢复某个键
+func (s *sortedSetStore) restore(entry *storeEntry) {
+	val := newSortSetValue()
+	members := make([]SZ, 0, len(entry.Values)/2)
+	for i := 0; i+1 < len(entry.Values); i += 2 {
+		score, _ := strconv.ParseFloat(entry.Values[i+1], 64)
+		members = append(members, SZ{Member: entry.Values[i], Score: score})
+	}
+	val.Set(members)
+	val.SetExpireAt(entry.ExpireAt)
+	s.baseStore.restore(entry.Key, val)
 }
 
 // ZAdd 添加有序集合的元素
 func (s *sortedSetStore) ZAdd(ctx context.Context, key string, members ...SZ) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	return s.ZAddArgs(ctx, key, 0, members...)
+}
+
+// ZAddArgs 按 flag 的条件添加有序集合的元素,返回新增成员的数量
+// flag 带有 zAddCH 时返回值还包括分数被更新的成员数量
+func (s *sortedSetStore) ZAddArgs(ctx context.Context, key string, flag zAddFlag, members ...SZ) (int64, error) {
+	for _, member := range members {
+		if math.IsNaN(member.Score) {
+			return 0, MemoryNotFloat
+		}
+	}
+
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
 	if !ok {
 		val = newSortSetValue()
 	}
 
-	// 使用多个变量一起插入
-	cnt := val.Set(members)
-
-	s.values[key] = val
+	cnt := int64(0)
+	for _, member := range members {
+		_, result, _ := val.addIf(flag, false, member.Member, member.Score)
+		if result == zAddAdded || (result == zAddUpdated && flag.has(zAddCH)) {
+			cnt++
+		}
+	}
 
+	// 只更新不添加时有序集合可能还是空的,不保存空的有序集合
+	if val.rankList.length > 0 {
+		sh.store(key, val)
+		s.evtSig.Publish(key)
+	}
 	return cnt, nil
 }
 
-// ZCard 获取有序集合的元素数量
-func (s *sortedSetStore) ZCard(ctx context.Context, key string) (int64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+// ZAddIncr 按 flag 的条件在成员原来的分数上增加 member.Score,返回成员新的分数
+// 条件不满足时返回 MemoryNil
+func (s *sortedSetStore) ZAddIncr(ctx context.Context, key string, flag zAddFlag, member SZ) (float64, error) {
+	if math.IsNaN(member.Score) {
+		return 0, MemoryNotFloat
+	}
+
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
 	if !ok {
-		return 0, nil
+		val = newSortSetValue()
+	}
+
+	score, result, err := val.addIf(flag, true, member.Member, member.Score)
+	if err != nil {
+		return 0, err
+	}
+	if result == zAddSkipped {
+		return 0, MemoryNil
 	}
 
-	return int64(len(val.rankList)), nil
+	sh.store(key, val)
+	s.evtSig.Publish(key)
+	return score, nil
 }
 
-// ZCount 返回有序集 key 中， score 值在 min 和 max 之间(默认包括 score 值等于 min 或 max )的成员的数量。
-func (s *sortedSetStore) ZCount(ctx context.Context, key, min, max string) (int64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+// ZCard 获取有序集合的元素数量
+func (s *sortedSetStore) ZCard(ctx context.Context, key string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
-	if !ok {
-		return 0, nil
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
 	}
-
-	if len(val.rankList) == 0 {
+	val, ok := v.(*sortedSetValue)
+	if !ok {
 		return 0, nil
 	}
 
-	var (
-		minop      utils.CompareFunc
-		maxop      utils.CompareFunc
-		maxbreakop utils.CompareFunc
-		err        error
-		minf       float64
-		maxf       float64
-		start      = -1
-		stop       = -1
-		listLen    = len(val.rankList)
-	)
-
-	// 操作左区间
-	if min == "-inf" {
-		start = 0
-	} else if strings.Index(min, "(") > -1 {
-		minop = utils.LT
-		min = strings.ReplaceAll(min, "(", "")
-	}
-
-	if min != "-inf" {
-		minf, err = strconv.ParseFloat(min, 64)
-		if err != nil {
-			return 0, err
-		}
+	return int64(val.rankList.length), nil
+}
 
-		if minop == nil {
-			minop = utils.LTE
-		}
-	}
+// ZCount 返回有序集 key 中， score 值在 min 和 max 之间(默认包括 score 值等于 min 或 max )的成员的数量。
+func (s *sortedSetStore) ZCount(ctx context.Context, key, min, max string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
-	// 操作右区间
-	if max == "+inf" {
-		stop = listLen - 1
-	} else if strings.Index(max, "(") > -1 {
-		maxop = utils.LT
-		maxbreakop = utils.LTE
-		max = strings.ReplaceAll(max, "(", "")
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return 0, err
 	}
 
-	if max != "+inf" {
-		maxf, err = strconv.ParseFloat(max, 64)
-		if err != nil {
-			return 0, err
-		}
-
-		if maxop == nil {
-			maxop = utils.LTE
-		}
-		if maxbreakop == nil {
-			maxbreakop = utils.LT
-		}
+	r, err := parseScoreRange(min, max)
+	if err != nil {
+		return 0, err
 	}
 
-	if minf > maxf {
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
 		return 0, err
 	}
+	val, ok := v.(*sortedSetValue)
+	if !ok {
+		return 0, nil
+	}
 
-	for i := 0; i < listLen; i++ {
-		data := val.rankList[i]
-		if maxbreakop != nil && maxbreakop(maxf, data.Score) {
-			break
-		}
-		stop++
-		if (minop == nil || minop(minf, data.Score)) && (maxop == nil || maxop(data.Score, maxf)) {
-			if start == -1 {
-				start = i
-			}
-		}
+	first := val.rankList.firstInRange(r)
+	if first == nil {
+		return 0, nil
 	}
+	last := val.rankList.lastInRange(r)
 
-	return int64(stop - start + 1), nil
+	// 通过首尾两个节点的排名计算数量
+	return int64(val.rankList.rank(last.Score, last.Member) - val.rankList.rank(first.Score, first.Member) + 1), nil
 }
 
 // ZIncrBy 为有序集 key 的成员 member 的 score 值加上增量 increment 。
 // 可以通过传递一个负数值 increment ，让 score 减去相应的值，比如 ZINCRBY key -5 member ，就是让 member 的 score 值减去 5
 // @return member 成员的新 score 值
 func (s *sortedSetStore) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
-	if !ok {
-		val = newSortSetValue()
-		s.values[key] = val
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
 	}
-
-	data, ok := val.mapping[member]
+	val, ok := v.(*sortedSetValue)
 	if !ok {
-		data = &sortedSetData{Member: member}
-		val.mapping[member] = data
-		val.rankList = append(val.rankList, data)
+		val = newSortSetValue()
 	}
-	data.Score += increment
 
-	val.Refresh()
+	newScore := val.mapping[member] + increment
+	val.add(member, newScore)
+	sh.store(key, val)
+	s.evtSig.Publish(key)
 
-	return data.Score, nil
+	return newScore, nil
 }
 
 // ZRange 返回有序集 key 中，指定区间内的成员。
@@ -304,172 +478,204 @@ func (s *sortedSetStore) ZIncrBy(ctx context.Context, key string, increment floa
 // 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
 // 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
 func (s *sortedSetStore) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
-
 	result := make([]string, 0)
-
-	if err := utils.ContextIsDone(ctx); err != nil {
-		return result, err
+	err := s.rangeByRank(ctx, key, start, stop, false, func(x *skipListNode) {
+		result = append(result, x.Member)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	val, ok := s.values[key].(*sortedSetValue)
-	if !ok {
-		return result, nil
+// ZRangeWithScores 同 ZRange, 返回的成员带上 score 值
+func (s *sortedSetStore) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	result := make([]Z, 0)
+	err := s.rangeByRank(ctx, key, start, stop, false, func(x *skipListNode) {
+		result = append(result, Z{Score: x.Score, Member: x.Member})
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	listLen := int64(len(val.rankList))
+// rangeByRank 按排名区间遍历有序集合的成员,rev 为 true 时按 score 值递减排名
+func (s *sortedSetStore) rangeByRank(ctx context.Context, key string, start, stop int64, rev bool, fn func(x *skipListNode)) error {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
-	if start < 0 {
-		start = listLen + start
-	}
-	if stop < 0 {
-		stop = listLen + stop
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return err
 	}
 
-	if listLen == 0 || stop < start || start >= listLen || stop < 0 {
-		return result, nil
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return err
 	}
-
-	// 提取正确的索引位置
-	if start < 0 {
-		start = 0
+	val, ok := v.(*sortedSetValue)
+	if !ok {
+		return nil
 	}
 
-	if stop >= listLen {
-		stop = listLen - 1
+	start, stop, ok = val.rangeIndex(start, stop)
+	if !ok {
+		return nil
 	}
-	//
-	//tmp := listLen - stop
-	//stop = listLen - start
-	//start = tmp
 
-	for i := 0; i < int(stop-start+1); i++ {
-		result = append(result, val.rankList[int(start)+i].Member)
+	// 定位到起始节点后顺序遍历,反向时从倒数第 start 个节点往前遍历
+	var x *skipListNode
+	if rev {
+		x = val.rankList.byRank(val.rankList.length - int(start))
+	} else {
+		x = val.rankList.byRank(int(start) + 1)
 	}
-	//sort.Slice(result, func(i, j int) bool {
-	//	return true
-	//})
-	return result, nil
+	for i := start; i <= stop && x != nil; i++ {
+		fn(x)
+		if rev {
+			x = x.backward
+		} else {
+			x = x.level[0].forward
+		}
+	}
+	return nil
 }
 
 // ZRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。有序集成员按 score 值递增(从小到大)次序排列。
 // 具有相同 score 值的成员按字典序(lexicographical order)来排列(该属性是有序集提供的，不需要额外的计算)。
 // 可选的 LIMIT 参数指定返回结果的数量及区间(就像SQL中的 SELECT LIMIT offset, count )，注意当 offset 很大时，定位 offset 的操作可能需要遍历整个有序集，此过程最坏复杂度为 O(N) 时间。
 func (s *sortedSetStore) ZRangeByScore(ctx context.Context, key string, opt *ZRangeBy) ([]string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
-
-	result := make([]string, 0)
-
-	if err := utils.ContextIsDone(ctx); err != nil {
-		return result, err
+	var result []string
+	err := s.rangeByScore(ctx, key, opt, false, func(x *skipListNode) {
+		result = append(result, x.Member)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	val, ok := s.values[key].(*sortedSetValue)
-	if !ok {
-		return result, nil
+// ZRangeByScoreWithScores 同 ZRangeByScore, 返回的成员带上 score 值
+func (s *sortedSetStore) ZRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ([]Z, error) {
+	result := make([]Z, 0)
+	err := s.rangeByScore(ctx, key, opt, false, func(x *skipListNode) {
+		result = append(result, Z{Score: x.Score, Member: x.Member})
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	listLen := len(val.rankList)
+// ZRevRangeByScore 同 ZRangeByScore,成员按 score 值递减(从大到小)排列,opt.Min 跟 opt.Max 的含义不变
+func (s *sortedSetStore) ZRevRangeByScore(ctx context.Context, key string, opt *ZRangeBy) ([]string, error) {
+	result := make([]string, 0)
+	err := s.rangeByScore(ctx, key, opt, true, func(x *skipListNode) {
+		result = append(result, x.Member)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	var (
-		min    = opt.Min
-		max    = opt.Max
-		offset = opt.Offset
-		count  = opt.Count
-		limit  = opt.Offset != 0 || opt.Count != 0
+// ZRevRangeByScoreWithScores 同 ZRevRangeByScore, 返回的成员带上 score 值
+func (s *sortedSetStore) ZRevRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) ([]Z, error) {
+	result := make([]Z, 0)
+	err := s.rangeByScore(ctx, key, opt, true, func(x *skipListNode) {
+		result = append(result, Z{Score: x.Score, Member: x.Member})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-		minop      utils.CompareFunc
-		maxop      utils.CompareFunc
-		maxbreakop utils.CompareFunc
-		err        error
-		minf       float64
-		maxf       float64
-		start      = -1
-		stop       = -1
-	)
+// rangeByScore 按分数区间遍历有序集合的成员,rev 为 true 时按 score 值递减遍历
+func (s *sortedSetStore) rangeByScore(ctx context.Context, key string, opt *ZRangeBy, rev bool, fn func(x *skipListNode)) error {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
-	// LIMIT参数限定为正数
-	if offset < 0 {
-		return result, nil
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return err
 	}
 
-	// 操作左区间
-	if min == "-inf" && offset == 0 {
-		start = 0
-	} else if strings.Index(min, "(") > -1 {
-		minop = utils.LT
-		min = strings.ReplaceAll(min, "(", "")
+	r, err := parseScoreRange(opt.Min, opt.Max)
+	if err != nil {
+		return err
 	}
 
-	if min != "-inf" {
-		minf, err = strconv.ParseFloat(min, 64)
-		if err != nil {
-			return result, err
-		}
-
-		if minop == nil {
-			minop = utils.LTE
-		}
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return err
+	}
+	val, ok := v.(*sortedSetValue)
+	if !ok {
+		return nil
 	}
 
-	// 操作右区间
-	if strings.Index(max, "(") > -1 {
-		maxop = utils.LT
-		maxbreakop = utils.LTE
-		max = strings.ReplaceAll(max, "(", "")
+	if rev {
+		val.rangeBy(val.rankList.lastInRange(r), func(x *skipListNode) bool {
+			return r.gteMin(x.Score)
+		}, opt.Offset, opt.Count, true, fn)
+	} else {
+		val.rangeBy(val.rankList.firstInRange(r), func(x *skipListNode) bool {
+			return r.lteMax(x.Score)
+		}, opt.Offset, opt.Count, false, fn)
 	}
+	return nil
+}
 
-	if max != "+inf" {
-		maxf, err = strconv.ParseFloat(max, 64)
-		if err != nil {
-			return result, err
-		}
+// ZRangeByLex 返回有序集 key 中，成员在字典序区间 opt.Min 跟 opt.Max 之间的成员
+// 区间以 "[" 开头表示包括边界, "(" 开头表示不包括边界, "-" 跟 "+" 表示无穷小跟无穷大
+// 跟 redis 一样只按成员比较,所有成员的 score 值相同时结果才有意义
+func (s *sortedSetStore) ZRangeByLex(ctx context.Context, key string, opt *ZRangeBy) ([]string, error) {
+	return s.rangeByLex(ctx, key, opt, false)
+}
 
-		if maxop == nil {
-			maxop = utils.LTE
-		}
-		if maxbreakop == nil {
-			maxbreakop = utils.LT
-		}
-	}
+// ZRevRangeByLex 同 ZRangeByLex,成员按字典序的逆序排列,opt.Min 跟 opt.Max 的含义不变
+func (s *sortedSetStore) ZRevRangeByLex(ctx context.Context, key string, opt *ZRangeBy) ([]string, error) {
+	return s.rangeByLex(ctx, key, opt, true)
+}
 
-	for i := 0; i < listLen; i++ {
-		data := val.rankList[i]
-		if (maxbreakop != nil && maxbreakop(maxf, data.Score)) || (count == 0 && limit) {
-			break
-		}
-		stop++
+func (s *sortedSetStore) rangeByLex(ctx context.Context, key string, opt *ZRangeBy, rev bool) ([]string, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
-		// 判断起始位置
-		if (minop == nil || minop(minf, data.Score)) && (maxop == nil || maxop(data.Score, maxf)) {
-			if offset == 0 && start == -1 {
-				start = i
-			}
-			// 判断最终位置
-			if offset == 0 && count > 0 {
-				count--
-			}
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return nil, err
+	}
 
-			if offset > 0 {
-				offset--
-			}
-		}
+	r, err := parseLexRange(opt.Min, opt.Max)
+	if err != nil {
+		return nil, err
+	}
 
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return nil, err
 	}
 
-	// 超过限制了
-	if start == -1 {
+	result := make([]string, 0)
+	val, ok := v.(*sortedSetValue)
+	if !ok {
 		return result, nil
 	}
 
-	if stop >= listLen {
-		stop = listLen - 1
+	add := func(x *skipListNode) {
+		result = append(result, x.Member)
 	}
-
-	for i := 0; i < stop-start+1; i++ {
-		result = append(result, val.rankList[start+i].Member)
+	if rev {
+		val.rangeBy(val.rankList.lastInLexRange(r), func(x *skipListNode) bool {
+			return r.gteMin(x.Member)
+		}, opt.Offset, opt.Count, true, add)
+	} else {
+		val.rangeBy(val.rankList.firstInLexRange(r), func(x *skipListNode) bool {
+			return r.lteMax(x.Member)
+		}, opt.Offset, opt.Count, false, add)
 	}
 	return result, nil
 }
@@ -477,30 +683,36 @@ func (s *sortedSetStore) ZRangeByScore(ctx context.Context, key string, opt *ZRa
 // ZRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递增(从小到大)顺序排列。
 // 排名以 0 为底，也就是说， score 值最小的成员排名为 0 。
 func (s *sortedSetStore) ZRank(ctx context.Context, key, member string) (int64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
 	if !ok {
 		return 0, MemoryNil
 	}
 
-	data, ok := val.mapping[member]
+	score, ok := val.mapping[member]
 	if !ok {
 		return 0, MemoryNil
 	}
-	return int64(data.Rank), nil
+	return int64(val.rankList.rank(score, member) - 1), nil
 }
 
 // ZRem 移除有序集 key 中的一个或多个成员，不存在的成员将被忽略。
 // @return 被成功移除的成员的数量，不包括被忽略的成员。
 func (s *sortedSetStore) ZRem(ctx context.Context, key string, members ...string) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
@@ -510,36 +722,22 @@ func (s *sortedSetStore) ZRem(ctx context.Context, key string, members ...string
 		return 0, nil
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
 	if !ok {
 		return 0, nil
 	}
 
 	affectCnt := 0
-	// 过滤一遍,将需要删除的对象排名设置成 -1
-	// 并将其在映射表中删除
 	for _, member := range members {
-		if data, ok := val.mapping[member]; ok {
-			data.Rank = -1
+		if val.remove(member) {
 			affectCnt++
-			delete(val.mapping, member)
 		}
 	}
-
-	// 根据元素排名进行排序
-	// 其余的位置是不会改变的
-	sort.SliceStable(val.rankList, func(i, j int) bool {
-		return val.rankList[i].Rank < val.rankList[j].Rank
-	})
-
-	// 丢掉原来的列表,用新列表顶替
-	rankLen := len(val.rankList) - affectCnt
-	result := make([]*sortedSetData, rankLen, rankLen)
-	copy(result, val.rankList[affectCnt:])
-	val.rankList = result
-
-	// 重新刷新
-	val.Refresh()
+	s.save(sh, key, val)
 
 	return int64(affectCnt), nil
 }
@@ -549,255 +747,517 @@ func (s *sortedSetStore) ZRem(ctx context.Context, key string, members ...string
 // 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
 // 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
 func (s *sortedSetStore) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
 	if !ok {
 		return 0, nil
 	}
 
-	affectCnt := 0
+	start, stop, ok = val.rangeIndex(start, stop)
+	if !ok {
+		return 0, nil
+	}
 
-	listLen := int64(len(val.rankList))
+	// 跳表的排名从1开始
+	affectCnt := val.rankList.deleteRangeByRank(int(start)+1, int(stop)+1, val.forget)
+	s.save(sh, key, val)
 
-	if start < 0 {
-		start = listLen + start
+	return int64(affectCnt), nil
+}
+
+// ZRemRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。
+// 有序集成员按 score 值递增(从小到大)次序排列。
+func (s *sortedSetStore) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return 0, err
 	}
-	if stop < 0 {
-		stop = listLen + stop
+
+	r, err := parseScoreRange(min, max)
+	if err != nil {
+		return 0, err
 	}
 
-	if listLen == 0 || stop < start || start >= listLen || stop < 0 {
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
+	if !ok {
 		return 0, nil
 	}
 
-	// 提取正确的索引位置
-	if start < 0 {
-		start = 0
+	if !val.rankList.isInRange(r) {
+		return 0, nil
 	}
 
-	if stop >= listLen {
-		stop = listLen - 1
+	affectCnt := val.rankList.deleteRangeByScore(r, val.forget)
+	s.save(sh, key, val)
+
+	return int64(affectCnt), nil
+}
+
+// ZRevRange 返回有序集 key 中，指定区间内的成员。
+// 其中成员的位置按 score 值递减(从大到小)来排列。
+// 具有相同 score 值的成员按字典序的逆序(reverse lexicographical order)排列。
+func (s *sortedSetStore) ZRevRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	result := make([]string, 0)
+	err := s.rangeByRank(ctx, key, start, stop, true, func(x *skipListNode) {
+		result = append(result, x.Member)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	for i := start; i <= stop; i++ {
-		data := val.rankList[i]
-		delete(val.mapping, data.Member)
+// ZRevRangeWithScores 同 ZRevRange, 返回的成员带上 score 值
+func (s *sortedSetStore) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	result := make([]Z, 0)
+	err := s.rangeByRank(ctx, key, start, stop, true, func(x *skipListNode) {
+		result = append(result, Z{Score: x.Score, Member: x.Member})
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	affectCnt = int(stop - start + 1)
-	result := make([]*sortedSetData, 0, affectCnt)
+// ZRevRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递减(从大到小)排序。
+// 排名以 0 为底，也就是说， score 值最大的成员排名为 0 。
+func (s *sortedSetStore) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
-	result = append(val.rankList[0:start], val.rankList[stop+1:]...)
-	val.rankList = result
-	val.Refresh()
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return 0, err
+	}
 
-	return int64(affectCnt), nil
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
+	if !ok {
+		return 0, MemoryNil
+	}
+
+	score, ok := val.mapping[member]
+	if !ok {
+		return 0, MemoryNil
+	}
+
+	return int64(val.rankList.length - val.rankList.rank(score, member)), nil
 }
 
-// ZRemRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。
-// 有序集成员按 score 值递增(从小到大)次序排列。
-func (s *sortedSetStore) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
-	s.rwMutex.Lock()
-	defer s.rwMutex.Unlock()
+// ZScore 返回有序集 key 中，成员 member 的 score 值。
+// 如果 member 元素不是有序集 key 的成员，或 key 不存在，返回 nil 。
+func (s *sortedSetStore) ZScore(ctx context.Context, key, member string) (float64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return 0, err
+	}
+	val, ok := v.(*sortedSetValue)
 	if !ok {
-		return 0, nil
+		return 0, MemoryNil
 	}
 
-	listLen := len(val.rankList)
-	if listLen == 0 {
-		return 0, nil
+	score, ok := val.mapping[member]
+	if !ok {
+		return 0, MemoryNil
 	}
+	return score, nil
+}
 
-	var (
-		minop      utils.CompareFunc
-		maxop      utils.CompareFunc
-		maxbreakop utils.CompareFunc
-		err        error
-		minf       float64
-		maxf       float64
-		start      = -1
-		stop       = -1
-	)
+// ZMScore 返回有序集 key 中多个成员的 score 值,不存在的成员返回0
+func (s *sortedSetStore) ZMScore(ctx context.Context, key string, members ...string) ([]float64, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
-	// 操作左区间
-	if min == "-inf" {
-		start = 0
-	} else if strings.Index(min, "(") > -1 {
-		minop = utils.LT
-		min = strings.ReplaceAll(min, "(", "")
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return nil, err
 	}
 
-	if min != "-inf" {
-		minf, err = strconv.ParseFloat(min, 64)
-		if err != nil {
-			return 0, err
-		}
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return nil, err
+	}
 
-		if minop == nil {
-			minop = utils.LTE
-		}
+	result := make([]float64, len(members))
+	val, ok := v.(*sortedSetValue)
+	if !ok {
+		return result, nil
 	}
 
-	// 操作右区间
-	if max == "+inf" {
-		stop = listLen - 1
-	} else if strings.Index(max, "(") > -1 {
-		maxop = utils.LT
-		maxbreakop = utils.LTE
-		max = strings.ReplaceAll(max, "(", "")
+	for i, member := range members {
+		result[i] = val.mapping[member]
 	}
+	return result, nil
+}
 
-	if max != "+inf" {
-		maxf, err = strconv.ParseFloat(max, 64)
-		if err != nil {
-			return 0, err
-		}
+// ZPopMin 移除并返回有序集 key 中 score 值最小的 count 个成员,有序集合为空时删除该键
+func (s *sortedSetStore) ZPopMin(ctx context.Context, key string, count int64) ([]Z, error) {
+	return s.pop(ctx, key, count, false)
+}
 
-		if maxop == nil {
-			maxop = utils.LTE
-		}
-		if maxbreakop == nil {
-			maxbreakop = utils.LT
-		}
+// ZPopMax 移除并返回有序集 key 中 score 值最大的 count 个成员,有序集合为空时删除该键
+func (s *sortedSetStore) ZPopMax(ctx context.Context, key string, count int64) ([]Z, error) {
+	return s.pop(ctx, key, count, true)
+}
+
+func (s *sortedSetStore) pop(ctx context.Context, key string, count int64, max bool) ([]Z, error) {
+	sh := s.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return nil, err
 	}
 
-	affectCnt := 0
-	for i := 0; i < listLen; i++ {
-		data := val.rankList[i]
-		if maxbreakop != nil && maxbreakop(maxf, data.Score) {
-			break
-		}
-		stop++
-		if (minop == nil || minop(minf, data.Score)) && (maxop == nil || maxop(data.Score, maxf)) {
-			if start == -1 {
-				start = i
-			}
-			delete(val.mapping, data.Member)
-		}
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return nil, err
 	}
+	return s.popValue(sh, key, v, count, max), nil
+}
 
-	if stop >= listLen {
-		stop = listLen - 1
+// popValue 从 v 中弹出最多 count 个成员,调用方需要持有 key 所在分片的写锁
+// v 不是有序集合时返回空结果,弹出后有序集合为空时删除该键
+func (s *sortedSetStore) popValue(sh *keyspaceShard, key string, v expireable, count int64, max bool) []Z {
+	result := make([]Z, 0)
+	val, ok := v.(*sortedSetValue)
+	if !ok {
+		return result
 	}
 
-	affectCnt = stop - start + 1
-	result := make([]*sortedSetData, 0, affectCnt)
+	for ; count > 0 && val.rankList.length > 0; count-- {
+		x := val.rankList.header.level[0].forward
+		if max {
+			x = val.rankList.tail
+		}
+		result = append(result, Z{Score: x.Score, Member: x.Member})
+		val.remove(x.Member)
+	}
 
-	result = append(val.rankList[0:start], val.rankList[stop+1:]...)
-	val.rankList = result
-	val.Refresh()
+	s.save(sh, key, val)
+	return result
+}
 
-	return int64(affectCnt), nil
+// save 保存修改后的有序集合,已经没有成员时删除 key
+func (s *sortedSetStore) save(sh *keyspaceShard, key string, val *sortedSetValue) {
+	if val.rankList.length == 0 {
+		sh.remove(key)
+		return
+	}
+	sh.store(key, val)
 }
 
-// ZRevRange 返回有序集 key 中，指定区间内的成员。
-// 其中成员的位置按 score 值递减(从大到小)来排列。
-// 具有相同 score 值的成员按字典序的逆序(reverse lexicographical order)排列。
-func (s *sortedSetStore) ZRevRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+// BZPopMin 依次检查 keys,从第一个非空有序集合中弹出 score 值最小的成员
+// 所有有序集合都为空时阻塞等待,直到超时(返回 MemoryNil)或者有可弹出的成员为止;timeout 为0时一直阻塞直到 ctx 结束
+func (s *sortedSetStore) BZPopMin(ctx context.Context, timeout time.Duration, keys ...string) (*ZWithKey, error) {
+	return s.bPop(ctx, timeout, false, keys...)
+}
 
-	result := make([]string, 0)
+// BZPopMax 同 BZPopMin,弹出 score 值最大的成员
+func (s *sortedSetStore) BZPopMax(ctx context.Context, timeout time.Duration, keys ...string) (*ZWithKey, error) {
+	return s.bPop(ctx, timeout, true, keys...)
+}
 
-	if err := utils.ContextIsDone(ctx); err != nil {
-		return result, err
+func (s *sortedSetStore) bPop(ctx context.Context, timeout time.Duration, max bool, keys ...string) (*ZWithKey, error) {
+	var result *ZWithKey
+	err := s.block(ctx, driverStoreTypeSortedSet, s.evtSig, timeout, keys, func() bool {
+		result = s.popFirst(keys, max)
+		return result != nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	val, ok := s.values[key].(*sortedSetValue)
-	if !ok {
-		return result, nil
+// popFirst 从第一个非空有序集合中弹出一个成员;所有有序集合都为空时返回nil
+func (s *sortedSetStore) popFirst(keys []string, max bool) *ZWithKey {
+	// 按分片序号锁住所有键所在的分片
+	unlock := s.lockShards(true, keys...)
+	defer unlock()
+
+	for _, key := range keys {
+		// 等待期间键可能被改成其他类型,跳过即可
+		sh := s.shard(key)
+		v, _ := sh.lookup(key, driverStoreTypeSortedSet)
+		if popped := s.popValue(sh, key, v, 1, max); len(popped) > 0 {
+			return &ZWithKey{Z: popped[0], Key: key}
+		}
 	}
+	return nil
+}
 
-	listLen := int64(len(val.rankList))
+// ZRandMember 随机返回有序集 key 中的成员,不会移除成员
+// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+func (s *sortedSetStore) ZRandMember(ctx context.Context, key string, count int) ([]Z, error) {
+	sh := s.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
-	if start < 0 {
-		start = listLen + start
+	if err := utils.ContextIsDone(ctx); err != nil {
+		return nil, err
 	}
-	if stop < 0 {
-		stop = listLen + stop
+
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err != nil {
+		return nil, err
 	}
 
-	if listLen == 0 || stop < start || start >= listLen || stop < 0 {
+	result := make([]Z, 0)
+	val, ok := v.(*sortedSetValue)
+	if !ok || count == 0 {
 		return result, nil
 	}
 
-	// 提取正确的索引位置
-	if start < 0 {
-		start = 0
+	length := val.rankList.length
+	add := func(x *skipListNode) {
+		result = append(result, Z{Score: x.Score, Member: x.Member})
 	}
 
-	if stop >= listLen {
-		stop = listLen - 1
+	switch {
+	case count >= length:
+		for x := val.rankList.header.level[0].forward; x != nil; x = x.level[0].forward {
+			add(x)
+		}
+	case count > 0:
+		for _, i := range rand.Perm(length)[:count] {
+			add(val.rankList.byRank(i + 1))
+		}
+	default:
+		for i := 0; i < -count; i++ {
+			add(val.rankList.byRank(rand.Intn(length) + 1))
+		}
 	}
+	return result, nil
+}
 
-	// 反相获取
-	tmp := listLen - stop
-	stop = listLen - start
-	start = tmp
+// zSetOp 有序集合运算的类型
+type zSetOp int
 
-	for i := 0; i < int(stop-start+1); i++ {
-		result = append(result, val.rankList[int(start-1)+i].Member)
+const (
+	zSetOpUnion zSetOp = iota
+	zSetOpInter
+	zSetOpDiff
+)
+
+// zStoreArgs 检查 ZStore 的参数,返回每个键的权重以及合并分数的函数
+// Weights 为空时权重都为1,Aggregate 为空时为 SUM
+func zStoreArgs(store *ZStore) ([]float64, func(a, b float64) float64, error) {
+	if len(store.Keys) == 0 {
+		return nil, nil, MemorySyntaxError
 	}
 
-	sort.Slice(result, func(i, j int) bool {
-		return true
-	})
-	return result, nil
-}
+	weights := store.Weights
+	if len(weights) == 0 {
+		weights = make([]float64, len(store.Keys))
+		for i := range weights {
+			weights[i] = 1
+		}
+	} else if len(weights) != len(store.Keys) {
+		return nil, nil, MemorySyntaxError
+	}
 
-// ZRevRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递减(从大到小)排序。
-// 排名以 0 为底，也就是说， score 值最大的成员排名为 0 。
-func (s *sortedSetStore) ZRevRank(ctx context.Context, key, member string) (int64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+	var aggregate func(a, b float64) float64
+	switch strings.ToUpper(store.Aggregate) {
+	case "", "SUM":
+		aggregate = func(a, b float64) float64 {
+			// 跟 redis 一样 inf 跟 -inf 相加的结果视为0
+			if sum := a + b; !math.IsNaN(sum) {
+				return sum
+			}
+			return 0
+		}
+	case "MIN":
+		aggregate = math.Min
+	case "MAX":
+		aggregate = math.Max
+	default:
+		return nil, nil, MemorySyntaxError
+	}
+	return weights, aggregate, nil
+}
 
-	if err := utils.ContextIsDone(ctx); err != nil {
-		return 0, err
+// zWeight 分数乘以权重,跟 redis 一样 inf 乘以0的结果视为0
+func zWeight(score, weight float64) float64 {
+	if result := score * weight; !math.IsNaN(result) {
+		return result
 	}
+	return 0
+}
 
-	val, ok := s.values[key].(*sortedSetValue)
-	if !ok {
-		return 0, MemoryNil
+// scores 返回 key 中所有成员的分数,调用方需要持有 key 所在分片的锁
+// 普通集合的成员分数视为1,不存在的键返回nil,其他类型返回 MemoryWrongType
+func (s *sortedSetStore) scores(key string) (map[string]float64, error) {
+	sh := s.shard(key)
+	v, err := sh.lookup(key, driverStoreTypeSortedSet)
+	if err == MemoryWrongType {
+		sv, serr := sh.lookup(key, driverStoreTypeSet)
+		set, ok := sv.(*setValue)
+		if serr != nil || !ok {
+			return nil, err
+		}
+
+		result := make(map[string]float64, len(set.value))
+		for member := range set.value {
+			result[member] = 1
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	data, ok := val.mapping[member]
-	if !ok {
-		return 0, MemoryNil
+	if val, ok := v.(*sortedSetValue); ok {
+		return val.mapping, nil
 	}
+	return nil, nil
+}
 
-	return int64(val.rankList[len(val.rankList)-1].Rank - data.Rank), nil
+// compute 计算多个有序集合运算后的结果,调用方需要持有所有键所在分片的锁
+// 按 keys 的顺序合并分数,保证各个节点的计算结果一致
+func (s *sortedSetStore) compute(op zSetOp, keys []string, weights []float64, aggregate func(a, b float64) float64) (map[string]float64, error) {
+	sets := make([]map[string]float64, len(keys))
+	for i, key := range keys {
+		set, err := s.scores(key)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	result := make(map[string]float64)
+	switch op {
+	case zSetOpUnion:
+		for i, set := range sets {
+			for member, score := range set {
+				score = zWeight(score, weights[i])
+				if old, ok := result[member]; ok {
+					score = aggregate(old, score)
+				}
+				result[member] = score
+			}
+		}
+	case zSetOpInter:
+		for _, set := range sets {
+			// 有一个是空集合,交集就是空集合
+			if len(set) == 0 {
+				return result, nil
+			}
+		}
+		for member, score := range sets[0] {
+			score = zWeight(score, weights[0])
+			in := true
+			for i, set := range sets[1:] {
+				other, ok := set[member]
+				if !ok {
+					in = false
+					break
+				}
+				score = aggregate(score, zWeight(other, weights[i+1]))
+			}
+			if in {
+				result[member] = score
+			}
+		}
+	case zSetOpDiff:
+		for member, score := range sets[0] {
+			in := false
+			for _, set := range sets[1:] {
+				if _, ok := set[member]; ok {
+					in = true
+					break
+				}
+			}
+			if !in {
+				result[member] = score
+			}
+		}
+	}
+	return result, nil
 }
 
-// ZScore 返回有序集 key 中，成员 member 的 score 值。
-// 如果 member 元素不是有序集 key 的成员，或 key 不存在，返回 nil 。
-func (s *sortedSetStore) ZScore(ctx context.Context, key, member string) (float64, error) {
-	s.rwMutex.RLock()
-	defer s.rwMutex.RUnlock()
+// storeOp 计算多个有序集合运算后的结果并保存到 destination 中
+// destination 原来的数值会被覆盖,结果为空时删除 destination
+func (s *sortedSetStore) storeOp(ctx context.Context, op zSetOp, destination string, store *ZStore) (int64, error) {
+	weights, aggregate, err := zStoreArgs(store)
+	if err != nil {
+		return 0, err
+	}
+
+	locks := make([]string, 0, len(store.Keys)+1)
+	locks = append(locks, destination)
+	locks = append(locks, store.Keys...)
+	unlock := s.lockShards(true, locks...)
+	defer unlock()
 
 	if err := utils.ContextIsDone(ctx); err != nil {
 		return 0, err
 	}
 
-	val, ok := s.values[key].(*sortedSetValue)
-	if !ok {
-		return 0, MemoryNil
+	members, err := s.compute(op, store.Keys, weights, aggregate)
+	if err != nil {
+		return 0, err
 	}
 
-	data, ok := val.mapping[member]
-	if !ok {
-		return 0, MemoryNil
+	sh := s.shard(destination)
+	sh.remove(destination)
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	val := newSortSetValue()
+	for member, score := range members {
+		val.add(member, score)
 	}
-	return data.Score, nil
+	sh.store(destination, val)
+	s.evtSig.Publish(destination)
+	return int64(len(val.mapping)), nil
+}
+
+// ZUnionStore 把多个有序集合的并集保存到 destination 中
+// 成员的 score 值乘以对应的权重后按 Aggregate 合并,keys 中也可以是普通集合,成员的 score 值视为1
+// @return 结果有序集合的成员数量
+func (s *sortedSetStore) ZUnionStore(ctx context.Context, destination string, store *ZStore) (int64, error) {
+	return s.storeOp(ctx, zSetOpUnion, destination, store)
+}
+
+// ZInterStore 把多个有序集合的交集保存到 destination 中,score 值的计算同 ZUnionStore
+// @return 结果有序集合的成员数量
+func (s *sortedSetStore) ZInterStore(ctx context.Context, destination string, store *ZStore) (int64, error) {
+	return s.storeOp(ctx, zSetOpInter, destination, store)
+}
+
+// ZDiffStore 把第一个有序集合跟其他有序集合的差集保存到 destination 中,成员保留第一个有序集合中的 score 值
+// @return 结果有序集合的成员数量
+func (s *sortedSetStore) ZDiffStore(ctx context.Context, destination string, keys ...string) (int64, error) {
+	return s.storeOp(ctx, zSetOpDiff, destination, &ZStore{Keys: keys})
 }
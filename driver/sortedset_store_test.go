@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"sort"
 	"strconv"
 	"testing"
 	"time"
@@ -17,7 +18,7 @@ import (
 */
 
 func Test_sortSetStore_ZAdd(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 
 	type args struct {
 		ctx     context.Context
@@ -190,7 +191,7 @@ func Test_sortSetStore_ZAdd(t *testing.T) {
 }
 
 func Test_sortSetStore_X_ZAdd(t *testing.T) {
-	sx := newSortSetStore()
+	sx := newSortSetStore(newKeyspace())
 	m := []SZ{}
 	rand.Seed(time.Now().UnixNano())
 	for i := 0; i < 1000; i++ {
@@ -203,7 +204,7 @@ func Test_sortSetStore_X_ZAdd(t *testing.T) {
 }
 
 func Benchmark_sortSetStore_ZAdd(b *testing.B) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	b.SetParallelism(10000)
 	rand.Seed(time.Now().UnixNano())
 	b.RunParallel(func(pb *testing.PB) {
@@ -229,7 +230,7 @@ func Benchmark_sortSetStore_ZAdd(b *testing.B) {
 }
 
 func Test_sortSetStore_ZRange(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	s.ZAdd(context.TODO(), "abc",
 		SZ{Score: 2, Member: "A"},
 		SZ{Score: 1, Member: "B"},
@@ -340,7 +341,7 @@ func Test_sortSetStore_ZRange(t *testing.T) {
 }
 
 func Test_sortSetStore_ZRem(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	KEY := "ABC"
 
 	member := []SZ{}
@@ -390,7 +391,10 @@ func Test_sortSetStore_ZRem(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := s.ZRem(tt.args.ctx, tt.args.key, tt.args.members...)
-			t.Logf("剩余数据:%+v", s.values[KEY].(*sortedSetValue).rankList)
+			// 移除最后一个成员后 key 会被删除
+			if val, ok := s.value(KEY).(*sortedSetValue); ok {
+				t.Logf("剩余数据:%+v", val.rankList)
+			}
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ZRem() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -403,7 +407,7 @@ func Test_sortSetStore_ZRem(t *testing.T) {
 }
 
 func Test_sortSetStore_ZRemRangeByRank(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	KEY := "ABC"
 
 	member := []SZ{}
@@ -457,7 +461,10 @@ func Test_sortSetStore_ZRemRangeByRank(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := s.ZRemRangeByRank(tt.args.ctx, tt.args.key, tt.args.start, tt.args.stop)
-			t.Logf("剩余数据:%+v", s.values[KEY].(*sortedSetValue).rankList)
+			// 移除最后一个成员后 key 会被删除
+			if val, ok := s.value(KEY).(*sortedSetValue); ok {
+				t.Logf("剩余数据:%+v", val.rankList)
+			}
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ZRemRangeByRank() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -470,7 +477,7 @@ func Test_sortSetStore_ZRemRangeByRank(t *testing.T) {
 }
 
 func Test_sortedSetStore_ZRemRangeByScore(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	KEY := "ABC"
 
 	member := []SZ{}
@@ -564,7 +571,10 @@ func Test_sortedSetStore_ZRemRangeByScore(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := s.ZRemRangeByScore(tt.args.ctx, tt.args.key, tt.args.min, tt.args.max)
-			t.Logf("剩余数据:%+v", s.values[KEY].(*sortedSetValue).rankList)
+			// 移除最后一个成员后 key 会被删除
+			if val, ok := s.value(KEY).(*sortedSetValue); ok {
+				t.Logf("剩余数据:%+v", val.rankList)
+			}
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ZRemRangeByScore() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -577,7 +587,7 @@ func Test_sortedSetStore_ZRemRangeByScore(t *testing.T) {
 }
 
 func Test_sortSetStore_ZRevRange(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	KEY := "ABC"
 
 	member := []SZ{}
@@ -633,7 +643,7 @@ func Test_sortSetStore_ZRevRange(t *testing.T) {
 }
 
 func Test_sortedSetStore_ZRangeByScore(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	KEY := "ABC"
 
 	member := []SZ{}
@@ -749,7 +759,7 @@ func Test_sortedSetStore_ZRangeByScore(t *testing.T) {
 }
 
 func Test_sortedSetStore_ZCard(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	KEY := "ABC"
 
 	member := []SZ{}
@@ -785,7 +795,7 @@ func Test_sortedSetStore_ZCard(t *testing.T) {
 }
 
 func Test_sortedSetStore_ZRank(t *testing.T) {
-	s := newSortSetStore()
+	s := newSortSetStore(newKeyspace())
 	KEY := "ABC"
 
 	member := []SZ{}
@@ -819,3 +829,351 @@ func Test_sortedSetStore_ZRank(t *testing.T) {
 		})
 	}
 }
+
+func Test_sortedSetStore_SkipList(t *testing.T) {
+	s := newSortSetStore(newKeyspace())
+	ctx := context.Background()
+	key := "skiplist"
+
+	// 随机增删改后,跟排好序的切片进行对比
+	scores := make(map[string]float64)
+	for i := 0; i < 5000; i++ {
+		member := strconv.Itoa(rand.Intn(500))
+		switch rand.Intn(3) {
+		case 0:
+			score := float64(rand.Intn(100))
+			s.ZAdd(ctx, key, SZ{Member: member, Score: score})
+			scores[member] = score
+		case 1:
+			s.ZIncrBy(ctx, key, float64(rand.Intn(20)-10), member)
+			scores[member], _ = s.ZScore(ctx, key, member)
+		case 2:
+			s.ZRem(ctx, key, member)
+			delete(scores, member)
+		}
+	}
+
+	want := make([]SZ, 0, len(scores))
+	for member, score := range scores {
+		want = append(want, SZ{Member: member, Score: score})
+	}
+	sort.Slice(want, func(i, j int) bool {
+		return want[i].Score < want[j].Score || (want[i].Score == want[j].Score && want[i].Member < want[j].Member)
+	})
+
+	got, _ := s.ZRangeWithScores(ctx, key, 0, -1)
+	if len(got) != len(want) {
+		t.Fatalf("ZRangeWithScores len = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Member != want[i].Member || got[i].Score != want[i].Score {
+			t.Fatalf("ZRangeWithScores[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+
+		rank, _ := s.ZRank(ctx, key, want[i].Member)
+		if rank != int64(i) {
+			t.Fatalf("ZRank(%s) = %d, want %d", want[i].Member, rank, i)
+		}
+
+		revRank, _ := s.ZRevRank(ctx, key, want[i].Member)
+		if revRank != int64(len(want)-1-i) {
+			t.Fatalf("ZRevRank(%s) = %d, want %d", want[i].Member, revRank, len(want)-1-i)
+		}
+	}
+
+	cnt, _ := s.ZCount(ctx, key, "(10", "50")
+	wantCnt := 0
+	for _, z := range want {
+		if z.Score > 10 && z.Score <= 50 {
+			wantCnt++
+		}
+	}
+	if cnt != int64(wantCnt) {
+		t.Fatalf("ZCount = %d, want %d", cnt, wantCnt)
+	}
+}
+
+func Test_sortedSetStore_ZAddArgs(t *testing.T) {
+	ctx := context.Background()
+	s := newSortSetStore(newKeyspace())
+	s.ZAdd(ctx, "z", SZ{Member: "a", Score: 1}, SZ{Member: "b", Score: 2})
+
+	tests := []struct {
+		name   string
+		args   ZAddArgs
+		want   int64
+		scores []float64
+	}{
+		{
+			name:   "NX 只添加新成员",
+			args:   ZAddArgs{NX: true, Members: []Z{{Member: "a", Score: 10}, {Member: "c", Score: 3}}},
+			want:   1,
+			scores: []float64{1, 2, 3},
+		},
+		{
+			name:   "XX 只更新已有成员",
+			args:   ZAddArgs{XX: true, Members: []Z{{Member: "a", Score: 5}, {Member: "d", Score: 4}}},
+			want:   0,
+			scores: []float64{5, 2, 3},
+		},
+		{
+			name:   "GT 分数更大时才更新,CH 计入更新数量",
+			args:   ZAddArgs{GT: true, Ch: true, Members: []Z{{Member: "a", Score: 1}, {Member: "b", Score: 20}}},
+			want:   1,
+			scores: []float64{5, 20, 3},
+		},
+		{
+			name:   "LT 分数更小时才更新",
+			args:   ZAddArgs{LT: true, Ch: true, Members: []Z{{Member: "a", Score: 1}, {Member: "c", Score: 30}}},
+			want:   1,
+			scores: []float64{1, 20, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ZAddArgs(ctx, "z", zAddFlags(tt.args), zMembers(tt.args.Members)...)
+			if err != nil || got != tt.want {
+				t.Errorf("ZAddArgs() got = %v, err = %v, want %v", got, err, tt.want)
+			}
+			if scores, _ := s.ZMScore(ctx, "z", "a", "b", "c"); !reflect.DeepEqual(scores, tt.scores) {
+				t.Errorf("ZMScore() got = %v, want %v", scores, tt.scores)
+			}
+		})
+	}
+
+	if got, err := s.ZAddIncr(ctx, "z", zAddXX, SZ{Member: "a", Score: 2.5}); err != nil || got != 3.5 {
+		t.Errorf("ZAddIncr() got = %v, err = %v, want 3.5", got, err)
+	}
+	if _, err := s.ZAddIncr(ctx, "z", zAddNX, SZ{Member: "a", Score: 1}); err != MemoryNil {
+		t.Errorf("ZAddIncr() error = %v, want %v", err, MemoryNil)
+	}
+	if _, err := s.ZAddArgs(ctx, "empty", zAddXX, SZ{Member: "a", Score: 1}); err != nil {
+		t.Errorf("ZAddArgs() error = %v", err)
+	}
+	if n, _ := s.ZCard(ctx, "empty"); n != 0 {
+		t.Errorf("ZCard(empty) got = %v, want 0", n)
+	}
+}
+
+// zMembers 把 []Z 转换成 []SZ
+func zMembers(members []Z) []SZ {
+	result := make([]SZ, 0, len(members))
+	for _, m := range members {
+		result = append(result, SZ{Member: fmt.Sprint(m.Member), Score: m.Score})
+	}
+	return result
+}
+
+func Test_sortedSetStore_RangeWithScores(t *testing.T) {
+	ctx := context.Background()
+	s := newSortSetStore(newKeyspace())
+	s.ZAdd(ctx, "z", SZ{Member: "a", Score: 1}, SZ{Member: "b", Score: 2}, SZ{Member: "c", Score: 3}, SZ{Member: "d", Score: 4})
+
+	if got, _ := s.ZRevRangeWithScores(ctx, "z", 0, 1); !reflect.DeepEqual(got, []Z{{Member: "d", Score: 4}, {Member: "c", Score: 3}}) {
+		t.Errorf("ZRevRangeWithScores() got = %v", got)
+	}
+
+	opt := &ZRangeBy{Min: "(1", Max: "4", Offset: 1, Count: 2}
+	if got, _ := s.ZRangeByScoreWithScores(ctx, "z", opt); !reflect.DeepEqual(got, []Z{{Member: "c", Score: 3}, {Member: "d", Score: 4}}) {
+		t.Errorf("ZRangeByScoreWithScores() got = %v", got)
+	}
+	if got, _ := s.ZRevRangeByScore(ctx, "z", opt); !reflect.DeepEqual(got, []string{"c", "b"}) {
+		t.Errorf("ZRevRangeByScore() got = %v, want [c b]", got)
+	}
+	if got, _ := s.ZRevRangeByScoreWithScores(ctx, "z", &ZRangeBy{Min: "-inf", Max: "+inf"}); len(got) != 4 || got[0].Member != "d" {
+		t.Errorf("ZRevRangeByScoreWithScores() got = %v", got)
+	}
+}
+
+func Test_sortedSetStore_ZRangeByLex(t *testing.T) {
+	ctx := context.Background()
+	s := newSortSetStore(newKeyspace())
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		s.ZAdd(ctx, "z", SZ{Member: m})
+	}
+
+	tests := []struct {
+		name    string
+		opt     *ZRangeBy
+		want    []string
+		wantRev []string
+		wantErr error
+	}{
+		{name: "全部", opt: &ZRangeBy{Min: "-", Max: "+"}, want: []string{"a", "b", "c", "d", "e"}, wantRev: []string{"e", "d", "c", "b", "a"}},
+		{name: "包含边界", opt: &ZRangeBy{Min: "[b", Max: "[d"}, want: []string{"b", "c", "d"}, wantRev: []string{"d", "c", "b"}},
+		{name: "不包含边界", opt: &ZRangeBy{Min: "(b", Max: "(d"}, want: []string{"c"}, wantRev: []string{"c"}},
+		{name: "LIMIT", opt: &ZRangeBy{Min: "-", Max: "+", Offset: 1, Count: 2}, want: []string{"b", "c"}, wantRev: []string{"d", "c"}},
+		{name: "空区间", opt: &ZRangeBy{Min: "[d", Max: "[b"}, want: []string{}, wantRev: []string{}},
+		{name: "格式错误", opt: &ZRangeBy{Min: "b", Max: "+"}, wantErr: MemoryInvalidLexRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ZRangeByLex(ctx, "z", tt.opt)
+			if err != tt.wantErr {
+				t.Fatalf("ZRangeByLex() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ZRangeByLex() got = %v, want %v", got, tt.want)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			rev := &ZRangeBy{Min: tt.opt.Min, Max: tt.opt.Max, Offset: tt.opt.Offset, Count: tt.opt.Count}
+			if got, _ = s.ZRevRangeByLex(ctx, "z", rev); !reflect.DeepEqual(got, tt.wantRev) {
+				t.Errorf("ZRevRangeByLex() got = %v, want %v", got, tt.wantRev)
+			}
+		})
+	}
+}
+
+func Test_sortedSetStore_ZPop(t *testing.T) {
+	ctx := context.Background()
+	s := newSortSetStore(newKeyspace())
+	s.ZAdd(ctx, "z", SZ{Member: "a", Score: 1}, SZ{Member: "b", Score: 2}, SZ{Member: "c", Score: 3})
+
+	if got, _ := s.ZPopMin(ctx, "z", 1); !reflect.DeepEqual(got, []Z{{Member: "a", Score: 1}}) {
+		t.Errorf("ZPopMin() got = %v", got)
+	}
+	if got, _ := s.ZPopMax(ctx, "z", 5); !reflect.DeepEqual(got, []Z{{Member: "c", Score: 3}, {Member: "b", Score: 2}}) {
+		t.Errorf("ZPopMax() got = %v", got)
+	}
+	if got, err := s.ZPopMin(ctx, "z", 1); err != nil || len(got) != 0 {
+		t.Errorf("ZPopMin() got = %v, err = %v, want []", got, err)
+	}
+	if n, _ := s.ZCard(ctx, "z"); n != 0 {
+		t.Errorf("ZCard() got = %v, want 0", n)
+	}
+}
+
+func Test_sortedSetStore_BZPopMin(t *testing.T) {
+	ctx := context.Background()
+	s := newSortSetStore(newKeyspace())
+
+	if _, err := s.BZPopMin(ctx, time.Millisecond*20, "z1", "z2"); err != MemoryNil {
+		t.Fatalf("BZPopMin() error = %v, want %v", err, MemoryNil)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		s.ZAdd(ctx, "z2", SZ{Member: "a", Score: 1}, SZ{Member: "b", Score: 2})
+	}()
+	got, err := s.BZPopMin(ctx, time.Second, "z1", "z2")
+	if err != nil || !reflect.DeepEqual(got, &ZWithKey{Z: Z{Member: "a", Score: 1}, Key: "z2"}) {
+		t.Errorf("BZPopMin() got = %v, err = %v", got, err)
+	}
+	got, err = s.BZPopMax(ctx, time.Second, "z1", "z2")
+	if err != nil || !reflect.DeepEqual(got, &ZWithKey{Z: Z{Member: "b", Score: 2}, Key: "z2"}) {
+		t.Errorf("BZPopMax() got = %v, err = %v", got, err)
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*20)
+	defer cancel()
+	if _, err = s.BZPopMin(cctx, 0, "z1"); err != context.DeadlineExceeded {
+		t.Errorf("BZPopMin() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_sortedSetStore_ZRandMember(t *testing.T) {
+	ctx := context.Background()
+	s := newSortSetStore(newKeyspace())
+	s.ZAdd(ctx, "z", SZ{Member: "a", Score: 1}, SZ{Member: "b", Score: 2}, SZ{Member: "c", Score: 3})
+
+	got, _ := s.ZRandMember(ctx, "z", 2)
+	if len(got) != 2 || got[0].Member == got[1].Member {
+		t.Errorf("ZRandMember(2) got = %v, want 2 distinct members", got)
+	}
+	for _, z := range got {
+		if score, _ := s.ZScore(ctx, "z", z.Member.(string)); score != z.Score {
+			t.Errorf("ZRandMember(2) member %v score = %v, want %v", z.Member, z.Score, score)
+		}
+	}
+	if got, _ = s.ZRandMember(ctx, "z", 10); len(got) != 3 {
+		t.Errorf("ZRandMember(10) got = %v, want 3 members", got)
+	}
+	if got, _ = s.ZRandMember(ctx, "z", -10); len(got) != 10 {
+		t.Errorf("ZRandMember(-10) got = %v, want 10 members", got)
+	}
+	if got, _ = s.ZRandMember(ctx, "missing", 1); len(got) != 0 {
+		t.Errorf("ZRandMember(missing) got = %v, want []", got)
+	}
+}
+
+func Test_sortedSetStore_Store(t *testing.T) {
+	ctx := context.Background()
+	ks := newKeyspace()
+	s := newSortSetStore(ks)
+	newSetStore(ks).SAdd(ctx, "set", "b", "d")
+	s.ZAdd(ctx, "z1", SZ{Member: "a", Score: 1}, SZ{Member: "b", Score: 2}, SZ{Member: "c", Score: 3})
+	s.ZAdd(ctx, "z2", SZ{Member: "b", Score: 10}, SZ{Member: "c", Score: 20})
+
+	n, err := s.ZUnionStore(ctx, "out", &ZStore{Keys: []string{"z1", "z2", "set"}, Weights: []float64{1, 2, 1}})
+	if err != nil || n != 4 {
+		t.Fatalf("ZUnionStore() got = %v, err = %v, want 4", n, err)
+	}
+	if got, _ := s.ZRangeWithScores(ctx, "out", 0, -1); !reflect.DeepEqual(got, []Z{{Member: "a", Score: 1}, {Member: "d", Score: 1}, {Member: "b", Score: 23}, {Member: "c", Score: 43}}) {
+		t.Errorf("ZUnionStore() result = %v", got)
+	}
+
+	n, err = s.ZInterStore(ctx, "out", &ZStore{Keys: []string{"z1", "z2"}, Aggregate: "MAX"})
+	if err != nil || n != 2 {
+		t.Fatalf("ZInterStore() got = %v, err = %v, want 2", n, err)
+	}
+	if got, _ := s.ZRangeWithScores(ctx, "out", 0, -1); !reflect.DeepEqual(got, []Z{{Member: "b", Score: 10}, {Member: "c", Score: 20}}) {
+		t.Errorf("ZInterStore() result = %v", got)
+	}
+
+	n, err = s.ZDiffStore(ctx, "out", "z1", "set")
+	if err != nil || n != 2 {
+		t.Fatalf("ZDiffStore() got = %v, err = %v, want 2", n, err)
+	}
+	if got, _ := s.ZRangeWithScores(ctx, "out", 0, -1); !reflect.DeepEqual(got, []Z{{Member: "a", Score: 1}, {Member: "c", Score: 3}}) {
+		t.Errorf("ZDiffStore() result = %v", got)
+	}
+
+	// 结果为空时删除 destination
+	if n, _ = s.ZInterStore(ctx, "out", &ZStore{Keys: []string{"z1", "missing"}}); n != 0 {
+		t.Errorf("ZInterStore() got = %v, want 0", n)
+	}
+	if n, _ = s.ZCard(ctx, "out"); n != 0 {
+		t.Errorf("ZCard(out) got = %v, want 0", n)
+	}
+
+	if _, err = s.ZUnionStore(ctx, "out", &ZStore{Keys: []string{"z1"}, Weights: []float64{1, 2}}); err != MemorySyntaxError {
+		t.Errorf("ZUnionStore() error = %v, want %v", err, MemorySyntaxError)
+	}
+	if _, err = s.ZUnionStore(ctx, "out", &ZStore{Keys: []string{"z1"}, Aggregate: "AVG"}); err != MemorySyntaxError {
+		t.Errorf("ZUnionStore() error = %v, want %v", err, MemorySyntaxError)
+	}
+}
+
+func Test_sortedSetStore_RemoveLast(t *testing.T) {
+	ctx := context.Background()
+	ks := newKeyspace()
+	s := newSortSetStore(ks)
+
+	tests := []struct {
+		name string
+		fn   func(key string) (int64, error)
+	}{
+		{name: "ZRem", fn: func(key string) (int64, error) { return s.ZRem(ctx, key, "a", "b") }},
+		{name: "ZRemRangeByRank", fn: func(key string) (int64, error) { return s.ZRemRangeByRank(ctx, key, 0, -1) }},
+		{name: "ZRemRangeByScore", fn: func(key string) (int64, error) { return s.ZRemRangeByScore(ctx, key, "-inf", "+inf") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "z-" + tt.name
+			s.ZAdd(ctx, key, SZ{Member: "a", Score: 1}, SZ{Member: "b", Score: 2})
+			if got, err := tt.fn(key); err != nil || got != 2 {
+				t.Fatalf("%s() got = %v, err = %v, want 2", tt.name, got, err)
+			}
+
+			// 移除最后一个成员后 key 不再存在,可以写入其他类型
+			if got, _ := ks.Exists(ctx, key); got != 0 {
+				t.Errorf("Exists() got = %v, want 0", got)
+			}
+			if _, err := newListStore(ks).LPush(ctx, key, "x"); err != nil {
+				t.Errorf("LPush() error = %v", err)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package driver
 
 import (
 	"context"
-	"sync"
+	"errors"
+	"math"
+	"strconv"
 	"time"
 )
 
@@ -21,60 +23,84 @@ type stringValue struct {
 }
 
 func newStringValue() *stringValue {
-	defaultExpireAt := time.Now().Add(ValueMaxTTL)
 	return &stringValue{
-		expireValue: expireValue{
-			expireAt: &defaultExpireAt,
-			expired:  false,
-		},
+		expireValue: expireValue{},
 	}
 }
 
-type stringStore struct {
-	baseStore
+// Type 返回数值的存储类型
+func (v *stringValue) Type() driverStoreType {
+	return driverStoreTypeString
 }
 
-func newStringStore() *stringStore {
-	ticker := time.NewTicker(time.Second * 10)
+// size 数值占用的字节数估算值
+func (v *stringValue) size() int64 {
+	return int64(len(v.value))
+}
 
-	store := &stringStore{
-		baseStore: baseStore{
-			values:       make(map[string]expireable),
-			rwMutex:      sync.RWMutex{},
-			expireTicker: ticker,
-		},
-	}
+// charge 记录当前占用的字节数,返回跟上次记录时的差值
+func (v *stringValue) charge() int64 {
+	return v.chargeSize(v.size())
+}
 
-	// 定时检测到期key
-	go store.checkExpireTick()
+// dump 导出数值
+func (v *stringValue) dump() []string {
+	return []string{v.value}
+}
+
+// MaxStringSize 字符串最大的字节数,跟 redis 的 proto-max-bulk-len 默认值一致
+const MaxStringSize = 512 * 1024 * 1024
 
-	return store
+type stringStore struct {
+	baseStore
 }
 
-func (s *stringStore) Type() driverStoreType {
-	return driverStoreTypeString
+func newStringStore(ks *keyspace) *stringStore {
+	return &stringStore{
+		baseStore: baseStore{keyspace: ks},
+	}
+}
+
+// restore 根据导出的数据恢复某个键
+func (s *stringStore) restore(entry *storeEntry) {
+	val := newStringValue()
+	if len(entry.Values) > 0 {
+		val.value = entry.Values[0]
+	}
+	val.SetExpireAt(entry.ExpireAt)
+	s.baseStore.restore(entry.Key, val)
 }
 
 // Set 设置数据
 func (ss *stringStore) Set(ctx context.Context, key, data string, expiration time.Duration) error {
-	ss.rwMutex.Lock()
-	defer ss.rwMutex.Unlock()
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		val, ok := ss.values[key].(*stringValue)
+		v, err := sh.lookup(key, driverStoreTypeString)
+		if err != nil {
+			return err
+		}
+		val, ok := v.(*stringValue)
 		if !ok {
 			val = newStringValue()
 		}
 
 		val.value = data
-		if ok && expiration != KeepTTL {
+		// 已有的数据在 KeepTTL 时保持原有的存活时长,没有指定存活时长的永不过期
+		switch {
+		case expiration > 0:
 			val.SetExpire(expiration)
+		case ok && expiration == KeepTTL:
+		default:
+			val.SetExpireAt(nil)
 		}
 
-		ss.values[key] = val
+		sh.store(key, val)
 	}
 
 	return nil
@@ -82,53 +108,59 @@ func (ss *stringStore) Set(ctx context.Context, key, data string, expiration tim
 
 // SetNX 设置数据,如果key不存在的话
 func (ss *stringStore) SetNX(ctx context.Context, key, data string, expiration time.Duration) (bool, error) {
-	ss.rwMutex.Lock()
-	defer ss.rwMutex.Unlock()
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
 
 	select {
 	case <-ctx.Done():
 		return false, ctx.Err()
 	default:
-		val, ok := ss.values[key].(*stringValue)
-		if ok {
+		// 键已经存在时,不论是什么类型都不能设置
+		if v, ok := sh.values[key]; ok && !v.IsExpire() {
 			return false, nil
 		}
 
-		val = newStringValue()
+		val := newStringValue()
 		val.value = data
-		if ok && expiration != KeepTTL {
+		// 没有指定存活时长的永不过期
+		if expiration > 0 {
 			val.SetExpire(expiration)
+		} else {
+			val.SetExpireAt(nil)
 		}
-		ss.values[key] = val
+		sh.store(key, val)
 		return true, nil
 	}
 }
 
 // Get 获取数据
 func (ss *stringStore) Get(ctx context.Context, key string) (string, error) {
-	ss.rwMutex.RLock()
-	defer ss.rwMutex.RUnlock()
+	sh := ss.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
 
 	select {
 	case <-ctx.Done():
 		return "", nil
 	default:
-		d, ok := ss.values[key]
-		if !ok {
-			return "", MemoryNil
+		v, err := sh.lookup(key, driverStoreTypeString)
+		if err != nil {
+			return "", err
 		}
 
-		if d.IsExpire() {
+		val, ok := v.(*stringValue)
+		if !ok {
 			return "", MemoryNil
 		}
-		return d.(*stringValue).value, nil
+		return val.value, nil
 	}
 }
 
 // MGet 根据多个Key获取多个值
 func (ss *stringStore) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
-	ss.rwMutex.RLock()
-	defer ss.rwMutex.RUnlock()
+	unlock := ss.lockShards(false, keys...)
+	defer unlock()
 
 	select {
 	case <-ctx.Done():
@@ -136,13 +168,411 @@ func (ss *stringStore) MGet(ctx context.Context, keys ...string) ([]interface{},
 	default:
 		var rst = make([]interface{}, len(keys), len(keys))
 		for i, key := range keys {
-			if d, ok := ss.values[key]; ok {
-				if d.IsExpire() {
-					continue
-				}
-				rst[i] = d.(*stringValue).value
+			// 不存在或者不是字符串类型的键返回nil
+			v, _ := ss.shard(key).lookup(key, driverStoreTypeString)
+			if val, ok := v.(*stringValue); ok {
+				rst[i] = val.value
 			}
 		}
 		return rst, nil
 	}
 }
+
+// lookupString 查找字符串数值,键不存在时返回nil,调用方需要持有分片的锁
+func (sh *keyspaceShard) lookupString(key string) (*stringValue, error) {
+	v, err := sh.lookup(key, driverStoreTypeString)
+	if err != nil {
+		return nil, err
+	}
+	val, _ := v.(*stringValue)
+	return val, nil
+}
+
+// IncrBy 将key中储存的数字加上增量 value,key不存在时先初始化为0,保持原有的存活时长
+// @return 增加之后的值
+func (ss *stringStore) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return 0, err
+		}
+
+		var i int64
+		if val != nil {
+			i, err = strconv.ParseInt(val.value, 10, 64)
+			if err != nil {
+				return 0, MemoryNotInteger
+			}
+		} else {
+			val = newStringValue()
+		}
+
+		if (value > 0 && i > math.MaxInt64-value) || (value < 0 && i < math.MinInt64-value) {
+			return 0, MemoryOverflow
+		}
+
+		i += value
+		val.value = strconv.FormatInt(i, 10)
+		sh.store(key, val)
+		return i, nil
+	}
+}
+
+// IncrByFloat 将key中储存的数字加上浮点数增量 value,key不存在时先初始化为0,保持原有的存活时长
+// @return 增加之后的值
+func (ss *stringStore) IncrByFloat(ctx context.Context, key string, value float64) (float64, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return 0, err
+		}
+
+		var f float64
+		if val != nil {
+			f, err = strconv.ParseFloat(val.value, 64)
+			if err != nil {
+				return 0, MemoryNotFloat
+			}
+		} else {
+			val = newStringValue()
+		}
+
+		f += value
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, MemoryNaN
+		}
+
+		val.value = strconv.FormatFloat(f, 'f', -1, 64)
+		sh.store(key, val)
+		return f, nil
+	}
+}
+
+// Append 将 value 追加到key原来的值的末尾,key不存在时相当于 Set 但不设置存活时长
+// @return 追加之后字符串的长度
+func (ss *stringStore) Append(ctx context.Context, key, value string) (int64, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return 0, err
+		}
+		if val == nil {
+			val = newStringValue()
+		}
+
+		if len(val.value)+len(value) > MaxStringSize {
+			return 0, MemoryStringTooLong
+		}
+
+		val.value += value
+		sh.store(key, val)
+		return int64(len(val.value)), nil
+	}
+}
+
+// GetRange 返回key中字符串值的子字符串,截取的范围由 start 和 end 决定(包括 start 和 end 在内)
+// 负数偏移量表示从字符串最后开始计数, -1 表示最后一个字符, -2 表示倒数第二个,以此类推
+func (ss *stringStore) GetRange(ctx context.Context, key string, start, end int64) (string, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil || val == nil {
+			return "", err
+		}
+
+		size := int64(len(val.value))
+		if start < 0 && end < 0 && start > end {
+			return "", nil
+		}
+		if start < 0 {
+			start += size
+		}
+		if end < 0 {
+			end += size
+		}
+		if start < 0 {
+			start = 0
+		}
+		if end < 0 {
+			end = 0
+		}
+		if end >= size {
+			end = size - 1
+		}
+		if start > end || size == 0 {
+			return "", nil
+		}
+		return val.value[start : end+1], nil
+	}
+}
+
+// SetRange 用 value 覆写key中字符串值从偏移量 offset 开始的内容,不足的部分用零字节填充
+// key不存在并且 value 为空时不会创建key
+// @return 覆写之后字符串的长度
+func (ss *stringStore) SetRange(ctx context.Context, key string, offset int64, value string) (int64, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		if offset < 0 {
+			return 0, MemoryOutOfRange
+		}
+
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return 0, err
+		}
+		if val == nil {
+			if len(value) == 0 {
+				return 0, nil
+			}
+			val = newStringValue()
+		}
+
+		if len(value) == 0 {
+			return int64(len(val.value)), nil
+		}
+		if offset+int64(len(value)) > MaxStringSize {
+			return 0, MemoryStringTooLong
+		}
+
+		data := []byte(val.value)
+		if end := int(offset) + len(value); end > len(data) {
+			data = append(data, make([]byte, end-len(data))...)
+		}
+		copy(data[offset:], value)
+
+		val.value = string(data)
+		sh.store(key, val)
+		return int64(len(val.value)), nil
+	}
+}
+
+// StrLen 返回key中字符串值的长度,key不存在时返回0
+func (ss *stringStore) StrLen(ctx context.Context, key string) (int64, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.RLock()
+	defer sh.rwMutex.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil || val == nil {
+			return 0, err
+		}
+		return int64(len(val.value)), nil
+	}
+}
+
+// GetSet 设置key的值并返回原来的值,原来的存活时长会被清除
+// key不存在时返回 MemoryNil,但仍然会设置新的值
+func (ss *stringStore) GetSet(ctx context.Context, key, value string) (string, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return "", err
+		}
+
+		old, oldErr := "", MemoryNil
+		if val != nil {
+			old, oldErr = val.value, nil
+		} else {
+			val = newStringValue()
+		}
+
+		val.value = value
+		val.SetExpireAt(nil)
+		sh.store(key, val)
+		return old, oldErr
+	}
+}
+
+// GetDel 获取key的值并删除key,key不存在时返回 MemoryNil
+func (ss *stringStore) GetDel(ctx context.Context, key string) (string, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return "", err
+		}
+		if val == nil {
+			return "", MemoryNil
+		}
+
+		sh.remove(key)
+		return val.value, nil
+	}
+}
+
+// GetEx 获取key的值并设置存活时长,key不存在时返回 MemoryNil
+// expiration 大于0时设置存活时长,等于0时移除存活时长,小于0时保持原有的存活时长
+func (ss *stringStore) GetEx(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return "", err
+		}
+		if val == nil {
+			return "", MemoryNil
+		}
+
+		switch {
+		case expiration > 0:
+			val.SetExpire(expiration)
+		case expiration == 0:
+			val.SetExpireAt(nil)
+		default:
+			return val.value, nil
+		}
+		sh.limitTTL(val)
+		sh.schedule(key, val)
+		return val.value, nil
+	}
+}
+
+// SetXX 只有key已经存在时才设置数据
+// expiration 大于0时设置存活时长,为 KeepTTL 时保持原有的存活时长,否则永不过期
+func (ss *stringStore) SetXX(ctx context.Context, key, data string, expiration time.Duration) (bool, error) {
+	sh := ss.shard(key)
+	sh.rwMutex.Lock()
+	defer sh.rwMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	default:
+		val, err := sh.lookupString(key)
+		if err != nil {
+			return false, err
+		}
+		if val == nil {
+			return false, nil
+		}
+
+		val.value = data
+		switch {
+		case expiration > 0:
+			val.SetExpire(expiration)
+		case expiration == KeepTTL:
+		default:
+			val.SetExpireAt(nil)
+		}
+		sh.store(key, val)
+		return true, nil
+	}
+}
+
+// MSet 同时设置多个key的值,参数格式为 key1, value1, key2, value2...
+// 所有的key都会被设置成永不过期;任意一个key的类型不是字符串时都不会设置
+func (ss *stringStore) MSet(ctx context.Context, pairs ...string) error {
+	if len(pairs)%2 != 0 {
+		return errors.New("the number of parameters is incorrect")
+	}
+
+	keys := pairKeys(pairs)
+	unlock := ss.lockShards(true, keys...)
+	defer unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// 先检测所有key的类型,保证要么全部设置要么全部不设置
+		for _, key := range keys {
+			if _, err := ss.shard(key).lookup(key, driverStoreTypeString); err != nil {
+				return err
+			}
+		}
+
+		for i := 0; i < len(pairs); i += 2 {
+			val := newStringValue()
+			val.value = pairs[i+1]
+			ss.shard(pairs[i]).store(pairs[i], val)
+		}
+		return nil
+	}
+}
+
+// MSetNX 只有所有的key都不存在时才同时设置多个key的值,参数格式为 key1, value1, key2, value2...
+// @return 是否设置成功
+func (ss *stringStore) MSetNX(ctx context.Context, pairs ...string) (bool, error) {
+	if len(pairs)%2 != 0 {
+		return false, errors.New("the number of parameters is incorrect")
+	}
+
+	keys := pairKeys(pairs)
+	unlock := ss.lockShards(true, keys...)
+	defer unlock()
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	default:
+		// 任意一个key已经存在,不论是什么类型都不能设置
+		for _, key := range keys {
+			if v, ok := ss.shard(key).values[key]; ok && !v.IsExpire() {
+				return false, nil
+			}
+		}
+
+		for i := 0; i < len(pairs); i += 2 {
+			val := newStringValue()
+			val.value = pairs[i+1]
+			ss.shard(pairs[i]).store(pairs[i], val)
+		}
+		return true, nil
+	}
+}
@@ -15,7 +15,7 @@ import (
 
 func Test_stringStore_Get(t *testing.T) {
 
-	ss := newStringStore()
+	ss := newStringStore(newKeyspace())
 	ss.Set(context.Background(), "key1", "value1", 0)
 
 	type args struct {
@@ -64,7 +64,7 @@ func Test_stringStore_Get(t *testing.T) {
 }
 
 func Test_stringStore_MGet(t *testing.T) {
-	ss := newStringStore()
+	ss := newStringStore(newKeyspace())
 	ss.Set(context.Background(), "key", "value", 0)
 
 	type args struct {
@@ -112,7 +112,7 @@ func Test_stringStore_MGet(t *testing.T) {
 }
 
 func Test_stringStore_Set(t *testing.T) {
-	ss := newStringStore()
+	ss := newStringStore(newKeyspace())
 	type args struct {
 		ctx        context.Context
 		key        string
@@ -162,7 +162,7 @@ func Test_stringStore_Set(t *testing.T) {
 }
 
 func Test_stringStore_SetNX(t *testing.T) {
-	ss := newStringStore()
+	ss := newStringStore(newKeyspace())
 	type args struct {
 		ctx        context.Context
 		key        string
@@ -211,3 +211,149 @@ func Test_stringStore_SetNX(t *testing.T) {
 		})
 	}
 }
+
+func Test_stringStore_IncrBy(t *testing.T) {
+	ss := newStringStore(newKeyspace())
+	ss.Set(context.Background(), "text", "abc", 0)
+	ss.Set(context.Background(), "max", "9223372036854775807", 0)
+	type args struct {
+		ctx   context.Context
+		key   string
+		value int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int64
+		wantErr error
+	}{
+		{
+			name: "key不存在时从0开始",
+			args: args{ctx: context.Background(), key: "counter", value: 5},
+			want: 5,
+		},
+		{
+			name: "在原来的值上累加",
+			args: args{ctx: context.Background(), key: "counter", value: -7},
+			want: -2,
+		},
+		{
+			name:    "值不是整数",
+			args:    args{ctx: context.Background(), key: "text", value: 1},
+			wantErr: MemoryNotInteger,
+		},
+		{
+			name:    "溢出",
+			args:    args{ctx: context.Background(), key: "max", value: 1},
+			wantErr: MemoryOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ss.IncrBy(tt.args.ctx, tt.args.key, tt.args.value)
+			if err != tt.wantErr {
+				t.Errorf("IncrBy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IncrBy() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	f, err := ss.IncrByFloat(context.Background(), "counter", 0.5)
+	if err != nil || f != -1.5 {
+		t.Errorf("IncrByFloat() got = %v, error = %v, want -1.5", f, err)
+	}
+}
+
+func Test_stringStore_Range(t *testing.T) {
+	ss := newStringStore(newKeyspace())
+	ctx := context.Background()
+	ss.Set(ctx, "key", "Hello World", 0)
+
+	if got, _ := ss.GetRange(ctx, "key", 0, 4); got != "Hello" {
+		t.Errorf("GetRange() got = %v, want Hello", got)
+	}
+	if got, _ := ss.GetRange(ctx, "key", -5, -1); got != "World" {
+		t.Errorf("GetRange() got = %v, want World", got)
+	}
+	if got, _ := ss.GetRange(ctx, "key", 5, 3); got != "" {
+		t.Errorf("GetRange() got = %v, want empty", got)
+	}
+
+	if got, _ := ss.SetRange(ctx, "key", 6, "Redis"); got != 11 {
+		t.Errorf("SetRange() got = %v, want 11", got)
+	}
+	if got, _ := ss.Get(ctx, "key"); got != "Hello Redis" {
+		t.Errorf("Get() got = %v, want Hello Redis", got)
+	}
+
+	// 超出原来长度的部分用0字节填充
+	if got, _ := ss.SetRange(ctx, "padded", 3, "x"); got != 4 {
+		t.Errorf("SetRange() got = %v, want 4", got)
+	}
+	if got, _ := ss.Get(ctx, "padded"); got != "\x00\x00\x00x" {
+		t.Errorf("Get() got = %q, want \\x00\\x00\\x00x", got)
+	}
+	if _, err := ss.SetRange(ctx, "padded", -1, "x"); err != MemoryOutOfRange {
+		t.Errorf("SetRange() error = %v, want %v", err, MemoryOutOfRange)
+	}
+
+	if got, _ := ss.Append(ctx, "padded", "yz"); got != 6 {
+		t.Errorf("Append() got = %v, want 6", got)
+	}
+	if got, _ := ss.StrLen(ctx, "missing"); got != 0 {
+		t.Errorf("StrLen() got = %v, want 0", got)
+	}
+}
+
+func Test_stringStore_MSetNX(t *testing.T) {
+	ss := newStringStore(newKeyspace())
+	ctx := context.Background()
+
+	if ok, err := ss.MSetNX(ctx, "k1", "v1", "k2", "v2"); !ok || err != nil {
+		t.Fatalf("MSetNX() got = %v, error = %v, want true", ok, err)
+	}
+
+	// 只要有一个key已经存在就不设置任何key
+	if ok, err := ss.MSetNX(ctx, "k2", "x", "k3", "v3"); ok || err != nil {
+		t.Fatalf("MSetNX() got = %v, error = %v, want false", ok, err)
+	}
+	if _, err := ss.Get(ctx, "k3"); err != MemoryNil {
+		t.Errorf("Get() error = %v, want %v", err, MemoryNil)
+	}
+
+	got, _ := ss.MGet(ctx, "k1", "k2")
+	if want := []interface{}{"v1", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MGet() got = %v, want %v", got, want)
+	}
+}
+
+func Test_stringStore_GetEx(t *testing.T) {
+	ss := newStringStore(newKeyspace())
+	ctx := context.Background()
+	ss.Set(ctx, "key", "value", 0)
+
+	if got, err := ss.GetEx(ctx, "key", time.Millisecond*50); got != "value" || err != nil {
+		t.Fatalf("GetEx() got = %v, error = %v", got, err)
+	}
+	time.Sleep(time.Millisecond * 80)
+	if _, err := ss.Get(ctx, "key"); err != MemoryNil {
+		t.Errorf("Get() error = %v, want %v", err, MemoryNil)
+	}
+
+	if ok, _ := ss.SetXX(ctx, "key", "value", 0); ok {
+		t.Errorf("SetXX() got = true, want false")
+	}
+	ss.Set(ctx, "key", "old", 0)
+	if got, _ := ss.GetSet(ctx, "key", "new"); got != "old" {
+		t.Errorf("GetSet() got = %v, want old", got)
+	}
+	if got, _ := ss.GetDel(ctx, "key"); got != "new" {
+		t.Errorf("GetDel() got = %v, want new", got)
+	}
+	if _, err := ss.GetDel(ctx, "key"); err != MemoryNil {
+		t.Errorf("GetDel() error = %v, want %v", err, MemoryNil)
+	}
+}
@@ -1,6 +1,9 @@
 package errors
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/jerbe/go-errors"
 )
 
@@ -19,3 +22,53 @@ var (
 type ErrorValuer interface {
 	Err() error
 }
+
+// DriverResult 某个驱动的写入结果
+type DriverResult struct {
+	// Index 驱动在写入顺序中的下标
+	Index int
+
+	// Err 写入错误,为nil时表示写入成功
+	Err error
+}
+
+// WriteError 写操作在一个或多个驱动中失败时返回的错误
+// 包含每个已执行写入的驱动的结果,可以通过 errors.As 获取
+type WriteError struct {
+	// Results 每个已执行写入的驱动的结果,按写入顺序排列
+	Results []DriverResult
+
+	// Compensated 是否已经对写入成功的驱动进行了补偿(删除相关的键)
+	Compensated bool
+}
+
+// Failed 返回写入失败的驱动结果
+func (e *WriteError) Failed() []DriverResult {
+	result := make([]DriverResult, 0, len(e.Results))
+	for _, r := range e.Results {
+		if r.Err != nil {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Error 实现 error 接口
+func (e *WriteError) Error() string {
+	failed := e.Failed()
+	msgs := make([]string, 0, len(failed))
+	for _, r := range failed {
+		msgs = append(msgs, fmt.Sprintf("[%d] %v", r.Index, r.Err))
+	}
+	return fmt.Sprintf("jcache: write failed on %d/%d drivers: %s", len(failed), len(e.Results), strings.Join(msgs, "; "))
+}
+
+// Unwrap 返回第一个写入失败的错误,方便使用 errors.Is 判断
+func (e *WriteError) Unwrap() error {
+	for _, r := range e.Results {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
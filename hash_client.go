@@ -2,7 +2,11 @@ package jcache
 
 import (
 	"context"
+	"time"
+
 	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/errors"
+	"github.com/redis/go-redis/v9"
 )
 
 /**
@@ -16,17 +20,17 @@ type HashClient struct {
 }
 
 func NewHashClient(drivers ...driver.Hash) *HashClient {
-	drs := make([]driver.Common, 0)
+	drs := make([]clientDriver, 0)
 	for i := 0; i < len(drivers); i++ {
-		drs = append(drs, drivers[i])
+		drs = append(drs, clientDriver{driver: drivers[i]})
 	}
 
 	if len(drs) == 0 {
-		drs = append(drs, driver.NewMemory())
+		drs = append(drs, clientDriver{driver: driver.NewMemory()})
 	}
 
 	return &HashClient{
-		BaseClient: BaseClient{drivers: drs},
+		BaseClient: newBaseClient(nil, drs...),
 	}
 }
 
@@ -46,37 +50,31 @@ func NewHashClient(drivers ...driver.Hash) *HashClient {
 // HSet("myhash", MyHash{"value1", "value2"}) 警告：redis-server >= 4.0
 // 对于struct，可以是结构体指针类型，我们只解析标签为redis的字段。如果你不想读取该字段，可以使用 `redis:"-"` 标志来忽略它，或者不需要设置 redis 标签。对于结构体字段的类型，我们只支持简单的数据类型：string、int/uint(8,16,32,64)、float(32,64)、time.Time(to RFC3339Nano)、time.Duration(to Nanoseconds) ），如果是其他更复杂或者自定义的数据类型，请实现encoding.BinaryMarshaler接口。
 func (cli *HashClient) HSet(ctx context.Context, key string, values ...interface{}) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
-
-	var value driver.IntValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.Hash).HSet(ctx, key, values); i == 0 {
-			value = v
-		}
-	}
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HSet(ctx, key, values...)
+	}).(driver.IntValuer)
 }
 
 // HSetNX 哈希表设置某个字段的值,如果存在的话返回true
 func (cli *HashClient) HSetNX(ctx context.Context, key, field string, data interface{}) driver.BoolValuer {
-	ctx, _ = cli.preCheck(ctx)
-	var value driver.BoolValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.Hash).HSetNX(ctx, key, field, data); i == 0 {
-			value = v
-		}
-	}
-
-	return value
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HSetNX(ctx, key, field, data)
+	}).(driver.BoolValuer)
 }
 
 // HVals 获取Hash表的所有值
 func (cli *HashClient) HVals(ctx context.Context, key string) driver.StringSliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 	var value driver.StringSliceValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.Hash).HVals(ctx, key); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.Hash).HVals(ctx, key); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteHash)
 			return value
 		}
 	}
@@ -85,11 +83,13 @@ func (cli *HashClient) HVals(ctx context.Context, key string) driver.StringSlice
 
 // HKeys 获取Hash表的所有键
 func (cli *HashClient) HKeys(ctx context.Context, key string) driver.StringSliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.StringSliceValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.Hash).HKeys(ctx, key); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.Hash).HKeys(ctx, key); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteHash)
 			return value
 		}
 
@@ -99,17 +99,29 @@ func (cli *HashClient) HKeys(ctx context.Context, key string) driver.StringSlice
 
 // HGetAll 获取哈希表中所有的值,包括键/值
 func (cli *HashClient) HGetAll(ctx context.Context, key string) driver.MapStringStringValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.MapStringStringValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.Hash).HGetAll(ctx, key); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.Hash).HGetAll(ctx, key); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteHash)
 			return value
 		}
 	}
 	return value
 }
 
+// HGetAllAndScan 获取哈希表中所有的值并扫描到dst中
+// dst 可以是 *map[string]string 或者带 redis 标签的结构体指针,跟 HSet 写入结构体时使用的标签一致
+func (cli *HashClient) HGetAllAndScan(ctx context.Context, dst interface{}, key string) error {
+	val := cli.HGetAll(ctx, key)
+	if err := val.Err(); err != nil {
+		return err
+	}
+	return scanMapStringString(val.Val(), dst)
+}
+
 // HKeysAndScan 获取Hash表的所有键并扫描到dst中
 func (cli *HashClient) HKeysAndScan(ctx context.Context, dst interface{}, key string) error {
 	return cli.HKeys(ctx, key).ScanSlice(dst)
@@ -117,11 +129,13 @@ func (cli *HashClient) HKeysAndScan(ctx context.Context, dst interface{}, key st
 
 // HLen 获取Hash表的所有键个数
 func (cli *HashClient) HLen(ctx context.Context, key string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.IntValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.Hash).HLen(ctx, key); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.Hash).HLen(ctx, key); found(value, value.Val() == 0) {
+			cli.promote(value, i, key, promoteHash)
 			return value
 		}
 	}
@@ -131,11 +145,13 @@ func (cli *HashClient) HLen(ctx context.Context, key string) driver.IntValuer {
 
 // HGet 获取Hash表指定字段的值
 func (cli *HashClient) HGet(ctx context.Context, key, field string) driver.StringValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.StringValuer
-	for _, c := range cli.drivers {
+	for i, c := range cli.drivers {
 		if value = c.(driver.Hash).HGet(ctx, key, field); returnable(value) {
+			cli.promote(value, i, key, promoteHash)
 			return value
 		}
 	}
@@ -149,10 +165,12 @@ func (cli *HashClient) HGetAndScan(ctx context.Context, dst interface{}, key, fi
 
 // HMGet 获取Hash表指定字段的值
 func (cli *HashClient) HMGet(ctx context.Context, key string, fields ...string) driver.SliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 	var value driver.SliceValuer
-	for _, c := range cli.drivers {
+	for i, c := range cli.drivers {
 		if value = c.(driver.Hash).HMGet(ctx, key, fields...); returnable(value) {
+			cli.promote(value, i, key, promoteHash)
 			return value
 		}
 	}
@@ -171,28 +189,184 @@ func (cli *HashClient) HValsAndScan(ctx context.Context, dst interface{}, key st
 
 // HDel 删除hash数据
 func (cli *HashClient) HDel(ctx context.Context, key string, fields ...string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.IntValuer
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HDel(ctx, key, fields...)
+	}).(driver.IntValuer)
+}
+
+// HExists 判断哈希表周公某个字段是否存在
+func (cli *HashClient) HExists(ctx context.Context, key, field string) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.BoolValuer
 	for i, c := range cli.drivers {
 		// @TODO 失败重做?
-		if v := c.(driver.Hash).HDel(ctx, key, fields...); i == 0 {
-			value = v
+		if value = c.(driver.Hash).HExists(ctx, key, field); found(value, !value.Val()) {
+			cli.promote(value, i, key, promoteHash)
+			return value
 		}
 	}
 	return value
 }
 
-// HExists 判断哈希表周公某个字段是否存在
-func (cli *HashClient) HExists(ctx context.Context, key, field string) driver.BoolValuer {
-	ctx, _ = cli.preCheck(ctx)
+// followField 其他驱动写入第一个驱动计算后的字段值,保证各个驱动的计数一致
+func followField(key, field string) func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+	return func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+		if lead.Err() != nil {
+			return new(redis.IntCmd)
+		}
 
-	var value driver.BoolValuer
+		var value interface{}
+		switch v := lead.(type) {
+		case driver.IntValuer:
+			value = v.Val()
+		case driver.FloatValuer:
+			value = v.Val()
+		}
+		return c.(driver.Hash).HSet(ctx, key, field, value)
+	}
+}
+
+// HIncrBy 哈希表field的值加上增量 incr,field不存在时先初始化为0
+// 第一个写入的驱动计算结果,其他驱动写入相同的结果
+func (cli *HashClient) HIncrBy(ctx context.Context, key, field string, incr int64) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HIncrBy(ctx, key, field, incr)
+	}, followField(key, field))).(driver.IntValuer)
+}
+
+// HIncrByFloat 哈希表field的值加上浮点数增量 incr,field不存在时先初始化为0
+// 第一个写入的驱动计算结果,其他驱动写入相同的结果
+func (cli *HashClient) HIncrByFloat(ctx context.Context, key, field string, incr float64) driver.FloatValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HIncrByFloat(ctx, key, field, incr)
+	}, followField(key, field))).(driver.FloatValuer)
+}
+
+// HStrLen 获取哈希表field的值的长度
+func (cli *HashClient) HStrLen(ctx context.Context, key, field string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.IntValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.Hash).HStrLen(ctx, key, field); found(value, value.Val() == 0) {
+			cli.promote(value, i, key, promoteHash)
+			return value
+		}
+	}
+	return value
+}
+
+// HRandField 随机返回哈希表中的字段
+// count 为正数时返回最多 count 个不重复的字段;为负数时返回 -count 个字段,字段可能重复
+func (cli *HashClient) HRandField(ctx context.Context, key string, count int) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.Hash).HRandField(ctx, key, count); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteHash)
+			return value
+		}
+	}
+	return value
+}
+
+// HScan 以游标的方式扫描哈希表,返回 字段1,值1,字段2,值2... 以及下一次扫描的游标
+// 游标为0时从头开始扫描,返回的游标为0时表示扫描结束
+// 游标只在同一个驱动中有效,从第一个存在该哈希表的驱动中扫描,不会提升到上层驱动
+func (cli *HashClient) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) driver.ScanValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.ScanValuer
 	for _, c := range cli.drivers {
-		// @TODO 失败重做?
-		if value = c.(driver.Hash).HExists(ctx, key, field); returnable(value) {
+		value = c.(driver.Hash).HScan(ctx, key, cursor, match, count)
+		keys, next := value.Val()
+		if found(value, len(keys) == 0 && next == 0) {
+			return value
+		}
+	}
+	return value
+}
+
+// HExpire 设置哈希表字段的存活时长,精确到秒
+// 每个字段的返回值: -2 字段不存在, 1 设置成功, 2 存活时长小于等于0,字段被删除
+func (cli *HashClient) HExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) driver.IntSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HExpire(ctx, key, expiration, fields...)
+	}).(driver.IntSliceValuer)
+}
+
+// HPExpire 设置哈希表字段的存活时长,精确到毫秒,返回值同 HExpire
+func (cli *HashClient) HPExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) driver.IntSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HPExpire(ctx, key, expiration, fields...)
+	}).(driver.IntSliceValuer)
+}
+
+// HExpireAt 设置哈希表字段的到期时间,返回值同 HExpire
+func (cli *HashClient) HExpireAt(ctx context.Context, key string, tm time.Time, fields ...string) driver.IntSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HExpireAt(ctx, key, tm, fields...)
+	}).(driver.IntSliceValuer)
+}
+
+// HTTL 获取哈希表字段剩余的存活时长(秒)
+// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间
+func (cli *HashClient) HTTL(ctx context.Context, key string, fields ...string) driver.IntSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.IntSliceValuer
+	for i, c := range cli.drivers {
+		value = c.(driver.Hash).HTTL(ctx, key, fields...)
+
+		empty := true
+		for _, ttl := range value.Val() {
+			if ttl != -2 {
+				empty = false
+				break
+			}
+		}
+		if found(value, empty) {
+			cli.promote(value, i, key, promoteHash)
 			return value
 		}
 	}
 	return value
 }
+
+// HPersist 清除哈希表字段的到期时间
+// 每个字段的返回值: -2 字段不存在, -1 字段没有到期时间, 1 清除成功
+func (cli *HashClient) HPersist(ctx context.Context, key string, fields ...string) driver.IntSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Hash).HPersist(ctx, key, fields...)
+	}).(driver.IntSliceValuer)
+}
@@ -0,0 +1,112 @@
+package singleflight
+
+import (
+	"fmt"
+	"sync"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/12 10:05
+  @describe : 合并相同key的并发调用,同一时间只有一个调用真正执行,其余调用等待并共享结果
+*/
+
+// call 正在执行或者已经完成的调用
+type call struct {
+	wg sync.WaitGroup
+
+	val interface{}
+	err error
+
+	// dups 共享该调用结果的调用方数量
+	dups int
+
+	// chans 通过 DoChan 等待结果的调用方
+	chans []chan<- Result
+}
+
+// Result DoChan 返回的调用结果
+type Result struct {
+	Val    interface{}
+	Err    error
+	Shared bool
+}
+
+// Group 调用组,同一个key的并发调用会被合并
+type Group struct {
+	mu sync.Mutex
+	m  map[string]*call
+}
+
+// Do 执行并返回 fn 的结果,同一个key同一时间只会有一个 fn 在执行,
+// 其他相同key的调用会等待该执行完成并返回相同的结果;shared 指示结果是否被多个调用方共享
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := new(call)
+	c.wg.Add(1)
+	g.m[key] = c
+	g.mu.Unlock()
+
+	g.doCall(c, key, fn)
+	return c.val, c.err, c.dups > 0
+}
+
+// DoChan 同 Do,但是 fn 在新的协程中执行,结果通过返回的通道传递,调用方可以在等待时自行放弃
+// 调用方放弃等待时 fn 不会被中断,其他调用方依然可以拿到结果
+func (g *Group) DoChan(key string, fn func() (interface{}, error)) <-chan Result {
+	ch := make(chan Result, 1)
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.mu.Unlock()
+		return ch
+	}
+	c := &call{chans: []chan<- Result{ch}}
+	c.wg.Add(1)
+	g.m[key] = c
+	g.mu.Unlock()
+
+	go g.doCall(c, key, fn)
+	return ch
+}
+
+// Forget 忘记某个key,之后的调用会重新执行而不是等待先前的调用
+func (g *Group) Forget(key string) {
+	g.mu.Lock()
+	delete(g.m, key)
+	g.mu.Unlock()
+}
+
+// doCall 执行调用,fn 发生 panic 时转换成错误返回给所有调用方
+func (g *Group) doCall(c *call, key string, fn func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("singleflight: panic in fn: %v", r)
+		}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		c.wg.Done()
+		if g.m[key] == c {
+			delete(g.m, key)
+		}
+		for _, ch := range c.chans {
+			ch <- Result{Val: c.val, Err: c.err, Shared: c.dups > 0}
+		}
+	}()
+
+	c.val, c.err = fn()
+}
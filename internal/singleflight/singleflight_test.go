@@ -0,0 +1,136 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/12 10:32
+  @describe :
+*/
+
+func TestGroup_Do(t *testing.T) {
+	var g Group
+	v, err, _ := g.Do("key", func() (interface{}, error) {
+		return "bar", nil
+	})
+	if v != "bar" || err != nil {
+		t.Errorf("Do() = %v, %v, want bar, nil", v, err)
+	}
+
+	someErr := errors.New("some error")
+	_, err, _ = g.Do("key", func() (interface{}, error) {
+		return nil, someErr
+	})
+	if err != someErr {
+		t.Errorf("Do() error = %v, want %v", err, someErr)
+	}
+}
+
+func TestGroup_Do_Dupes(t *testing.T) {
+	var g Group
+	var calls int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	release := make(chan struct{})
+
+	const n = 10
+	results := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], _, _ = g.Do("key", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "value", nil
+			})
+		}(i)
+	}
+
+	close(start)
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fn calls = %d, want 1", got)
+	}
+	for i, v := range results {
+		if v != "value" {
+			t.Errorf("results[%d] = %v, want value", i, v)
+		}
+	}
+}
+
+func TestGroup_Do_Panic(t *testing.T) {
+	var g Group
+	_, err, _ := g.Do("key", func() (interface{}, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Errorf("Do() error = nil, want panic error")
+	}
+
+	// panic 之后可以继续使用
+	v, err, _ := g.Do("key", func() (interface{}, error) {
+		return "ok", nil
+	})
+	if v != "ok" || err != nil {
+		t.Errorf("Do() = %v, %v, want ok, nil", v, err)
+	}
+}
+
+func TestGroup_DoChan(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	first := g.DoChan("key", func() (interface{}, error) {
+		<-release
+		return "value", nil
+	})
+	second := g.DoChan("key", func() (interface{}, error) {
+		return "other", nil
+	})
+
+	// fn 执行完成之前不会返回结果,相同key的调用共享第一个调用的结果
+	select {
+	case <-first:
+		t.Fatal("DoChan() returned before fn finished")
+	case <-time.After(time.Millisecond * 50):
+	}
+	close(release)
+
+	for _, ch := range []<-chan Result{first, second} {
+		if r := <-ch; r.Val != "value" || r.Err != nil || !r.Shared {
+			t.Errorf("DoChan() = %+v, want value shared", r)
+		}
+	}
+
+	// Do 跟 DoChan 共享同一个调用
+	release = make(chan struct{})
+	ch := g.DoChan("key", func() (interface{}, error) {
+		<-release
+		return "chan", nil
+	})
+	done := make(chan interface{})
+	go func() {
+		v, _, _ := g.Do("key", func() (interface{}, error) {
+			return "do", nil
+		})
+		done <- v
+	}()
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	if v := <-done; v != "chan" {
+		t.Errorf("Do() = %v, want chan", v)
+	}
+	if r := <-ch; r.Val != "chan" {
+		t.Errorf("DoChan() = %v, want chan", r.Val)
+	}
+}
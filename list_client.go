@@ -2,8 +2,10 @@ package jcache
 
 import (
 	"context"
+	"time"
 
 	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/errors"
 )
 
 /**
@@ -17,17 +19,17 @@ type ListClient struct {
 }
 
 func NewListClient(drivers ...driver.List) *ListClient {
-	drs := make([]driver.Common, 0)
+	drs := make([]clientDriver, 0)
 	for i := 0; i < len(drivers); i++ {
-		drs = append(drs, drivers[i])
+		drs = append(drs, clientDriver{driver: drivers[i]})
 	}
 
 	if len(drs) == 0 {
-		drs = append(drs, driver.NewMemory())
+		drs = append(drs, clientDriver{driver: driver.NewMemory()})
 	}
 
 	return &ListClient{
-		BaseClient{drivers: drs},
+		newBaseClient(nil, drs...),
 	}
 }
 
@@ -35,94 +37,283 @@ func NewListClient(drivers ...driver.List) *ListClient {
 // ================= LIST ================================
 // =======================================================
 
-// LTrim 获取列表内的范围数据
+// LTrim 对一个列表进行修剪,只保留 start 到 stop 之间的元素
 func (cli *ListClient) LTrim(ctx context.Context, key string, start, stop int64) driver.StatusValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.StatusValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.List).LTrim(ctx, key, start, stop); i == 0 {
-			value = v
-		}
-	}
-
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).LTrim(ctx, key, start, stop)
+	}).(driver.StatusValuer)
 }
 
-// LPush 推送数据
+// LPush 依次将数据推入到列表头部,返回推入后的列表长度
 func (cli *ListClient) LPush(ctx context.Context, key string, data ...interface{}) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.IntValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.List).LPush(ctx, key, data...); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).LPush(ctx, key, data...)
+	}).(driver.IntValuer)
 }
 
-// LRang 获取列表内的范围数据
-func (cli *ListClient) LRang(ctx context.Context, key string, start, stop int64) driver.StringSliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+// RPush 依次将数据推入到列表尾部,返回推入后的列表长度
+func (cli *ListClient) RPush(ctx context.Context, key string, data ...interface{}) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).RPush(ctx, key, data...)
+	}).(driver.IntValuer)
+}
+
+// LRange 按从头部到尾部的顺序获取列表内的范围数据,start 跟 stop 都包含在内
+func (cli *ListClient) LRange(ctx context.Context, key string, start, stop int64) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.StringSliceValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.List).LRang(ctx, key, start, stop); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.List).LRange(ctx, key, start, stop); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteList)
 			return value
 		}
 	}
 	return value
 }
 
+// LRangeAndScan 通过扫描方式获取列表内的范围内数据
+func (cli *ListClient) LRangeAndScan(ctx context.Context, dst interface{}, key string, start, stop int64) error {
+	return cli.LRange(ctx, key, start, stop).ScanSlice(dst)
+}
+
+// LRang 获取列表内的范围数据
+// Deprecated: 使用 LRange
+func (cli *ListClient) LRang(ctx context.Context, key string, start, stop int64) driver.StringSliceValuer {
+	return cli.LRange(ctx, key, start, stop)
+}
+
 // LRangAndScan 通过扫描方式获取列表内的范围内数据
+// Deprecated: 使用 LRangeAndScan
 func (cli *ListClient) LRangAndScan(ctx context.Context, dst interface{}, key string, start, stop int64) error {
-	return cli.LRang(ctx, key, start, stop).ScanSlice(dst)
+	return cli.LRangeAndScan(ctx, dst, key, start, stop)
 }
 
-// LPop 移除并取出列表内的最后一个元素
+// LPop 移除并取出列表头部的第一个元素
 func (cli *ListClient) LPop(ctx context.Context, key string) driver.StringValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.StringValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.List).LPop(ctx, key); returnable(value) {
-			return value
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).LPop(ctx, key)
+	}).(driver.StringValuer)
 }
 
-// LPopAndScan 通过扫描方式移除并取出列表内的最后一个元素
+// LPopAndScan 通过扫描方式移除并取出列表头部的第一个元素
 func (cli *ListClient) LPopAndScan(ctx context.Context, dst interface{}, key string) error {
 	return cli.LPop(ctx, key).Scan(dst)
 }
 
-// LShift 移除并取出列表内的第一个元素
+// RPop 移除并取出列表尾部的最后一个元素
+func (cli *ListClient) RPop(ctx context.Context, key string) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).RPop(ctx, key)
+	}).(driver.StringValuer)
+}
+
+// RPopAndScan 通过扫描方式移除并取出列表尾部的最后一个元素
+func (cli *ListClient) RPopAndScan(ctx context.Context, dst interface{}, key string) error {
+	return cli.RPop(ctx, key).Scan(dst)
+}
+
+// LShift 移除并取出列表头部的第一个元素
+// Deprecated: 使用 LPop
 func (cli *ListClient) LShift(ctx context.Context, key string) driver.StringValuer {
-	ctx, _ = cli.preCheck(ctx)
+	return cli.LPop(ctx, key)
+}
+
+// LShiftAndScan 通过扫描方式移除并取出列表头部的第一个元素
+// Deprecated: 使用 LPopAndScan
+func (cli *ListClient) LShiftAndScan(ctx context.Context, dst interface{}, key string) error {
+	return cli.LPopAndScan(ctx, dst, key)
+}
+
+// LIndex 返回列表中下标对应的元素,下标超出范围时返回 Nil
+func (cli *ListClient) LIndex(ctx context.Context, key string, index int64) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.StringValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.List).LShift(ctx, key); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.List).LIndex(ctx, key, index); returnable(value) {
+			cli.promote(value, i, key, promoteList)
 			return value
 		}
 	}
-
 	return value
 }
 
-// LShiftAndScan 通过扫描方式移除并取出列表内的第一个元素
-func (cli *ListClient) LShiftAndScan(ctx context.Context, dst interface{}, key string) error {
-	return cli.LShift(ctx, key).Scan(dst)
+// LIndexAndScan 通过扫描方式获取列表中下标对应的元素
+func (cli *ListClient) LIndexAndScan(ctx context.Context, dst interface{}, key string, index int64) error {
+	return cli.LIndex(ctx, key, index).Scan(dst)
+}
+
+// LSet 替换列表中下标对应的元素,键不存在或者下标超出范围时返回错误
+func (cli *ListClient) LSet(ctx context.Context, key string, index int64, value interface{}) driver.StatusValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).LSet(ctx, key, index, value)
+	}).(driver.StatusValuer)
+}
+
+// LRem 移除列表中与 value 相等的元素,返回被移除的数量
+// count > 0 从头部开始移除 count 个; count < 0 从尾部开始移除 |count| 个; count = 0 移除所有
+func (cli *ListClient) LRem(ctx context.Context, key string, count int64, value interface{}) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).LRem(ctx, key, count, value)
+	}).(driver.IntValuer)
+}
+
+// LInsert 把 value 插入到列表中第一个 pivot 的前面(op 为 BEFORE)或者后面(op 为 AFTER),返回插入后的列表长度
+// 键不存在时返回 0,找不到 pivot 时返回 -1
+func (cli *ListClient) LInsert(ctx context.Context, key, op string, pivot, value interface{}) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).LInsert(ctx, key, op, pivot, value)
+	}).(driver.IntValuer)
+}
+
+// LInsertBefore 把 value 插入到列表中第一个 pivot 的前面
+func (cli *ListClient) LInsertBefore(ctx context.Context, key string, pivot, value interface{}) driver.IntValuer {
+	return cli.LInsert(ctx, key, "BEFORE", pivot, value)
+}
+
+// LInsertAfter 把 value 插入到列表中第一个 pivot 的后面
+func (cli *ListClient) LInsertAfter(ctx context.Context, key string, pivot, value interface{}) driver.IntValuer {
+	return cli.LInsert(ctx, key, "AFTER", pivot, value)
+}
+
+// LPos 返回列表中与 value 相等的元素的下标,没有匹配的元素时返回 Nil
+func (cli *ListClient) LPos(ctx context.Context, key string, value string, args driver.LPosArgs) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var val driver.IntValuer
+	for i, c := range cli.drivers {
+		if val = c.(driver.List).LPos(ctx, key, value, args); returnable(val) {
+			cli.promote(val, i, key, promoteList)
+			return val
+		}
+	}
+	return val
+}
+
+// LPosCount 返回列表中最多 count 个与 value 相等的元素的下标,count 为0时返回所有匹配元素的下标
+func (cli *ListClient) LPosCount(ctx context.Context, key string, value string, count int64, args driver.LPosArgs) driver.IntSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var val driver.IntSliceValuer
+	for i, c := range cli.drivers {
+		if val = c.(driver.List).LPosCount(ctx, key, value, count, args); found(val, len(val.Val()) == 0) {
+			cli.promote(val, i, key, promoteList)
+			return val
+		}
+	}
+	return val
+}
+
+// LMove 从 source 的 srcpos(LEFT/RIGHT) 推出一个元素,再推入 destination 的 destpos(LEFT/RIGHT),返回该元素
+func (cli *ListClient) LMove(ctx context.Context, source, destination, srcpos, destpos string) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{source, destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).LMove(ctx, source, destination, srcpos, destpos)
+	}).(driver.StringValuer)
+}
+
+// RPopLPush 从 source 的尾部推出一个元素,再推入 destination 的头部,返回该元素
+func (cli *ListClient) RPopLPush(ctx context.Context, source, destination string) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{source, destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).RPopLPush(ctx, source, destination)
+	}).(driver.StringValuer)
+}
+
+// BLMove 同 LMove,source 为空时阻塞等待,直到超时或者有可移动的元素为止;timeout 为0时一直阻塞直到 ctx 结束
+// 只会在同步写入的驱动中依次阻塞等待,移动成功后其他写入驱动执行相同的 LMove,并删除只读驱动中的 source 跟 destination
+func (cli *ListClient) BLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) driver.StringValuer {
+	return cli.block(ctx, timeout, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).BLMove(ctx, source, destination, srcpos, destpos, timeout)
+	}, func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+		return c.(driver.List).LMove(ctx, source, destination, srcpos, destpos)
+	}, func(value errors.ErrorValuer) []string {
+		return []string{source, destination}
+	}).(driver.StringValuer)
+}
+
+// BLPop 移出并获取第一个非空列表的头部元素,如果列表都没有元素会阻塞列表直到等待超时或发现可弹出元素为止
+// 返回 [key, 元素];timeout 为0时一直阻塞直到 ctx 结束
+// 只会在同步写入的驱动中依次阻塞等待,弹出成功后其他写入驱动从同一个列表中弹出,并删除只读驱动中对应的键
+func (cli *ListClient) BLPop(ctx context.Context, timeout time.Duration, keys ...string) driver.StringSliceValuer {
+	return cli.block(ctx, timeout, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).BLPop(ctx, timeout, keys...)
+	}, followListPop(true), poppedKey).(driver.StringSliceValuer)
+}
+
+// BRPop 同 BLPop,移出并获取列表的尾部元素
+func (cli *ListClient) BRPop(ctx context.Context, timeout time.Duration, keys ...string) driver.StringSliceValuer {
+	return cli.block(ctx, timeout, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.List).BRPop(ctx, timeout, keys...)
+	}, followListPop(false), poppedKey).(driver.StringSliceValuer)
+}
+
+// LBPop 移出并获取列表的尾部元素,如果列表没有元素会阻塞列表直到等待超时或发现可弹出元素为止
+// Deprecated: 使用 BRPop
+func (cli *ListClient) LBPop(ctx context.Context, timeout time.Duration, keys ...string) driver.StringSliceValuer {
+	return cli.BRPop(ctx, timeout, keys...)
+}
+
+// followListPop 其他驱动从基准驱动弹出数据的那个列表中弹出元素,left 为是否从头部弹出
+func followListPop(left bool) followFunc {
+	return func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+		key := poppedKey(lead)[0]
+		if left {
+			return c.(driver.List).LPop(ctx, key)
+		}
+		return c.(driver.List).RPop(ctx, key)
+	}
+}
+
+// poppedKey 返回阻塞弹出结果 [key, 元素] 中的键
+func poppedKey(value errors.ErrorValuer) []string {
+	if val := value.(driver.StringSliceValuer).Val(); len(val) > 0 {
+		return val[:1]
+	}
+	return nil
 }
 
 // LLen 返回列表长度
 func (cli *ListClient) LLen(ctx context.Context, key string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 	var value driver.IntValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.List).LLen(ctx, key); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.List).LLen(ctx, key); found(value, value.Val() == 0) {
+			cli.promote(value, i, key, promoteList)
 			return value
 		}
 	}
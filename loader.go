@@ -0,0 +1,282 @@
+package jcache
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/internal/hscan"
+
+	jerrors "github.com/jerbe/go-errors"
+	"github.com/redis/go-redis/v9"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/12 11:20
+  @describe : 读穿透加载,缓存中没有数据时调用加载函数获取数据并写入到所有驱动中
+*/
+
+const (
+	// emptyPlaceholder 空结果的占位值,用于防止缓存穿透
+	emptyPlaceholder = "\x00jcache:empty"
+
+	// emptyPlaceholderField 哈希表空结果的占位字段
+	emptyPlaceholderField = "\x00jcache:empty"
+)
+
+// Loader 数据加载函数,缓存中没有数据时调用
+// 返回 nil 数据或者 Nil 错误表示数据不存在,会缓存一个空结果防止缓存穿透;返回其他错误时不会缓存
+// 对于 String 类型,返回的数据需要能被 Set 接受;对于 Hash 类型,返回的数据需要能被 HSet 接受,如 map 或者带 redis 标签的结构体
+type Loader func(ctx context.Context) (interface{}, error)
+
+// LoadOptions 加载选项
+type LoadOptions struct {
+	// Expiration 加载到的数据的缓存时长,为0时使用 RandomExpirationDuration
+	Expiration time.Duration
+
+	// EmptyExpiration 空结果的缓存时长,为0时使用 DefaultEmptySetNXDuration,小于0时不缓存空结果
+	EmptyExpiration time.Duration
+}
+
+// expiration 返回加载到的数据的缓存时长
+func (opts *LoadOptions) expiration() time.Duration {
+	if opts == nil || opts.Expiration <= 0 {
+		return RandomExpirationDuration()
+	}
+	return opts.Expiration
+}
+
+// emptyExpiration 返回空结果的缓存时长
+func (opts *LoadOptions) emptyExpiration() time.Duration {
+	if opts == nil || opts.EmptyExpiration == 0 {
+		return DefaultEmptySetNXDuration
+	}
+	return opts.EmptyExpiration
+}
+
+// isNilErr 判断是否是数据不存在的错误
+func isNilErr(err error) bool {
+	return err != nil && jerrors.IsIn(err, Nil, redis.Nil, driver.MemoryNil)
+}
+
+// isEmptyLoad 判断加载结果是否是空结果
+func isEmptyLoad(data interface{}, err error) bool {
+	if isNilErr(err) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	if data == nil {
+		return true
+	}
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// assignLoaded 将加载到的数据直接赋值给dst,类型不一致时返回false
+func assignLoaded(dst, data interface{}) bool {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return false
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Type().AssignableTo(dv.Elem().Type()) {
+		dv.Elem().Set(v)
+		return true
+	}
+
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Type().AssignableTo(dv.Elem().Type()) {
+		dv.Elem().Set(v.Elem())
+		return true
+	}
+	return false
+}
+
+// load 合并相同key的并发加载
+// fn 使用脱离调用方取消信号的上下文执行,超时时长为客户端的超时时长,避免第一个调用方取消后其他等待的调用方一起失败;
+// 每个调用方等待时使用自己的 ctx,ctx 结束时直接返回 ctx 的错误
+func (cli *BaseClient) load(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// 没有合并时只有当前调用方在等待,直接使用它的 ctx
+	if cli.group == nil {
+		ctx, cancel := context.WithTimeout(ctx, cli.options.timeout())
+		defer cancel()
+		return fn(ctx)
+	}
+
+	ch := cli.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, cli.options.timeout())
+		defer cancel()
+		return fn(loadCtx)
+	})
+	select {
+	case r := <-ch:
+		return r.Val, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detachedContext 保留父上下文的值,但是不继承父上下文的取消信号跟截止时间
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// =======================================================
+// ================= STRING ==============================
+// =======================================================
+
+// GetOrLoad 获取数据并扫描到dst中,缓存中没有数据时调用 loader 加载并写入到所有驱动中
+// 相同key的并发加载只会调用一次 loader;数据不存在时返回 Nil
+func (cli *StringClient) GetOrLoad(ctx context.Context, key string, dst interface{}, loader Loader, opts *LoadOptions) error {
+	val := cli.Get(ctx, key)
+	if err := val.Err(); err == nil {
+		if val.Val() == emptyPlaceholder {
+			return Nil
+		}
+		return val.Scan(dst)
+	} else if !isNilErr(err) {
+		return err
+	}
+
+	data, err := cli.load(ctx, "string:"+key, func(ctx context.Context) (interface{}, error) {
+		data, err := loader(ctx)
+		if isEmptyLoad(data, err) {
+			if expiration := opts.emptyExpiration(); expiration > 0 {
+				cli.SetNX(ctx, key, emptyPlaceholder, expiration)
+			}
+			return nil, Nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cli.Set(ctx, key, data, opts.expiration())
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if assignLoaded(dst, data) {
+		return nil
+	}
+
+	// 类型不一致时从缓存中读取
+	return cli.Get(ctx, key).Scan(dst)
+}
+
+// =======================================================
+// ================= HASH ================================
+// =======================================================
+
+// HGetOrLoad 获取Hash表指定字段的值并扫描到dst中,Hash表不存在时调用 loader 加载整个Hash表并写入到所有驱动中
+// Hash表存在但是没有该字段时返回 Nil,不会调用 loader
+func (cli *HashClient) HGetOrLoad(ctx context.Context, key, field string, dst interface{}, loader Loader, opts *LoadOptions) error {
+	val := cli.HGet(ctx, key, field)
+	if err := val.Err(); err == nil {
+		return val.Scan(dst)
+	} else if !isNilErr(err) {
+		return err
+	}
+
+	// Hash表已经存在,只是没有该字段
+	exists, err := cli.Exists(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return Nil
+	}
+
+	if _, err = cli.hLoad(ctx, key, loader, opts); err != nil {
+		return err
+	}
+	return cli.HGet(ctx, key, field).Scan(dst)
+}
+
+// HGetAllOrLoad 获取Hash表的所有数据并扫描到dst中,Hash表不存在时调用 loader 加载并写入到所有驱动中
+// dst 可以是 *map[string]string 或者带 redis 标签的结构体指针;数据不存在时返回 Nil
+func (cli *HashClient) HGetAllOrLoad(ctx context.Context, key string, dst interface{}, loader Loader, opts *LoadOptions) error {
+	val := cli.HGetAll(ctx, key)
+	if err := val.Err(); err != nil && !isNilErr(err) {
+		return err
+	}
+
+	if m := val.Val(); len(m) > 0 {
+		if _, ok := m[emptyPlaceholderField]; ok {
+			if len(m) == 1 {
+				return Nil
+			}
+			delete(m, emptyPlaceholderField)
+		}
+		return scanMapStringString(m, dst)
+	}
+
+	data, err := cli.hLoad(ctx, key, loader, opts)
+	if err != nil {
+		return err
+	}
+
+	if assignLoaded(dst, data) {
+		return nil
+	}
+
+	// 类型不一致时从缓存中读取
+	return scanMapStringString(cli.HGetAll(ctx, key).Val(), dst)
+}
+
+// hLoad 加载整个Hash表并写入到所有驱动中
+func (cli *HashClient) hLoad(ctx context.Context, key string, loader Loader, opts *LoadOptions) (interface{}, error) {
+	return cli.load(ctx, "hash:"+key, func(ctx context.Context) (interface{}, error) {
+		data, err := loader(ctx)
+		if isEmptyLoad(data, err) {
+			if expiration := opts.emptyExpiration(); expiration > 0 {
+				cli.HSetNX(ctx, key, emptyPlaceholderField, "1")
+				cli.Expire(ctx, key, expiration)
+			}
+			return nil, Nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err = cli.HSet(ctx, key, data).Err(); err == nil {
+			cli.Expire(ctx, key, opts.expiration())
+		}
+		return data, nil
+	})
+}
+
+// scanMapStringString 将哈希表数据扫描到dst中
+// dst 可以是 *map[string]string 或者带 redis 标签的结构体指针
+func scanMapStringString(m map[string]string, dst interface{}) error {
+	if v, ok := dst.(*map[string]string); ok {
+		*v = m
+		return nil
+	}
+
+	keys := make([]interface{}, 0, len(m))
+	vals := make([]interface{}, 0, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	return hscan.Scan(dst, keys, vals)
+}
@@ -0,0 +1,214 @@
+package jcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/errors"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/12 16:40
+  @describe : 低层驱动命中后回写到高层驱动
+*/
+
+// PromotePolicy 低层驱动命中后回写到高层驱动的策略
+type PromotePolicy int
+
+const (
+	// PromoteNone 不回写
+	PromoteNone PromotePolicy = iota
+
+	// PromoteAsync 异步回写,不影响本次读取的耗时
+	PromoteAsync
+
+	// PromoteSync 同步回写,回写完成后才返回本次读取的结果
+	PromoteSync
+)
+
+// promoteFunc 将key的数据从src复制到dst中
+// ttl 为写入到dst的存活时长,为0时表示使用dst的默认存活时长
+type promoteFunc func(ctx context.Context, key string, src, dst driver.Common, ttl time.Duration) error
+
+// promote 低层驱动命中时,将数据跟剩余存活时长回写到更高层的驱动中
+// hit 为命中的驱动下标,只有在读取成功时才会回写
+func (cli *BaseClient) promote(val errors.ErrorValuer, hit int, key string, fn promoteFunc) {
+	if hit <= 0 || val.Err() != nil || cli.options == nil || cli.options.Promote == PromoteNone {
+		return
+	}
+
+	run := func() {
+		// 相同key并发回写时只执行一次
+		cli.load(context.Background(), "promote:"+key, func(ctx context.Context) (interface{}, error) {
+			return nil, cli.promoteTo(ctx, hit, key, fn)
+		})
+	}
+
+	if cli.options.Promote == PromoteSync {
+		run()
+		return
+	}
+
+	// 关闭客户端时需要等待异步回写完成
+	cli.closer.inflight.Add(1)
+	go func() {
+		defer cli.closer.inflight.Done()
+		run()
+	}()
+}
+
+// promoteTo 将命中驱动中的数据回写到下标比它小的所有驱动中
+func (cli *BaseClient) promoteTo(ctx context.Context, hit int, key string, fn promoteFunc) error {
+	src := cli.drivers[hit]
+	// TTL 只精确到秒,不足一秒时为0,会被当成没有到期时间,所以使用 PTTL
+	ttl, err := src.PTTL(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ttl == -1:
+		// 没有到期时间
+		ttl = 0
+	case ttl <= 0:
+		// 已经不存在了(-2),或者马上就要过期,不需要回写
+		return nil
+	}
+
+	if max := cli.options.PromoteMaxTTL; max > 0 && (ttl == 0 || ttl > max) {
+		ttl = max
+	}
+
+	for i := 0; i < hit; i++ {
+		if err = fn(ctx, key, src, cli.drivers[i], ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promoteString 回写字符串
+// 使用 SetNX 写入,避免覆盖并发写入的新数据
+func promoteString(ctx context.Context, key string, src, dst driver.Common, ttl time.Duration) error {
+	val, err := src.(driver.String).Get(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	return dst.(driver.String).SetNX(ctx, key, val, ttl).Err()
+}
+
+// promoteHash 回写哈希表
+// 只在dst中不存在该key时写入,避免覆盖并发写入的新数据
+func promoteHash(ctx context.Context, key string, src, dst driver.Common, ttl time.Duration) error {
+	val, err := src.(driver.Hash).HGetAll(ctx, key).Result()
+	if err != nil || len(val) == 0 {
+		return err
+	}
+
+	if dst.Exists(ctx, key).Val() > 0 {
+		return nil
+	}
+
+	if err = dst.(driver.Hash).HSet(ctx, key, val).Err(); err != nil {
+		return err
+	}
+	promoteFieldExpire(ctx, key, val, src.(driver.Hash), dst.(driver.Hash))
+	return promoteExpire(ctx, key, dst, ttl)
+}
+
+// promoteFieldExpire 复制哈希表字段的存活时长,源驱动不支持字段存活时长时忽略
+func promoteFieldExpire(ctx context.Context, key string, val map[string]string, src, dst driver.Hash) {
+	fields := make([]string, 0, len(val))
+	for field := range val {
+		fields = append(fields, field)
+	}
+
+	ttls, err := src.HTTL(ctx, key, fields...).Result()
+	if err != nil || len(ttls) != len(fields) {
+		return
+	}
+
+	// 相同存活时长的字段一起设置
+	groups := make(map[int64][]string)
+	for i, ttl := range ttls {
+		if ttl >= 0 {
+			groups[ttl] = append(groups[ttl], fields[i])
+		}
+	}
+	for ttl, fields := range groups {
+		dst.HExpire(ctx, key, time.Duration(ttl)*time.Second, fields...)
+	}
+}
+
+// promoteList 回写列表
+// 只在dst中不存在该key时写入,避免覆盖并发写入的新数据
+func promoteList(ctx context.Context, key string, src, dst driver.Common, ttl time.Duration) error {
+	val, err := src.(driver.List).LRange(ctx, key, 0, -1).Result()
+	if err != nil || len(val) == 0 {
+		return err
+	}
+
+	if dst.Exists(ctx, key).Val() > 0 {
+		return nil
+	}
+
+	data := make([]interface{}, len(val))
+	for i := range val {
+		data[i] = val[i]
+	}
+	// 按从头部到尾部的顺序推入尾部,保持跟 src 相同的顺序
+	if err = dst.(driver.List).RPush(ctx, key, data...).Err(); err != nil {
+		return err
+	}
+	return promoteExpire(ctx, key, dst, ttl)
+}
+
+// promoteSet 回写集合
+// 只在dst中不存在该key时写入,避免覆盖并发写入的新数据
+func promoteSet(ctx context.Context, key string, src, dst driver.Common, ttl time.Duration) error {
+	val, err := src.(driver.Set).SMembers(ctx, key).Result()
+	if err != nil || len(val) == 0 {
+		return err
+	}
+
+	if dst.Exists(ctx, key).Val() > 0 {
+		return nil
+	}
+
+	data := make([]interface{}, len(val))
+	for i := range val {
+		data[i] = val[i]
+	}
+	if err = dst.(driver.Set).SAdd(ctx, key, data...).Err(); err != nil {
+		return err
+	}
+	return promoteExpire(ctx, key, dst, ttl)
+}
+
+// promoteSortedSet 回写有序集合
+// 只在dst中不存在该key时写入,避免覆盖并发写入的新数据
+func promoteSortedSet(ctx context.Context, key string, src, dst driver.Common, ttl time.Duration) error {
+	val, err := src.(driver.SortedSet).ZRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil || len(val) == 0 {
+		return err
+	}
+
+	if dst.Exists(ctx, key).Val() > 0 {
+		return nil
+	}
+
+	if err = dst.(driver.SortedSet).ZAdd(ctx, key, val...).Err(); err != nil {
+		return err
+	}
+	return promoteExpire(ctx, key, dst, ttl)
+}
+
+// promoteExpire 设置回写数据的存活时长
+func promoteExpire(ctx context.Context, key string, dst driver.Common, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return dst.Expire(ctx, key, ttl).Err()
+}
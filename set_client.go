@@ -0,0 +1,289 @@
+package jcache
+
+import (
+	"context"
+
+	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+/**
+  @author : Jerbe - The porter from Earth
+  @time : 2023/10/16 14:20
+  @describe :
+*/
+
+// SetClient 集合客户端
+type SetClient struct {
+	BaseClient
+}
+
+// NewSetClient 返回一个集合客户端
+func NewSetClient(drivers ...driver.Set) *SetClient {
+	drs := make([]clientDriver, 0)
+	for i := 0; i < len(drivers); i++ {
+		drs = append(drs, clientDriver{driver: drivers[i]})
+	}
+
+	if len(drs) == 0 {
+		drs = append(drs, clientDriver{driver: driver.NewMemory()})
+	}
+
+	return &SetClient{
+		BaseClient: newBaseClient(nil, drs...),
+	}
+}
+
+// =======================================================
+// ======================== SET ==========================
+// =======================================================
+
+// SAdd 添加一个或多个成员到集合中,已经存在的成员将被忽略
+func (cli *SetClient) SAdd(ctx context.Context, key string, members ...interface{}) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Set).SAdd(ctx, key, members...)
+	}).(driver.IntValuer)
+}
+
+// SRem 移除集合中的一个或多个成员,不存在的成员将被忽略
+func (cli *SetClient) SRem(ctx context.Context, key string, members ...interface{}) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Set).SRem(ctx, key, members...)
+	}).(driver.IntValuer)
+}
+
+// followPop 其他驱动移除跟第一个驱动弹出的相同的成员,保证各个驱动的数据一致
+func followPop(key string, members func(lead errors.ErrorValuer) []string) func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+	return func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+		if lead.Err() != nil {
+			return new(redis.IntCmd)
+		}
+
+		popped := members(lead)
+		// 没有弹出成员时其他驱动不需要处理
+		if len(popped) == 0 {
+			return new(redis.IntCmd)
+		}
+
+		data := make([]interface{}, len(popped))
+		for i := range popped {
+			data[i] = popped[i]
+		}
+		return c.(driver.Set).SRem(ctx, key, data...)
+	}
+}
+
+// SPop 随机移除并返回集合中的一个成员
+// 第一个写入的驱动随机弹出,其他驱动移除相同的成员
+func (cli *SetClient) SPop(ctx context.Context, key string) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Set).SPop(ctx, key)
+	}, followPop(key, func(lead errors.ErrorValuer) []string {
+		return []string{lead.(driver.StringValuer).Val()}
+	}))).(driver.StringValuer)
+}
+
+// SPopN 随机移除并返回集合中最多 count 个成员
+// 第一个写入的驱动随机弹出,其他驱动移除相同的成员
+func (cli *SetClient) SPopN(ctx context.Context, key string, count int64) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Set).SPopN(ctx, key, count)
+	}, followPop(key, func(lead errors.ErrorValuer) []string {
+		return lead.(driver.StringSliceValuer).Val()
+	}))).(driver.StringSliceValuer)
+}
+
+// SMembers 返回集合中的所有成员
+func (cli *SetClient) SMembers(ctx context.Context, key string) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.Set).SMembers(ctx, key); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSet)
+			return value
+		}
+	}
+	return value
+}
+
+// SMembersAndScan 返回集合中的所有成员并扫描到dst中
+func (cli *SetClient) SMembersAndScan(ctx context.Context, dst interface{}, key string) error {
+	return cli.SMembers(ctx, key).ScanSlice(dst)
+}
+
+// SIsMember 判断成员是否在集合中
+func (cli *SetClient) SIsMember(ctx context.Context, key string, member interface{}) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.BoolValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.Set).SIsMember(ctx, key, member); found(value, !value.Val()) {
+			cli.promote(value, i, key, promoteSet)
+			return value
+		}
+	}
+	return value
+}
+
+// SMIsMember 判断多个成员是否在集合中,返回结果的顺序跟 members 一致
+func (cli *SetClient) SMIsMember(ctx context.Context, key string, members ...interface{}) driver.BoolSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.BoolSliceValuer
+	for i, c := range cli.drivers {
+		value = c.(driver.Set).SMIsMember(ctx, key, members...)
+
+		empty := true
+		for _, b := range value.Val() {
+			if b {
+				empty = false
+				break
+			}
+		}
+		if found(value, empty) {
+			cli.promote(value, i, key, promoteSet)
+			return value
+		}
+	}
+	return value
+}
+
+// SCard 返回集合的成员数量
+func (cli *SetClient) SCard(ctx context.Context, key string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.IntValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.Set).SCard(ctx, key); found(value, value.Val() == 0) {
+			cli.promote(value, i, key, promoteSet)
+			return value
+		}
+	}
+	return value
+}
+
+// SRandMember 随机返回集合中的一个成员
+func (cli *SetClient) SRandMember(ctx context.Context, key string) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.Set).SRandMember(ctx, key); returnable(value) {
+			cli.promote(value, i, key, promoteSet)
+			return value
+		}
+	}
+	return value
+}
+
+// SRandMemberN 随机返回集合中的成员
+// count 为正数时返回最多 count 个不重复的成员;为负数时返回 -count 个成员,成员可能重复
+func (cli *SetClient) SRandMemberN(ctx context.Context, key string, count int64) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.Set).SRandMemberN(ctx, key, count); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSet)
+			return value
+		}
+	}
+	return value
+}
+
+// SInter 返回多个集合的交集
+func (cli *SetClient) SInter(ctx context.Context, keys ...string) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for _, c := range cli.drivers {
+		if value = c.(driver.Set).SInter(ctx, keys...); returnable(value) {
+			return value
+		}
+	}
+	return value
+}
+
+// SUnion 返回多个集合的并集
+func (cli *SetClient) SUnion(ctx context.Context, keys ...string) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for _, c := range cli.drivers {
+		if value = c.(driver.Set).SUnion(ctx, keys...); returnable(value) {
+			return value
+		}
+	}
+	return value
+}
+
+// SDiff 返回第一个集合跟其他集合的差集
+func (cli *SetClient) SDiff(ctx context.Context, keys ...string) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for _, c := range cli.drivers {
+		if value = c.(driver.Set).SDiff(ctx, keys...); returnable(value) {
+			return value
+		}
+	}
+	return value
+}
+
+// SInterStore 把多个集合的交集保存到 destination 中,返回结果集合的成员数量
+// 每个驱动按自身的数据计算
+func (cli *SetClient) SInterStore(ctx context.Context, destination string, keys ...string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Set).SInterStore(ctx, destination, keys...)
+	}).(driver.IntValuer)
+}
+
+// SUnionStore 把多个集合的并集保存到 destination 中,返回结果集合的成员数量
+// 每个驱动按自身的数据计算
+func (cli *SetClient) SUnionStore(ctx context.Context, destination string, keys ...string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Set).SUnionStore(ctx, destination, keys...)
+	}).(driver.IntValuer)
+}
+
+// SDiffStore 把第一个集合跟其他集合的差集保存到 destination 中,返回结果集合的成员数量
+// 每个驱动按自身的数据计算
+func (cli *SetClient) SDiffStore(ctx context.Context, destination string, keys ...string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.Set).SDiffStore(ctx, destination, keys...)
+	}).(driver.IntValuer)
+}
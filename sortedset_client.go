@@ -2,8 +2,15 @@ package jcache
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/errors"
+	"github.com/jerbe/jcache/v2/internal/hscan"
+	"github.com/redis/go-redis/v9"
 )
 
 /**
@@ -19,17 +26,17 @@ type SortedSetClient struct {
 
 // NewSortedSetClient 返回一个已排序的集合客户端
 func NewSortedSetClient(drivers ...driver.SortedSet) *SortedSetClient {
-	drs := make([]driver.Common, 0)
+	drs := make([]clientDriver, 0)
 	for i := 0; i < len(drivers); i++ {
-		drs = append(drs, drivers[i])
+		drs = append(drs, clientDriver{driver: drivers[i]})
 	}
 
 	if len(drs) == 0 {
-		drs = append(drs, driver.NewMemory())
+		drs = append(drs, clientDriver{driver: driver.NewMemory()})
 	}
 
 	return &SortedSetClient{
-		BaseClient: BaseClient{drivers: drs},
+		BaseClient: newBaseClient(nil, drs...),
 	}
 }
 
@@ -39,24 +46,23 @@ func NewSortedSetClient(drivers ...driver.SortedSet) *SortedSetClient {
 
 // ZAdd 添加有序集合的元素
 func (cli *SortedSetClient) ZAdd(ctx context.Context, key string, members ...driver.Z) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.IntValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.SortedSet).ZAdd(ctx, key, members...); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZAdd(ctx, key, members...)
+	}).(driver.IntValuer)
 }
 
 // ZCard 获取有序集合的元素数量
 func (cli *SortedSetClient) ZCard(ctx context.Context, key string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.IntValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.SortedSet).ZCard(ctx, key); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZCard(ctx, key); found(value, value.Val() == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
@@ -65,11 +71,13 @@ func (cli *SortedSetClient) ZCard(ctx context.Context, key string) driver.IntVal
 
 // ZCount 返回有序集 key 中， score 值在 min 和 max 之间(默认包括 score 值等于 min 或 max )的成员的数量。
 func (cli *SortedSetClient) ZCount(ctx context.Context, key, min, max string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.IntValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.SortedSet).ZCount(ctx, key, min, max); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZCount(ctx, key, min, max); found(value, value.Val() == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
@@ -78,17 +86,26 @@ func (cli *SortedSetClient) ZCount(ctx context.Context, key, min, max string) dr
 
 // ZIncrBy 为有序集 key 的成员 member 的 score 值加上增量 increment 。
 // 可以通过传递一个负数值 increment ，让 score 减去相应的值，比如 ZINCRBY key -5 member ，就是让 member 的 score 值减去 5
+// 第一个写入的驱动计算结果,其他驱动写入相同的 score 值
 // @return member 成员的新 score 值
 func (cli *SortedSetClient) ZIncrBy(ctx context.Context, key string, increment float64, member string) driver.FloatValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.FloatValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.SortedSet).ZIncrBy(ctx, key, increment, member); i == 0 {
-			value = v
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZIncrBy(ctx, key, increment, member)
+	}, followScore(key, member))).(driver.FloatValuer)
+}
+
+// followScore 其他驱动写入第一个驱动计算后的 score 值,保证各个驱动的 score 值一致
+func followScore(key string, member interface{}) followFunc {
+	return func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+		if lead.Err() != nil {
+			return new(redis.IntCmd)
 		}
+		return c.(driver.SortedSet).ZAdd(ctx, key, driver.Z{Score: lead.(driver.FloatValuer).Val(), Member: member})
 	}
-	return value
 }
 
 // ZRange 返回有序集 key 中，指定区间内的成员。
@@ -98,11 +115,13 @@ func (cli *SortedSetClient) ZIncrBy(ctx context.Context, key string, increment f
 // 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
 // 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
 func (cli *SortedSetClient) ZRange(ctx context.Context, key string, start, stop int64) driver.StringSliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.StringSliceValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.SortedSet).ZRange(ctx, key, start, stop); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRange(ctx, key, start, stop); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
@@ -113,11 +132,28 @@ func (cli *SortedSetClient) ZRange(ctx context.Context, key string, start, stop
 // 具有相同 score 值的成员按字典序(lexicographical order)来排列(该属性是有序集提供的，不需要额外的计算)。
 // 可选的 LIMIT 参数指定返回结果的数量及区间(就像SQL中的 SELECT LIMIT offset, count )，注意当 offset 很大时，定位 offset 的操作可能需要遍历整个有序集，此过程最坏复杂度为 O(N) 时间。
 func (cli *SortedSetClient) ZRangeByScore(ctx context.Context, key string, opt *driver.ZRangeBy) driver.StringSliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.StringSliceValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.SortedSet).ZRangeByScore(ctx, key, opt); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRangeByScore(ctx, key, opt); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZRangeWithScores 同 ZRange, 返回的成员带上 score 值
+func (cli *SortedSetClient) ZRangeWithScores(ctx context.Context, key string, start, stop int64) driver.ZSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.ZSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRangeWithScores(ctx, key, start, stop); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
@@ -127,11 +163,13 @@ func (cli *SortedSetClient) ZRangeByScore(ctx context.Context, key string, opt *
 // ZRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递增(从小到大)顺序排列。
 // 排名以 0 为底，也就是说， score 值最小的成员排名为 0 。
 func (cli *SortedSetClient) ZRank(ctx context.Context, key, member string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.IntValuer
-	for _, c := range cli.drivers {
+	for i, c := range cli.drivers {
 		if value = c.(driver.SortedSet).ZRank(ctx, key, member); returnable(value) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
@@ -141,15 +179,12 @@ func (cli *SortedSetClient) ZRank(ctx context.Context, key, member string) drive
 // ZRem 移除有序集 key 中的一个或多个成员，不存在的成员将被忽略。
 // @return 被成功移除的成员的数量，不包括被忽略的成员
 func (cli *SortedSetClient) ZRem(ctx context.Context, key string, members ...interface{}) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.IntValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.SortedSet).ZRem(ctx, key, members...); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZRem(ctx, key, members...)
+	}).(driver.IntValuer)
 }
 
 // ZRemRangeByRank 移除有序集 key 中，指定排名(rank)区间内的所有成员。
@@ -157,40 +192,36 @@ func (cli *SortedSetClient) ZRem(ctx context.Context, key string, members ...int
 // 下标参数 start 和 stop 都以 0 为底，也就是说，以 0 表示有序集第一个成员，以 1 表示有序集第二个成员，以此类推。
 // 你也可以使用负数下标，以 -1 表示最后一个成员， -2 表示倒数第二个成员，以此类推。
 func (cli *SortedSetClient) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.IntValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.SortedSet).ZRemRangeByRank(ctx, key, start, stop); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZRemRangeByRank(ctx, key, start, stop)
+	}).(driver.IntValuer)
 }
 
 // ZRemRangeByScore 返回有序集 key 中，所有 score 值介于 min 和 max 之间(包括等于 min 或 max )的成员。
 // 有序集成员按 score 值递增(从小到大)次序排列。
 func (cli *SortedSetClient) ZRemRangeByScore(ctx context.Context, key, min, max string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.IntValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.SortedSet).ZRemRangeByScore(ctx, key, min, max); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZRemRangeByScore(ctx, key, min, max)
+	}).(driver.IntValuer)
 }
 
 // ZRevRange 返回有序集 key 中，指定区间内的成员。
 // 其中成员的位置按 score 值递减(从大到小)来排列。
 // 具有相同 score 值的成员按字典序的逆序(reverse lexicographical order)排列。
 func (cli *SortedSetClient) ZRevRange(ctx context.Context, key string, start, stop int64) driver.StringSliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.StringSliceValuer
-	for _, c := range cli.drivers {
-		if value = c.(driver.SortedSet).ZRevRange(ctx, key, start, stop); returnable(value) {
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRevRange(ctx, key, start, stop); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
@@ -200,11 +231,13 @@ func (cli *SortedSetClient) ZRevRange(ctx context.Context, key string, start, st
 // ZRevRank 返回有序集 key 中成员 member 的排名。其中有序集成员按 score 值递减(从大到小)排序。
 // 排名以 0 为底，也就是说， score 值最大的成员排名为 0 。
 func (cli *SortedSetClient) ZRevRank(ctx context.Context, key, member string) driver.IntValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.IntValuer
-	for _, c := range cli.drivers {
+	for i, c := range cli.drivers {
 		if value = c.(driver.SortedSet).ZRevRank(ctx, key, member); returnable(value) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
@@ -214,13 +247,397 @@ func (cli *SortedSetClient) ZRevRank(ctx context.Context, key, member string) dr
 // ZScore 返回有序集 key 中，成员 member 的 score 值。
 // 如果 member 元素不是有序集 key 的成员，或 key 不存在，返回 nil 。
 func (cli *SortedSetClient) ZScore(ctx context.Context, key, member string) driver.FloatValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.FloatValuer
-	for _, c := range cli.drivers {
+	for i, c := range cli.drivers {
 		if value = c.(driver.SortedSet).ZScore(ctx, key, member); returnable(value) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZAddNX 只添加新成员,不更新已经存在的成员
+func (cli *SortedSetClient) ZAddNX(ctx context.Context, key string, members ...driver.Z) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZAddNX(ctx, key, members...)
+	}).(driver.IntValuer)
+}
+
+// ZAddXX 只更新已经存在的成员,不添加新成员
+func (cli *SortedSetClient) ZAddXX(ctx context.Context, key string, members ...driver.Z) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZAddXX(ctx, key, members...)
+	}).(driver.IntValuer)
+}
+
+// ZAddGT 新分数大于原来的分数时才更新,新成员照常添加
+func (cli *SortedSetClient) ZAddGT(ctx context.Context, key string, members ...driver.Z) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZAddGT(ctx, key, members...)
+	}).(driver.IntValuer)
+}
+
+// ZAddLT 新分数小于原来的分数时才更新,新成员照常添加
+func (cli *SortedSetClient) ZAddLT(ctx context.Context, key string, members ...driver.Z) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZAddLT(ctx, key, members...)
+	}).(driver.IntValuer)
+}
+
+// ZAddArgs 按 NX/XX/GT/LT/CH 条件添加成员
+// @return 新添加的成员数量,设置 Ch 时包括分数被更新的成员数量
+func (cli *SortedSetClient) ZAddArgs(ctx context.Context, key string, args driver.ZAddArgs) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZAddArgs(ctx, key, args)
+	}).(driver.IntValuer)
+}
+
+// ZAddArgsIncr 同 ZAddArgs,在成员原来的分数上增加,只能有一个成员
+// @return 成员的新 score 值,条件不满足时返回 errors.Nil
+func (cli *SortedSetClient) ZAddArgsIncr(ctx context.Context, key string, args driver.ZAddArgs) driver.FloatValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var member interface{}
+	if len(args.Members) > 0 {
+		member = args.Members[0].Member
+	}
+
+	// 条件不满足(errors.Nil)时第一个驱动没有写入,其他驱动同样跳过
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZAddArgsIncr(ctx, key, args)
+	}, followScore(key, member))).(driver.FloatValuer)
+}
+
+// ZRangeWithScoresAndScan 同 ZRangeWithScores,将结果扫描到dst中
+// dst 可以是 *[]driver.Z,或者元素为结构体(或结构体指针)的切片指针,结构体使用 redis 标签 member 跟 score 接收成员跟 score 值
+func (cli *SortedSetClient) ZRangeWithScoresAndScan(ctx context.Context, dst interface{}, key string, start, stop int64) error {
+	return scanZSlice(cli.ZRangeWithScores(ctx, key, start, stop), dst)
+}
+
+// ZRangeByScoreWithScores 同 ZRangeByScore, 返回的成员带上 score 值
+func (cli *SortedSetClient) ZRangeByScoreWithScores(ctx context.Context, key string, opt *driver.ZRangeBy) driver.ZSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.ZSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRangeByScoreWithScores(ctx, key, opt); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZRangeByScoreWithScoresAndScan 同 ZRangeByScoreWithScores,将结果扫描到dst中,dst 同 ZRangeWithScoresAndScan
+func (cli *SortedSetClient) ZRangeByScoreWithScoresAndScan(ctx context.Context, dst interface{}, key string, opt *driver.ZRangeBy) error {
+	return scanZSlice(cli.ZRangeByScoreWithScores(ctx, key, opt), dst)
+}
+
+// ZRangeByLex 返回有序集 key 中，成员在字典序区间 min 跟 max 之间的成员。
+// 区间以 "[" (包含) 或 "(" (不包含) 开头，"-" 跟 "+" 表示无穷小跟无穷大。
+// 只有所有成员的 score 值相同时结果才有意义。
+func (cli *SortedSetClient) ZRangeByLex(ctx context.Context, key string, opt *driver.ZRangeBy) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRangeByLex(ctx, key, opt); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZRevRangeWithScores 同 ZRevRange, 返回的成员带上 score 值
+func (cli *SortedSetClient) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) driver.ZSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.ZSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRevRangeWithScores(ctx, key, start, stop); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZRevRangeWithScoresAndScan 同 ZRevRangeWithScores,将结果扫描到dst中,dst 同 ZRangeWithScoresAndScan
+func (cli *SortedSetClient) ZRevRangeWithScoresAndScan(ctx context.Context, dst interface{}, key string, start, stop int64) error {
+	return scanZSlice(cli.ZRevRangeWithScores(ctx, key, start, stop), dst)
+}
+
+// ZRevRangeByScore 返回有序集 key 中， score 值介于 max 和 min 之间(默认包括等于 max 或 min )的所有的成员。有序集成员按 score 值递减(从大到小)的次序排列。
+// 具有相同 score 值的成员按字典序的逆序(reverse lexicographical order )排列。
+func (cli *SortedSetClient) ZRevRangeByScore(ctx context.Context, key string, opt *driver.ZRangeBy) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRevRangeByScore(ctx, key, opt); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZRevRangeByScoreWithScores 同 ZRevRangeByScore, 返回的成员带上 score 值
+func (cli *SortedSetClient) ZRevRangeByScoreWithScores(ctx context.Context, key string, opt *driver.ZRangeBy) driver.ZSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.ZSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRevRangeByScoreWithScores(ctx, key, opt); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZRevRangeByScoreWithScoresAndScan 同 ZRevRangeByScoreWithScores,将结果扫描到dst中,dst 同 ZRangeWithScoresAndScan
+func (cli *SortedSetClient) ZRevRangeByScoreWithScoresAndScan(ctx context.Context, dst interface{}, key string, opt *driver.ZRangeBy) error {
+	return scanZSlice(cli.ZRevRangeByScoreWithScores(ctx, key, opt), dst)
+}
+
+// ZRevRangeByLex 同 ZRangeByLex,成员按字典序的逆序排列
+func (cli *SortedSetClient) ZRevRangeByLex(ctx context.Context, key string, opt *driver.ZRangeBy) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRevRangeByLex(ctx, key, opt); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZMScore 返回有序集 key 中，多个成员的 score 值。
+// 不是有序集 key 成员的 member 返回 0 。
+func (cli *SortedSetClient) ZMScore(ctx context.Context, key string, members ...string) driver.FloatSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.FloatSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZMScore(ctx, key, members...); found(value, zeroScores(value.Val())) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// zeroScores 所有的 score 值都为0时,视为成员都不存在
+func zeroScores(scores []float64) bool {
+	for _, score := range scores {
+		if score != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ZPopMin 移除并返回有序集 key 中， score 值最小的 count 个成员，不传 count 时为 1 。
+func (cli *SortedSetClient) ZPopMin(ctx context.Context, key string, count ...int64) driver.ZSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZPopMin(ctx, key, count...)
+	}).(driver.ZSliceValuer)
+}
+
+// ZPopMinAndScan 同 ZPopMin,将结果扫描到dst中,dst 同 ZRangeWithScoresAndScan
+func (cli *SortedSetClient) ZPopMinAndScan(ctx context.Context, dst interface{}, key string, count ...int64) error {
+	return scanZSlice(cli.ZPopMin(ctx, key, count...), dst)
+}
+
+// ZPopMax 移除并返回有序集 key 中， score 值最大的 count 个成员，不传 count 时为 1 。
+func (cli *SortedSetClient) ZPopMax(ctx context.Context, key string, count ...int64) driver.ZSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZPopMax(ctx, key, count...)
+	}).(driver.ZSliceValuer)
+}
+
+// ZPopMaxAndScan 同 ZPopMax,将结果扫描到dst中,dst 同 ZRangeWithScoresAndScan
+func (cli *SortedSetClient) ZPopMaxAndScan(ctx context.Context, dst interface{}, key string, count ...int64) error {
+	return scanZSlice(cli.ZPopMax(ctx, key, count...), dst)
+}
+
+// BZPopMin 从第一个非空的有序集合中移除并返回 score 值最小的成员
+// 有序集合都为空时阻塞等待,直到超时或者有可弹出的成员为止;timeout 为0时一直阻塞直到 ctx 结束
+// 只会在同步写入的驱动中依次阻塞等待,弹出成功后其他写入驱动移除相同的成员,并删除只读驱动中对应的键
+func (cli *SortedSetClient) BZPopMin(ctx context.Context, timeout time.Duration, keys ...string) driver.ZWithKeyValuer {
+	return cli.block(ctx, timeout, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).BZPopMin(ctx, timeout, keys...)
+	}, followZPop, zPoppedKey).(driver.ZWithKeyValuer)
+}
+
+// BZPopMax 同 BZPopMin,移除并返回 score 值最大的成员
+func (cli *SortedSetClient) BZPopMax(ctx context.Context, timeout time.Duration, keys ...string) driver.ZWithKeyValuer {
+	return cli.block(ctx, timeout, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).BZPopMax(ctx, timeout, keys...)
+	}, followZPop, zPoppedKey).(driver.ZWithKeyValuer)
+}
+
+// followZPop 其他驱动移除跟基准驱动弹出的相同的成员
+func followZPop(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+	val := lead.(driver.ZWithKeyValuer).Val()
+	return c.(driver.SortedSet).ZRem(ctx, val.Key, val.Member)
+}
+
+// zPoppedKey 返回阻塞弹出结果中成员所在的键
+func zPoppedKey(value errors.ErrorValuer) []string {
+	if val := value.(driver.ZWithKeyValuer).Val(); val != nil {
+		return []string{val.Key}
+	}
+	return nil
+}
+
+// ZRandMember 随机返回有序集 key 中的成员。
+// count 为正数时返回最多 count 个不重复的成员；为负数时返回 -count 个成员，成员可能重复。
+func (cli *SortedSetClient) ZRandMember(ctx context.Context, key string, count int) driver.StringSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRandMember(ctx, key, count); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
+			return value
+		}
+	}
+	return value
+}
+
+// ZRandMemberWithScores 同 ZRandMember, 返回的成员带上 score 值
+func (cli *SortedSetClient) ZRandMemberWithScores(ctx context.Context, key string, count int) driver.ZSliceValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.ZSliceValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.SortedSet).ZRandMemberWithScores(ctx, key, count); found(value, len(value.Val()) == 0) {
+			cli.promote(value, i, key, promoteSortedSet)
 			return value
 		}
 	}
 	return value
 }
+
+// ZRandMemberWithScoresAndScan 同 ZRandMemberWithScores,将结果扫描到dst中,dst 同 ZRangeWithScoresAndScan
+func (cli *SortedSetClient) ZRandMemberWithScoresAndScan(ctx context.Context, dst interface{}, key string, count int) error {
+	return scanZSlice(cli.ZRandMemberWithScores(ctx, key, count), dst)
+}
+
+// ZUnionStore 计算给定的一个或多个有序集的并集，并将该并集(结果集)储存到 destination 。
+// 成员的 score 值乘以对应的权重(Weights)后按 Aggregate(SUM/MIN/MAX，默认为 SUM)合并，keys 中也可以是普通集合，成员的 score 值视为 1 。
+// @return 保存到 destination 的结果集的成员数量
+func (cli *SortedSetClient) ZUnionStore(ctx context.Context, destination string, store *driver.ZStore) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZUnionStore(ctx, destination, store)
+	}).(driver.IntValuer)
+}
+
+// ZInterStore 计算给定的一个或多个有序集的交集，并将该交集(结果集)储存到 destination 。
+// score 值的计算方式同 ZUnionStore 。
+// @return 保存到 destination 的结果集的成员数量
+func (cli *SortedSetClient) ZInterStore(ctx context.Context, destination string, store *driver.ZStore) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZInterStore(ctx, destination, store)
+	}).(driver.IntValuer)
+}
+
+// ZDiffStore 计算第一个有序集跟其他有序集的差集，并将该差集(结果集)储存到 destination ，成员保留在第一个有序集中的 score 值。
+// @return 保存到 destination 的结果集的成员数量
+func (cli *SortedSetClient) ZDiffStore(ctx context.Context, destination string, keys ...string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{destination}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.SortedSet).ZDiffStore(ctx, destination, keys...)
+	}).(driver.IntValuer)
+}
+
+// scanZSlice 将有序集合的成员扫描到dst中
+// dst 可以是 *[]driver.Z,或者元素为结构体(或结构体指针)的切片指针,结构体使用 redis 标签 member 跟 score 接收成员跟 score 值
+func scanZSlice(value driver.ZSliceValuer, dst interface{}) error {
+	zs, err := value.Result()
+	if err != nil {
+		return err
+	}
+
+	if v, ok := dst.(*[]driver.Z); ok {
+		*v = zs
+		return nil
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("jcache: scan(non-slice-pointer %T)", dst)
+	}
+
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	keys := []interface{}{"member", "score"}
+	result := reflect.MakeSlice(slice.Type(), 0, len(zs))
+	for _, z := range zs {
+		elem := reflect.New(elemType)
+		vals := []interface{}{fmt.Sprint(z.Member), strconv.FormatFloat(z.Score, 'f', -1, 64)}
+		if err := hscan.Scan(elem.Interface(), keys, vals); err != nil {
+			return err
+		}
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		result = reflect.Append(result, elem)
+	}
+	slice.Set(result)
+	return nil
+}
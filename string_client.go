@@ -2,9 +2,12 @@ package jcache
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/jerbe/jcache/v2/driver"
+	"github.com/jerbe/jcache/v2/errors"
+	"github.com/redis/go-redis/v9"
 )
 
 /**
@@ -18,17 +21,17 @@ type StringClient struct {
 }
 
 func NewStringClient(drivers ...driver.String) *StringClient {
-	drs := make([]driver.Common, 0)
+	drs := make([]clientDriver, 0)
 	for i := 0; i < len(drivers); i++ {
-		drs = append(drs, drivers[i])
+		drs = append(drs, clientDriver{driver: drivers[i]})
 	}
 
 	if len(drs) == 0 {
-		drs = append(drs, driver.NewMemory())
+		drs = append(drs, clientDriver{driver: driver.NewMemory()})
 	}
 
 	return &StringClient{
-		BaseClient: BaseClient{drivers: drs},
+		BaseClient: newBaseClient(nil, drs...),
 	}
 }
 
@@ -38,36 +41,32 @@ func NewStringClient(drivers ...driver.String) *StringClient {
 
 // Set 设置数据
 func (cli *StringClient) Set(ctx context.Context, key string, data interface{}, expiration time.Duration) driver.StatusValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.StatusValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.String).Set(ctx, key, data, expiration); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).Set(ctx, key, data, expiration)
+	}).(driver.StatusValuer)
 }
 
 // SetNX 设置数据,如果key不存在的话
 func (cli *StringClient) SetNX(ctx context.Context, key string, data interface{}, expiration time.Duration) driver.BoolValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
-	var value driver.BoolValuer
-	for i, c := range cli.drivers {
-		if v := c.(driver.String).SetNX(ctx, key, data, expiration); i == 0 {
-			value = v
-		}
-	}
-	return value
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).SetNX(ctx, key, data, expiration)
+	}).(driver.BoolValuer)
 }
 
 // Get 获取数据
 func (cli *StringClient) Get(ctx context.Context, key string) driver.StringValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 	var value driver.StringValuer
-	for _, c := range cli.drivers {
+	for i, c := range cli.drivers {
 		if value = c.(driver.String).Get(ctx, key); returnable(value) {
+			cli.promote(value, i, key, promoteString)
 			return value
 		}
 	}
@@ -76,7 +75,8 @@ func (cli *StringClient) Get(ctx context.Context, key string) driver.StringValue
 
 // MGet 获取多个Keys的值
 func (cli *StringClient) MGet(ctx context.Context, keys ...string) driver.SliceValuer {
-	ctx, _ = cli.preCheck(ctx)
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
 
 	var value driver.SliceValuer
 	for _, c := range cli.drivers {
@@ -96,3 +96,234 @@ func (cli *StringClient) GetAndScan(ctx context.Context, dst interface{}, key st
 func (cli *StringClient) MGetAndScan(ctx context.Context, dst interface{}, keys ...string) error {
 	return cli.MGet(ctx, keys...).Scan(dst)
 }
+
+// followValue 其他驱动以 KeepTTL 的方式写入第一个驱动计算后的结果,保证各个驱动的计数一致
+func followValue(key string) func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+	return func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+		if lead.Err() != nil {
+			return new(redis.StatusCmd)
+		}
+
+		var value interface{}
+		switch v := lead.(type) {
+		case driver.IntValuer:
+			value = v.Val()
+		case driver.FloatValuer:
+			value = v.Val()
+		}
+		return c.(driver.String).Set(ctx, key, value, driver.KeepTTL)
+	}
+}
+
+// Incr 将key中储存的数字加1,key不存在时先初始化为0
+// 第一个写入的驱动计算结果,其他驱动写入相同的结果
+func (cli *StringClient) Incr(ctx context.Context, key string) driver.IntValuer {
+	return cli.incrBy(ctx, key, func(ctx context.Context, c driver.String) driver.IntValuer {
+		return c.Incr(ctx, key)
+	})
+}
+
+// IncrBy 将key中储存的数字加上增量 value,key不存在时先初始化为0
+// 第一个写入的驱动计算结果,其他驱动写入相同的结果
+func (cli *StringClient) IncrBy(ctx context.Context, key string, value int64) driver.IntValuer {
+	return cli.incrBy(ctx, key, func(ctx context.Context, c driver.String) driver.IntValuer {
+		return c.IncrBy(ctx, key, value)
+	})
+}
+
+// Decr 将key中储存的数字减1,key不存在时先初始化为0
+// 第一个写入的驱动计算结果,其他驱动写入相同的结果
+func (cli *StringClient) Decr(ctx context.Context, key string) driver.IntValuer {
+	return cli.incrBy(ctx, key, func(ctx context.Context, c driver.String) driver.IntValuer {
+		return c.Decr(ctx, key)
+	})
+}
+
+// DecrBy 将key中储存的数字减去 decrement,key不存在时先初始化为0
+// 第一个写入的驱动计算结果,其他驱动写入相同的结果
+func (cli *StringClient) DecrBy(ctx context.Context, key string, decrement int64) driver.IntValuer {
+	return cli.incrBy(ctx, key, func(ctx context.Context, c driver.String) driver.IntValuer {
+		return c.DecrBy(ctx, key, decrement)
+	})
+}
+
+// incrBy 执行整数计数器的写操作
+func (cli *StringClient) incrBy(ctx context.Context, key string, fn func(ctx context.Context, c driver.String) driver.IntValuer) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return fn(ctx, c.(driver.String))
+	}, followValue(key))).(driver.IntValuer)
+}
+
+// IncrByFloat 将key中储存的数字加上浮点数增量 value,key不存在时先初始化为0
+// 第一个写入的驱动计算结果,其他驱动写入相同的结果
+func (cli *StringClient) IncrByFloat(ctx context.Context, key string, value float64) driver.FloatValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	leader := new(leaderWriter)
+	return cli.write(ctx, []string{key}, leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).IncrByFloat(ctx, key, value)
+	}, followValue(key))).(driver.FloatValuer)
+}
+
+// Append 将 value 追加到key原来的值的末尾,返回追加之后字符串的长度
+func (cli *StringClient) Append(ctx context.Context, key, value string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).Append(ctx, key, value)
+	}).(driver.IntValuer)
+}
+
+// GetRange 返回key中字符串值的子字符串,截取的范围由 start 和 end 决定(包括 start 和 end 在内)
+func (cli *StringClient) GetRange(ctx context.Context, key string, start, end int64) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.StringValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.String).GetRange(ctx, key, start, end); found(value, value.Val() == "") {
+			cli.promote(value, i, key, promoteString)
+			return value
+		}
+	}
+	return value
+}
+
+// SetRange 用 value 覆写key中字符串值从偏移量 offset 开始的内容,返回覆写之后字符串的长度
+func (cli *StringClient) SetRange(ctx context.Context, key string, offset int64, value string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).SetRange(ctx, key, offset, value)
+	}).(driver.IntValuer)
+}
+
+// StrLen 返回key中字符串值的长度
+func (cli *StringClient) StrLen(ctx context.Context, key string) driver.IntValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	var value driver.IntValuer
+	for i, c := range cli.drivers {
+		if value = c.(driver.String).StrLen(ctx, key); found(value, value.Val() == 0) {
+			cli.promote(value, i, key, promoteString)
+			return value
+		}
+	}
+	return value
+}
+
+// GetSet 设置key的值并返回原来的值,原来的存活时长会被清除
+func (cli *StringClient) GetSet(ctx context.Context, key string, data interface{}) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).GetSet(ctx, key, data)
+	}).(driver.StringValuer)
+}
+
+// GetDel 获取key的值并删除key
+func (cli *StringClient) GetDel(ctx context.Context, key string) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).GetDel(ctx, key)
+	}).(driver.StringValuer)
+}
+
+// GetEx 获取key的值并设置存活时长
+// expiration 大于0时设置存活时长,等于0时移除存活时长,小于0时保持原有的存活时长
+func (cli *StringClient) GetEx(ctx context.Context, key string, expiration time.Duration) driver.StringValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).GetEx(ctx, key, expiration)
+	}).(driver.StringValuer)
+}
+
+// SetXX 只有key已经存在时才设置数据
+func (cli *StringClient) SetXX(ctx context.Context, key string, data interface{}, expiration time.Duration) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, []string{key}, func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).SetXX(ctx, key, data, expiration)
+	}).(driver.BoolValuer)
+}
+
+// MSet 同时设置多个key的值
+// 接受以下格式的值：
+// MSet("key1", "value1", "key2", "value2")
+//
+// MSet([]string{"key1", "value1", "key2", "value2"})
+//
+// MSet(map[string]interface{}{"key1": "value1", "key2": "value2"})
+func (cli *StringClient) MSet(ctx context.Context, values ...interface{}) driver.StatusValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	return cli.write(ctx, pairKeys(values), func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).MSet(ctx, values...)
+	}).(driver.StatusValuer)
+}
+
+// MSetNX 只有所有的key都不存在时才同时设置多个key的值,参数格式同 MSet
+// 第一个写入的驱动判断是否设置,设置成功后其他驱动直接写入
+func (cli *StringClient) MSetNX(ctx context.Context, values ...interface{}) driver.BoolValuer {
+	ctx, cancel := cli.preCheck(ctx)
+	defer cancel()
+
+	leader := new(leaderWriter)
+	return cli.write(ctx, pairKeys(values), leader.writeFunc(func(ctx context.Context, c driver.Common) errors.ErrorValuer {
+		return c.(driver.String).MSetNX(ctx, values...)
+	}, func(ctx context.Context, c driver.Common, lead errors.ErrorValuer) errors.ErrorValuer {
+		if lead.Err() != nil || !lead.(driver.BoolValuer).Val() {
+			return new(redis.StatusCmd)
+		}
+		return c.(driver.String).MSet(ctx, values...)
+	})).(driver.BoolValuer)
+}
+
+// pairKeys 返回 key1, value1, key2, value2... 格式参数中的所有key
+func pairKeys(values []interface{}) []string {
+	if len(values) == 1 {
+		switch arg := values[0].(type) {
+		case []string:
+			keys := make([]string, 0, len(arg)/2)
+			for i := 0; i < len(arg); i += 2 {
+				keys = append(keys, arg[i])
+			}
+			return keys
+		case []interface{}:
+			values = arg
+		case map[string]interface{}:
+			keys := make([]string, 0, len(arg))
+			for k := range arg {
+				keys = append(keys, k)
+			}
+			return keys
+		case map[string]string:
+			keys := make([]string, 0, len(arg))
+			for k := range arg {
+				keys = append(keys, k)
+			}
+			return keys
+		}
+	}
+
+	keys := make([]string, 0, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		keys = append(keys, fmt.Sprint(values[i]))
+	}
+	return keys
+}
@@ -138,7 +138,14 @@ func main() {
 			}
 			val := mem.LTrim(context.Background(), values[1], start, stop)
 			log.Printf(">> val:[%v], err:[%v]", val.Val(), val.Err())
-		case "lrang":
+		case "rpush":
+			args := make([]interface{}, 0, len(values)-2)
+			for i := 0; i < cap(args); i++ {
+				args = append(args, values[i+2])
+			}
+			val := mem.RPush(context.Background(), values[1], args...)
+			log.Printf(">> val:[%v], err:[%v]", val.Val(), val.Err())
+		case "lrange":
 			start, err := strconv.ParseInt(values[2], 10, 64)
 			if err != nil {
 				log.Printf("ltrim failure. reason:[%v]", err)
@@ -149,13 +156,13 @@ func main() {
 				log.Printf("ltrim failure. reason:[%v]", err)
 				continue
 			}
-			val := mem.LRang(context.Background(), values[1], start, stop)
+			val := mem.LRange(context.Background(), values[1], start, stop)
 			log.Printf(">> val:[%v], err:[%v]", val.Val(), val.Err())
 		case "lpop":
 			val := mem.LPop(context.Background(), values[1])
 			log.Printf(">> val:[%v], err:[%v]", val.Val(), val.Err())
-		case "lshift":
-			val := mem.LShift(context.Background(), values[1])
+		case "rpop":
+			val := mem.RPop(context.Background(), values[1])
 			log.Printf(">> val:[%v], err:[%v]", val.Val(), val.Err())
 		}
 